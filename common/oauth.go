@@ -0,0 +1,201 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAccountsURL = "https://accounts.livechat.com"
+	// DefaultExpiryLeeway is how long before expiry a cached token is refreshed.
+	DefaultExpiryLeeway = time.Minute
+	// DefaultRefreshTimeout limits a token request, it is shared by all waiting callers so none of their contexts bounds it.
+	DefaultRefreshTimeout = 30 * time.Second
+)
+
+// TokenProvider is a TokenFn that can drop a token the API rejected, see livechat.WithTokenProvider.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+	Invalidate(token string)
+}
+
+// OAuthConfig configures OAuthTokenProvider. With RefreshToken set it uses the refresh_token
+// grant, otherwise client_credentials.
+type OAuthConfig struct {
+	// AccountsURL defaults to DefaultAccountsURL, tokens are requested from AccountsURL + "/v2/token".
+	AccountsURL  string
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+	Scopes       []string
+	// ExpiryLeeway defaults to DefaultExpiryLeeway.
+	ExpiryLeeway time.Duration
+	// RefreshTimeout defaults to DefaultRefreshTimeout.
+	RefreshTimeout time.Duration
+	HttpClient     *http.Client
+}
+
+// OAuthTokenProvider fetches access tokens from the LiveChat accounts service and caches them until
+// ExpiryLeeway before they expire, tokens without expires_in are kept until invalidated. Concurrent callers
+// share a single refresh.
+type OAuthTokenProvider struct {
+	cfg OAuthConfig
+	now func() time.Time
+
+	mu           sync.Mutex
+	token        string
+	expiresAt    time.Time
+	refreshToken string
+	inflight     *tokenCall
+}
+
+type tokenCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+func NewOAuthTokenProvider(cfg OAuthConfig) *OAuthTokenProvider {
+	if cfg.AccountsURL == "" {
+		cfg.AccountsURL = DefaultAccountsURL
+	}
+	if cfg.ExpiryLeeway <= 0 {
+		cfg.ExpiryLeeway = DefaultExpiryLeeway
+	}
+	if cfg.RefreshTimeout <= 0 {
+		cfg.RefreshTimeout = DefaultRefreshTimeout
+	}
+	if cfg.HttpClient == nil {
+		cfg.HttpClient = http.DefaultClient
+	}
+
+	return &OAuthTokenProvider{cfg: cfg, now: time.Now, refreshToken: cfg.RefreshToken}
+}
+
+// TokenFn returns p.Token as a TokenFn.
+func (p *OAuthTokenProvider) TokenFn() TokenFn {
+	return p.Token
+}
+
+// Token returns the cached token or waits for a refresh, started by the first caller that found none.
+func (p *OAuthTokenProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	if p.token != "" && (p.expiresAt.IsZero() || p.now().Before(p.expiresAt.Add(-p.cfg.ExpiryLeeway))) {
+		token := p.token
+		p.mu.Unlock()
+		return token, nil
+	}
+
+	call := p.inflight
+	if call == nil {
+		call = &tokenCall{done: make(chan struct{})}
+		p.inflight = call
+		// The refresh is shared, one caller giving up must not fail the others.
+		go p.refresh(context.WithoutCancel(ctx), call)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-call.done:
+		return call.token, call.err
+	}
+}
+
+// Invalidate drops token from the cache, unless it was already replaced by a newer one.
+func (p *OAuthTokenProvider) Invalidate(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token == token {
+		p.token = ""
+	}
+}
+
+func (p *OAuthTokenProvider) refresh(ctx context.Context, call *tokenCall) {
+	p.mu.Lock()
+	refreshToken := p.refreshToken
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.RefreshTimeout)
+	resp, err := p.requestToken(ctx, refreshToken)
+	cancel()
+
+	p.mu.Lock()
+	if err == nil {
+		p.token = resp.AccessToken
+		p.expiresAt = time.Time{}
+		if resp.ExpiresIn > 0 {
+			p.expiresAt = p.now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+		}
+		if resp.RefreshToken != "" {
+			p.refreshToken = resp.RefreshToken
+		}
+		call.token = resp.AccessToken
+	}
+	call.err = err
+	p.inflight = nil
+	p.mu.Unlock()
+
+	close(call.done)
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
+
+func (p *OAuthTokenProvider) requestToken(ctx context.Context, refreshToken string) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("client_secret", p.cfg.ClientSecret)
+	if refreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", refreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+	if len(p.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.AccountsURL+"/v2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oauth: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.cfg.HttpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth: send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("oauth: read response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("oauth: bad status code %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	var tr tokenResponse
+	if err = json.Unmarshal(body, &tr); err != nil {
+		return nil, fmt.Errorf("oauth: decode response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("oauth: empty access token")
+	}
+
+	return &tr, nil
+}
@@ -0,0 +1,202 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type accountsFake struct {
+	*httptest.Server
+	calls   atomic.Int32
+	release chan struct{}
+	status  int
+	forms   chan map[string]string
+	// expiresIn is 3600 unless set, a negative value leaves it out of the response.
+	expiresIn int
+}
+
+func newAccountsFake(t *testing.T) *accountsFake {
+	f := &accountsFake{status: http.StatusOK, forms: make(chan map[string]string, 100)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := f.calls.Add(1)
+		if f.release != nil {
+			<-f.release
+		}
+		_ = r.ParseForm()
+		form := map[string]string{}
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		form["path"] = r.URL.Path
+		f.forms <- form
+
+		if f.status != http.StatusOK {
+			w.WriteHeader(f.status)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		switch {
+		case f.expiresIn < 0:
+			_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","refresh_token":"refresh-%d","token_type":"Bearer"}`, n, n)
+		case f.expiresIn > 0:
+			_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","refresh_token":"refresh-%d","expires_in":%d,"token_type":"Bearer"}`, n, n, f.expiresIn)
+		default:
+			_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","refresh_token":"refresh-%d","expires_in":3600,"token_type":"Bearer"}`, n, n)
+		}
+	}))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func TestOAuthTokenProvider_Token(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("client credentials and caching", func(t *testing.T) {
+		f := newAccountsFake(t)
+		p := NewOAuthTokenProvider(OAuthConfig{AccountsURL: f.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"billing--all:rw", "ledger--all:rw"}})
+
+		token, err := p.Token(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+
+		token, err = p.TokenFn()(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+		assert.Equal(t, int32(1), f.calls.Load())
+
+		form := <-f.forms
+		assert.Equal(t, map[string]string{
+			"path":          "/v2/token",
+			"grant_type":    "client_credentials",
+			"client_id":     "id",
+			"client_secret": "secret",
+			"scope":         "billing--all:rw ledger--all:rw",
+		}, form)
+	})
+
+	t.Run("refreshes shortly before expiry with the rotated refresh token", func(t *testing.T) {
+		f := newAccountsFake(t)
+		now := time.Now()
+		p := NewOAuthTokenProvider(OAuthConfig{AccountsURL: f.URL, RefreshToken: "refresh-0", ExpiryLeeway: time.Minute})
+		p.now = func() time.Time { return now }
+
+		token, err := p.Token(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+		assert.Equal(t, "refresh-0", (<-f.forms)["refresh_token"])
+
+		now = now.Add(58 * time.Minute)
+		token, _ = p.Token(ctx)
+		assert.Equal(t, "token-1", token)
+
+		now = now.Add(time.Minute)
+		token, err = p.Token(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token)
+
+		form := <-f.forms
+		assert.Equal(t, "refresh_token", form["grant_type"])
+		assert.Equal(t, "refresh-1", form["refresh_token"])
+	})
+
+	t.Run("tokens without expiry are cached", func(t *testing.T) {
+		f := newAccountsFake(t)
+		f.expiresIn = -1
+		p := NewOAuthTokenProvider(OAuthConfig{AccountsURL: f.URL})
+
+		token, err := p.Token(ctx)
+		require.NoError(t, err)
+		token2, err := p.Token(ctx)
+		require.NoError(t, err)
+
+		assert.Equal(t, "token-1", token)
+		assert.Equal(t, token, token2)
+		assert.Equal(t, int32(1), f.calls.Load())
+	})
+
+	t.Run("single refresh across goroutines", func(t *testing.T) {
+		f := newAccountsFake(t)
+		f.release = make(chan struct{})
+		p := NewOAuthTokenProvider(OAuthConfig{AccountsURL: f.URL})
+
+		var wg sync.WaitGroup
+		tokens := make([]string, 20)
+		for i := range tokens {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				tokens[i], _ = p.Token(ctx)
+			}(i)
+		}
+		assert.Eventually(t, func() bool { return f.calls.Load() == 1 }, time.Second, time.Millisecond)
+		close(f.release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), f.calls.Load())
+		for _, token := range tokens {
+			assert.Equal(t, "token-1", token)
+		}
+	})
+
+	t.Run("waiting caller gives up", func(t *testing.T) {
+		f := newAccountsFake(t)
+		f.release = make(chan struct{})
+		defer close(f.release)
+		p := NewOAuthTokenProvider(OAuthConfig{AccountsURL: f.URL})
+
+		cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := p.Token(cctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("refresh timeout", func(t *testing.T) {
+		f := newAccountsFake(t)
+		f.release = make(chan struct{})
+		defer close(f.release)
+		p := NewOAuthTokenProvider(OAuthConfig{AccountsURL: f.URL, RefreshTimeout: 10 * time.Millisecond})
+
+		_, err := p.Token(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		f := newAccountsFake(t)
+		f.status = http.StatusUnauthorized
+		p := NewOAuthTokenProvider(OAuthConfig{AccountsURL: f.URL})
+
+		_, err := p.Token(ctx)
+		assert.ErrorContains(t, err, "bad status code 401")
+
+		f.status = http.StatusOK
+		token, err := p.Token(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token, "errors are not cached")
+	})
+}
+
+func TestOAuthTokenProvider_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountsFake(t)
+	p := NewOAuthTokenProvider(OAuthConfig{AccountsURL: f.URL})
+
+	token, _ := p.Token(ctx)
+	p.Invalidate("stale")
+	token2, _ := p.Token(ctx)
+	assert.Equal(t, token, token2, "only the current token is dropped")
+
+	p.Invalidate(token)
+	token3, err := p.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token3)
+}
@@ -0,0 +1,104 @@
+// Package lock keeps the locks a storage holds for a context, so nested calls reuse them and the queries of the
+// locked function run on the connection holding them instead of taking a second one from the pool.
+package lock
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// HoldTimeout bounds how long a lock and its connection are held. The locked functions call the LiveChat Billing
+// API, retries and Retry-After waits included, while their connection sits idle in a transaction; once the timeout
+// passes their ctx is canceled, so a slow API cannot pin pool connections and row locks for longer.
+const HoldTimeout = 30 * time.Second
+
+type ctxKey struct {
+	owner any
+}
+
+type held struct {
+	conn  any
+	names map[string]bool
+}
+
+// With returns a copy of ctx in which owner holds the named lock on conn.
+func With(ctx context.Context, owner any, conn any, name string) context.Context {
+	h := &held{conn: conn, names: map[string]bool{name: true}}
+	if prev, ok := ctx.Value(ctxKey{owner: owner}).(*held); ok {
+		for n := range prev.names {
+			h.names[n] = true
+		}
+	}
+
+	return context.WithValue(ctx, ctxKey{owner: owner}, h)
+}
+
+// Held reports whether owner holds the named lock in ctx.
+func Held(ctx context.Context, owner any, name string) bool {
+	h, ok := ctx.Value(ctxKey{owner: owner}).(*held)
+	return ok && h.names[name]
+}
+
+// Conn returns the connection on which owner holds its locks in ctx.
+func Conn[T any](ctx context.Context, owner any) (T, bool) {
+	h, ok := ctx.Value(ctxKey{owner: owner}).(*held)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	conn, ok := h.conn.(T)
+
+	return conn, ok
+}
+
+// WithTx runs fn while owner holds the named lock, taken by lock in a transaction begun on conn. When ctx already
+// has a lock transaction of owner, the lock is taken in it. The queries fn makes through Conn run in the transaction,
+// which stays open for at most HoldTimeout.
+//
+// The transaction is committed once fn returns, even when it fails: the writes fn made before failing, such as the
+// sync error counters of a charge, must be kept as if they were made outside of the lock. A failed query aborts the
+// transaction, its commit then rolls back and fn's error is returned.
+func WithTx(
+	ctx context.Context,
+	owner any,
+	conn interface {
+		Begin(ctx context.Context) (pgx.Tx, error)
+	},
+	name string,
+	lock func(ctx context.Context, tx pgx.Tx) error,
+	fn func(ctx context.Context) error,
+) error {
+	if Held(ctx, owner, name) {
+		return fn(ctx)
+	}
+
+	if tx, ok := Conn[pgx.Tx](ctx, owner); ok {
+		if err := lock(ctx, tx); err != nil {
+			return err
+		}
+		return fn(With(ctx, owner, tx, name))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, HoldTimeout)
+	defer cancel()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = lock(ctx, tx); err != nil {
+		_ = tx.Rollback(context.WithoutCancel(ctx))
+		return err
+	}
+
+	// the timeout must not roll back what fn already wrote
+	if err = fn(With(ctx, owner, tx, name)); err != nil {
+		_ = tx.Commit(context.WithoutCancel(ctx))
+		return err
+	}
+
+	return tx.Commit(context.WithoutCancel(ctx))
+}
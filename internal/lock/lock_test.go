@@ -0,0 +1,58 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWith(t *testing.T) {
+	owner, other := new(int), new(int)
+
+	ctx := With(context.Background(), owner, "conn", "a")
+	ctx = With(ctx, owner, "conn", "b")
+
+	assert.True(t, Held(ctx, owner, "a"))
+	assert.True(t, Held(ctx, owner, "b"))
+	assert.False(t, Held(ctx, owner, "c"))
+	assert.False(t, Held(ctx, other, "a"))
+
+	conn, ok := Conn[string](ctx, owner)
+	assert.True(t, ok)
+	assert.Equal(t, "conn", conn)
+
+	_, ok = Conn[string](ctx, other)
+	assert.False(t, ok)
+	_, ok = Conn[int](ctx, owner)
+	assert.False(t, ok)
+}
+
+func TestWithTx(t *testing.T) {
+	t.Run("bounds the lock and commits when fn fails", func(t *testing.T) {
+		conn, err := pgxmock.NewConn()
+		require.NoError(t, err)
+		conn.ExpectBegin().Times(1)
+		conn.ExpectCommit().Times(1)
+
+		owner := new(int)
+		fnErr := errors.New("sync failed")
+		err = WithTx(context.Background(), owner, conn, "a", func(ctx context.Context, tx pgx.Tx) error {
+			return nil
+		}, func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(HoldTimeout), deadline, time.Second)
+			assert.True(t, Held(ctx, owner, "a"))
+			return fnErr
+		})
+
+		assert.ErrorIs(t, err, fnErr)
+		assert.NoError(t, conn.ExpectationsWereMet())
+	})
+}
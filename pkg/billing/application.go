@@ -0,0 +1,98 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/common"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+)
+
+// ErrUnknownApplication is returned for an ApplicationIDCtxKey or a stored charge of an app that is not registered with WithApplication.
+var ErrUnknownApplication = errors.New("unknown application")
+
+// ErrNoDefaultApplicationID is returned by BackfillApplicationID when WithDefaultApplicationID is not set.
+var ErrNoDefaultApplicationID = errors.New("default application id is not set")
+
+// ApplicationIDCtxKey selects the Application a Service call runs for, HandleDPSWebhook sets it from DPSWebhookRequest.ApplicationID.
+type ApplicationIDCtxKey struct{}
+
+// Application is a LiveChat Marketplace app billed by the Service, with its own credentials and return URL.
+type Application struct {
+	ID          string
+	TokenFn     common.TokenFn
+	ReturnURL   string
+	MasterOrgID string
+	// APIOptions are applied after the Service WithAPIOptions, e.g. livechat.WithTokenProvider.
+	APIOptions []livechat.Option
+}
+
+// WithApplication registers an app. Once any app is registered, charges and subscriptions are tagged with the
+// ApplicationIDCtxKey of the call and queries only see the ones of that app. Without registered apps
+// ApplicationIDCtxKey is ignored.
+func WithApplication(app Application) Option {
+	return func(s *Service) {
+		s.applicationConfigs = append(s.applicationConfigs, app)
+	}
+}
+
+// WithDefaultApplicationID sets the ID of the app NewService is created with, the one its webhooks carry in
+// DPSWebhookRequest.ApplicationID. It is registered under that ID next to the WithApplication apps and selected for
+// calls without ApplicationIDCtxKey. Call BackfillApplicationID once to tag the rows stored before with it.
+func WithDefaultApplicationID(id string) Option {
+	return func(s *Service) {
+		s.defaultApplicationID = id
+	}
+}
+
+type application struct {
+	id          string
+	billingAPI  livechat.ApiInterface
+	returnURL   string
+	masterOrgID string
+}
+
+// application returns the app selected by ApplicationIDCtxKey.
+func (s *Service) application(ctx context.Context) (*application, error) {
+	id, _ := ctx.Value(ApplicationIDCtxKey{}).(string)
+	return s.applicationByID(id)
+}
+
+// applicationByID returns the app a stored charge or subscription is tagged with.
+func (s *Service) applicationByID(id string) (*application, error) {
+	if id == "" || len(s.applications) == 0 {
+		return s.defaultApplication(), nil
+	}
+
+	app, ok := s.applications[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownApplication, id)
+	}
+
+	return app, nil
+}
+
+// defaultApplication returns the app NewService was created with.
+func (s *Service) defaultApplication() *application {
+	return &application{id: s.defaultApplicationID, billingAPI: s.billingAPI, returnURL: s.returnURL, masterOrgID: s.masterOrgID}
+}
+
+// BackfillApplicationID tags the charges, subscriptions and trials stored without an app with the
+// WithDefaultApplicationID ID, so the queries of the default app keep seeing them. It is safe to run again.
+func (s *Service) BackfillApplicationID(ctx context.Context) (err error) {
+	ctx, end := s.start(ctx, "BackfillApplicationID")
+	defer func() { end(err) }()
+
+	if s.defaultApplicationID == "" {
+		return ErrNoDefaultApplicationID
+	}
+
+	n, err := s.storage.BackfillApplicationID(ctx, s.defaultApplicationID)
+	if err != nil {
+		return fmt.Errorf("failed to backfill application id: %w", err)
+	}
+	s.logger.InfoContext(ctx, "backfilled application id", "application_id", s.defaultApplicationID, "rows", n)
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+)
+
+func TestNewService_WithApplication(t *testing.T) {
+	tokenFn := func(ctx context.Context) (string, error) { return "", nil }
+	newService := NewService(nil, nil, nil, "labs", tokenFn, &storageMock{}, nil, "returnURL", "masterOrgID",
+		WithApplication(Application{ID: "app2", TokenFn: tokenFn, ReturnURL: "returnURL2", APIOptions: []livechat.Option{livechat.WithUserAgent("app2/1.0")}}))
+
+	app, err := newService.applicationByID("app2")
+	assert.NoError(t, err)
+	assert.Equal(t, "app2", app.id)
+	assert.Equal(t, "returnURL2", app.returnURL)
+	assert.Equal(t, "app2/1.0", app.billingAPI.(*livechat.Api).UserAgent)
+	assert.Empty(t, newService.billingAPI.(*livechat.Api).UserAgent)
+
+	app, err = newService.applicationByID("")
+	assert.NoError(t, err)
+	assert.Equal(t, newService.billingAPI, app.billingAPI)
+
+	_, err = newService.applicationByID("app3")
+	assert.ErrorIs(t, err, ErrUnknownApplication)
+}
+
+func TestNewService_WithDefaultApplicationID(t *testing.T) {
+	tokenFn := func(ctx context.Context) (string, error) { return "", nil }
+	newService := NewService(nil, nil, nil, "labs", tokenFn, &storageMock{}, nil, "returnURL", "masterOrgID",
+		WithDefaultApplicationID("app1"), WithApplication(Application{ID: "app2", TokenFn: tokenFn}))
+
+	app, err := newService.applicationByID("app1")
+	assert.NoError(t, err)
+	assert.Equal(t, "app1", app.id)
+	assert.Equal(t, "returnURL", app.returnURL)
+	assert.Equal(t, newService.billingAPI, app.billingAPI)
+
+	app, err = newService.applicationByID("")
+	assert.NoError(t, err)
+	assert.Equal(t, "app1", app.id)
+
+	app, err = newService.applicationByID("app2")
+	assert.NoError(t, err)
+	assert.Equal(t, "app2", app.id)
+}
+
+func TestHandler_HandleDPSWebhook_DefaultApplication(t *testing.T) {
+	tokenFn := func(ctx context.Context) (string, error) { return "", nil }
+	newService := NewService(em, xm, nil, "labs", tokenFn, sm, nil, "returnURL", "masterOrgID",
+		WithDefaultApplicationID(applicationID), WithApplication(Application{ID: "app2", TokenFn: tokenFn}), WithLogger(discardLogger))
+	mh := h
+	mh.billing = newService
+
+	req := DPSWebhookRequest{
+		ApplicationID:    applicationID,
+		Event:            "payment_cancelled",
+		License:          lid,
+		LCOrganizationID: lcoid,
+		Payload: map[string]interface{}{
+			"paymentID": "x1c2v3",
+		},
+	}
+	xm.On("GenerateId").Return(xid, nil)
+	levent := events.Event{ID: xid, LCOrganizationID: lcoid, Type: events.EventTypeInfo, Action: events.EventActionDPSWebhookPayment}
+	em.On("ToEvent", mock.Anything, lcoid, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+	deleteEvent := events.Event{ID: xid, LCOrganizationID: lcoid, Type: events.EventTypeInfo, Action: events.EventActionDeleteSubscriptionWithCharge}
+	em.On("ToEvent", mock.Anything, lcoid, events.EventActionDeleteSubscriptionWithCharge, events.EventTypeInfo, DeleteSubscriptionWithChargeEventPayload{ChargeID: "x1c2v3"}).Return(deleteEvent).Once()
+	sm.On("DeleteSubscriptionByChargeID", mock.Anything, applicationID, lcoid, "x1c2v3").Return(nil).Once()
+	sm.On("DeleteCharge", mock.Anything, applicationID, "x1c2v3").Return(nil).Once()
+	em.On("CreateEvent", mock.Anything, deleteEvent).Return(nil).Once()
+	em.On("CreateEvent", mock.Anything, mock.MatchedBy(func(e events.Event) bool {
+		return e.Action == events.EventActionDPSWebhookPayment
+	})).Return(nil).Once()
+
+	err := mh.HandleDPSWebhook(context.Background(), req)
+
+	assert.NoError(t, err)
+	assertExpectations(t)
+}
+
+func TestService_BackfillApplicationID(t *testing.T) {
+	ms := s
+	ms.defaultApplicationID = "app1"
+
+	t.Run("success", func(t *testing.T) {
+		sm.On("BackfillApplicationID", ctx, "app1").Return(int64(3), nil).Once()
+
+		err := ms.BackfillApplicationID(ctx)
+
+		assert.NoError(t, err)
+		assertExpectations(t)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		sm.On("BackfillApplicationID", ctx, "app1").Return(int64(0), assert.AnError).Once()
+
+		err := ms.BackfillApplicationID(ctx)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assertExpectations(t)
+	})
+
+	t.Run("no default application", func(t *testing.T) {
+		err := s.BackfillApplicationID(ctx)
+
+		assert.ErrorIs(t, err, ErrNoDefaultApplicationID)
+		assertExpectations(t)
+	})
+}
+
+func TestService_CreateRecurrentCharge_Application(t *testing.T) {
+	am2 := new(apiMock)
+	ms := s
+	ms.applications = map[string]*application{
+		"app2": {id: "app2", billingAPI: am2, returnURL: "returnURL2", masterOrgID: "masterOrgID2"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		appCtx := context.WithValue(ctx, ApplicationIDCtxKey{}, "app2")
+		rc := &livechat.RecurrentCharge{BaseCharge: livechat.BaseCharge{ID: "id", Name: "name", Price: 10}, Months: 1}
+		rawRC, _ := json.Marshal(rc)
+		domainCharge := Charge{
+			ID:               "id",
+			Type:             ChargeTypeRecurring,
+			Payload:          rawRC,
+			LCOrganizationID: lcoid,
+			ApplicationID:    "app2",
+		}
+		am2.On("CreateRecurrentCharge", appCtx, livechat.CreateRecurrentChargeParams{
+			Name:      "name",
+			ReturnURL: "returnURL2",
+			Price:     10,
+			Months:    1,
+		}).Return(rc, nil).Once()
+		sm.On("CreateCharge", appCtx, domainCharge).Return(nil).Once()
+		payload := CreateChargeEventPayload{Name: "name", Price: 10, ChargeFrequency: 1}
+		levent := events.Event{ID: xid, LCOrganizationID: lcoid, Type: events.EventTypeInfo, Action: events.EventActionCreateCharge}
+		em.On("ToEvent", appCtx, lcoid, events.EventActionCreateCharge, events.EventTypeInfo, payload).Return(levent).Once()
+		createdEvent := levent
+		createdEvent.Payload, _ = json.Marshal(CreateChargeEventPayload{Version: 1, Name: "name", Price: 10, ChargeFrequency: 1, Charge: rc})
+		em.On("CreateEvent", appCtx, createdEvent).Return(nil).Once()
+
+		id, err := ms.CreateRecurrentCharge(appCtx, "name", 10, lcoid, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "id", id)
+		am2.AssertExpectations(t)
+		assertExpectations(t)
+	})
+
+	t.Run("unknown application", func(t *testing.T) {
+		appCtx := context.WithValue(ctx, ApplicationIDCtxKey{}, "app3")
+		payload := CreateChargeEventPayload{Name: "name", Price: 10, ChargeFrequency: 1}
+		levent := events.Event{ID: xid, LCOrganizationID: lcoid, Type: events.EventTypeInfo, Action: events.EventActionCreateCharge}
+		em.On("ToEvent", appCtx, lcoid, events.EventActionCreateCharge, events.EventTypeInfo, payload).Return(levent).Once()
+		em.On("ToError", appCtx, events.ToErrorParams{
+			Event: events.Event{ID: xid, LCOrganizationID: lcoid, Type: events.EventTypeError, Action: events.EventActionCreateCharge},
+			Err:   fmt.Errorf("%w: %s", ErrUnknownApplication, "app3"),
+		}).Return(ErrUnknownApplication).Once()
+
+		_, err := ms.CreateRecurrentCharge(appCtx, "name", 10, lcoid, 1)
+
+		assert.ErrorIs(t, err, ErrUnknownApplication)
+		assertExpectations(t)
+	})
+}
@@ -5,12 +5,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/livechat-integrations/go-billing-sdk/v2/common"
-	"github.com/livechat-integrations/go-billing-sdk/v2/internal/livechat"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
 )
 
 const (
@@ -18,6 +23,9 @@ const (
 	ChargeFrequencyAnnually = 12
 )
 
+// ErrBillingAPIUnavailable is returned while the LiveChat Billing API circuit breaker is open.
+var ErrBillingAPIUnavailable = livechat.ErrBillingAPIUnavailable
+
 type (
 	EventIDCtxKey              struct{}
 	LicenseIDCtxKey            struct{}
@@ -36,6 +44,11 @@ type ServiceInterface interface {
 	GetSubscriptionsByOrganizationID(ctx context.Context, lcOrganizationID string) ([]Subscription, error)
 	SyncCharges(ctx context.Context) error
 	CleanupFailedCharges(ctx context.Context) error
+	DryRunCleanupFailedCharges(ctx context.Context) (*CleanupReport, error)
+	GetQuarantinedCharges(ctx context.Context) ([]Charge, error)
+	ConfirmChargeCleanup(ctx context.Context, id string) error
+	Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconciliationReport, error)
+	BackfillApplicationID(ctx context.Context) error
 
 	// Trial methods
 	CreateRecurrentChargeWithTrial(ctx context.Context, name string, price int, lcOrganizationID string, chargeFrequency int) (string, error)
@@ -50,41 +63,141 @@ type Service struct {
 	plans        Plans
 	returnURL    string
 	masterOrgID  string
+	syncPolicy   SyncPolicy
+	retryPolicy  RetryPolicy
+	breaker      CircuitBreakerPolicy
+	apiOptions   []livechat.Option
+	metrics      metrics.Metrics
+	tracer       trace.Tracer
+	logger       *slog.Logger
+
+	defaultApplicationID string
+	applicationConfigs   []Application
+	applications         map[string]*application
 }
 
-func NewService(eventService events.EventService, idProvider events.IdProviderInterface, httpClient *http.Client, livechatEnvironment string, tokenFn common.TokenFn, storage Storage, plans Plans, returnUrl, masterOrgID string) *Service {
-	a := &livechat.Api{
-		HttpClient: httpClient,
-		ApiBaseURL: events.EnvURL(livechat.BillingAPIBaseURL, livechatEnvironment),
-		TokenFn:    tokenFn,
+// Option configures optional Service behaviour.
+type Option func(*Service)
+
+// RetryPolicy configures retries of LiveChat Billing API calls.
+type RetryPolicy = livechat.RetryPolicy
+
+// WithRetryPolicy replaces livechat.DefaultRetryPolicy. Charge creation is never retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *Service) {
+		s.retryPolicy = policy
 	}
+}
+
+// CircuitBreakerPolicy configures when LiveChat Billing API calls fail fast with ErrBillingAPIUnavailable.
+type CircuitBreakerPolicy = livechat.CircuitBreakerPolicy
 
-	return &Service{
-		billingAPI:   a,
+// WithCircuitBreaker replaces livechat.DefaultCircuitBreakerPolicy. A zero FailureRate disables the breaker.
+func WithCircuitBreaker(policy CircuitBreakerPolicy) Option {
+	return func(s *Service) {
+		s.breaker = policy
+	}
+}
+
+// WithAPIOptions configures the LiveChat Billing API client, e.g. with livechat.WithTimeout or livechat.WithUserAgent.
+func WithAPIOptions(opts ...livechat.Option) Option {
+	return func(s *Service) {
+		s.apiOptions = append(s.apiOptions, opts...)
+	}
+}
+
+// WithMetrics reports the SyncCharges and CleanupFailedCharges runs and the LiveChat Billing API calls to m.
+func WithMetrics(m metrics.Metrics) Option {
+	return func(s *Service) {
+		s.metrics = m
+	}
+}
+
+// WithSyncPolicy replaces DefaultSyncPolicy.
+func WithSyncPolicy(policy SyncPolicy) Option {
+	return func(s *Service) {
+		s.syncPolicy = policy
+	}
+}
+
+func NewService(eventService events.EventService, idProvider events.IdProviderInterface, httpClient *http.Client, livechatEnvironment string, tokenFn common.TokenFn, storage Storage, plans Plans, returnUrl, masterOrgID string, opts ...Option) *Service {
+	s := &Service{
 		eventService: eventService,
 		idProvider:   idProvider,
 		storage:      storage,
 		plans:        plans,
 		returnURL:    returnUrl,
 		masterOrgID:  masterOrgID,
+		syncPolicy:   DefaultSyncPolicy(),
+		retryPolicy:  livechat.DefaultRetryPolicy(),
+		breaker:      livechat.DefaultCircuitBreakerPolicy(),
+		metrics:      metrics.Noop{},
+		logger:       slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.tracer != nil {
+		s.storage = &tracingStorage{Storage: s.storage, tracer: s.tracer}
+	}
+
+	apiOptions := append([]livechat.Option{
+		livechat.WithRetryPolicy(s.retryPolicy),
+		livechat.WithCircuitBreaker(s.breaker),
+		livechat.WithMetrics(s.metrics),
+	}, s.apiOptions...)
+	apiURL := events.EnvURL(livechat.BillingAPIBaseURL, livechatEnvironment)
+	s.billingAPI = livechat.NewApi(httpClient, apiURL, tokenFn, apiOptions...)
+
+	if len(s.applicationConfigs) > 0 {
+		s.applications = make(map[string]*application, len(s.applicationConfigs)+1)
+		if s.defaultApplicationID != "" {
+			s.applications[s.defaultApplicationID] = s.defaultApplication()
+		}
+		for _, app := range s.applicationConfigs {
+			s.applications[app.ID] = &application{
+				id:          app.ID,
+				billingAPI:  livechat.NewApi(httpClient, apiURL, app.TokenFn, append(apiOptions[:len(apiOptions):len(apiOptions)], app.APIOptions...)...),
+				returnURL:   app.ReturnURL,
+				masterOrgID: app.MasterOrgID,
+			}
+		}
 	}
+
+	return s
 }
 
-func (s *Service) CreateRecurrentChargeWithTrial(ctx context.Context, name string, price int, lcOrganizationID string, chargeFrequency int) (string, error) {
+func (s *Service) CreateRecurrentChargeWithTrial(ctx context.Context, name string, price int, lcOrganizationID string, chargeFrequency int) (_ string, err error) {
+	ctx, end := s.start(ctx, "CreateRecurrentChargeWithTrial", tracing.OrganizationIDKey.String(lcOrganizationID))
+	defer func() { end(err) }()
+
 	return s.createRecurrentChargeInternal(ctx, name, price, lcOrganizationID, chargeFrequency, 7)
 }
 
-func (s *Service) CreateRecurrentCharge(ctx context.Context, name string, price int, lcOrganizationID string, chargeFrequency int) (string, error) {
+func (s *Service) CreateRecurrentCharge(ctx context.Context, name string, price int, lcOrganizationID string, chargeFrequency int) (_ string, err error) {
+	ctx, end := s.start(ctx, "CreateRecurrentCharge", tracing.OrganizationIDKey.String(lcOrganizationID))
+	defer func() { end(err) }()
+
 	return s.createRecurrentChargeInternal(ctx, name, price, lcOrganizationID, chargeFrequency, 0)
 }
 
 func (s *Service) createRecurrentChargeInternal(ctx context.Context, name string, price int, lcOrganizationID string, chargeFrequency int, trialDays int) (string, error) {
-	event := s.eventService.ToEvent(ctx, lcOrganizationID, events.EventActionCreateCharge, events.EventTypeInfo, map[string]interface{}{"name": name, "price": price, "chargeFrequency": chargeFrequency})
-	lcCharge, err := s.billingAPI.CreateRecurrentCharge(ctx, livechat.CreateRecurrentChargeParams{
+	eventPayload := CreateChargeEventPayload{Name: name, Price: price, ChargeFrequency: chargeFrequency, TrialDays: trialDays}
+	event := s.eventService.ToEvent(ctx, lcOrganizationID, events.EventActionCreateCharge, events.EventTypeInfo, eventPayload)
+	app, err := s.application(ctx)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return "", s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   err,
+		})
+	}
+
+	lcCharge, err := app.billingAPI.CreateRecurrentCharge(ctx, livechat.CreateRecurrentChargeParams{
 		Name:      name,
-		ReturnURL: s.returnURL,
+		ReturnURL: app.returnURL,
 		Price:     price,
-		Test:      s.masterOrgID == lcOrganizationID,
+		Test:      app.masterOrgID == lcOrganizationID,
 		TrialDays: trialDays,
 		Months:    chargeFrequency,
 	})
@@ -108,6 +221,7 @@ func (s *Service) createRecurrentChargeInternal(ctx context.Context, name string
 	rawCharge, _ := json.Marshal(lcCharge)
 	charge := Charge{
 		LCOrganizationID: lcOrganizationID,
+		ApplicationID:    app.id,
 		ID:               lcCharge.ID,
 		Type:             ChargeTypeRecurring,
 		Payload:          rawCharge,
@@ -121,15 +235,34 @@ func (s *Service) createRecurrentChargeInternal(ctx context.Context, name string
 		})
 	}
 
-	event.SetPayload(charge)
-	_ = s.eventService.CreateEvent(ctx, event)
+	eventPayload.Charge = lcCharge
+	event.SetPayload(eventPayload)
+	s.createEvent(ctx, event)
 
 	return lcCharge.ID, nil
 }
 
-func (s *Service) SyncRecurrentCharge(ctx context.Context, lcOrganizationID string, id string) error {
-	event := s.eventService.ToEvent(ctx, lcOrganizationID, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, map[string]interface{}{"id": id})
-	charge, err := s.storage.GetCharge(ctx, id)
+func (s *Service) SyncRecurrentCharge(ctx context.Context, lcOrganizationID string, id string) (err error) {
+	ctx, end := s.start(ctx, "SyncRecurrentCharge", tracing.OrganizationIDKey.String(lcOrganizationID), tracing.ChargeIDKey.String(id))
+	defer func() { end(err) }()
+
+	return s.storage.WithChargeLock(ctx, id, func(ctx context.Context) error {
+		return s.syncRecurrentCharge(ctx, lcOrganizationID, id)
+	})
+}
+
+func (s *Service) syncRecurrentCharge(ctx context.Context, lcOrganizationID string, id string) error {
+	event := s.eventService.ToEvent(ctx, lcOrganizationID, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: id})
+	app, err := s.application(ctx)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   err,
+		})
+	}
+
+	charge, err := s.storage.GetCharge(ctx, app.id, id)
 	if err != nil {
 		event.Type = events.EventTypeError
 		return s.eventService.ToError(ctx, events.ToErrorParams{
@@ -146,7 +279,14 @@ func (s *Service) SyncRecurrentCharge(ctx context.Context, lcOrganizationID stri
 		})
 	}
 
-	lcCharge, err := s.billingAPI.GetRecurrentCharge(ctx, id)
+	lcCharge, err := app.billingAPI.GetRecurrentCharge(ctx, id)
+	if errors.Is(err, livechat.ErrNotFound) {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("recurrent charge not found in LiveChat: %w", err),
+		})
+	}
 	if err != nil {
 		event.Type = events.EventTypeError
 		return s.eventService.ToError(ctx, events.ToErrorParams{
@@ -164,18 +304,38 @@ func (s *Service) SyncRecurrentCharge(ctx context.Context, lcOrganizationID stri
 		})
 	}
 
-	event.SetPayload(lcCharge)
-	_ = s.eventService.CreateEvent(ctx, event)
+	if charge.SyncErrorCount > 0 {
+		s.logChargeError(ctx, "failed to reset charge sync error count", id, s.storage.ResetChargeSyncErrorCount(ctx, id))
+	}
+
+	event.SetPayload(SyncRecurrentChargeEventPayload{ChargeID: id, Charge: lcCharge})
+	s.createEvent(ctx, event)
 
 	return nil
 }
 
-func (s *Service) GetCharge(ctx context.Context, id string) (*Charge, error) {
-	return s.storage.GetCharge(ctx, id)
+func (s *Service) GetCharge(ctx context.Context, id string) (_ *Charge, err error) {
+	ctx, end := s.start(ctx, "GetCharge", tracing.ChargeIDKey.String(id))
+	defer func() { end(err) }()
+
+	app, err := s.application(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetCharge(ctx, app.id, id)
 }
 
-func (s *Service) IsPremium(ctx context.Context, id string) (bool, error) {
-	sub, err := s.storage.GetSubscriptionsByOrganizationID(ctx, id)
+func (s *Service) IsPremium(ctx context.Context, id string) (_ bool, err error) {
+	ctx, end := s.start(ctx, "IsPremium", tracing.OrganizationIDKey.String(id))
+	defer func() { end(err) }()
+
+	app, err := s.application(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	sub, err := s.storage.GetSubscriptionsByOrganizationID(ctx, app.id, id)
 	if err != nil {
 		return false, fmt.Errorf("failed to get charge by installation id: %w", err)
 	}
@@ -184,8 +344,16 @@ func (s *Service) IsPremium(ctx context.Context, id string) (bool, error) {
 	return len(sub) > 0 && sub[0].IsActive(), nil
 }
 
-func (s *Service) GetActiveSubscriptionsByOrganizationID(ctx context.Context, lcOrganizationID string) ([]Subscription, error) {
-	subs, err := s.storage.GetSubscriptionsByOrganizationID(ctx, lcOrganizationID)
+func (s *Service) GetActiveSubscriptionsByOrganizationID(ctx context.Context, lcOrganizationID string) (_ []Subscription, err error) {
+	ctx, end := s.start(ctx, "GetActiveSubscriptionsByOrganizationID", tracing.OrganizationIDKey.String(lcOrganizationID))
+	defer func() { end(err) }()
+
+	app, err := s.application(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := s.storage.GetSubscriptionsByOrganizationID(ctx, app.id, lcOrganizationID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get subscriptions by organization id: %w", err)
 	}
@@ -199,8 +367,16 @@ func (s *Service) GetActiveSubscriptionsByOrganizationID(ctx context.Context, lc
 	return res, nil
 }
 
-func (s *Service) GetSubscriptionsByOrganizationID(ctx context.Context, lcOrganizationID string) ([]Subscription, error) {
-	subs, err := s.storage.GetSubscriptionsByOrganizationID(ctx, lcOrganizationID)
+func (s *Service) GetSubscriptionsByOrganizationID(ctx context.Context, lcOrganizationID string) (_ []Subscription, err error) {
+	ctx, end := s.start(ctx, "GetSubscriptionsByOrganizationID", tracing.OrganizationIDKey.String(lcOrganizationID))
+	defer func() { end(err) }()
+
+	app, err := s.application(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := s.storage.GetSubscriptionsByOrganizationID(ctx, app.id, lcOrganizationID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get subscriptions by organization id: %w", err)
 	}
@@ -208,9 +384,17 @@ func (s *Service) GetSubscriptionsByOrganizationID(ctx context.Context, lcOrgani
 	return subs, nil
 }
 
-func (s *Service) CreateSubscription(ctx context.Context, lcOrganizationID string, chargeID string, planName string) error {
+func (s *Service) CreateSubscription(ctx context.Context, lcOrganizationID string, chargeID string, planName string) (err error) {
+	ctx, end := s.start(ctx, "CreateSubscription", tracing.OrganizationIDKey.String(lcOrganizationID), tracing.ChargeIDKey.String(chargeID))
+	defer func() { end(err) }()
+
 	// Get charge first to determine if it's a trial
-	charge, err := s.storage.GetCharge(ctx, chargeID)
+	app, err := s.application(ctx)
+	if err != nil {
+		return err
+	}
+
+	charge, err := s.storage.GetCharge(ctx, app.id, chargeID)
 	if err != nil {
 		return fmt.Errorf("failed to get charge: %w", err)
 	}
@@ -222,16 +406,12 @@ func (s *Service) CreateSubscription(ctx context.Context, lcOrganizationID strin
 	// Check if this is a trial charge
 	isTrial := isTrialCharge(charge)
 
-	// Create event with trial metadata if applicable
-	eventPayload := map[string]interface{}{"planName": planName, "chargeID": chargeID}
-	if isTrial {
-		eventPayload["trial"] = true
-	}
+	eventPayload := CreateSubscriptionEventPayload{PlanName: planName, ChargeID: chargeID, Trial: isTrial}
 	event := s.eventService.ToEvent(ctx, lcOrganizationID, events.EventActionCreateSubscription, events.EventTypeInfo, eventPayload)
 
 	// If trial, check if trial already used
 	if isTrial {
-		hasUsed, err := s.storage.HasUsedTrial(ctx, lcOrganizationID)
+		hasUsed, err := s.storage.HasUsedTrial(ctx, app.id, lcOrganizationID)
 		if err != nil {
 			event.Type = events.EventTypeError
 			return s.eventService.ToError(ctx, events.ToErrorParams{
@@ -248,7 +428,7 @@ func (s *Service) CreateSubscription(ctx context.Context, lcOrganizationID strin
 		}
 	}
 
-	dbSubscriptions, err := s.storage.GetSubscriptionsByOrganizationID(ctx, lcOrganizationID)
+	dbSubscriptions, err := s.storage.GetSubscriptionsByOrganizationID(ctx, app.id, lcOrganizationID)
 	if err != nil {
 		event.Type = events.EventTypeError
 		return s.eventService.ToError(ctx, events.ToErrorParams{
@@ -259,8 +439,9 @@ func (s *Service) CreateSubscription(ctx context.Context, lcOrganizationID strin
 
 	for _, sub := range dbSubscriptions {
 		if sub.Charge != nil && sub.Charge.ID == chargeID {
-			event.SetPayload(map[string]interface{}{"planName": planName, "chargeID": chargeID, "result": "subscription already exists"})
-			_ = s.eventService.CreateEvent(ctx, event)
+			eventPayload.Result = "subscription already exists"
+			event.SetPayload(eventPayload)
+			s.createEvent(ctx, event)
 			return nil
 		}
 	}
@@ -278,6 +459,7 @@ func (s *Service) CreateSubscription(ctx context.Context, lcOrganizationID strin
 		ID:               s.idProvider.GenerateId(),
 		Charge:           charge,
 		LCOrganizationID: lcOrganizationID,
+		ApplicationID:    app.id,
 		PlanName:         planName,
 	}); err != nil {
 		event.Type = events.EventTypeError
@@ -289,21 +471,34 @@ func (s *Service) CreateSubscription(ctx context.Context, lcOrganizationID strin
 
 	// If trial, record trial usage
 	if isTrial {
-		if err = s.storage.RecordTrialUsage(ctx, lcOrganizationID); err != nil {
+		if err = s.storage.RecordTrialUsage(ctx, app.id, lcOrganizationID); err != nil {
 			// Log warning but don't fail - subscription is already created
-			fmt.Printf("Warning: failed to record trial usage for org %s: %v\n", lcOrganizationID, err)
+			s.logger.WarnContext(ctx, "failed to record trial usage", string(tracing.OrganizationIDKey), lcOrganizationID, string(tracing.ChargeIDKey), chargeID, "error", err)
 		}
 	}
 
-	event.SetPayload(charge)
-	_ = s.eventService.CreateEvent(ctx, event)
+	eventPayload.Result = "success"
+	event.SetPayload(eventPayload)
+	s.createEvent(ctx, event)
 
 	return nil
 }
 
-func (s *Service) DeleteSubscriptionWithCharge(ctx context.Context, lcOrganizationID string, chargeID string) error {
-	event := s.eventService.ToEvent(ctx, lcOrganizationID, events.EventActionDeleteSubscriptionWithCharge, events.EventTypeInfo, map[string]interface{}{"chargeID": chargeID})
-	if err := s.storage.DeleteSubscriptionByChargeID(ctx, lcOrganizationID, chargeID); err != nil {
+func (s *Service) DeleteSubscriptionWithCharge(ctx context.Context, lcOrganizationID string, chargeID string) (err error) {
+	ctx, end := s.start(ctx, "DeleteSubscriptionWithCharge", tracing.OrganizationIDKey.String(lcOrganizationID), tracing.ChargeIDKey.String(chargeID))
+	defer func() { end(err) }()
+
+	event := s.eventService.ToEvent(ctx, lcOrganizationID, events.EventActionDeleteSubscriptionWithCharge, events.EventTypeInfo, DeleteSubscriptionWithChargeEventPayload{ChargeID: chargeID})
+	app, err := s.application(ctx)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   err,
+		})
+	}
+
+	if err := s.storage.DeleteSubscriptionByChargeID(ctx, app.id, lcOrganizationID, chargeID); err != nil {
 		event.Type = events.EventTypeError
 		return s.eventService.ToError(ctx, events.ToErrorParams{
 			Event: event,
@@ -311,7 +506,7 @@ func (s *Service) DeleteSubscriptionWithCharge(ctx context.Context, lcOrganizati
 		})
 	}
 
-	if err := s.storage.DeleteCharge(ctx, chargeID); err != nil {
+	if err := s.storage.DeleteCharge(ctx, app.id, chargeID); err != nil {
 		event.Type = events.EventTypeError
 		return s.eventService.ToError(ctx, events.ToErrorParams{
 			Event: event,
@@ -319,13 +514,21 @@ func (s *Service) DeleteSubscriptionWithCharge(ctx context.Context, lcOrganizati
 		})
 	}
 
-	_ = s.eventService.CreateEvent(ctx, event)
+	s.createEvent(ctx, event)
 
 	return nil
 }
 
-func (s *Service) GetChargesByOrganizationID(ctx context.Context, lcOrganizationID string) ([]Charge, error) {
-	rows, err := s.storage.GetChargesByOrganizationID(ctx, lcOrganizationID)
+func (s *Service) GetChargesByOrganizationID(ctx context.Context, lcOrganizationID string) (_ []Charge, err error) {
+	ctx, end := s.start(ctx, "GetChargesByOrganizationID", tracing.OrganizationIDKey.String(lcOrganizationID))
+	defer func() { end(err) }()
+
+	app, err := s.application(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.storage.GetChargesByOrganizationID(ctx, app.id, lcOrganizationID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get charges by organization id: %w", err)
 	}
@@ -333,83 +536,71 @@ func (s *Service) GetChargesByOrganizationID(ctx context.Context, lcOrganization
 	return rows, nil
 }
 
-func (s *Service) CancelRecurrentCharge(ctx context.Context, chargeID string) error {
-	recCharge, _ := s.billingAPI.CancelRecurrentCharge(ctx, chargeID)
+func (s *Service) CancelRecurrentCharge(ctx context.Context, chargeID string) (err error) {
+	ctx, end := s.start(ctx, "CancelRecurrentCharge", tracing.ChargeIDKey.String(chargeID))
+	defer func() { end(err) }()
 
-	rawCharge, _ := json.Marshal(recCharge)
-	if err := s.storage.UpdateChargePayload(ctx, chargeID, rawCharge); err != nil {
-		return fmt.Errorf("failed to update charge payload: %w", err)
-	}
+	app, err := s.application(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.WithChargeLock(ctx, chargeID, func(ctx context.Context) error {
+		recCharge, err := app.billingAPI.CancelRecurrentCharge(ctx, chargeID)
+		switch {
+		case errors.Is(err, livechat.ErrNotFound):
+			return fmt.Errorf("recurrent charge not found in LiveChat: %w", err)
+		case errors.Is(err, livechat.ErrUnprocessableEntity):
+			return fmt.Errorf("charge cannot be cancelled: %w", err)
+		case err != nil:
+			return fmt.Errorf("failed to cancel recurrent charge: %w", err)
+		}
 
-	return nil
+		rawCharge, _ := json.Marshal(recCharge)
+		if err := s.storage.UpdateChargePayload(ctx, chargeID, rawCharge); err != nil {
+			return fmt.Errorf("failed to update charge payload: %w", err)
+		}
+
+		return nil
+	})
 }
 
-func (s *Service) SyncCharges(ctx context.Context) error {
-	charges, err := s.storage.GetChargesByStatuses(ctx, GetSyncValidStatuses())
+func (s *Service) SyncCharges(ctx context.Context) (err error) {
+	ctx, end := s.start(ctx, "SyncCharges")
+	defer func() { end(err) }()
+
+	start := time.Now()
+	defer func() {
+		metrics.ObserveSince(s.metrics, metrics.RunDurationSeconds, metrics.Labels{Action: metrics.ActionSyncCharges, Status: metrics.Status(err)}, start)
+	}()
+
+	charges, err := s.storage.GetChargesByStatuses(ctx, GetSyncValidStatuses(), s.syncPolicy.Backoff)
 	if err != nil {
 		return fmt.Errorf("failed to get charges by statuses: %w", err)
 	}
+	s.metrics.SetGauge(metrics.RunCharges, metrics.Labels{Action: metrics.ActionSyncCharges}, float64(len(charges)))
 
 	var errs []error
 
 	for _, charge := range charges {
 		organizationCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, charge.LCOrganizationID)
+		organizationCtx = context.WithValue(organizationCtx, ApplicationIDCtxKey{}, charge.ApplicationID)
 		organizationCtx = context.WithValue(organizationCtx, EventIDCtxKey{}, s.idProvider.GenerateId())
+		chargeCtx, end := s.start(organizationCtx, "syncCharge", tracing.OrganizationIDKey.String(charge.LCOrganizationID), tracing.ChargeIDKey.String(charge.ID))
 
-		var recCharge livechat.RecurrentCharge
-		_ = json.Unmarshal(charge.Payload, &recCharge)
-		if recCharge.Status == livechat.RecurrentChargeStatusActive && recCharge.NextChargeAt != nil && recCharge.NextChargeAt.After(time.Now()) {
-			continue
-		}
-
-		if recCharge.Status == livechat.RecurrentChargeStatusPending && recCharge.CreatedAt.AddDate(0, 1, 0).Before(time.Now()) {
-			if err = s.cancelChange(organizationCtx, charge); err != nil {
-				errs = append(errs, err)
-			}
-
-			continue
-		}
-
-		event := s.eventService.ToEvent(organizationCtx, charge.LCOrganizationID, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, map[string]interface{}{"id": charge.ID})
-		lcCharge, err := s.billingAPI.GetRecurrentCharge(organizationCtx, charge.ID)
+		err = s.storage.WithChargeLock(chargeCtx, charge.ID, func(ctx context.Context) error {
+			return s.syncCharge(ctx, charge.ApplicationID, charge.ID)
+		})
+		end(err)
+		s.metrics.IncCounter(metrics.ChargesTotal, metrics.Labels{Action: metrics.ActionSyncCharges, Status: metrics.Status(err)})
 		if err != nil {
-			event.Type = events.EventTypeError
-			errs = append(errs, s.eventService.ToError(organizationCtx, events.ToErrorParams{
-				Event: event,
-				Err:   fmt.Errorf("failed to get recurrent charge: %w", err),
-			}))
-			_ = s.storage.IncrementChargeSyncErrorCount(organizationCtx, charge.ID)
-			continue
-		}
-
-		switch lcCharge.Status {
-		case livechat.RecurrentChargeStatusAccepted,
-			livechat.RecurrentChargeStatusFrozen:
-			lcCharge, err = s.billingAPI.ActivateRecurrentCharge(organizationCtx, charge.ID)
-			if err != nil {
-				event.Type = events.EventTypeError
-				errs = append(errs, s.eventService.ToError(organizationCtx, events.ToErrorParams{
-					Event: event,
-					Err:   fmt.Errorf("failed to activate charge: %w", err),
-				}))
-				_ = s.storage.IncrementChargeSyncErrorCount(organizationCtx, charge.ID)
-				continue
+			// The remaining charges would fail the same way, resume on the next run.
+			if errors.Is(err, ErrBillingAPIUnavailable) {
+				errs = append(errs, fmt.Errorf("sync charges paused: %w", err))
+				break
 			}
+			errs = append(errs, err)
 		}
-
-		rawCharge, _ := json.Marshal(lcCharge)
-		if err = s.storage.UpdateChargePayload(organizationCtx, charge.ID, rawCharge); err != nil {
-			event.Type = events.EventTypeError
-			errs = append(errs, s.eventService.ToError(organizationCtx, events.ToErrorParams{
-				Event: event,
-				Err:   fmt.Errorf("failed to update charge payload: %w", err),
-			}))
-			_ = s.storage.IncrementChargeSyncErrorCount(organizationCtx, charge.ID)
-			continue
-		}
-
-		event.SetPayload(lcCharge)
-		_ = s.eventService.CreateEvent(organizationCtx, event)
 	}
 
 	if len(errs) > 0 {
@@ -419,42 +610,108 @@ func (s *Service) SyncCharges(ctx context.Context) error {
 	return nil
 }
 
-func (s *Service) CleanupFailedCharges(ctx context.Context) error {
-	charges, err := s.storage.GetChargesWithHighErrorCount(ctx, 10)
+// syncCharge re-reads the charge under its lock, the list fetched by SyncCharges may already be stale.
+func (s *Service) syncCharge(ctx context.Context, applicationID, id string) error {
+	charge, err := s.storage.GetCharge(ctx, applicationID, id)
 	if err != nil {
-		return fmt.Errorf("failed to get charges with high error count: %w", err)
+		if errors.Is(err, ErrChargeNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get charge %s: %w", id, err)
+	}
+	if charge == nil {
+		return nil
 	}
 
-	var errs []error
+	var recCharge livechat.RecurrentCharge
+	_ = json.Unmarshal(charge.Payload, &recCharge)
+	if recCharge.Status == livechat.RecurrentChargeStatusActive && recCharge.NextChargeAt != nil && recCharge.NextChargeAt.After(time.Now()) {
+		return nil
+	}
 
-	for _, charge := range charges {
-		organizationCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, charge.LCOrganizationID)
-		organizationCtx = context.WithValue(organizationCtx, EventIDCtxKey{}, s.idProvider.GenerateId())
+	if recCharge.Status == livechat.RecurrentChargeStatusPending && recCharge.CreatedAt.AddDate(0, 1, 0).Before(time.Now()) {
+		return s.cancelChange(ctx, *charge)
+	}
+
+	event := s.eventService.ToEvent(ctx, charge.LCOrganizationID, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: charge.ID})
+	app, err := s.applicationByID(charge.ApplicationID)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   err,
+		})
+	}
 
-		event := s.eventService.ToEvent(organizationCtx, charge.LCOrganizationID, events.EventActionCleanupFailedCharge, events.EventTypeInfo, map[string]interface{}{"id": charge.ID, "sync_error_count": charge.SyncErrorCount})
+	lcCharge, err := app.billingAPI.GetRecurrentCharge(ctx, charge.ID)
+	if errors.Is(err, ErrBillingAPIUnavailable) {
+		return err
+	}
+	if err != nil {
+		event.Type = events.EventTypeError
+		err = s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to get recurrent charge: %w", err),
+		})
+		s.logChargeError(ctx, "failed to increment charge sync error count", charge.ID, s.storage.IncrementChargeSyncErrorCount(ctx, charge.ID))
+		return err
+	}
 
-		if err = s.storage.DeleteCharge(organizationCtx, charge.ID); err != nil {
+	switch lcCharge.Status {
+	case livechat.RecurrentChargeStatusAccepted,
+		livechat.RecurrentChargeStatusFrozen:
+		lcCharge, err = app.billingAPI.ActivateRecurrentCharge(ctx, charge.ID)
+		s.metrics.IncCounter(metrics.ChargesTotal, metrics.Labels{Action: metrics.ActionActivateCharge, Status: metrics.Status(err)})
+		if errors.Is(err, ErrBillingAPIUnavailable) {
+			return err
+		}
+		if err != nil {
 			event.Type = events.EventTypeError
-			errs = append(errs, s.eventService.ToError(organizationCtx, events.ToErrorParams{
+			err = s.eventService.ToError(ctx, events.ToErrorParams{
 				Event: event,
-				Err:   fmt.Errorf("failed to delete charge: %w", err),
-			}))
-			continue
+				Err:   fmt.Errorf("failed to activate charge: %w", err),
+			})
+			s.logChargeError(ctx, "failed to increment charge sync error count", charge.ID, s.storage.IncrementChargeSyncErrorCount(ctx, charge.ID))
+			return err
 		}
+	}
 
-		_ = s.eventService.CreateEvent(organizationCtx, event)
+	rawCharge, _ := json.Marshal(lcCharge)
+	if err = s.storage.UpdateChargePayload(ctx, charge.ID, rawCharge); err != nil {
+		event.Type = events.EventTypeError
+		err = s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to update charge payload: %w", err),
+		})
+		s.logChargeError(ctx, "failed to increment charge sync error count", charge.ID, s.storage.IncrementChargeSyncErrorCount(ctx, charge.ID))
+		return err
 	}
 
-	if len(errs) > 0 {
-		return errors.Join(errs...)
+	if charge.SyncErrorCount > 0 {
+		s.logChargeError(ctx, "failed to reset charge sync error count", charge.ID, s.storage.ResetChargeSyncErrorCount(ctx, charge.ID))
 	}
 
+	event.SetPayload(SyncRecurrentChargeEventPayload{ChargeID: charge.ID, Charge: lcCharge})
+	s.createEvent(ctx, event)
+
 	return nil
 }
 
-func (s *Service) DeleteSubscription(ctx context.Context, lcOrganizationID, subscriptionID string) error {
-	event := s.eventService.ToEvent(ctx, lcOrganizationID, events.EventActionDeleteSubscription, events.EventTypeInfo, map[string]interface{}{"subscriptionID": subscriptionID})
-	subs, err := s.storage.GetSubscriptionsByOrganizationID(ctx, lcOrganizationID)
+func (s *Service) DeleteSubscription(ctx context.Context, lcOrganizationID, subscriptionID string) (err error) {
+	ctx, end := s.start(ctx, "DeleteSubscription", tracing.OrganizationIDKey.String(lcOrganizationID))
+	defer func() { end(err) }()
+
+	event := s.eventService.ToEvent(ctx, lcOrganizationID, events.EventActionDeleteSubscription, events.EventTypeInfo, DeleteSubscriptionEventPayload{SubscriptionID: subscriptionID})
+	app, err := s.application(ctx)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   err,
+		})
+	}
+
+	subs, err := s.storage.GetSubscriptionsByOrganizationID(ctx, app.id, lcOrganizationID)
 	if err != nil {
 		event.Type = events.EventTypeError
 		return s.eventService.ToError(ctx, events.ToErrorParams{
@@ -478,7 +735,7 @@ func (s *Service) DeleteSubscription(ctx context.Context, lcOrganizationID, subs
 		})
 	}
 
-	if err = s.storage.DeleteSubscription(ctx, lcOrganizationID, subscriptionID); err != nil {
+	if err = s.storage.DeleteSubscription(ctx, app.id, lcOrganizationID, subscriptionID); err != nil {
 		event.Type = events.EventTypeError
 		return s.eventService.ToError(ctx, events.ToErrorParams{
 			Event: event,
@@ -487,7 +744,7 @@ func (s *Service) DeleteSubscription(ctx context.Context, lcOrganizationID, subs
 	}
 
 	if sub.Charge == nil {
-		_ = s.eventService.CreateEvent(ctx, event)
+		s.createEvent(ctx, event)
 		return nil
 	}
 
@@ -499,13 +756,21 @@ func (s *Service) DeleteSubscription(ctx context.Context, lcOrganizationID, subs
 		})
 	}
 
-	_ = s.eventService.CreateEvent(ctx, event)
+	s.createEvent(ctx, event)
 
 	return nil
 }
 
-func (s *Service) HasUsedTrial(ctx context.Context, lcOrganizationID string) (bool, error) {
-	return s.storage.HasUsedTrial(ctx, lcOrganizationID)
+func (s *Service) HasUsedTrial(ctx context.Context, lcOrganizationID string) (_ bool, err error) {
+	ctx, end := s.start(ctx, "HasUsedTrial", tracing.OrganizationIDKey.String(lcOrganizationID))
+	defer func() { end(err) }()
+
+	app, err := s.application(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return s.storage.HasUsedTrial(ctx, app.id, lcOrganizationID)
 }
 
 func isTrialCharge(charge *Charge) bool {
@@ -519,11 +784,23 @@ func isTrialCharge(charge *Charge) bool {
 }
 
 func (s *Service) cancelChange(ctx context.Context, charge Charge) error {
-	event := s.eventService.ToEvent(ctx, charge.LCOrganizationID, events.EventActionForceCancelCharge, events.EventTypeInfo, map[string]interface{}{"id": charge.ID})
-	cancelledCharge, err := s.billingAPI.CancelRecurrentCharge(ctx, charge.ID)
+	event := s.eventService.ToEvent(ctx, charge.LCOrganizationID, events.EventActionForceCancelCharge, events.EventTypeInfo, ForceCancelChargeEventPayload{ChargeID: charge.ID})
+	app, err := s.applicationByID(charge.ApplicationID)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   err,
+		})
+	}
+
+	cancelledCharge, err := app.billingAPI.CancelRecurrentCharge(ctx, charge.ID)
 	if err != nil {
 		if errors.Is(err, livechat.ErrUnprocessableEntity) {
-			return s.storage.DeleteCharge(ctx, charge.ID)
+			return s.storage.DeleteCharge(ctx, charge.ApplicationID, charge.ID)
+		}
+		if errors.Is(err, livechat.ErrNotFound) {
+			err = fmt.Errorf("recurrent charge not found in LiveChat: %w", err)
 		}
 
 		event.Type = events.EventTypeError
@@ -542,6 +819,6 @@ func (s *Service) cancelChange(ctx context.Context, charge Charge) error {
 		})
 	}
 
-	_ = s.eventService.CreateEvent(ctx, event)
+	s.createEvent(ctx, event)
 	return nil
 }
@@ -5,14 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
-	"github.com/livechat-integrations/go-billing-sdk/v2/internal/livechat"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics/metricstest"
 )
 
 var am = new(apiMock)
@@ -31,13 +35,18 @@ var s = Service{
 	plans:        Plans{{Name: "super"}},
 	returnURL:    "returnURL",
 	masterOrgID:  "masterOrgID",
+	metrics:      metrics.Noop{},
+	logger:       discardLogger,
 }
 var ctx = context.Background()
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
 
 var assertExpectations = func(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, am, sm, em, xm, bm)
 	am.Calls = nil
 	sm.Calls = nil
+	sm.lockedCharges = nil
+	sm.lockErr = nil
 	em.Calls = nil
 	xm.Calls = nil
 	bm.Calls = nil
@@ -164,6 +173,17 @@ func (m *apiMock) ActivateDirectCharge(ctx context.Context, id string) (*livecha
 
 type storageMock struct {
 	mock.Mock
+	// lockedCharges records WithChargeLock calls, the lock is a pass-through so it does not need expectations
+	lockedCharges []string
+	lockErr       error
+}
+
+func (m *storageMock) WithChargeLock(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	m.lockedCharges = append(m.lockedCharges, id)
+	if m.lockErr != nil {
+		return m.lockErr
+	}
+	return fn(ctx)
 }
 
 func (m *storageMock) CreateEvent(ctx context.Context, event events.Event) error {
@@ -171,8 +191,8 @@ func (m *storageMock) CreateEvent(ctx context.Context, event events.Event) error
 	return args.Error(0)
 }
 
-func (m *storageMock) GetChargesByOrganizationID(ctx context.Context, lcID string) ([]Charge, error) {
-	args := m.Called(ctx, lcID)
+func (m *storageMock) GetChargesByOrganizationID(ctx context.Context, applicationID, lcID string) ([]Charge, error) {
+	args := m.Called(ctx, applicationID, lcID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -180,13 +200,13 @@ func (m *storageMock) GetChargesByOrganizationID(ctx context.Context, lcID strin
 	return args.Get(0).([]Charge), args.Error(1)
 }
 
-func (m *storageMock) DeleteCharge(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *storageMock) DeleteCharge(ctx context.Context, applicationID, id string) error {
+	args := m.Called(ctx, applicationID, id)
 	return args.Error(0)
 }
 
-func (m *storageMock) DeleteSubscriptionByChargeID(ctx context.Context, LCOrganizationID string, id string) error {
-	args := m.Called(ctx, LCOrganizationID, id)
+func (m *storageMock) DeleteSubscriptionByChargeID(ctx context.Context, applicationID, LCOrganizationID string, id string) error {
+	args := m.Called(ctx, applicationID, LCOrganizationID, id)
 	return args.Error(0)
 }
 
@@ -195,8 +215,8 @@ func (m *storageMock) CreateCharge(ctx context.Context, c Charge) error {
 	return args.Error(0)
 }
 
-func (m *storageMock) GetCharge(ctx context.Context, id string) (*Charge, error) {
-	args := m.Called(ctx, id)
+func (m *storageMock) GetCharge(ctx context.Context, applicationID, id string) (*Charge, error) {
+	args := m.Called(ctx, applicationID, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -223,8 +243,26 @@ func (m *storageMock) CreateSubscription(ctx context.Context, subscription Subsc
 	return args.Error(0)
 }
 
-func (m *storageMock) GetSubscriptionsByOrganizationID(ctx context.Context, lcID string) ([]Subscription, error) {
-	args := m.Called(ctx, lcID)
+func (m *storageMock) GetSubscriptions(ctx context.Context) ([]Subscription, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Subscription), args.Error(1)
+}
+
+func (m *storageMock) GetCharges(ctx context.Context) ([]Charge, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]Charge), args.Error(1)
+}
+
+func (m *storageMock) GetSubscriptionsByOrganizationID(ctx context.Context, applicationID, lcID string) ([]Subscription, error) {
+	args := m.Called(ctx, applicationID, lcID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -237,8 +275,8 @@ func (m *storageMock) UpdateSubscriptionNextChargeAt(ctx context.Context, id str
 	return args.Error(0)
 }
 
-func (m *storageMock) GetChargesByStatuses(ctx context.Context, statuses []string) ([]Charge, error) {
-	args := m.Called(ctx, statuses)
+func (m *storageMock) GetChargesByStatuses(ctx context.Context, statuses []string, backoff BackoffPolicy) ([]Charge, error) {
+	args := m.Called(ctx, statuses, backoff)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -246,26 +284,36 @@ func (m *storageMock) GetChargesByStatuses(ctx context.Context, statuses []strin
 	return args.Get(0).([]Charge), args.Error(1)
 }
 
-func (m *storageMock) DeleteSubscription(ctx context.Context, lcID, subID string) error {
-	args := m.Called(ctx, lcID, subID)
+func (m *storageMock) DeleteSubscription(ctx context.Context, applicationID, lcID, subID string) error {
+	args := m.Called(ctx, applicationID, lcID, subID)
 	return args.Error(0)
 }
 
-func (m *storageMock) RecordTrialUsage(ctx context.Context, lcOrganizationID string) error {
-	args := m.Called(ctx, lcOrganizationID)
+func (m *storageMock) RecordTrialUsage(ctx context.Context, applicationID, lcOrganizationID string) error {
+	args := m.Called(ctx, applicationID, lcOrganizationID)
 	return args.Error(0)
 }
 
-func (m *storageMock) HasUsedTrial(ctx context.Context, lcOrganizationID string) (bool, error) {
-	args := m.Called(ctx, lcOrganizationID)
+func (m *storageMock) HasUsedTrial(ctx context.Context, applicationID, lcOrganizationID string) (bool, error) {
+	args := m.Called(ctx, applicationID, lcOrganizationID)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *storageMock) BackfillApplicationID(ctx context.Context, applicationID string) (int64, error) {
+	args := m.Called(ctx, applicationID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *storageMock) IncrementChargeSyncErrorCount(ctx context.Context, chargeID string) error {
 	args := m.Called(ctx, chargeID)
 	return args.Error(0)
 }
 
+func (m *storageMock) ResetChargeSyncErrorCount(ctx context.Context, chargeID string) error {
+	args := m.Called(ctx, chargeID)
+	return args.Error(0)
+}
+
 func (m *storageMock) GetChargesWithHighErrorCount(ctx context.Context, threshold int) ([]Charge, error) {
 	args := m.Called(ctx, threshold)
 	if args.Get(0) == nil {
@@ -274,11 +322,99 @@ func (m *storageMock) GetChargesWithHighErrorCount(ctx context.Context, threshol
 	return args.Get(0).([]Charge), args.Error(1)
 }
 
+func (m *storageMock) QuarantineCharge(ctx context.Context, chargeID string) error {
+	args := m.Called(ctx, chargeID)
+	return args.Error(0)
+}
+
+func (m *storageMock) GetQuarantinedCharges(ctx context.Context) ([]Charge, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Charge), args.Error(1)
+}
+
 func TestNewService(t *testing.T) {
 	t.Run("NewService", func(t *testing.T) {
 		newService := NewService(nil, nil, nil, "labs", func(ctx context.Context) (string, error) { return "", nil }, &storageMock{}, nil, "returnURL", "masterOrgID")
 
 		assert.NotNil(t, newService)
+		assert.Equal(t, DefaultSyncPolicy(), newService.syncPolicy)
+	})
+
+	t.Run("WithSyncPolicy", func(t *testing.T) {
+		policy := SyncPolicy{MaxSyncErrors: 3, Backoff: BackoffPolicy{Initial: time.Second}}
+		newService := NewService(nil, nil, nil, "labs", func(ctx context.Context) (string, error) { return "", nil }, &storageMock{}, nil, "returnURL", "masterOrgID", WithSyncPolicy(policy))
+
+		assert.Equal(t, policy, newService.syncPolicy)
+	})
+
+	t.Run("WithRetryPolicy", func(t *testing.T) {
+		policy := RetryPolicy{MaxAttempts: 5}
+		newService := NewService(nil, nil, nil, "labs", func(ctx context.Context) (string, error) { return "", nil }, &storageMock{}, nil, "returnURL", "masterOrgID", WithRetryPolicy(policy))
+
+		assert.Equal(t, policy, newService.billingAPI.(*livechat.Api).Retry)
+	})
+
+	t.Run("WithAPIOptions", func(t *testing.T) {
+		newService := NewService(nil, nil, nil, "labs", func(ctx context.Context) (string, error) { return "", nil }, &storageMock{}, nil, "returnURL", "masterOrgID", WithCircuitBreaker(CircuitBreakerPolicy{}), WithAPIOptions(livechat.WithUserAgent("app/1.0")))
+
+		api := newService.billingAPI.(*livechat.Api)
+		assert.Equal(t, "app/1.0", api.UserAgent)
+		assert.Nil(t, api.Breaker)
+	})
+
+	t.Run("WithMetrics", func(t *testing.T) {
+		recorder := metricstest.NewRecorder()
+		newService := NewService(nil, nil, nil, "labs", func(ctx context.Context) (string, error) { return "", nil }, &storageMock{}, nil, "returnURL", "masterOrgID", WithMetrics(recorder))
+
+		assert.Equal(t, recorder, newService.metrics)
+		assert.Equal(t, recorder, newService.billingAPI.(*livechat.Api).Metrics)
+	})
+}
+
+func TestService_CancelRecurrentCharge(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		rc := &livechat.RecurrentCharge{BaseCharge: livechat.BaseCharge{ID: "id", Status: livechat.RecurrentChargeStatusCancelled}}
+		am.On("CancelRecurrentCharge", ctx, "id").Return(rc, nil).Once()
+		raw, _ := json.Marshal(rc)
+		sm.On("UpdateChargePayload", ctx, "id", json.RawMessage(raw)).Return(nil).Once()
+
+		err := s.CancelRecurrentCharge(ctx, "id")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"id"}, sm.lockedCharges)
+
+		assertExpectations(t)
+	})
+
+	t.Run("charge not found in LiveChat", func(t *testing.T) {
+		am.On("CancelRecurrentCharge", ctx, "id").Return(nil, livechat.ErrNotFound).Once()
+
+		err := s.CancelRecurrentCharge(ctx, "id")
+		assert.ErrorIs(t, err, livechat.ErrNotFound)
+		assert.ErrorContains(t, err, "recurrent charge not found in LiveChat")
+
+		assertExpectations(t)
+	})
+
+	t.Run("charge cannot be cancelled", func(t *testing.T) {
+		am.On("CancelRecurrentCharge", ctx, "id").Return(nil, livechat.ErrUnprocessableEntity).Once()
+
+		err := s.CancelRecurrentCharge(ctx, "id")
+		assert.ErrorIs(t, err, livechat.ErrUnprocessableEntity)
+
+		assertExpectations(t)
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		am.On("CancelRecurrentCharge", ctx, "id").Return(nil, assert.AnError).Once()
+
+		err := s.CancelRecurrentCharge(ctx, "id")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "failed to cancel recurrent charge")
+
+		assertExpectations(t)
 	})
 }
 
@@ -311,8 +447,10 @@ func TestService_CreateRecurrentCharge(t *testing.T) {
 			Months:    1,
 		}).Return(rc, nil).Once()
 		sm.On("CreateCharge", ctx, domainCharge).Return(nil).Once()
-		payload := map[string]interface{}{"name": "name", "price": 10, "chargeFrequency": 1}
-		sc, _ := json.Marshal(domainCharge)
+		payload := CreateChargeEventPayload{Name: "name", Price: 10, ChargeFrequency: 1}
+		created := payload
+		created.Version, created.Charge = 1, rc
+		sc, _ := json.Marshal(created)
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -359,8 +497,10 @@ func TestService_CreateRecurrentCharge(t *testing.T) {
 			Months:    1,
 		}).Return(rc, nil).Once()
 		sm.On("CreateCharge", ctx, domainCharge).Return(nil).Once()
-		payload := map[string]interface{}{"name": "name", "price": 10, "chargeFrequency": 1}
-		sc, _ := json.Marshal(domainCharge)
+		payload := CreateChargeEventPayload{Name: "name", Price: 10, ChargeFrequency: 1}
+		created := payload
+		created.Version, created.Charge = 1, rc
+		sc, _ := json.Marshal(created)
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: "masterOrgID",
@@ -388,7 +528,7 @@ func TestService_CreateRecurrentCharge(t *testing.T) {
 			TrialDays: 0,
 			Months:    1,
 		}).Return(nil, assert.AnError).Once()
-		payload := map[string]interface{}{"name": "name", "price": 10, "chargeFrequency": 1}
+		payload := CreateChargeEventPayload{Name: "name", Price: 10, ChargeFrequency: 1}
 		sc, _ := json.Marshal(payload)
 		levent := events.Event{
 			ID:               xid,
@@ -421,7 +561,7 @@ func TestService_CreateRecurrentCharge(t *testing.T) {
 			TrialDays: 0,
 			Months:    1,
 		}).Return(nil, nil).Once()
-		payload := map[string]interface{}{"name": "name", "price": 10, "chargeFrequency": 1}
+		payload := CreateChargeEventPayload{Name: "name", Price: 10, ChargeFrequency: 1}
 		sc, _ := json.Marshal(payload)
 		levent := events.Event{
 			ID:               xid,
@@ -474,7 +614,7 @@ func TestService_CreateRecurrentCharge(t *testing.T) {
 			Months:    1,
 		}).Return(rc, nil).Once()
 		sm.On("CreateCharge", ctx, domainCharge).Return(assert.AnError).Once()
-		payload := map[string]interface{}{"name": "name", "price": 10, "chargeFrequency": 1}
+		payload := CreateChargeEventPayload{Name: "name", Price: 10, ChargeFrequency: 1}
 		sc, _ := json.Marshal(payload)
 		levent := events.Event{
 			ID:               xid,
@@ -526,8 +666,10 @@ func TestService_CreateRecurrentCharge(t *testing.T) {
 			Months:    12,
 		}).Return(rc, nil).Once()
 		sm.On("CreateCharge", ctx, domainCharge).Return(nil).Once()
-		payload := map[string]interface{}{"name": "name", "price": 10, "chargeFrequency": 12}
-		sc, _ := json.Marshal(domainCharge)
+		payload := CreateChargeEventPayload{Name: "name", Price: 10, ChargeFrequency: 12}
+		created := payload
+		created.Version, created.Charge = 1, rc
+		sc, _ := json.Marshal(created)
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -574,8 +716,10 @@ func TestService_CreateRecurrentCharge(t *testing.T) {
 			Months:    ChargeFrequencyMonthly,
 		}).Return(rc, nil).Once()
 		sm.On("CreateCharge", ctx, domainCharge).Return(nil).Once()
-		payload := map[string]interface{}{"name": "name", "price": 10, "chargeFrequency": ChargeFrequencyMonthly}
-		sc, _ := json.Marshal(domainCharge)
+		payload := CreateChargeEventPayload{Name: "name", Price: 10, ChargeFrequency: ChargeFrequencyMonthly}
+		created := payload
+		created.Version, created.Charge = 1, rc
+		sc, _ := json.Marshal(created)
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -622,8 +766,10 @@ func TestService_CreateRecurrentCharge(t *testing.T) {
 			Months:    ChargeFrequencyAnnually,
 		}).Return(rc, nil).Once()
 		sm.On("CreateCharge", ctx, domainCharge).Return(nil).Once()
-		payload := map[string]interface{}{"name": "name", "price": 10, "chargeFrequency": ChargeFrequencyAnnually}
-		sc, _ := json.Marshal(domainCharge)
+		payload := CreateChargeEventPayload{Name: "name", Price: 10, ChargeFrequency: ChargeFrequencyAnnually}
+		created := payload
+		created.Version, created.Charge = 1, rc
+		sc, _ := json.Marshal(created)
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -649,8 +795,8 @@ func TestService_CreateSubscription(t *testing.T) {
 		charge := Charge{
 			ID: "id",
 		}
-		sm.On("GetCharge", ctx, "id").Return(&charge, nil).Once()
-		sm.On("GetSubscriptionsByOrganizationID", ctx, lcoid).Return([]Subscription{}, nil).Once()
+		sm.On("GetCharge", ctx, "", "id").Return(&charge, nil).Once()
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", lcoid).Return([]Subscription{}, nil).Once()
 		sm.On("CreateSubscription", ctx, mock.Anything).Run(func(args mock.Arguments) {
 			argsSub := args.Get(1).(Subscription)
 			assert.NotNil(t, argsSub)
@@ -660,8 +806,8 @@ func TestService_CreateSubscription(t *testing.T) {
 			assert.NotNil(t, argsSub.ID)
 		}).Return(nil).Once()
 		xm.On("GenerateId").Return(xid, nil)
-		payload := map[string]interface{}{"planName": "super", "chargeID": "id"}
-		sc, _ := json.Marshal(charge)
+		payload := CreateSubscriptionEventPayload{PlanName: "super", ChargeID: "id"}
+		sc, _ := json.Marshal(CreateSubscriptionEventPayload{Version: 1, PlanName: "super", ChargeID: "id", Result: "success"})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -689,9 +835,9 @@ func TestService_CreateSubscription(t *testing.T) {
 			LCOrganizationID: lcoid,
 			PlanName:         "super",
 		}
-		sm.On("GetCharge", ctx, "id").Return(&charge, nil).Once()
-		sm.On("GetSubscriptionsByOrganizationID", ctx, lcoid).Return([]Subscription{sub}, nil).Once()
-		payload := map[string]interface{}{"planName": "super", "chargeID": "id"}
+		sm.On("GetCharge", ctx, "", "id").Return(&charge, nil).Once()
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", lcoid).Return([]Subscription{sub}, nil).Once()
+		payload := CreateSubscriptionEventPayload{PlanName: "super", ChargeID: "id"}
 		sc, _ := json.Marshal(payload)
 		levent := events.Event{
 			ID:               xid,
@@ -701,7 +847,7 @@ func TestService_CreateSubscription(t *testing.T) {
 			Payload:          sc,
 		}
 
-		afterPayload := map[string]interface{}{"planName": "super", "chargeID": "id", "result": "subscription already exists"}
+		afterPayload := CreateSubscriptionEventPayload{Version: 1, PlanName: "super", ChargeID: "id", Result: "subscription already exists"}
 		asc, _ := json.Marshal(afterPayload)
 		afterEvent := levent
 		afterEvent.Payload = asc
@@ -720,9 +866,9 @@ func TestService_CreateSubscription(t *testing.T) {
 		charge := Charge{
 			ID: "xyz",
 		}
-		sm.On("GetCharge", ctx, "xyz").Return(&charge, nil).Once()
-		sm.On("GetSubscriptionsByOrganizationID", ctx, lcoid).Return([]Subscription{}, nil).Once()
-		payload := map[string]interface{}{"planName": "notFound", "chargeID": "xyz"}
+		sm.On("GetCharge", ctx, "", "xyz").Return(&charge, nil).Once()
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", lcoid).Return([]Subscription{}, nil).Once()
+		payload := CreateSubscriptionEventPayload{PlanName: "notFound", ChargeID: "xyz"}
 		sc, _ := json.Marshal(payload)
 		levent := events.Event{
 			ID:               xid,
@@ -745,7 +891,7 @@ func TestService_CreateSubscription(t *testing.T) {
 	})
 
 	t.Run("error getting charge", func(t *testing.T) {
-		sm.On("GetCharge", ctx, "id").Return(nil, assert.AnError).Once()
+		sm.On("GetCharge", ctx, "", "id").Return(nil, assert.AnError).Once()
 
 		err := s.CreateSubscription(context.Background(), lcoid, "id", "super")
 
@@ -756,7 +902,7 @@ func TestService_CreateSubscription(t *testing.T) {
 	})
 
 	t.Run("error charge is nil", func(t *testing.T) {
-		sm.On("GetCharge", ctx, "id").Return(nil, nil).Once()
+		sm.On("GetCharge", ctx, "", "id").Return(nil, nil).Once()
 
 		err := s.CreateSubscription(context.Background(), lcoid, "id", "super")
 
@@ -767,13 +913,13 @@ func TestService_CreateSubscription(t *testing.T) {
 	})
 
 	t.Run("error creating subscription", func(t *testing.T) {
-		sm.On("GetSubscriptionsByOrganizationID", ctx, lcoid).Return([]Subscription{}, nil).Once()
-		sm.On("GetCharge", ctx, "id").Return(&Charge{
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", lcoid).Return([]Subscription{}, nil).Once()
+		sm.On("GetCharge", ctx, "", "id").Return(&Charge{
 			ID: "id",
 		}, nil).Once()
 		xm.On("GenerateId").Return(xid, nil)
 		sm.On("CreateSubscription", ctx, mock.Anything).Return(assert.AnError).Once()
-		payload := map[string]interface{}{"planName": "super", "chargeID": "id"}
+		payload := CreateSubscriptionEventPayload{PlanName: "super", ChargeID: "id"}
 		sc, _ := json.Marshal(payload)
 		levent := events.Event{
 			ID:               xid,
@@ -798,7 +944,7 @@ func TestService_CreateSubscription(t *testing.T) {
 
 func TestService_GetCharge(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		sm.On("GetCharge", ctx, "id").Return(&Charge{
+		sm.On("GetCharge", ctx, "", "id").Return(&Charge{
 			ID: "id",
 		}, nil).Once()
 
@@ -811,7 +957,7 @@ func TestService_GetCharge(t *testing.T) {
 	})
 
 	t.Run("error", func(t *testing.T) {
-		sm.On("GetCharge", ctx, "id").Return(nil, assert.AnError).Once()
+		sm.On("GetCharge", ctx, "", "id").Return(nil, assert.AnError).Once()
 
 		charge, err := s.GetCharge(context.Background(), "id")
 
@@ -824,7 +970,7 @@ func TestService_GetCharge(t *testing.T) {
 
 func TestService_IsPremium(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		sm.On("GetSubscriptionsByOrganizationID", ctx, "id").Return([]Subscription{{
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", "id").Return([]Subscription{{
 			ID: "id",
 		}}, nil).Once()
 
@@ -837,7 +983,7 @@ func TestService_IsPremium(t *testing.T) {
 	})
 
 	t.Run("error", func(t *testing.T) {
-		sm.On("GetSubscriptionsByOrganizationID", ctx, "id").Return(nil, assert.AnError).Once()
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", "id").Return(nil, assert.AnError).Once()
 
 		premium, err := s.IsPremium(context.Background(), "id")
 
@@ -848,7 +994,7 @@ func TestService_IsPremium(t *testing.T) {
 	})
 
 	t.Run("not premium", func(t *testing.T) {
-		sm.On("GetSubscriptionsByOrganizationID", ctx, "id").Return(nil, nil).Once()
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", "id").Return(nil, nil).Once()
 
 		premium, err := s.IsPremium(context.Background(), "id")
 
@@ -864,7 +1010,7 @@ func TestService_GetActiveSubscriptionsByOrganizationID(t *testing.T) {
 		rsubs := []Subscription{{
 			ID: "id",
 		}}
-		sm.On("GetSubscriptionsByOrganizationID", ctx, "id").Return(rsubs, nil).Once()
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", "id").Return(rsubs, nil).Once()
 
 		subs, err := s.GetActiveSubscriptionsByOrganizationID(context.Background(), "id")
 
@@ -894,7 +1040,7 @@ func TestService_GetActiveSubscriptionsByOrganizationID(t *testing.T) {
 				Payload: payload,
 			},
 		}}
-		sm.On("GetSubscriptionsByOrganizationID", ctx, "id").Return(rsubs, nil).Once()
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", "id").Return(rsubs, nil).Once()
 
 		subs, err := s.GetActiveSubscriptionsByOrganizationID(context.Background(), "id")
 
@@ -920,7 +1066,7 @@ func TestService_GetActiveSubscriptionsByOrganizationID(t *testing.T) {
 				Payload: payload,
 			},
 		}}
-		sm.On("GetSubscriptionsByOrganizationID", ctx, "id").Return(rsubs, nil).Once()
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", "id").Return(rsubs, nil).Once()
 
 		subs, err := s.GetActiveSubscriptionsByOrganizationID(context.Background(), "id")
 
@@ -931,7 +1077,7 @@ func TestService_GetActiveSubscriptionsByOrganizationID(t *testing.T) {
 	})
 	t.Run("no subscriptions", func(t *testing.T) {
 		var rsubs []Subscription
-		sm.On("GetSubscriptionsByOrganizationID", ctx, "id").Return(rsubs, nil).Once()
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", "id").Return(rsubs, nil).Once()
 
 		subs, err := s.GetActiveSubscriptionsByOrganizationID(context.Background(), "id")
 
@@ -941,7 +1087,7 @@ func TestService_GetActiveSubscriptionsByOrganizationID(t *testing.T) {
 		assertExpectations(t)
 	})
 	t.Run("error", func(t *testing.T) {
-		sm.On("GetSubscriptionsByOrganizationID", ctx, "id").Return(nil, assert.AnError).Once()
+		sm.On("GetSubscriptionsByOrganizationID", ctx, "", "id").Return(nil, assert.AnError).Once()
 
 		subs, err := s.GetActiveSubscriptionsByOrganizationID(context.Background(), "id")
 
@@ -954,7 +1100,7 @@ func TestService_GetActiveSubscriptionsByOrganizationID(t *testing.T) {
 
 func TestService_SyncRecurrentCharge(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		sm.On("GetCharge", ctx, "id").Return(&Charge{
+		sm.On("GetCharge", ctx, "", "id").Return(&Charge{
 			ID: "id",
 		}, nil).Once()
 		charge := livechat.RecurrentCharge{
@@ -977,8 +1123,8 @@ func TestService_SyncRecurrentCharge(t *testing.T) {
 			assert.Equal(t, "name", p.Name)
 			assert.Equal(t, 10, p.Price)
 		}).Return(nil).Once()
-		payload := map[string]interface{}{"id": "id"}
-		sc, _ := json.Marshal(charge)
+		payload := SyncRecurrentChargeEventPayload{ChargeID: "id"}
+		sc, _ := json.Marshal(SyncRecurrentChargeEventPayload{Version: 1, ChargeID: "id", Charge: &charge})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -992,13 +1138,14 @@ func TestService_SyncRecurrentCharge(t *testing.T) {
 		err := s.SyncRecurrentCharge(context.Background(), lcoid, "id")
 
 		assert.Nil(t, err)
+		assert.Equal(t, []string{"id"}, sm.lockedCharges)
 
 		assertExpectations(t)
 	})
 
 	t.Run("error getting charge", func(t *testing.T) {
-		sm.On("GetCharge", ctx, "id").Return(nil, assert.AnError).Once()
-		payload := map[string]interface{}{"id": "id"}
+		sm.On("GetCharge", ctx, "", "id").Return(nil, assert.AnError).Once()
+		payload := SyncRecurrentChargeEventPayload{ChargeID: "id"}
 		sc, _ := json.Marshal(payload)
 		levent := events.Event{
 			ID:               xid,
@@ -1021,8 +1168,8 @@ func TestService_SyncRecurrentCharge(t *testing.T) {
 	})
 
 	t.Run("error charge is nil", func(t *testing.T) {
-		sm.On("GetCharge", ctx, "id").Return(nil, nil).Once()
-		payload := map[string]interface{}{"id": "id"}
+		sm.On("GetCharge", ctx, "", "id").Return(nil, nil).Once()
+		payload := SyncRecurrentChargeEventPayload{ChargeID: "id"}
 		sc, _ := json.Marshal(payload)
 		levent := events.Event{
 			ID:               xid,
@@ -1045,11 +1192,11 @@ func TestService_SyncRecurrentCharge(t *testing.T) {
 	})
 
 	t.Run("error getting recurrent charge", func(t *testing.T) {
-		sm.On("GetCharge", ctx, "id").Return(&Charge{
+		sm.On("GetCharge", ctx, "", "id").Return(&Charge{
 			ID: "id",
 		}, nil).Once()
 		am.On("GetRecurrentCharge", ctx, "id").Return(nil, assert.AnError).Once()
-		payload := map[string]interface{}{"id": "id"}
+		payload := SyncRecurrentChargeEventPayload{ChargeID: "id"}
 		sc, _ := json.Marshal(payload)
 		levent := events.Event{
 			ID:               xid,
@@ -1071,8 +1218,35 @@ func TestService_SyncRecurrentCharge(t *testing.T) {
 		assertExpectations(t)
 	})
 
+	t.Run("recurrent charge not found", func(t *testing.T) {
+		sm.On("GetCharge", ctx, "", "id").Return(&Charge{
+			ID: "id",
+		}, nil).Once()
+		am.On("GetRecurrentCharge", ctx, "id").Return(nil, livechat.ErrNotFound).Once()
+		payload := SyncRecurrentChargeEventPayload{ChargeID: "id"}
+		sc, _ := json.Marshal(payload)
+		levent := events.Event{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			Type:             events.EventTypeError,
+			Action:           events.EventActionSyncRecurrentCharge,
+			Payload:          sc,
+		}
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, payload).Return(levent).Once()
+		em.On("ToError", context.Background(), events.ToErrorParams{
+			Event: levent,
+			Err:   fmt.Errorf("recurrent charge not found in LiveChat: %w", livechat.ErrNotFound),
+		}).Return(assert.AnError).Once()
+
+		err := s.SyncRecurrentCharge(context.Background(), lcoid, "id")
+
+		assert.ErrorIs(t, err, assert.AnError)
+
+		assertExpectations(t)
+	})
+
 	t.Run("error updating charge payload", func(t *testing.T) {
-		sm.On("GetCharge", ctx, "id").Return(&Charge{
+		sm.On("GetCharge", ctx, "", "id").Return(&Charge{
 			ID: "id",
 		}, nil).Once()
 		am.On("GetRecurrentCharge", ctx, "id").Return(&livechat.RecurrentCharge{
@@ -1086,7 +1260,7 @@ func TestService_SyncRecurrentCharge(t *testing.T) {
 			Months:    1,
 		}, nil).Once()
 		sm.On("UpdateChargePayload", ctx, "id", mock.Anything).Return(assert.AnError).Once()
-		payload := map[string]interface{}{"id": "id"}
+		payload := SyncRecurrentChargeEventPayload{ChargeID: "id"}
 		sc, _ := json.Marshal(payload)
 		levent := events.Event{
 			ID:               xid,
@@ -1112,14 +1286,16 @@ func TestService_SyncRecurrentCharge(t *testing.T) {
 func TestService_SyncCharges(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
 		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
-		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses()).Return([]Charge{
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
 			{
 				ID:               "some-id",
 				LCOrganizationID: lcoid,
 			},
 		}, nil).Once()
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, map[string]interface{}{"id": "some-id"}).Return(events.Event{}).Once()
+		sm.On("GetCharge", orgCtx, "", "some-id").Return(&Charge{ID: "some-id", LCOrganizationID: lcoid}, nil).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "some-id"}).Return(events.Event{}).Once()
 		am.On("GetRecurrentCharge", orgCtx, "some-id").Return(&livechat.RecurrentCharge{
 			BaseCharge: livechat.BaseCharge{},
 		}, nil).Once()
@@ -1128,6 +1304,71 @@ func TestService_SyncCharges(t *testing.T) {
 		xm.On("GenerateId").Return(xid, nil).Once()
 		em.On("CreateEvent", orgCtx, mock.Anything).Return(nil).Once()
 
+		err := s.SyncCharges(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"some-id"}, sm.lockedCharges)
+
+		assertExpectations(t)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		recorder := metricstest.NewRecorder()
+		ms := s
+		ms.metrics = recorder
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
+			{ID: "some-id", LCOrganizationID: lcoid},
+			{ID: "other-id", LCOrganizationID: lcoid},
+		}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "some-id").Return(&Charge{ID: "some-id", LCOrganizationID: lcoid}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "other-id").Return(nil, assert.AnError).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "some-id"}).Return(events.Event{}).Once()
+		am.On("GetRecurrentCharge", orgCtx, "some-id").Return(&livechat.RecurrentCharge{
+			BaseCharge: livechat.BaseCharge{Status: livechat.RecurrentChargeStatusAccepted},
+		}, nil).Once()
+		am.On("ActivateRecurrentCharge", orgCtx, "some-id").Return(&livechat.RecurrentCharge{
+			BaseCharge: livechat.BaseCharge{Status: livechat.RecurrentChargeStatusActive},
+		}, nil).Once()
+		sm.On("UpdateChargePayload", orgCtx, "some-id", mock.Anything).Return(nil).Once()
+		xm.On("GenerateId").Return(xid, nil).Twice()
+		em.On("CreateEvent", orgCtx, mock.Anything).Return(nil).Once()
+
+		err := ms.SyncCharges(ctx)
+		assert.ErrorIs(t, err, assert.AnError)
+
+		runCharges, _ := recorder.Gauge(metrics.RunCharges, metrics.ActionSyncCharges, "")
+		assert.Equal(t, float64(2), runCharges)
+		assert.Equal(t, 1, recorder.Counter(metrics.ChargesTotal, metrics.ActionSyncCharges, metrics.StatusSuccess))
+		assert.Equal(t, 1, recorder.Counter(metrics.ChargesTotal, metrics.ActionSyncCharges, metrics.StatusError))
+		assert.Equal(t, 1, recorder.Counter(metrics.ChargesTotal, metrics.ActionActivateCharge, metrics.StatusSuccess))
+		assert.Len(t, recorder.Observations(metrics.RunDurationSeconds, metrics.ActionSyncCharges, metrics.StatusError), 1)
+
+		assertExpectations(t)
+	})
+
+	t.Run("resets sync error count after success", func(t *testing.T) {
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
+			{
+				ID:               "some-id",
+				LCOrganizationID: lcoid,
+				SyncErrorCount:   3,
+			},
+		}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "some-id").Return(&Charge{ID: "some-id", LCOrganizationID: lcoid, SyncErrorCount: 3}, nil).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "some-id"}).Return(events.Event{}).Once()
+		am.On("GetRecurrentCharge", orgCtx, "some-id").Return(&livechat.RecurrentCharge{
+			BaseCharge: livechat.BaseCharge{},
+		}, nil).Once()
+		sm.On("UpdateChargePayload", orgCtx, "some-id", mock.Anything).Return(nil).Once()
+		sm.On("ResetChargeSyncErrorCount", orgCtx, "some-id").Return(nil).Once()
+		xm.On("GenerateId").Return(xid, nil).Once()
+		em.On("CreateEvent", orgCtx, mock.Anything).Return(nil).Once()
+
 		err := s.SyncCharges(ctx)
 		assert.NoError(t, err)
 
@@ -1135,7 +1376,7 @@ func TestService_SyncCharges(t *testing.T) {
 	})
 
 	t.Run("error getting charges", func(t *testing.T) {
-		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses()).Return(nil, errors.New("woopsie")).Once()
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return(nil, errors.New("woopsie")).Once()
 
 		err := s.SyncCharges(ctx)
 		assert.ErrorContains(t, err, "failed to get charges by statuses")
@@ -1145,14 +1386,16 @@ func TestService_SyncCharges(t *testing.T) {
 
 	t.Run("error getting recurrent charge", func(t *testing.T) {
 		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
 		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
-		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses()).Return([]Charge{
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
 			{
 				ID:               "some-id",
 				LCOrganizationID: lcoid,
 			},
 		}, nil).Once()
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, map[string]interface{}{"id": "some-id"}).Return(events.Event{}).Once()
+		sm.On("GetCharge", orgCtx, "", "some-id").Return(&Charge{ID: "some-id", LCOrganizationID: lcoid}, nil).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "some-id"}).Return(events.Event{}).Once()
 		am.On("GetRecurrentCharge", orgCtx, "some-id").Return(nil, errors.New("whoopsie")).Once()
 		em.On("ToError", orgCtx, mock.Anything).Return(errors.New("failed to get recurrent charge: whoopsie")).Once()
 		sm.On("IncrementChargeSyncErrorCount", orgCtx, "some-id").Return(nil).Once()
@@ -1164,16 +1407,86 @@ func TestService_SyncCharges(t *testing.T) {
 		assertExpectations(t)
 	})
 
+	t.Run("charge not found in LiveChat counts as a sync error", func(t *testing.T) {
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
+			{ID: "some-id", LCOrganizationID: lcoid},
+		}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "some-id").Return(&Charge{ID: "some-id", LCOrganizationID: lcoid}, nil).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "some-id"}).Return(events.Event{}).Once()
+		am.On("GetRecurrentCharge", orgCtx, "some-id").Return(nil, livechat.ErrNotFound).Once()
+		em.On("ToError", orgCtx, events.ToErrorParams{
+			Event: events.Event{Type: events.EventTypeError},
+			Err:   fmt.Errorf("failed to get recurrent charge: %w", livechat.ErrNotFound),
+		}).Return(assert.AnError).Once()
+		sm.On("IncrementChargeSyncErrorCount", orgCtx, "some-id").Return(nil).Once()
+		xm.On("GenerateId").Return(xid, nil).Once()
+
+		err := s.SyncCharges(ctx)
+		assert.ErrorIs(t, err, assert.AnError)
+
+		assertExpectations(t)
+	})
+
+	t.Run("expired pending charge not found in LiveChat returns an error", func(t *testing.T) {
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		createdAt := time.Now().AddDate(0, -2, 0)
+		payload, _ := json.Marshal(livechat.RecurrentCharge{BaseCharge: livechat.BaseCharge{ID: "some-id", Status: livechat.RecurrentChargeStatusPending, CreatedAt: &createdAt}})
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
+			{ID: "some-id", LCOrganizationID: lcoid},
+		}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "some-id").Return(&Charge{ID: "some-id", LCOrganizationID: lcoid, Payload: payload}, nil).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, ForceCancelChargeEventPayload{ChargeID: "some-id"}).Return(events.Event{}).Once()
+		am.On("CancelRecurrentCharge", orgCtx, "some-id").Return(nil, livechat.ErrNotFound).Once()
+		em.On("ToError", orgCtx, events.ToErrorParams{
+			Event: events.Event{Type: events.EventTypeError},
+			Err:   fmt.Errorf("failed to cancel charge: %w", fmt.Errorf("recurrent charge not found in LiveChat: %w", livechat.ErrNotFound)),
+		}).Return(assert.AnError).Once()
+		xm.On("GenerateId").Return(xid, nil).Once()
+
+		err := s.SyncCharges(ctx)
+		assert.ErrorIs(t, err, assert.AnError)
+
+		assertExpectations(t)
+	})
+
+	t.Run("billing api unavailable pauses the run", func(t *testing.T) {
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
+			{ID: "some-id", LCOrganizationID: lcoid},
+			{ID: "other-id", LCOrganizationID: lcoid},
+		}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "some-id").Return(&Charge{ID: "some-id", LCOrganizationID: lcoid}, nil).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "some-id"}).Return(events.Event{}).Once()
+		am.On("GetRecurrentCharge", orgCtx, "some-id").Return(nil, ErrBillingAPIUnavailable).Once()
+		xm.On("GenerateId").Return(xid, nil).Once()
+
+		err := s.SyncCharges(ctx)
+		assert.ErrorIs(t, err, ErrBillingAPIUnavailable)
+		assert.ErrorContains(t, err, "sync charges paused")
+		assert.Equal(t, []string{"some-id"}, sm.lockedCharges)
+
+		assertExpectations(t)
+	})
+
 	t.Run("error updating payload", func(t *testing.T) {
 		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
 		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
-		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses()).Return([]Charge{
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
 			{
 				ID:               "some-id",
 				LCOrganizationID: lcoid,
 			},
 		}, nil).Once()
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, map[string]interface{}{"id": "some-id"}).Return(events.Event{}).Once()
+		sm.On("GetCharge", orgCtx, "", "some-id").Return(&Charge{ID: "some-id", LCOrganizationID: lcoid}, nil).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "some-id"}).Return(events.Event{}).Once()
 		am.On("GetRecurrentCharge", orgCtx, "some-id").Return(&livechat.RecurrentCharge{
 			BaseCharge: livechat.BaseCharge{},
 		}, nil).Once()
@@ -1195,13 +1508,17 @@ func TestService_SyncCharges(t *testing.T) {
 		xid3 := "event-id-3"
 
 		orgCtx1 := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+
+		orgCtx1 = context.WithValue(orgCtx1, ApplicationIDCtxKey{}, "")
 		orgCtx1 = context.WithValue(orgCtx1, EventIDCtxKey{}, xid1)
 		orgCtx2 := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx2 = context.WithValue(orgCtx2, ApplicationIDCtxKey{}, "")
 		orgCtx2 = context.WithValue(orgCtx2, EventIDCtxKey{}, xid2)
 		orgCtx3 := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx3 = context.WithValue(orgCtx3, ApplicationIDCtxKey{}, "")
 		orgCtx3 = context.WithValue(orgCtx3, EventIDCtxKey{}, xid3)
 
-		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses()).Return([]Charge{
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
 			{
 				ID:               "charge-1",
 				LCOrganizationID: lcoid,
@@ -1218,14 +1535,16 @@ func TestService_SyncCharges(t *testing.T) {
 
 		// First charge - fails to get recurrent charge
 		xm.On("GenerateId").Return(xid1, nil).Once()
-		em.On("ToEvent", orgCtx1, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, map[string]interface{}{"id": "charge-1"}).Return(events.Event{}).Once()
+		sm.On("GetCharge", orgCtx1, "", "charge-1").Return(&Charge{ID: "charge-1", LCOrganizationID: lcoid}, nil).Once()
+		em.On("ToEvent", orgCtx1, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "charge-1"}).Return(events.Event{}).Once()
 		am.On("GetRecurrentCharge", orgCtx1, "charge-1").Return(nil, errors.New("api error")).Once()
 		em.On("ToError", orgCtx1, mock.Anything).Return(errors.New("failed to get recurrent charge: api error")).Once()
 		sm.On("IncrementChargeSyncErrorCount", orgCtx1, "charge-1").Return(nil).Once()
 
 		// Second charge - succeeds
 		xm.On("GenerateId").Return(xid2, nil).Once()
-		em.On("ToEvent", orgCtx2, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, map[string]interface{}{"id": "charge-2"}).Return(events.Event{}).Once()
+		sm.On("GetCharge", orgCtx2, "", "charge-2").Return(&Charge{ID: "charge-2", LCOrganizationID: lcoid}, nil).Once()
+		em.On("ToEvent", orgCtx2, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "charge-2"}).Return(events.Event{}).Once()
 		am.On("GetRecurrentCharge", orgCtx2, "charge-2").Return(&livechat.RecurrentCharge{
 			BaseCharge: livechat.BaseCharge{},
 		}, nil).Once()
@@ -1234,7 +1553,8 @@ func TestService_SyncCharges(t *testing.T) {
 
 		// Third charge - fails to update payload
 		xm.On("GenerateId").Return(xid3, nil).Once()
-		em.On("ToEvent", orgCtx3, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, map[string]interface{}{"id": "charge-3"}).Return(events.Event{}).Once()
+		sm.On("GetCharge", orgCtx3, "", "charge-3").Return(&Charge{ID: "charge-3", LCOrganizationID: lcoid}, nil).Once()
+		em.On("ToEvent", orgCtx3, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "charge-3"}).Return(events.Event{}).Once()
 		am.On("GetRecurrentCharge", orgCtx3, "charge-3").Return(&livechat.RecurrentCharge{
 			BaseCharge: livechat.BaseCharge{},
 		}, nil).Once()
@@ -1246,6 +1566,43 @@ func TestService_SyncCharges(t *testing.T) {
 		assert.Error(t, err)
 		assert.ErrorContains(t, err, "failed to get recurrent charge")
 		assert.ErrorContains(t, err, "failed to update charge payload")
+		assert.Equal(t, []string{"charge-1", "charge-2", "charge-3"}, sm.lockedCharges)
+
+		assertExpectations(t)
+	})
+
+	t.Run("skips charge deleted before lock", func(t *testing.T) {
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
+			{
+				ID:               "some-id",
+				LCOrganizationID: lcoid,
+			},
+		}, nil).Once()
+		xm.On("GenerateId").Return(xid, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "some-id").Return(nil, ErrChargeNotFound).Once()
+
+		err := s.SyncCharges(ctx)
+		assert.NoError(t, err)
+
+		assertExpectations(t)
+	})
+
+	t.Run("error locking charge", func(t *testing.T) {
+		sm.lockErr = assert.AnError
+		sm.On("GetChargesByStatuses", ctx, GetSyncValidStatuses(), BackoffPolicy{}).Return([]Charge{
+			{
+				ID:               "some-id",
+				LCOrganizationID: lcoid,
+			},
+		}, nil).Once()
+		xm.On("GenerateId").Return(xid, nil).Once()
+
+		err := s.SyncCharges(ctx)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, []string{"some-id"}, sm.lockedCharges)
 
 		assertExpectations(t)
 	})
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"time"
 
-	"github.com/livechat-integrations/go-billing-sdk/v2/internal/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
 )
 
 type ChargeType string
@@ -19,6 +19,7 @@ const (
 type Charge struct {
 	ID               string
 	LCOrganizationID string
+	ApplicationID    string
 	Type             ChargeType
 	Status           livechat.ChargeStatus
 	Payload          json.RawMessage
@@ -28,11 +29,13 @@ type Charge struct {
 	CanceledAt       *time.Time
 	SyncErrorCount   int
 	LastSyncErrorAt  *time.Time
+	QuarantinedAt    *time.Time
 }
 type Subscription struct {
 	ID               string
 	Charge           *Charge
 	LCOrganizationID string
+	ApplicationID    string
 	PlanName         string
 	DunningEndDate   *time.Time
 	CreatedAt        time.Time
@@ -0,0 +1,239 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+// CleanupAction is what CleanupFailedCharges does with a charge that reached the sync error threshold.
+type CleanupAction string
+
+const (
+	// CleanupActionQuarantine marks a charge LiveChat no longer knows (404/422). Only ConfirmChargeCleanup deletes it.
+	CleanupActionQuarantine CleanupAction = "quarantine"
+	// CleanupActionKeep leaves a charge that still exists in LiveChat or could not be checked, e.g. during an outage.
+	CleanupActionKeep CleanupAction = "keep"
+)
+
+type CleanupCandidate struct {
+	ChargeID         string
+	LCOrganizationID string
+	SyncErrorCount   int
+	Action           CleanupAction
+	Reason           string
+}
+
+type CleanupReport struct {
+	DryRun     bool
+	Candidates []CleanupCandidate
+}
+
+// CleanupFailedCharges checks every charge that reached the sync error threshold against LiveChat
+// and quarantines the ones that are gone there. Charges are never deleted here, see ConfirmChargeCleanup.
+func (s *Service) CleanupFailedCharges(ctx context.Context) (err error) {
+	ctx, end := s.start(ctx, "CleanupFailedCharges")
+	defer func() { end(err) }()
+
+	start := time.Now()
+	_, err = s.cleanupFailedCharges(ctx, false)
+	metrics.ObserveSince(s.metrics, metrics.RunDurationSeconds, metrics.Labels{Action: metrics.ActionCleanupFailedCharges, Status: metrics.Status(err)}, start)
+
+	return err
+}
+
+// DryRunCleanupFailedCharges reports what CleanupFailedCharges would do without changing anything.
+func (s *Service) DryRunCleanupFailedCharges(ctx context.Context) (_ *CleanupReport, err error) {
+	ctx, end := s.start(ctx, "DryRunCleanupFailedCharges")
+	defer func() { end(err) }()
+
+	return s.cleanupFailedCharges(ctx, true)
+}
+
+func (s *Service) GetQuarantinedCharges(ctx context.Context) (_ []Charge, err error) {
+	ctx, end := s.start(ctx, "GetQuarantinedCharges")
+	defer func() { end(err) }()
+
+	charges, err := s.storage.GetQuarantinedCharges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined charges: %w", err)
+	}
+
+	return charges, nil
+}
+
+// ConfirmChargeCleanup deletes a quarantined charge after checking once more that LiveChat no longer knows it. The
+// charge must belong to the app selected by ApplicationIDCtxKey, see GetQuarantinedCharges for its ApplicationID.
+func (s *Service) ConfirmChargeCleanup(ctx context.Context, id string) (err error) {
+	ctx, end := s.start(ctx, "ConfirmChargeCleanup", tracing.ChargeIDKey.String(id))
+	defer func() { end(err) }()
+
+	return s.storage.WithChargeLock(ctx, id, func(ctx context.Context) error {
+		return s.confirmChargeCleanup(ctx, id)
+	})
+}
+
+func (s *Service) confirmChargeCleanup(ctx context.Context, id string) error {
+	app, err := s.application(ctx)
+	if err != nil {
+		return err
+	}
+
+	charge, err := s.storage.GetCharge(ctx, app.id, id)
+	if err != nil {
+		return fmt.Errorf("failed to get charge: %w", err)
+	}
+	if charge == nil {
+		return ErrChargeNotFound
+	}
+
+	organizationCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, charge.LCOrganizationID)
+	organizationCtx = context.WithValue(organizationCtx, ApplicationIDCtxKey{}, charge.ApplicationID)
+	organizationCtx = context.WithValue(organizationCtx, EventIDCtxKey{}, s.idProvider.GenerateId())
+
+	event := s.eventService.ToEvent(organizationCtx, charge.LCOrganizationID, events.EventActionCleanupFailedCharge, events.EventTypeInfo, CleanupFailedChargeEventPayload{ChargeID: charge.ID, SyncErrorCount: charge.SyncErrorCount})
+
+	if charge.QuarantinedAt == nil {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(organizationCtx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("charge %s is not quarantined", id),
+		})
+	}
+
+	if action, reason := s.cleanupAction(organizationCtx, *charge); action != CleanupActionQuarantine {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(organizationCtx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("charge %s can't be deleted: %s", id, reason),
+		})
+	}
+
+	if err = s.storage.DeleteCharge(organizationCtx, app.id, id); err != nil {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(organizationCtx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to delete charge: %w", err),
+		})
+	}
+
+	s.createEvent(organizationCtx, event)
+
+	return nil
+}
+
+func (s *Service) cleanupFailedCharges(ctx context.Context, dryRun bool) (*CleanupReport, error) {
+	charges, err := s.storage.GetChargesWithHighErrorCount(ctx, s.syncPolicy.maxSyncErrors())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get charges with high error count: %w", err)
+	}
+	if !dryRun {
+		s.metrics.SetGauge(metrics.RunCharges, metrics.Labels{Action: metrics.ActionCleanupFailedCharges}, float64(len(charges)))
+	}
+
+	report := &CleanupReport{DryRun: dryRun, Candidates: []CleanupCandidate{}}
+	var errs []error
+
+	for _, charge := range charges {
+		organizationCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, charge.LCOrganizationID)
+		organizationCtx = context.WithValue(organizationCtx, ApplicationIDCtxKey{}, charge.ApplicationID)
+		organizationCtx = context.WithValue(organizationCtx, EventIDCtxKey{}, s.idProvider.GenerateId())
+
+		candidate := CleanupCandidate{
+			ChargeID:         charge.ID,
+			LCOrganizationID: charge.LCOrganizationID,
+			SyncErrorCount:   charge.SyncErrorCount,
+		}
+
+		if dryRun {
+			candidate.Action, candidate.Reason = s.cleanupAction(organizationCtx, charge)
+			report.Candidates = append(report.Candidates, candidate)
+			continue
+		}
+
+		err = s.storage.WithChargeLock(organizationCtx, charge.ID, func(ctx context.Context) error {
+			return s.quarantineFailedCharge(ctx, charge, &candidate)
+		})
+		status := string(candidate.Action)
+		if err != nil {
+			errs = append(errs, err)
+			status = metrics.StatusError
+		}
+		s.metrics.IncCounter(metrics.ChargesTotal, metrics.Labels{Action: metrics.ActionCleanupFailedCharges, Status: status})
+
+		report.Candidates = append(report.Candidates, candidate)
+	}
+
+	if len(errs) > 0 {
+		return report, errors.Join(errs...)
+	}
+
+	return report, nil
+}
+
+// quarantineFailedCharge re-reads the charge under its lock, a webhook may have synced it since it was listed, and
+// quarantines it when it still reached the sync error threshold and LiveChat no longer knows it.
+func (s *Service) quarantineFailedCharge(ctx context.Context, listed Charge, candidate *CleanupCandidate) error {
+	charge, err := s.storage.GetCharge(ctx, listed.ApplicationID, listed.ID)
+	if err != nil && !errors.Is(err, ErrChargeNotFound) {
+		return fmt.Errorf("failed to get charge %s: %w", listed.ID, err)
+	}
+	switch {
+	case charge == nil:
+		candidate.Action, candidate.Reason = CleanupActionKeep, "charge no longer exists"
+		return nil
+	case charge.QuarantinedAt != nil:
+		candidate.Action, candidate.Reason = CleanupActionKeep, "charge is already quarantined"
+		return nil
+	case charge.SyncErrorCount < s.syncPolicy.maxSyncErrors():
+		candidate.SyncErrorCount = charge.SyncErrorCount
+		candidate.Action, candidate.Reason = CleanupActionKeep, "charge synced since it was listed"
+		return nil
+	}
+	candidate.SyncErrorCount = charge.SyncErrorCount
+
+	candidate.Action, candidate.Reason = s.cleanupAction(ctx, *charge)
+	if candidate.Action != CleanupActionQuarantine {
+		return nil
+	}
+
+	event := s.eventService.ToEvent(ctx, charge.LCOrganizationID, events.EventActionQuarantineCharge, events.EventTypeInfo, QuarantineChargeEventPayload{ChargeID: charge.ID, SyncErrorCount: charge.SyncErrorCount, Reason: candidate.Reason})
+	if err := s.storage.QuarantineCharge(ctx, charge.ID); err != nil {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to quarantine charge: %w", err),
+		})
+	}
+
+	s.createEvent(ctx, event)
+
+	return nil
+}
+
+// cleanupAction asks LiveChat about the charge. Only a charge LiveChat answers with 404 or 422 for is really gone,
+// any other failure may be our own outage and keeps the charge.
+func (s *Service) cleanupAction(ctx context.Context, charge Charge) (CleanupAction, string) {
+	app, err := s.applicationByID(charge.ApplicationID)
+	if err != nil {
+		return CleanupActionKeep, err.Error()
+	}
+
+	_, err = app.billingAPI.GetRecurrentCharge(ctx, charge.ID)
+	switch {
+	case errors.Is(err, livechat.ErrNotFound):
+		return CleanupActionQuarantine, "charge not found in LiveChat"
+	case errors.Is(err, livechat.ErrUnprocessableEntity):
+		return CleanupActionQuarantine, "charge is unprocessable in LiveChat"
+	case err != nil:
+		return CleanupActionKeep, fmt.Sprintf("failed to check charge in LiveChat: %v", err)
+	default:
+		return CleanupActionKeep, "charge still exists in LiveChat"
+	}
+}
@@ -0,0 +1,275 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics/metricstest"
+)
+
+func TestService_CleanupFailedCharges(t *testing.T) {
+	t.Run("quarantines charges gone from LiveChat and keeps the rest", func(t *testing.T) {
+		recorder := metricstest.NewRecorder()
+		ms := s
+		ms.metrics = recorder
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		sm.On("GetChargesWithHighErrorCount", ctx, DefaultMaxSyncErrors).Return([]Charge{
+			{ID: "gone", LCOrganizationID: lcoid, SyncErrorCount: 10},
+			{ID: "unprocessable", LCOrganizationID: lcoid, SyncErrorCount: 11},
+			{ID: "exists", LCOrganizationID: lcoid, SyncErrorCount: 12},
+			{ID: "outage", LCOrganizationID: lcoid, SyncErrorCount: 13},
+		}, nil).Once()
+		xm.On("GenerateId").Return(xid).Times(4)
+		sm.On("GetCharge", orgCtx, "", "gone").Return(&Charge{ID: "gone", LCOrganizationID: lcoid, SyncErrorCount: 10}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "unprocessable").Return(&Charge{ID: "unprocessable", LCOrganizationID: lcoid, SyncErrorCount: 11}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "exists").Return(&Charge{ID: "exists", LCOrganizationID: lcoid, SyncErrorCount: 12}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "outage").Return(&Charge{ID: "outage", LCOrganizationID: lcoid, SyncErrorCount: 13}, nil).Once()
+		am.On("GetRecurrentCharge", orgCtx, "gone").Return(nil, livechat.ErrNotFound).Once()
+		am.On("GetRecurrentCharge", orgCtx, "unprocessable").Return(nil, livechat.ErrUnprocessableEntity).Once()
+		am.On("GetRecurrentCharge", orgCtx, "exists").Return(&livechat.RecurrentCharge{}, nil).Once()
+		am.On("GetRecurrentCharge", orgCtx, "outage").Return(nil, assert.AnError).Once()
+		goneEvent := events.Event{ID: "1"}
+		unprocessableEvent := events.Event{ID: "2"}
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionQuarantineCharge, events.EventTypeInfo, QuarantineChargeEventPayload{ChargeID: "gone", SyncErrorCount: 10, Reason: "charge not found in LiveChat"}).Return(goneEvent).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionQuarantineCharge, events.EventTypeInfo, QuarantineChargeEventPayload{ChargeID: "unprocessable", SyncErrorCount: 11, Reason: "charge is unprocessable in LiveChat"}).Return(unprocessableEvent).Once()
+		sm.On("QuarantineCharge", orgCtx, "gone").Return(nil).Once()
+		sm.On("QuarantineCharge", orgCtx, "unprocessable").Return(nil).Once()
+		em.On("CreateEvent", orgCtx, goneEvent).Return(nil).Once()
+		em.On("CreateEvent", orgCtx, unprocessableEvent).Return(nil).Once()
+
+		err := ms.CleanupFailedCharges(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"gone", "unprocessable", "exists", "outage"}, sm.lockedCharges)
+		runCharges, _ := recorder.Gauge(metrics.RunCharges, metrics.ActionCleanupFailedCharges, "")
+		assert.Equal(t, float64(4), runCharges)
+		assert.Equal(t, 2, recorder.Counter(metrics.ChargesTotal, metrics.ActionCleanupFailedCharges, string(CleanupActionQuarantine)))
+		assert.Equal(t, 2, recorder.Counter(metrics.ChargesTotal, metrics.ActionCleanupFailedCharges, string(CleanupActionKeep)))
+		assert.Len(t, recorder.Observations(metrics.RunDurationSeconds, metrics.ActionCleanupFailedCharges, metrics.StatusSuccess), 1)
+		assertExpectations(t)
+	})
+
+	t.Run("error getting charges", func(t *testing.T) {
+		sm.On("GetChargesWithHighErrorCount", ctx, DefaultMaxSyncErrors).Return(nil, assert.AnError).Once()
+
+		err := s.CleanupFailedCharges(ctx)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assertExpectations(t)
+	})
+
+	t.Run("error quarantining charge", func(t *testing.T) {
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		sm.On("GetChargesWithHighErrorCount", ctx, DefaultMaxSyncErrors).Return([]Charge{
+			{ID: "gone", LCOrganizationID: lcoid, SyncErrorCount: 10},
+		}, nil).Once()
+		xm.On("GenerateId").Return(xid).Once()
+		sm.On("GetCharge", orgCtx, "", "gone").Return(&Charge{ID: "gone", LCOrganizationID: lcoid, SyncErrorCount: 10}, nil).Once()
+		am.On("GetRecurrentCharge", orgCtx, "gone").Return(nil, livechat.ErrNotFound).Once()
+		event := events.Event{ID: "1"}
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionQuarantineCharge, events.EventTypeInfo, QuarantineChargeEventPayload{ChargeID: "gone", SyncErrorCount: 10, Reason: "charge not found in LiveChat"}).Return(event).Once()
+		sm.On("QuarantineCharge", orgCtx, "gone").Return(assert.AnError).Once()
+		event.Type = events.EventTypeError
+		em.On("ToError", orgCtx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to quarantine charge: %w", assert.AnError),
+		}).Return(assert.AnError).Once()
+
+		err := s.CleanupFailedCharges(ctx)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assertExpectations(t)
+	})
+
+	t.Run("re-reads the charges under their lock", func(t *testing.T) {
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		quarantinedAt := time.Now()
+		sm.On("GetChargesWithHighErrorCount", ctx, DefaultMaxSyncErrors).Return([]Charge{
+			{ID: "synced", LCOrganizationID: lcoid, SyncErrorCount: 10},
+			{ID: "quarantined", LCOrganizationID: lcoid, SyncErrorCount: 11},
+			{ID: "deleted", LCOrganizationID: lcoid, SyncErrorCount: 12},
+			{ID: "failing", LCOrganizationID: lcoid, SyncErrorCount: 13},
+		}, nil).Once()
+		xm.On("GenerateId").Return(xid).Times(4)
+		sm.On("GetCharge", orgCtx, "", "synced").Return(&Charge{ID: "synced", LCOrganizationID: lcoid}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "quarantined").Return(&Charge{ID: "quarantined", LCOrganizationID: lcoid, SyncErrorCount: 11, QuarantinedAt: &quarantinedAt}, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "deleted").Return(nil, nil).Once()
+		sm.On("GetCharge", orgCtx, "", "failing").Return(&Charge{ID: "failing", LCOrganizationID: lcoid, SyncErrorCount: 14}, nil).Once()
+		am.On("GetRecurrentCharge", orgCtx, "failing").Return(nil, livechat.ErrNotFound).Once()
+		event := events.Event{ID: "1"}
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionQuarantineCharge, events.EventTypeInfo, QuarantineChargeEventPayload{ChargeID: "failing", SyncErrorCount: 14, Reason: "charge not found in LiveChat"}).Return(event).Once()
+		sm.On("QuarantineCharge", orgCtx, "failing").Return(nil).Once()
+		em.On("CreateEvent", orgCtx, event).Return(nil).Once()
+
+		report, err := s.cleanupFailedCharges(ctx, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []CleanupCandidate{
+			{ChargeID: "synced", LCOrganizationID: lcoid, Action: CleanupActionKeep, Reason: "charge synced since it was listed"},
+			{ChargeID: "quarantined", LCOrganizationID: lcoid, SyncErrorCount: 11, Action: CleanupActionKeep, Reason: "charge is already quarantined"},
+			{ChargeID: "deleted", LCOrganizationID: lcoid, SyncErrorCount: 12, Action: CleanupActionKeep, Reason: "charge no longer exists"},
+			{ChargeID: "failing", LCOrganizationID: lcoid, SyncErrorCount: 14, Action: CleanupActionQuarantine, Reason: "charge not found in LiveChat"},
+		}, report.Candidates)
+		assertExpectations(t)
+	})
+
+	t.Run("uses the configured threshold", func(t *testing.T) {
+		service := s
+		service.syncPolicy = SyncPolicy{MaxSyncErrors: 3}
+		sm.On("GetChargesWithHighErrorCount", ctx, 3).Return([]Charge{}, nil).Once()
+
+		err := service.CleanupFailedCharges(ctx)
+
+		assert.NoError(t, err)
+		assertExpectations(t)
+	})
+}
+
+func TestService_DryRunCleanupFailedCharges(t *testing.T) {
+	t.Run("reports without changing anything", func(t *testing.T) {
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		sm.On("GetChargesWithHighErrorCount", ctx, DefaultMaxSyncErrors).Return([]Charge{
+			{ID: "gone", LCOrganizationID: lcoid, SyncErrorCount: 10},
+			{ID: "outage", LCOrganizationID: lcoid, SyncErrorCount: 11},
+		}, nil).Once()
+		xm.On("GenerateId").Return(xid).Twice()
+		am.On("GetRecurrentCharge", orgCtx, "gone").Return(nil, livechat.ErrNotFound).Once()
+		am.On("GetRecurrentCharge", orgCtx, "outage").Return(nil, assert.AnError).Once()
+
+		report, err := s.DryRunCleanupFailedCharges(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &CleanupReport{
+			DryRun: true,
+			Candidates: []CleanupCandidate{
+				{ChargeID: "gone", LCOrganizationID: lcoid, SyncErrorCount: 10, Action: CleanupActionQuarantine, Reason: "charge not found in LiveChat"},
+				{ChargeID: "outage", LCOrganizationID: lcoid, SyncErrorCount: 11, Action: CleanupActionKeep, Reason: fmt.Sprintf("failed to check charge in LiveChat: %v", assert.AnError)},
+			},
+		}, report)
+		assert.Empty(t, sm.lockedCharges)
+		assertExpectations(t)
+	})
+}
+
+func TestService_GetQuarantinedCharges(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		sm.On("GetQuarantinedCharges", ctx).Return([]Charge{{ID: "id"}}, nil).Once()
+
+		charges, err := s.GetQuarantinedCharges(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Charge{{ID: "id"}}, charges)
+		assertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		sm.On("GetQuarantinedCharges", ctx).Return(nil, assert.AnError).Once()
+
+		_, err := s.GetQuarantinedCharges(ctx)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assertExpectations(t)
+	})
+}
+
+func TestService_ConfirmChargeCleanup(t *testing.T) {
+	quarantinedAt := time.Now()
+	orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+	orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+	orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+	payload := CleanupFailedChargeEventPayload{ChargeID: "id", SyncErrorCount: 10}
+
+	t.Run("success", func(t *testing.T) {
+		sm.On("GetCharge", ctx, "", "id").Return(&Charge{ID: "id", LCOrganizationID: lcoid, SyncErrorCount: 10, QuarantinedAt: &quarantinedAt}, nil).Once()
+		xm.On("GenerateId").Return(xid).Once()
+		event := events.Event{ID: "1"}
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionCleanupFailedCharge, events.EventTypeInfo, payload).Return(event).Once()
+		am.On("GetRecurrentCharge", orgCtx, "id").Return(nil, livechat.ErrNotFound).Once()
+		sm.On("DeleteCharge", orgCtx, "", "id").Return(nil).Once()
+		em.On("CreateEvent", orgCtx, event).Return(nil).Once()
+
+		err := s.ConfirmChargeCleanup(ctx, "id")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"id"}, sm.lockedCharges)
+		assertExpectations(t)
+	})
+
+	t.Run("charge not quarantined", func(t *testing.T) {
+		sm.On("GetCharge", ctx, "", "id").Return(&Charge{ID: "id", LCOrganizationID: lcoid, SyncErrorCount: 10}, nil).Once()
+		xm.On("GenerateId").Return(xid).Once()
+		event := events.Event{ID: "1"}
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionCleanupFailedCharge, events.EventTypeInfo, payload).Return(event).Once()
+		event.Type = events.EventTypeError
+		em.On("ToError", orgCtx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("charge id is not quarantined"),
+		}).Return(assert.AnError).Once()
+
+		err := s.ConfirmChargeCleanup(ctx, "id")
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assertExpectations(t)
+	})
+
+	t.Run("charge reappeared in LiveChat", func(t *testing.T) {
+		sm.On("GetCharge", ctx, "", "id").Return(&Charge{ID: "id", LCOrganizationID: lcoid, SyncErrorCount: 10, QuarantinedAt: &quarantinedAt}, nil).Once()
+		xm.On("GenerateId").Return(xid).Once()
+		event := events.Event{ID: "1"}
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionCleanupFailedCharge, events.EventTypeInfo, payload).Return(event).Once()
+		am.On("GetRecurrentCharge", orgCtx, "id").Return(&livechat.RecurrentCharge{}, nil).Once()
+		event.Type = events.EventTypeError
+		em.On("ToError", orgCtx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("charge id can't be deleted: charge still exists in LiveChat"),
+		}).Return(assert.AnError).Once()
+
+		err := s.ConfirmChargeCleanup(ctx, "id")
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assertExpectations(t)
+	})
+
+	t.Run("charge not found", func(t *testing.T) {
+		sm.On("GetCharge", ctx, "", "id").Return(nil, ErrChargeNotFound).Once()
+
+		err := s.ConfirmChargeCleanup(ctx, "id")
+
+		assert.ErrorIs(t, err, ErrChargeNotFound)
+		assertExpectations(t)
+	})
+
+	t.Run("error deleting charge", func(t *testing.T) {
+		sm.On("GetCharge", ctx, "", "id").Return(&Charge{ID: "id", LCOrganizationID: lcoid, SyncErrorCount: 10, QuarantinedAt: &quarantinedAt}, nil).Once()
+		xm.On("GenerateId").Return(xid).Once()
+		event := events.Event{ID: "1"}
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionCleanupFailedCharge, events.EventTypeInfo, payload).Return(event).Once()
+		am.On("GetRecurrentCharge", orgCtx, "id").Return(nil, livechat.ErrUnprocessableEntity).Once()
+		sm.On("DeleteCharge", orgCtx, "", "id").Return(assert.AnError).Once()
+		event.Type = events.EventTypeError
+		em.On("ToError", orgCtx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to delete charge: %w", assert.AnError),
+		}).Return(assert.AnError).Once()
+
+		err := s.ConfirmChargeCleanup(ctx, "id")
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assertExpectations(t)
+	})
+}
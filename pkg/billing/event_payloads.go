@@ -0,0 +1,108 @@
+package billing
+
+import (
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+)
+
+// RegisterEventPayloads registers the payloads of the billing events, the events of the billing events table.
+func RegisterEventPayloads(r *events.PayloadRegistry) {
+	r.Register(events.EventActionCreateCharge, CreateChargeEventPayload{})
+	r.Register(events.EventActionSyncRecurrentCharge, SyncRecurrentChargeEventPayload{})
+	r.Register(events.EventActionCreateSubscription, CreateSubscriptionEventPayload{})
+	r.Register(events.EventActionDeleteSubscriptionWithCharge, DeleteSubscriptionWithChargeEventPayload{})
+	r.Register(events.EventActionDeleteSubscription, DeleteSubscriptionEventPayload{})
+	r.Register(events.EventActionForceCancelCharge, ForceCancelChargeEventPayload{})
+	r.Register(events.EventActionCleanupFailedCharge, CleanupFailedChargeEventPayload{})
+	r.Register(events.EventActionQuarantineCharge, QuarantineChargeEventPayload{})
+	r.Register(events.EventActionReconcile, ReconcileEventPayload{})
+	r.Register(events.EventActionDPSWebhookApplicationUninstalled, DPSWebhookEventPayload{})
+	r.Register(events.EventActionDPSWebhookPayment, DPSWebhookEventPayload{})
+}
+
+// CreateChargeEventPayload has the LiveChat charge once it is created.
+type CreateChargeEventPayload struct {
+	Version         int                       `json:"version"`
+	Name            string                    `json:"name"`
+	Price           int                       `json:"price"`
+	ChargeFrequency int                       `json:"charge_frequency"`
+	TrialDays       int                       `json:"trial_days,omitempty"`
+	Charge          *livechat.RecurrentCharge `json:"charge,omitempty"`
+}
+
+func (CreateChargeEventPayload) PayloadVersion() int { return 1 }
+
+// SyncRecurrentChargeEventPayload has the LiveChat charge once it is synced.
+type SyncRecurrentChargeEventPayload struct {
+	Version  int                       `json:"version"`
+	ChargeID string                    `json:"charge_id"`
+	Charge   *livechat.RecurrentCharge `json:"charge,omitempty"`
+}
+
+func (SyncRecurrentChargeEventPayload) PayloadVersion() int { return 1 }
+
+type CreateSubscriptionEventPayload struct {
+	Version  int    `json:"version"`
+	PlanName string `json:"plan_name"`
+	ChargeID string `json:"charge_id"`
+	Trial    bool   `json:"trial,omitempty"`
+	// Result is set once the subscription is created or found.
+	Result string `json:"result,omitempty"`
+}
+
+func (CreateSubscriptionEventPayload) PayloadVersion() int { return 1 }
+
+type DeleteSubscriptionWithChargeEventPayload struct {
+	Version  int    `json:"version"`
+	ChargeID string `json:"charge_id"`
+}
+
+func (DeleteSubscriptionWithChargeEventPayload) PayloadVersion() int { return 1 }
+
+type DeleteSubscriptionEventPayload struct {
+	Version        int    `json:"version"`
+	SubscriptionID string `json:"subscription_id"`
+}
+
+func (DeleteSubscriptionEventPayload) PayloadVersion() int { return 1 }
+
+type ForceCancelChargeEventPayload struct {
+	Version  int    `json:"version"`
+	ChargeID string `json:"charge_id"`
+}
+
+func (ForceCancelChargeEventPayload) PayloadVersion() int { return 1 }
+
+type CleanupFailedChargeEventPayload struct {
+	Version        int    `json:"version"`
+	ChargeID       string `json:"charge_id"`
+	SyncErrorCount int    `json:"sync_error_count"`
+}
+
+func (CleanupFailedChargeEventPayload) PayloadVersion() int { return 1 }
+
+type QuarantineChargeEventPayload struct {
+	Version        int    `json:"version"`
+	ChargeID       string `json:"charge_id"`
+	SyncErrorCount int    `json:"sync_error_count"`
+	Reason         string `json:"reason"`
+}
+
+func (QuarantineChargeEventPayload) PayloadVersion() int { return 1 }
+
+// ReconcileEventPayload has the report once the reconciliation is done.
+type ReconcileEventPayload struct {
+	Version int                   `json:"version"`
+	AutoFix bool                  `json:"auto_fix"`
+	Report  *ReconciliationReport `json:"report,omitempty"`
+}
+
+func (ReconcileEventPayload) PayloadVersion() int { return 1 }
+
+// DPSWebhookEventPayload is the webhook request, its fields are promoted to the payload.
+type DPSWebhookEventPayload struct {
+	Version int `json:"version"`
+	DPSWebhookRequest
+}
+
+func (DPSWebhookEventPayload) PayloadVersion() int { return 1 }
@@ -0,0 +1,32 @@
+package billing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+func TestRegisterEventPayloads(t *testing.T) {
+	r := events.NewPayloadRegistry()
+	RegisterEventPayloads(r)
+
+	p, err := r.Decode(events.Event{
+		Action:  events.EventActionCreateSubscription,
+		Payload: []byte(`{"version":1,"plan_name":"plan","charge_id":"id","result":"success"}`),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &CreateSubscriptionEventPayload{Version: 1, PlanName: "plan", ChargeID: "id", Result: "success"}, p)
+
+	// payloads stored before the typed ones, e.g. by CreateSubscription
+	_, err = r.Decode(events.Event{
+		Action:  events.EventActionCreateSubscription,
+		Payload: []byte(`{"planName":"plan","chargeID":"id","result":"subscription already exists"}`),
+	})
+	assert.ErrorIs(t, err, events.ErrLegacyPayload)
+
+	_, err = r.JSONSchema()
+	assert.NoError(t, err)
+}
@@ -3,51 +3,102 @@ package billing
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
 )
 
 type DPSWebhookRequest struct {
 	ApplicationID    string                 `json:"applicationID"`
 	ApplicationName  string                 `json:"applicationName"`
-	ClientID         string                 `json:"clientID"`
+	ClientID         string                 `json:"clientID" redact:"mask"`
 	Date             time.Time              `json:"date"`
 	Event            string                 `json:"event"`
 	License          int32                  `json:"licenseID"`
 	LCOrganizationID string                 `json:"organizationID"`
 	Payload          map[string]interface{} `json:"payload"`
-	UserID           string                 `json:"userID"`
+	UserID           string                 `json:"userID" redact:"mask"`
 }
 
 type Handler struct {
 	billing      ServiceInterface
 	idProvider   events.IdProviderInterface
 	eventService events.EventService
+	metrics      metrics.Metrics
+	tracer       trace.Tracer
+	logger       *slog.Logger
+}
+
+// HandlerOption configures optional Handler behaviour.
+type HandlerOption func(*Handler)
+
+// WithHandlerMetrics reports every handled webhook to m, labelled with its event.
+func WithHandlerMetrics(m metrics.Metrics) HandlerOption {
+	return func(h *Handler) {
+		h.metrics = m
+	}
 }
 
 type HandlerInterface interface {
 	HandleDPSWebhook(ctx context.Context, req DPSWebhookRequest) error
 }
 
-func NewHandler(eventService events.EventService, billing ServiceInterface, idProvider events.IdProviderInterface) *Handler {
-	return &Handler{
+func NewHandler(eventService events.EventService, billing ServiceInterface, idProvider events.IdProviderInterface, opts ...HandlerOption) *Handler {
+	h := &Handler{
 		billing:      billing,
 		idProvider:   idProvider,
 		eventService: eventService,
+		metrics:      metrics.Noop{},
+		logger:       slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	return h
 }
 
 func (h *Handler) HandleDPSWebhook(ctx context.Context, req DPSWebhookRequest) error {
-	ctx = context.WithValue(ctx, EventIDCtxKey{}, h.idProvider.GenerateId())
+	start := time.Now()
+	eventID := h.idProvider.GenerateId()
+	ctx = context.WithValue(ctx, EventIDCtxKey{}, eventID)
+
+	attrs := []attribute.KeyValue{tracing.WebhookEventKey.String(req.Event), tracing.OrganizationIDKey.String(req.LCOrganizationID), tracing.EventIDKey.String(eventID)}
+	if chargeID, ok := req.Payload["paymentID"].(string); ok {
+		attrs = append(attrs, tracing.ChargeIDKey.String(chargeID))
+	}
+	ctx, span := tracing.Start(ctx, h.tracer, "billing.HandleDPSWebhook", attrs...)
+	err := h.handleDPSWebhook(ctx, req)
+	tracing.End(span, err)
+	if err != nil {
+		h.logger.LogAttrs(ctx, slog.LevelError, "billing.HandleDPSWebhook failed", append(tracing.LogAttrs(attrs), slog.Any("error", err))...)
+	}
+
+	labels := metrics.Labels{Action: req.Event, Status: metrics.Status(err)}
+	h.metrics.IncCounter(metrics.WebhooksTotal, labels)
+	metrics.ObserveSince(h.metrics, metrics.WebhookDurationSeconds, labels, start)
+
+	return err
+}
+
+// handleDPSWebhook expects the EventIDCtxKey set by HandleDPSWebhook.
+func (h *Handler) handleDPSWebhook(ctx context.Context, req DPSWebhookRequest) error {
 	ctx = context.WithValue(ctx, OrganizationIDCtxKey{}, req.LCOrganizationID)
 	ctx = context.WithValue(ctx, LicenseIDCtxKey{}, req.License)
+	ctx = context.WithValue(ctx, ApplicationIDCtxKey{}, req.ApplicationID)
 	chargeID, exists := req.Payload["paymentID"].(string)
 	if !exists {
 		return nil
 	}
 
-	event := h.eventService.ToEvent(ctx, req.LCOrganizationID, events.EventActionUnknown, events.EventTypeInfo, req)
+	event := h.eventService.ToEvent(ctx, req.LCOrganizationID, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req})
+	ctx = events.WithParent(ctx, event)
 
 	switch req.Event {
 	case "application_uninstalled":
@@ -100,7 +151,7 @@ func (h *Handler) HandleDPSWebhook(ctx context.Context, req DPSWebhookRequest) e
 		}
 
 		if len(subs) > 0 {
-			_ = h.eventService.CreateEvent(ctx, event)
+			h.createEvent(ctx, event)
 
 			return nil
 		}
@@ -123,7 +174,7 @@ func (h *Handler) HandleDPSWebhook(ctx context.Context, req DPSWebhookRequest) e
 		}
 	}
 
-	_ = h.eventService.CreateEvent(ctx, event)
+	h.createEvent(ctx, event)
 
 	return nil
 }
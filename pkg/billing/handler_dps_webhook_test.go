@@ -11,6 +11,8 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics/metricstest"
 )
 
 var bm = new(billingMock)
@@ -19,9 +21,13 @@ var h = Handler{
 	eventService: em,
 	billing:      bm,
 	idProvider:   xm,
+	metrics:      metrics.Noop{},
+	logger:       discardLogger,
 }
 
 var planName = "some_plan"
+
+var applicationID = "123"
 var billingCtx = initCtx()
 
 func initCtx() context.Context {
@@ -29,6 +35,7 @@ func initCtx() context.Context {
 	wCtx = context.WithValue(wCtx, EventIDCtxKey{}, xid)
 	wCtx = context.WithValue(wCtx, OrganizationIDCtxKey{}, lcoid)
 	wCtx = context.WithValue(wCtx, LicenseIDCtxKey{}, lid)
+	wCtx = context.WithValue(wCtx, ApplicationIDCtxKey{}, applicationID)
 
 	return wCtx
 }
@@ -107,13 +114,55 @@ func (b *billingMock) CleanupFailedCharges(ctx context.Context) error {
 	return args.Error(0)
 }
 
+func (b *billingMock) DryRunCleanupFailedCharges(ctx context.Context) (*CleanupReport, error) {
+	args := b.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CleanupReport), args.Error(1)
+}
+
+func (b *billingMock) GetQuarantinedCharges(ctx context.Context) ([]Charge, error) {
+	args := b.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Charge), args.Error(1)
+}
+
+func (b *billingMock) ConfirmChargeCleanup(ctx context.Context, id string) error {
+	args := b.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (b *billingMock) Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconciliationReport, error) {
+	args := b.Called(ctx, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ReconciliationReport), args.Error(1)
+}
+
+func (b *billingMock) BackfillApplicationID(ctx context.Context) error {
+	args := b.Called(ctx)
+	return args.Error(0)
+}
+
 func TestNewHandler(t *testing.T) {
 	t.Run("NewHandler", func(t *testing.T) {
 		newService := NewHandler(&eventMock{}, &billingMock{}, &xIdMock{})
 
 		assert.NotNil(t, newService)
+		assert.Equal(t, metrics.Noop{}, newService.metrics)
 		assertExpectations(t)
 	})
+
+	t.Run("WithHandlerMetrics", func(t *testing.T) {
+		recorder := metricstest.NewRecorder()
+		newService := NewHandler(&eventMock{}, &billingMock{}, &xIdMock{}, WithHandlerMetrics(recorder))
+
+		assert.Equal(t, recorder, newService.metrics)
+	})
 }
 
 func TestService_HandleDPSWebhook(t *testing.T) {
@@ -125,7 +174,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 		xm.On("GenerateId").Return(xid, nil)
 
 		req := DPSWebhookRequest{
-			ApplicationID:    "123",
+			ApplicationID:    applicationID,
 			ApplicationName:  "ttt",
 			ClientID:         "321",
 			Date:             someDate,
@@ -157,10 +206,10 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		bm.On("GetSubscriptionsByOrganizationID", billingCtx, lcoid).Return([]Subscription{sub}, nil)
-		bm.On("DeleteSubscription", billingCtx, lcoid, sub.ID).Return(nil).Once()
-		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", billingCtx, levent).Return(nil).Once()
+		bm.On("GetSubscriptionsByOrganizationID", events.WithParent(billingCtx, levent), lcoid).Return([]Subscription{sub}, nil)
+		bm.On("DeleteSubscription", events.WithParent(billingCtx, levent), lcoid, sub.ID).Return(nil).Once()
+		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(billingCtx, levent), levent).Return(nil).Once()
 		lctx := context.WithValue(context.Background(), SubscriptionPlanNameCtxKey{}, planName)
 		err := h.HandleDPSWebhook(lctx, req)
 
@@ -176,7 +225,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 		xm.On("GenerateId").Return(xid, nil)
 
 		req := DPSWebhookRequest{
-			ApplicationID:    "123",
+			ApplicationID:    applicationID,
 			ApplicationName:  "ttt",
 			ClientID:         "321",
 			Date:             someDate,
@@ -198,9 +247,9 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		bm.On("GetSubscriptionsByOrganizationID", billingCtx, lcoid).Return(nil, assert.AnError).Once()
-		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", billingCtx, events.ToErrorParams{
+		bm.On("GetSubscriptionsByOrganizationID", events.WithParent(billingCtx, levent), lcoid).Return(nil, assert.AnError).Once()
+		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(billingCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
 		}).Return(assert.AnError).Once()
@@ -219,7 +268,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 		xm.On("GenerateId").Return(xid, nil)
 
 		req := DPSWebhookRequest{
-			ApplicationID:    "123",
+			ApplicationID:    applicationID,
 			ApplicationName:  "ttt",
 			ClientID:         "321",
 			Date:             someDate,
@@ -251,10 +300,10 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		bm.On("GetSubscriptionsByOrganizationID", billingCtx, lcoid).Return([]Subscription{sub}, nil)
-		bm.On("DeleteSubscription", billingCtx, lcoid, sub.ID).Return(assert.AnError).Once()
-		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", billingCtx, events.ToErrorParams{
+		bm.On("GetSubscriptionsByOrganizationID", events.WithParent(billingCtx, levent), lcoid).Return([]Subscription{sub}, nil)
+		bm.On("DeleteSubscription", events.WithParent(billingCtx, levent), lcoid, sub.ID).Return(assert.AnError).Once()
+		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(billingCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("delete subscription with charge: %w", assert.AnError),
 		}).Return(assert.AnError).Once()
@@ -274,7 +323,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 		xm.On("GenerateId").Return(xid, nil)
 
 		req := DPSWebhookRequest{
-			ApplicationID:    "123",
+			ApplicationID:    applicationID,
 			ApplicationName:  "ttt",
 			ClientID:         "321",
 			Date:             someDate,
@@ -295,11 +344,11 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		bm.On("SyncRecurrentCharge", billingCtx, lcoid, paymentID).Return(nil).Once()
-		bm.On("CreateSubscription", billingCtx, lcoid, paymentID, planName).Return(nil).Once()
-		bm.On("GetSubscriptionsByOrganizationID", billingCtx, lcoid).Return([]Subscription{}, nil)
-		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", billingCtx, levent).Return(nil).Once()
+		bm.On("SyncRecurrentCharge", events.WithParent(billingCtx, levent), lcoid, paymentID).Return(nil).Once()
+		bm.On("CreateSubscription", events.WithParent(billingCtx, levent), lcoid, paymentID, planName).Return(nil).Once()
+		bm.On("GetSubscriptionsByOrganizationID", events.WithParent(billingCtx, levent), lcoid).Return([]Subscription{}, nil)
+		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(billingCtx, levent), levent).Return(nil).Once()
 		lctx := context.WithValue(context.Background(), SubscriptionPlanNameCtxKey{}, planName)
 		err := h.HandleDPSWebhook(lctx, req)
 
@@ -316,7 +365,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 		xm.On("GenerateId").Return(xid, nil)
 
 		req := DPSWebhookRequest{
-			ApplicationID:    "123",
+			ApplicationID:    applicationID,
 			ApplicationName:  "ttt",
 			ClientID:         "321",
 			Date:             someDate,
@@ -337,14 +386,14 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		bm.On("GetSubscriptionsByOrganizationID", billingCtx, lcoid).Return([]Subscription{
+		bm.On("GetSubscriptionsByOrganizationID", events.WithParent(billingCtx, levent), lcoid).Return([]Subscription{
 			{
 				ID: "sub1",
 			},
 		}, nil)
-		bm.On("SyncRecurrentCharge", billingCtx, lcoid, paymentID).Return(nil).Once()
-		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", billingCtx, levent).Return(nil).Once()
+		bm.On("SyncRecurrentCharge", events.WithParent(billingCtx, levent), lcoid, paymentID).Return(nil).Once()
+		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(billingCtx, levent), levent).Return(nil).Once()
 		lctx := context.WithValue(context.Background(), SubscriptionPlanNameCtxKey{}, planName)
 		err := h.HandleDPSWebhook(lctx, req)
 
@@ -360,7 +409,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 		xm.On("GenerateId").Return(xid, nil)
 
 		req := DPSWebhookRequest{
-			ApplicationID:    "123",
+			ApplicationID:    applicationID,
 			ApplicationName:  "ttt",
 			ClientID:         "321",
 			Date:             someDate,
@@ -381,9 +430,9 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		bm.On("SyncRecurrentCharge", billingCtx, lcoid, paymentID).Return(assert.AnError).Once()
-		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", billingCtx, events.ToErrorParams{
+		bm.On("SyncRecurrentCharge", events.WithParent(billingCtx, levent), lcoid, paymentID).Return(assert.AnError).Once()
+		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(billingCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("sync recurrent charge x1c2v3: %w", assert.AnError),
 		}).Return(assert.AnError).Once()
@@ -403,7 +452,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 		xm.On("GenerateId").Return(xid, nil)
 
 		req := DPSWebhookRequest{
-			ApplicationID:    "123",
+			ApplicationID:    applicationID,
 			ApplicationName:  "ttt",
 			ClientID:         "321",
 			Date:             someDate,
@@ -428,11 +477,12 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 		wCtx = context.WithValue(wCtx, EventIDCtxKey{}, xid)
 		wCtx = context.WithValue(wCtx, OrganizationIDCtxKey{}, lcoid)
 		wCtx = context.WithValue(wCtx, LicenseIDCtxKey{}, lid)
+		wCtx = context.WithValue(wCtx, ApplicationIDCtxKey{}, applicationID)
 
-		bm.On("SyncRecurrentCharge", wCtx, lcoid, paymentID).Return(nil).Once()
-		bm.On("GetSubscriptionsByOrganizationID", wCtx, lcoid).Return([]Subscription{}, nil)
-		em.On("ToEvent", wCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", wCtx, events.ToErrorParams{
+		bm.On("SyncRecurrentCharge", events.WithParent(wCtx, levent), lcoid, paymentID).Return(nil).Once()
+		bm.On("GetSubscriptionsByOrganizationID", events.WithParent(wCtx, levent), lcoid).Return([]Subscription{}, nil)
+		em.On("ToEvent", wCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(wCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("no plan name found in context"),
 		}).Return(assert.AnError).Once()
@@ -452,7 +502,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 		xm.On("GenerateId").Return(xid, nil)
 
 		req := DPSWebhookRequest{
-			ApplicationID:    "123",
+			ApplicationID:    applicationID,
 			ApplicationName:  "ttt",
 			ClientID:         "321",
 			Date:             someDate,
@@ -473,9 +523,9 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		bm.On("DeleteSubscriptionWithCharge", billingCtx, lcoid, paymentID).Return(nil).Once()
-		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", billingCtx, levent).Return(nil).Once()
+		bm.On("DeleteSubscriptionWithCharge", events.WithParent(billingCtx, levent), lcoid, paymentID).Return(nil).Once()
+		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(billingCtx, levent), levent).Return(nil).Once()
 		lctx := context.WithValue(context.Background(), SubscriptionPlanNameCtxKey{}, planName)
 		err := h.HandleDPSWebhook(lctx, req)
 
@@ -492,7 +542,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 		xm.On("GenerateId").Return(xid, nil)
 
 		req := DPSWebhookRequest{
-			ApplicationID:    "123",
+			ApplicationID:    applicationID,
 			ApplicationName:  "ttt",
 			ClientID:         "321",
 			Date:             someDate,
@@ -513,16 +563,21 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		bm.On("DeleteSubscriptionWithCharge", billingCtx, lcoid, paymentID).Return(assert.AnError).Once()
-		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", billingCtx, events.ToErrorParams{
+		bm.On("DeleteSubscriptionWithCharge", events.WithParent(billingCtx, levent), lcoid, paymentID).Return(assert.AnError).Once()
+		em.On("ToEvent", billingCtx, lcoid, events.EventActionUnknown, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(billingCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("delete subscription with charge: %w", assert.AnError),
 		}).Return(assert.AnError).Once()
+		recorder := metricstest.NewRecorder()
+		mh := h
+		mh.metrics = recorder
 		lctx := context.WithValue(context.Background(), SubscriptionPlanNameCtxKey{}, planName)
-		err := h.HandleDPSWebhook(lctx, req)
+		err := mh.HandleDPSWebhook(lctx, req)
 
 		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 1, recorder.Counter(metrics.WebhooksTotal, eventType, metrics.StatusError))
+		assert.Len(t, recorder.Observations(metrics.WebhookDurationSeconds, eventType, metrics.StatusError), 1)
 
 		assertExpectations(t)
 	})
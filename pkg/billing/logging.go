@@ -0,0 +1,60 @@
+package billing
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+// WithLogger replaces slog.Default. Failed calls are logged with the organization, charge and event ID they ran for.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Service) {
+		s.logger = logger
+	}
+}
+
+// WithHandlerLogger replaces slog.Default.
+func WithHandlerLogger(logger *slog.Logger) HandlerOption {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
+
+// start starts the span of a Service method. The returned func ends it and logs a non-nil err with the span attributes.
+func (s *Service) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(err error)) {
+	if id, ok := ctx.Value(EventIDCtxKey{}).(string); ok {
+		attrs = append(attrs, tracing.EventIDKey.String(id))
+	}
+
+	ctx, span := tracing.Start(ctx, s.tracer, "billing."+name, attrs...)
+	return ctx, func(err error) {
+		tracing.End(span, err)
+		if err != nil {
+			s.logger.LogAttrs(ctx, slog.LevelError, "billing."+name+" failed", append(tracing.LogAttrs(attrs), slog.Any("error", err))...)
+		}
+	}
+}
+
+// createEvent stores the event of a call that succeeded, a failed write is logged instead of failing the call.
+func (s *Service) createEvent(ctx context.Context, event events.Event) {
+	if err := s.eventService.CreateEvent(ctx, event); err != nil {
+		s.logger.ErrorContext(ctx, "failed to store billing event", "event", event, "error", err)
+	}
+}
+
+// logChargeError logs an err the call does not return, e.g. of a sync error count update.
+func (s *Service) logChargeError(ctx context.Context, msg, chargeID string, err error) {
+	if err != nil {
+		s.logger.WarnContext(ctx, msg, string(tracing.ChargeIDKey), chargeID, "error", err)
+	}
+}
+
+func (h *Handler) createEvent(ctx context.Context, event events.Event) {
+	if err := h.eventService.CreateEvent(ctx, event); err != nil {
+		h.logger.ErrorContext(ctx, "failed to store billing event", "event", event, "error", err)
+	}
+}
@@ -0,0 +1,43 @@
+package billing
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+func TestService_logging(t *testing.T) {
+	var buf bytes.Buffer
+	ms := s
+	ms.logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+	t.Run("failed call", func(t *testing.T) {
+		buf.Reset()
+		am.On("CancelRecurrentCharge", ctx, "id").Return(nil, assert.AnError).Once()
+
+		err := ms.CancelRecurrentCharge(ctx, "id")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, buf.String(), `level=ERROR msg="billing.CancelRecurrentCharge failed" livechat.charge_id=id error="failed to cancel recurrent charge: assert.AnError`)
+
+		assertExpectations(t)
+	})
+
+	t.Run("failed event write", func(t *testing.T) {
+		buf.Reset()
+		event := events.Event{ID: "eid", LCOrganizationID: lcoid, Type: events.EventTypeInfo, Action: events.EventActionDeleteSubscriptionWithCharge}
+		em.On("ToEvent", ctx, lcoid, events.EventActionDeleteSubscriptionWithCharge, events.EventTypeInfo, DeleteSubscriptionWithChargeEventPayload{ChargeID: "id"}).Return(event).Once()
+		sm.On("DeleteSubscriptionByChargeID", ctx, "", lcoid, "id").Return(nil).Once()
+		sm.On("DeleteCharge", ctx, "", "id").Return(nil).Once()
+		em.On("CreateEvent", ctx, event).Return(assert.AnError).Once()
+
+		err := ms.DeleteSubscriptionWithCharge(ctx, lcoid, "id")
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), `level=ERROR msg="failed to store billing event" event.id=eid event.organization_id=lcOrganizationID event.type=info event.action=delete_subscription_with_charge`)
+
+		assertExpectations(t)
+	})
+}
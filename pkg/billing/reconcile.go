@@ -0,0 +1,232 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+)
+
+// DriftKind classifies a difference between stored charges and subscriptions and the LiveChat Billing API.
+type DriftKind string
+
+const (
+	DriftKindStatusMismatch DriftKind = "status_mismatch"
+	DriftKindAmountMismatch DriftKind = "amount_mismatch"
+	// DriftKindOrphanCharge is a stored charge LiveChat does not know (404/422).
+	DriftKindOrphanCharge DriftKind = "orphan_charge"
+	// DriftKindMissingSubscription is a charge LiveChat bills as active that no active subscription points to.
+	DriftKindMissingSubscription DriftKind = "missing_subscription"
+	// DriftKindDeletedCharge is an active subscription whose charge is deleted.
+	DriftKindDeletedCharge DriftKind = "deleted_charge"
+)
+
+// DriftFix is the action ReconcileOptions.AutoFix takes for a drift.
+type DriftFix string
+
+const (
+	DriftFixSyncCharge       DriftFix = "sync_charge"
+	DriftFixQuarantineCharge DriftFix = "quarantine_charge"
+	// DriftFixDeleteSubscription deletes only the local subscription row, the charge is not canceled in LiveChat.
+	DriftFixDeleteSubscription DriftFix = "delete_subscription"
+)
+
+type Drift struct {
+	Kind             DriftKind `json:"kind"`
+	LCOrganizationID string    `json:"lc_organization_id"`
+	ApplicationID    string    `json:"application_id"`
+	ChargeID         string    `json:"charge_id,omitempty"`
+	SubscriptionID   string    `json:"subscription_id,omitempty"`
+	// Local and Remote hold the stored and the LiveChat value for mismatches.
+	Local  string `json:"local,omitempty"`
+	Remote string `json:"remote,omitempty"`
+	// Fix is empty when the drift needs a human, e.g. a missing subscription whose plan is unknown.
+	Fix      DriftFix `json:"fix,omitempty"`
+	Fixed    bool     `json:"fixed"`
+	FixError string   `json:"fix_error,omitempty"`
+}
+
+type ReconcileOptions struct {
+	AutoFix bool `json:"auto_fix"`
+}
+
+type ReconciliationReport struct {
+	CheckedCharges       int     `json:"checked_charges"`
+	CheckedSubscriptions int     `json:"checked_subscriptions"`
+	Drifts               []Drift `json:"drifts"`
+	// Errors lists charges that could not be checked against LiveChat.
+	Errors []string `json:"errors"`
+}
+
+// Reconcile compares every stored charge and active subscription with the LiveChat Billing API and reports the drift.
+// With AutoFix it also applies the fix of every drift that has one.
+func (s *Service) Reconcile(ctx context.Context, opts ReconcileOptions) (_ *ReconciliationReport, err error) {
+	ctx, end := s.start(ctx, "Reconcile")
+	defer func() { end(err) }()
+
+	event := s.eventService.ToEvent(ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{AutoFix: opts.AutoFix})
+
+	charges, err := s.storage.GetCharges(ctx)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return nil, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to get charges: %w", err),
+		})
+	}
+
+	subscriptions, err := s.storage.GetSubscriptions(ctx)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return nil, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to get subscriptions: %w", err),
+		})
+	}
+
+	report := &ReconciliationReport{
+		CheckedCharges:       len(charges),
+		CheckedSubscriptions: len(subscriptions),
+		Drifts:               []Drift{},
+		Errors:               []string{},
+	}
+
+	subscribed := make(map[string]bool)
+	for _, sub := range subscriptions {
+		if sub.Charge == nil {
+			continue
+		}
+		if sub.Charge.CanceledAt != nil {
+			report.Drifts = append(report.Drifts, Drift{
+				Kind:             DriftKindDeletedCharge,
+				LCOrganizationID: sub.LCOrganizationID,
+				ApplicationID:    sub.ApplicationID,
+				ChargeID:         sub.Charge.ID,
+				SubscriptionID:   sub.ID,
+				Fix:              DriftFixDeleteSubscription,
+			})
+			continue
+		}
+		subscribed[sub.Charge.ID] = true
+	}
+
+	for _, charge := range charges {
+		drifts, err := s.reconcileCharge(ctx, charge, subscribed[charge.ID])
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("charge %s: %v", charge.ID, err))
+			if errors.Is(err, ErrBillingAPIUnavailable) {
+				break
+			}
+			continue
+		}
+		report.Drifts = append(report.Drifts, drifts...)
+	}
+
+	if opts.AutoFix {
+		s.fixDrifts(ctx, report.Drifts)
+	}
+
+	event.SetPayload(ReconcileEventPayload{AutoFix: opts.AutoFix, Report: report})
+	if len(report.Errors) > 0 {
+		event.Type = events.EventTypeError
+		return report, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to check %d charges", len(report.Errors)),
+		})
+	}
+
+	s.createEvent(ctx, event)
+
+	return report, nil
+}
+
+func (s *Service) reconcileCharge(ctx context.Context, charge Charge, subscribed bool) ([]Drift, error) {
+	drift := Drift{LCOrganizationID: charge.LCOrganizationID, ApplicationID: charge.ApplicationID, ChargeID: charge.ID}
+
+	app, err := s.applicationByID(charge.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	lcCharge, err := app.billingAPI.GetRecurrentCharge(ctx, charge.ID)
+	// 404 and 422 mean LiveChat does not know the charge, anything else is a failed check.
+	if err != nil && !errors.Is(err, livechat.ErrNotFound) && !errors.Is(err, livechat.ErrUnprocessableEntity) {
+		return nil, err
+	}
+	if lcCharge == nil {
+		drift.Kind = DriftKindOrphanCharge
+		if charge.QuarantinedAt == nil {
+			drift.Fix = DriftFixQuarantineCharge
+		}
+		return []Drift{drift}, nil
+	}
+
+	var local livechat.BaseCharge
+	_ = json.Unmarshal(charge.Payload, &local)
+
+	var drifts []Drift
+	if local.Status != lcCharge.Status {
+		d := drift
+		d.Kind, d.Local, d.Remote, d.Fix = DriftKindStatusMismatch, string(local.Status), string(lcCharge.Status), DriftFixSyncCharge
+		drifts = append(drifts, d)
+	}
+	if local.Price != lcCharge.Price {
+		d := drift
+		d.Kind, d.Local, d.Remote, d.Fix = DriftKindAmountMismatch, strconv.Itoa(local.Price), strconv.Itoa(lcCharge.Price), DriftFixSyncCharge
+		drifts = append(drifts, d)
+	}
+	if !subscribed && (lcCharge.Status == livechat.RecurrentChargeStatusActive || lcCharge.Status == livechat.RecurrentChargeStatusPastDue) {
+		d := drift
+		d.Kind, d.Remote = DriftKindMissingSubscription, string(lcCharge.Status)
+		drifts = append(drifts, d)
+	}
+
+	return drifts, nil
+}
+
+// fixDrifts applies every fix once, a charge with both a status and an amount mismatch is synced a single time.
+func (s *Service) fixDrifts(ctx context.Context, drifts []Drift) {
+	results := make(map[string]error)
+	for i := range drifts {
+		d := &drifts[i]
+		if d.Fix == "" {
+			continue
+		}
+
+		key := string(d.Fix) + ":" + d.ChargeID + ":" + d.SubscriptionID
+		err, done := results[key]
+		if !done {
+			err = s.fixDrift(ctx, *d)
+			results[key] = err
+		}
+
+		d.Fixed = err == nil
+		if err != nil {
+			d.FixError = err.Error()
+		}
+	}
+}
+
+func (s *Service) fixDrift(ctx context.Context, drift Drift) error {
+	organizationCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, drift.LCOrganizationID)
+	organizationCtx = context.WithValue(organizationCtx, ApplicationIDCtxKey{}, drift.ApplicationID)
+	organizationCtx = context.WithValue(organizationCtx, EventIDCtxKey{}, s.idProvider.GenerateId())
+
+	switch drift.Fix {
+	case DriftFixSyncCharge:
+		return s.SyncRecurrentCharge(organizationCtx, drift.LCOrganizationID, drift.ChargeID)
+	case DriftFixQuarantineCharge:
+		return s.storage.WithChargeLock(organizationCtx, drift.ChargeID, func(ctx context.Context) error {
+			return s.storage.QuarantineCharge(ctx, drift.ChargeID)
+		})
+	case DriftFixDeleteSubscription:
+		// only the local row, DeleteSubscription would also cancel the charge in LiveChat
+		return s.storage.DeleteSubscription(organizationCtx, drift.ApplicationID, drift.LCOrganizationID, drift.SubscriptionID)
+	default:
+		return fmt.Errorf("unknown fix %q", drift.Fix)
+	}
+}
@@ -0,0 +1,153 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+)
+
+func TestService_Reconcile(t *testing.T) {
+	deletedAt := time.Now()
+	payload := func(status livechat.ChargeStatus, price int) json.RawMessage {
+		p, _ := json.Marshal(livechat.RecurrentCharge{BaseCharge: livechat.BaseCharge{Status: status, Price: price}})
+		return p
+	}
+	lcCharge := func(status livechat.ChargeStatus, price int) *livechat.RecurrentCharge {
+		return &livechat.RecurrentCharge{BaseCharge: livechat.BaseCharge{Status: status, Price: price}}
+	}
+
+	t.Run("reports drift", func(t *testing.T) {
+		em.On("ToEvent", ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{AutoFix: false}).Return(events.Event{}).Once()
+		sm.On("GetCharges", ctx).Return([]Charge{
+			{ID: "in-sync", LCOrganizationID: lcoid, Payload: payload(livechat.RecurrentChargeStatusActive, 100)},
+			{ID: "drifted", LCOrganizationID: lcoid, Payload: payload(livechat.RecurrentChargeStatusActive, 100)},
+			{ID: "orphan", LCOrganizationID: lcoid, Payload: payload(livechat.RecurrentChargeStatusActive, 100)},
+			{ID: "unsubscribed", LCOrganizationID: lcoid, Payload: payload(livechat.RecurrentChargeStatusActive, 100)},
+		}, nil).Once()
+		sm.On("GetSubscriptions", ctx).Return([]Subscription{
+			{ID: "sub-1", LCOrganizationID: lcoid, Charge: &Charge{ID: "in-sync"}},
+			{ID: "sub-2", LCOrganizationID: lcoid, Charge: &Charge{ID: "drifted"}},
+			{ID: "sub-3", LCOrganizationID: lcoid, Charge: &Charge{ID: "orphan"}},
+			{ID: "sub-4", LCOrganizationID: lcoid, Charge: &Charge{ID: "deleted", CanceledAt: &deletedAt}},
+			{ID: "sub-5", LCOrganizationID: lcoid},
+		}, nil).Once()
+		am.On("GetRecurrentCharge", ctx, "in-sync").Return(lcCharge(livechat.RecurrentChargeStatusActive, 100), nil).Once()
+		am.On("GetRecurrentCharge", ctx, "drifted").Return(lcCharge(livechat.RecurrentChargeStatusCancelled, 200), nil).Once()
+		am.On("GetRecurrentCharge", ctx, "orphan").Return(nil, livechat.ErrUnprocessableEntity).Once()
+		am.On("GetRecurrentCharge", ctx, "unsubscribed").Return(lcCharge(livechat.RecurrentChargeStatusActive, 100), nil).Once()
+		em.On("CreateEvent", ctx, mock.Anything).Return(nil).Once()
+
+		report, err := s.Reconcile(ctx, ReconcileOptions{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &ReconciliationReport{
+			CheckedCharges:       4,
+			CheckedSubscriptions: 5,
+			Drifts: []Drift{
+				{Kind: DriftKindDeletedCharge, LCOrganizationID: lcoid, ChargeID: "deleted", SubscriptionID: "sub-4", Fix: DriftFixDeleteSubscription},
+				{Kind: DriftKindStatusMismatch, LCOrganizationID: lcoid, ChargeID: "drifted", Local: "active", Remote: "cancelled", Fix: DriftFixSyncCharge},
+				{Kind: DriftKindAmountMismatch, LCOrganizationID: lcoid, ChargeID: "drifted", Local: "100", Remote: "200", Fix: DriftFixSyncCharge},
+				{Kind: DriftKindOrphanCharge, LCOrganizationID: lcoid, ChargeID: "orphan", Fix: DriftFixQuarantineCharge},
+				{Kind: DriftKindMissingSubscription, LCOrganizationID: lcoid, ChargeID: "unsubscribed", Remote: "active"},
+			},
+			Errors: []string{},
+		}, report)
+		assertExpectations(t)
+	})
+
+	t.Run("auto fix", func(t *testing.T) {
+		orgCtx := context.WithValue(ctx, OrganizationIDCtxKey{}, lcoid)
+		orgCtx = context.WithValue(orgCtx, ApplicationIDCtxKey{}, "")
+		orgCtx = context.WithValue(orgCtx, EventIDCtxKey{}, xid)
+		em.On("ToEvent", ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{AutoFix: true}).Return(events.Event{}).Once()
+		sm.On("GetCharges", ctx).Return([]Charge{
+			{ID: "drifted", LCOrganizationID: lcoid, Payload: payload(livechat.RecurrentChargeStatusActive, 100)},
+			{ID: "orphan", LCOrganizationID: lcoid, Payload: payload(livechat.RecurrentChargeStatusActive, 100)},
+		}, nil).Once()
+		sm.On("GetSubscriptions", ctx).Return([]Subscription{
+			{ID: "sub-1", LCOrganizationID: lcoid, Charge: &Charge{ID: "drifted"}},
+			{ID: "sub-2", LCOrganizationID: lcoid, Charge: &Charge{ID: "deleted", CanceledAt: &deletedAt}},
+		}, nil).Once()
+		am.On("GetRecurrentCharge", ctx, "drifted").Return(lcCharge(livechat.RecurrentChargeStatusCancelled, 200), nil).Once()
+		am.On("GetRecurrentCharge", ctx, "orphan").Return(nil, livechat.ErrNotFound).Once()
+
+		xm.On("GenerateId").Return(xid).Times(3)
+		sm.On("DeleteSubscription", orgCtx, "", lcoid, "sub-2").Return(nil).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncRecurrentCharge, events.EventTypeInfo, SyncRecurrentChargeEventPayload{ChargeID: "drifted"}).Return(events.Event{}).Once()
+		sm.On("GetCharge", orgCtx, "", "drifted").Return(&Charge{ID: "drifted", LCOrganizationID: lcoid}, nil).Once()
+		am.On("GetRecurrentCharge", orgCtx, "drifted").Return(lcCharge(livechat.RecurrentChargeStatusCancelled, 200), nil).Once()
+		sm.On("UpdateChargePayload", orgCtx, "drifted", mock.Anything).Return(nil).Once()
+		em.On("CreateEvent", orgCtx, mock.Anything).Return(nil).Once()
+		sm.On("QuarantineCharge", orgCtx, "orphan").Return(assert.AnError).Once()
+		em.On("CreateEvent", ctx, mock.Anything).Return(nil).Once()
+
+		report, err := s.Reconcile(ctx, ReconcileOptions{AutoFix: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Drift{
+			{Kind: DriftKindDeletedCharge, LCOrganizationID: lcoid, ChargeID: "deleted", SubscriptionID: "sub-2", Fix: DriftFixDeleteSubscription, Fixed: true},
+			{Kind: DriftKindStatusMismatch, LCOrganizationID: lcoid, ChargeID: "drifted", Local: "active", Remote: "cancelled", Fix: DriftFixSyncCharge, Fixed: true},
+			{Kind: DriftKindAmountMismatch, LCOrganizationID: lcoid, ChargeID: "drifted", Local: "100", Remote: "200", Fix: DriftFixSyncCharge, Fixed: true},
+			{Kind: DriftKindOrphanCharge, LCOrganizationID: lcoid, ChargeID: "orphan", Fix: DriftFixQuarantineCharge, FixError: assert.AnError.Error()},
+		}, report.Drifts)
+		assert.Equal(t, []string{"drifted", "orphan"}, sm.lockedCharges)
+		assertExpectations(t)
+	})
+
+	t.Run("skips fix for quarantined orphan", func(t *testing.T) {
+		em.On("ToEvent", ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{AutoFix: true}).Return(events.Event{}).Once()
+		sm.On("GetCharges", ctx).Return([]Charge{
+			{ID: "orphan", LCOrganizationID: lcoid, QuarantinedAt: &deletedAt},
+		}, nil).Once()
+		sm.On("GetSubscriptions", ctx).Return([]Subscription{}, nil).Once()
+		am.On("GetRecurrentCharge", ctx, "orphan").Return(nil, livechat.ErrNotFound).Once()
+		em.On("CreateEvent", ctx, mock.Anything).Return(nil).Once()
+
+		report, err := s.Reconcile(ctx, ReconcileOptions{AutoFix: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Drift{{Kind: DriftKindOrphanCharge, LCOrganizationID: lcoid, ChargeID: "orphan"}}, report.Drifts)
+		assertExpectations(t)
+	})
+
+	t.Run("charge could not be checked", func(t *testing.T) {
+		em.On("ToEvent", ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{AutoFix: false}).Return(events.Event{}).Once()
+		sm.On("GetCharges", ctx).Return([]Charge{{ID: "id", LCOrganizationID: lcoid}}, nil).Once()
+		sm.On("GetSubscriptions", ctx).Return([]Subscription{}, nil).Once()
+		am.On("GetRecurrentCharge", ctx, "id").Return(nil, assert.AnError).Once()
+		em.On("ToError", ctx, mock.MatchedBy(func(params events.ToErrorParams) bool {
+			return params.Event.Type == events.EventTypeError && params.Err.Error() == "failed to check 1 charges"
+		})).Return(assert.AnError).Once()
+
+		report, err := s.Reconcile(ctx, ReconcileOptions{})
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, []string{fmt.Sprintf("charge id: %v", assert.AnError)}, report.Errors)
+		assertExpectations(t)
+	})
+
+	t.Run("error getting charges", func(t *testing.T) {
+		event := events.Event{}
+		em.On("ToEvent", ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{AutoFix: false}).Return(event).Once()
+		sm.On("GetCharges", ctx).Return(nil, assert.AnError).Once()
+		event.Type = events.EventTypeError
+		em.On("ToError", ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to get charges: %w", assert.AnError),
+		}).Return(assert.AnError).Once()
+
+		report, err := s.Reconcile(ctx, ReconcileOptions{})
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, report)
+		assertExpectations(t)
+	})
+}
@@ -12,24 +12,44 @@ var (
 	ErrSubscriptionNotFound = errors.New("subscription not found")
 )
 
+// Storage methods taking an applicationID only see the charges, subscriptions and trials of that app, see Application.
 type Storage interface {
 	CreateCharge(ctx context.Context, ic Charge) error
-	GetCharge(ctx context.Context, id string) (*Charge, error)
+	GetCharge(ctx context.Context, applicationID, id string) (*Charge, error)
 	UpdateChargePayload(ctx context.Context, id string, payload json.RawMessage) error
-	DeleteCharge(ctx context.Context, id string) error
-	GetChargesByOrganizationID(ctx context.Context, lcID string) ([]Charge, error)
-	GetChargesByStatuses(ctx context.Context, statuses []string) ([]Charge, error)
+	DeleteCharge(ctx context.Context, applicationID, id string) error
+	GetChargesByOrganizationID(ctx context.Context, applicationID, lcID string) ([]Charge, error)
+	// GetCharges returns every charge that is not deleted.
+	GetCharges(ctx context.Context) ([]Charge, error)
+	// GetChargesByStatuses skips charges that are still backing off after their last sync error.
+	GetChargesByStatuses(ctx context.Context, statuses []string, backoff BackoffPolicy) ([]Charge, error)
 	IncrementChargeSyncErrorCount(ctx context.Context, chargeID string) error
+	// ResetChargeSyncErrorCount also releases the charge from quarantine.
+	ResetChargeSyncErrorCount(ctx context.Context, chargeID string) error
+	// GetChargesWithHighErrorCount skips quarantined charges.
 	GetChargesWithHighErrorCount(ctx context.Context, threshold int) ([]Charge, error)
+	QuarantineCharge(ctx context.Context, chargeID string) error
+	GetQuarantinedCharges(ctx context.Context) ([]Charge, error)
+	// WithChargeLock runs fn while holding an exclusive lock on the charge, so a webhook and a sync job
+	// cannot interleave reading and rewriting the same charge. Nested calls for the same charge reuse the held lock.
+	// The calls fn makes on the storage with its ctx run on the connection holding the lock, they must not be
+	// made concurrently. Implementations may bound how long the lock is held with a deadline on fn's ctx.
+	WithChargeLock(ctx context.Context, id string, fn func(ctx context.Context) error) error
 
 	CreateSubscription(ctx context.Context, subscription Subscription) error
-	GetSubscriptionsByOrganizationID(ctx context.Context, lcID string) ([]Subscription, error)
-	DeleteSubscriptionByChargeID(ctx context.Context, lcID string, id string) error
-	DeleteSubscription(ctx context.Context, lcID, subID string) error
+	GetSubscriptionsByOrganizationID(ctx context.Context, applicationID, lcID string) ([]Subscription, error)
+	// GetSubscriptions returns every active subscription with its charge, including charges that are deleted.
+	GetSubscriptions(ctx context.Context) ([]Subscription, error)
+	DeleteSubscriptionByChargeID(ctx context.Context, applicationID, lcID string, id string) error
+	DeleteSubscription(ctx context.Context, applicationID, lcID, subID string) error
 
 	CreateEvent(ctx context.Context, event events.Event) error
 
 	// Trial management
-	RecordTrialUsage(ctx context.Context, lcOrganizationID string) error
-	HasUsedTrial(ctx context.Context, lcOrganizationID string) (bool, error)
+	RecordTrialUsage(ctx context.Context, applicationID, lcOrganizationID string) error
+	HasUsedTrial(ctx context.Context, applicationID, lcOrganizationID string) (bool, error)
+
+	// BackfillApplicationID tags the charges, subscriptions and trials stored without an app with applicationID and
+	// returns how many were tagged. A trial the organization already has for applicationID is left untagged.
+	BackfillApplicationID(ctx context.Context, applicationID string) (int64, error)
 }
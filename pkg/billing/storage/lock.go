@@ -0,0 +1,8 @@
+package storage
+
+// chargeLockTimeout is how many seconds MySQL waits for a charge lock held by another session.
+const chargeLockTimeout = 30
+
+func chargeLockName(id string) string {
+	return "charge:" + id
+}
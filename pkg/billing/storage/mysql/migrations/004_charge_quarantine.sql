@@ -0,0 +1,2 @@
+ALTER TABLE charges ADD COLUMN quarantined_at DATETIME;
+CREATE INDEX idx_charges_quarantined_at ON charges(quarantined_at);
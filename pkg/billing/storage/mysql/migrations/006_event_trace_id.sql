@@ -0,0 +1,2 @@
+ALTER TABLE billing_events ADD COLUMN trace_id VARCHAR(32) NOT NULL DEFAULT '';
+CREATE INDEX idx_billing_events_trace_id ON billing_events(trace_id);
@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/livechat-integrations/go-billing-sdk/v2/internal/lock"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/billing"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
 )
@@ -33,6 +35,8 @@ type SQLCharge struct {
 	DeletedAt        *time.Time `json:"deleted_at" db:"deleted_at"`
 	SyncErrorCount   int        `json:"sync_error_count" db:"sync_error_count"`
 	LastSyncErrorAt  *time.Time `json:"last_sync_error_at" db:"last_sync_error_at"`
+	QuarantinedAt    *time.Time `json:"quarantined_at" db:"quarantined_at"`
+	ApplicationID    string     `json:"application_id" db:"application_id"`
 }
 
 type SQLSubscription struct {
@@ -46,10 +50,27 @@ type SQLSubscription struct {
 	Payload          string     `json:"payload" db:"payload"`
 	ChargeCreatedAt  time.Time  `json:"charge_created_at" db:"charge_created_at"`
 	ChargeDeletedAt  *time.Time `json:"charge_deleted_at" db:"charge_deleted_at"`
+	ApplicationID    string     `json:"application_id" db:"application_id"`
+}
+
+type SQLEvent struct {
+	ID               string            `json:"id" db:"id"`
+	LcOrganizationID string            `json:"lc_organization_id" db:"lc_organization_id"`
+	Type             string            `json:"type" db:"type"`
+	Action           string            `json:"action" db:"action"`
+	Payload          string            `json:"payload" db:"payload"`
+	Error            stdsql.NullString `json:"error" db:"error"`
+	TraceID          string            `json:"trace_id" db:"trace_id"`
+	CorrelationID    string            `json:"correlation_id" db:"correlation_id"`
+	ParentID         string            `json:"parent_id" db:"parent_id"`
+	CreatedAt        time.Time         `json:"created_at" db:"created_at"`
 }
 
 // Make sure its Storage implementation
 var _ billing.Storage = (*SQLClient)(nil)
+var _ events.Storage = (*SQLClient)(nil)
+var _ events.Reader = (*SQLClient)(nil)
+var _ events.RetentionStorage = (*SQLClient)(nil)
 
 type SQLClient struct {
 	db    *sqlx.DB
@@ -70,7 +91,7 @@ func (c *SQLClient) CreateCharge(ctx context.Context, ch billing.Charge) error {
 		return err
 	}
 
-	res, err := c.db.ExecContext(ctx, "INSERT INTO charges(id, type, payload, lc_organization_id, created_at) VALUES (?, ?, ?, ?, ?)", ch.ID, string(ch.Type), rawPayload, ch.LCOrganizationID, c.clock.Now())
+	res, err := c.conn(ctx).ExecContext(ctx, "INSERT INTO charges(id, type, payload, lc_organization_id, application_id, created_at) VALUES (?, ?, ?, ?, ?, ?)", ch.ID, string(ch.Type), rawPayload, ch.LCOrganizationID, ch.ApplicationID, c.clock.Now())
 	if err != nil {
 		return fmt.Errorf("couldn't add new charge: %w", err)
 	}
@@ -82,9 +103,9 @@ func (c *SQLClient) CreateCharge(ctx context.Context, ch billing.Charge) error {
 	return nil
 }
 
-func (c *SQLClient) GetCharge(ctx context.Context, id string) (*billing.Charge, error) {
+func (c *SQLClient) GetCharge(ctx context.Context, applicationID, id string) (*billing.Charge, error) {
 	var ch SQLCharge
-	if err := c.db.GetContext(ctx, &ch, "SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE id = ? AND deleted_at IS NULL", id); err != nil {
+	if err := c.conn(ctx).GetContext(ctx, &ch, "SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE application_id = ? AND id = ? AND deleted_at IS NULL", applicationID, id); err != nil {
 		if errors.Is(err, stdsql.ErrNoRows) {
 			return nil, billing.ErrChargeNotFound
 		}
@@ -94,7 +115,7 @@ func (c *SQLClient) GetCharge(ctx context.Context, id string) (*billing.Charge,
 }
 
 func (c *SQLClient) UpdateChargePayload(ctx context.Context, id string, payload json.RawMessage) error {
-	res, err := c.db.ExecContext(ctx, "UPDATE charges SET payload = ? WHERE id = ? AND deleted_at IS NULL", payload, id)
+	res, err := c.conn(ctx).ExecContext(ctx, "UPDATE charges SET payload = ? WHERE id = ? AND deleted_at IS NULL", payload, id)
 	if err != nil {
 		return fmt.Errorf("couldn't update charge: %w", err)
 	}
@@ -105,8 +126,8 @@ func (c *SQLClient) UpdateChargePayload(ctx context.Context, id string, payload
 	return nil
 }
 
-func (c *SQLClient) DeleteCharge(ctx context.Context, id string) error {
-	res, err := c.db.ExecContext(ctx, "UPDATE charges SET deleted_at = ? WHERE id = ?", c.clock.Now(), id)
+func (c *SQLClient) DeleteCharge(ctx context.Context, applicationID, id string) error {
+	res, err := c.conn(ctx).ExecContext(ctx, "UPDATE charges SET deleted_at = ? WHERE application_id = ? AND id = ?", c.clock.Now(), applicationID, id)
 	if err != nil {
 		return fmt.Errorf("couldn't delete charge: %w", err)
 	}
@@ -119,7 +140,7 @@ func (c *SQLClient) DeleteCharge(ctx context.Context, id string) error {
 }
 
 func (c *SQLClient) CreateSubscription(ctx context.Context, subscription billing.Subscription) error {
-	res, err := c.db.ExecContext(ctx, "INSERT INTO subscriptions(id, lc_organization_id, plan_name, charge_id, created_at) VALUES (?, ?, ?, ?, ?)", subscription.ID, subscription.LCOrganizationID, subscription.PlanName, subscription.Charge.ID, c.clock.Now())
+	res, err := c.conn(ctx).ExecContext(ctx, "INSERT INTO subscriptions(id, lc_organization_id, application_id, plan_name, charge_id, created_at) VALUES (?, ?, ?, ?, ?, ?)", subscription.ID, subscription.LCOrganizationID, subscription.ApplicationID, subscription.PlanName, subscription.Charge.ID, c.clock.Now())
 	if err != nil {
 		return fmt.Errorf("couldn't add new subscription: %w", err)
 	}
@@ -131,10 +152,26 @@ func (c *SQLClient) CreateSubscription(ctx context.Context, subscription billing
 	return nil
 }
 
-func (c *SQLClient) GetSubscriptionsByOrganizationID(ctx context.Context, lcID string) ([]billing.Subscription, error) {
+func (c *SQLClient) GetSubscriptionsByOrganizationID(ctx context.Context, applicationID, lcID string) ([]billing.Subscription, error) {
+	var subs []*SQLSubscription
+	query := "SELECT s.id, s.lc_organization_id, s.plan_name, s.charge_id, s.created_at, s.deleted_at, s.application_id, c.type, c.payload, c.created_at AS charge_created_at, c.deleted_at AS charge_deleted_at FROM active_subscriptions s LEFT JOIN charges c ON s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id WHERE s.application_id = ? AND s.lc_organization_id = ?"
+	if err := c.conn(ctx).SelectContext(ctx, &subs, query, applicationID, lcID); err != nil {
+		return nil, fmt.Errorf("couldn't select subscriptions from DB: %w", err)
+	}
+	if len(subs) == 0 {
+		return []billing.Subscription{}, nil
+	}
+	var subscriptions []billing.Subscription
+	for _, sub := range subs {
+		subscriptions = append(subscriptions, *ToBillingSubscription(sub))
+	}
+	return subscriptions, nil
+}
+
+func (c *SQLClient) GetSubscriptions(ctx context.Context) ([]billing.Subscription, error) {
 	var subs []*SQLSubscription
-	query := "SELECT s.id, s.lc_organization_id, s.plan_name, s.charge_id, s.created_at, s.deleted_at, c.type, c.payload, c.created_at AS charge_created_at, c.deleted_at AS charge_deleted_at FROM active_subscriptions s LEFT JOIN charges c ON s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id WHERE s.lc_organization_id = ?"
-	if err := c.db.SelectContext(ctx, &subs, query, lcID); err != nil {
+	query := "SELECT s.id, s.lc_organization_id, s.plan_name, s.charge_id, s.created_at, s.deleted_at, s.application_id, c.type, c.payload, c.created_at AS charge_created_at, c.deleted_at AS charge_deleted_at FROM active_subscriptions s LEFT JOIN charges c ON s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id ORDER BY s.created_at"
+	if err := c.conn(ctx).SelectContext(ctx, &subs, query); err != nil {
 		return nil, fmt.Errorf("couldn't select subscriptions from DB: %w", err)
 	}
 	if len(subs) == 0 {
@@ -147,8 +184,8 @@ func (c *SQLClient) GetSubscriptionsByOrganizationID(ctx context.Context, lcID s
 	return subscriptions, nil
 }
 
-func (c *SQLClient) DeleteSubscriptionByChargeID(ctx context.Context, lcID string, id string) error {
-	res, err := c.db.ExecContext(ctx, "UPDATE subscriptions SET deleted_at = ? WHERE charge_id = ? AND lc_organization_id = ?", c.clock.Now(), id, lcID)
+func (c *SQLClient) DeleteSubscriptionByChargeID(ctx context.Context, applicationID, lcID string, id string) error {
+	res, err := c.conn(ctx).ExecContext(ctx, "UPDATE subscriptions SET deleted_at = ? WHERE charge_id = ? AND lc_organization_id = ? AND application_id = ?", c.clock.Now(), id, lcID, applicationID)
 	if err != nil {
 		return fmt.Errorf("couldn't delete subsctiption: %w", err)
 	}
@@ -160,9 +197,24 @@ func (c *SQLClient) DeleteSubscriptionByChargeID(ctx context.Context, lcID strin
 	return nil
 }
 
-func (c *SQLClient) GetChargesByOrganizationID(ctx context.Context, lcID string) ([]billing.Charge, error) {
+func (c *SQLClient) GetChargesByOrganizationID(ctx context.Context, applicationID, lcID string) ([]billing.Charge, error) {
+	var chs []*SQLCharge
+	if err := c.conn(ctx).SelectContext(ctx, &chs, "SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE application_id = ? AND lc_organization_id = ?", applicationID, lcID); err != nil {
+		return nil, fmt.Errorf("couldn't select charges from DB: %w", err)
+	}
+	if len(chs) == 0 {
+		return []billing.Charge{}, nil
+	}
+	var charges []billing.Charge
+	for _, ch := range chs {
+		charges = append(charges, *ToBillingCharge(ch))
+	}
+	return charges, nil
+}
+
+func (c *SQLClient) GetCharges(ctx context.Context) ([]billing.Charge, error) {
 	var chs []*SQLCharge
-	if err := c.db.SelectContext(ctx, &chs, "SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE lc_organization_id = ?", lcID); err != nil {
+	if err := c.conn(ctx).SelectContext(ctx, &chs, "SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE deleted_at IS NULL ORDER BY created_at"); err != nil {
 		return nil, fmt.Errorf("couldn't select charges from DB: %w", err)
 	}
 	if len(chs) == 0 {
@@ -181,7 +233,7 @@ func (c *SQLClient) CreateEvent(ctx context.Context, e events.Event) error {
 		return err
 	}
 
-	res, err := c.db.ExecContext(ctx, "INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)", e.ID, e.LCOrganizationID, string(e.Type), string(e.Action), rawPayload, e.Error, c.clock.Now())
+	res, err := c.conn(ctx).ExecContext(ctx, "INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", e.ID, e.LCOrganizationID, string(e.Type), string(e.Action), rawPayload, e.Error, e.TraceID, e.CorrelationID, e.ParentID, c.clock.Now())
 	if err != nil {
 		return fmt.Errorf("couldn't add new billing event: %w", err)
 	}
@@ -193,6 +245,126 @@ func (c *SQLClient) CreateEvent(ctx context.Context, e events.Event) error {
 	return nil
 }
 
+// ListEvents returns a page of billing_events, newest first.
+func (c *SQLClient) ListEvents(ctx context.Context, filter events.ListEventsFilter) (*events.EventsPage, error) {
+	cursor, err := events.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if filter.OrganizationID != "" {
+		conditions = append(conditions, "lc_organization_id = ?")
+		args = append(args, filter.OrganizationID)
+	}
+	if filter.CorrelationID != "" {
+		conditions = append(conditions, "correlation_id = ?")
+		args = append(args, filter.CorrelationID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, string(filter.Action))
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, string(filter.Type))
+	}
+	if filter.ErrorsOnly {
+		conditions = append(conditions, "COALESCE(error, '') <> ''")
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To)
+	}
+	if cursor != nil {
+		conditions = append(conditions, "(created_at, id, action) < (?, ?, ?)")
+		args = append(args, cursor.CreatedAt, cursor.ID, string(cursor.Action))
+	}
+
+	query := "SELECT id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at FROM billing_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC, action DESC LIMIT ?"
+	limit := filter.PageLimit()
+	args = append(args, limit+1)
+
+	var rows []*SQLEvent
+	if err = c.conn(ctx).SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("couldn't select billing events from DB: %w", err)
+	}
+
+	evs := make([]events.Event, 0, len(rows))
+	for _, r := range rows {
+		evs = append(evs, *ToEvent(r))
+	}
+	return events.NewEventsPage(evs, limit), nil
+}
+
+// ListExpiredEvents returns up to params.Limit billing_events matching params, oldest first.
+func (c *SQLClient) ListExpiredEvents(ctx context.Context, params events.ExpiredEventsParams) ([]events.Event, error) {
+	conditions := []string{"created_at < ?"}
+	args := []interface{}{params.CreatedBefore}
+	if params.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, string(params.Type))
+	}
+	if len(params.Actions) > 0 {
+		conditions = append(conditions, "action IN (?)")
+		args = append(args, params.Actions)
+	}
+	if len(params.ExcludeActions) > 0 {
+		conditions = append(conditions, "action NOT IN (?)")
+		args = append(args, params.ExcludeActions)
+	}
+	args = append(args, params.Limit)
+
+	query, args, err := sqlx.In("SELECT id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at FROM billing_events WHERE "+strings.Join(conditions, " AND ")+" ORDER BY created_at LIMIT ?", args...)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't build query: %w", err)
+	}
+
+	var rows []*SQLEvent
+	if err = c.conn(ctx).SelectContext(ctx, &rows, c.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("couldn't select expired billing events from DB: %w", err)
+	}
+
+	evs := make([]events.Event, 0, len(rows))
+	for _, r := range rows {
+		evs = append(evs, *ToEvent(r))
+	}
+	return evs, nil
+}
+
+// DeleteEvents deletes billing_events by their ID and action.
+func (c *SQLClient) DeleteEvents(ctx context.Context, evs []events.Event) (int64, error) {
+	if len(evs) == 0 {
+		return 0, nil
+	}
+
+	keys := make([]string, 0, len(evs))
+	args := make([]interface{}, 0, 2*len(evs))
+	for _, e := range evs {
+		keys = append(keys, "(?, ?)")
+		args = append(args, e.ID, string(e.Action))
+	}
+
+	res, err := c.conn(ctx).ExecContext(ctx, "DELETE FROM billing_events WHERE (id, action) IN ("+strings.Join(keys, ", ")+")", args...)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't delete billing events: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+
+	return affected, nil
+}
+
 func ToBillingSubscription(r *SQLSubscription) *billing.Subscription {
 	var canceledAt *time.Time
 	if r.DeletedAt != nil {
@@ -202,6 +374,7 @@ func ToBillingSubscription(r *SQLSubscription) *billing.Subscription {
 	subscription := &billing.Subscription{
 		ID:               r.ID,
 		LCOrganizationID: r.LcOrganizationID,
+		ApplicationID:    r.ApplicationID,
 		PlanName:         r.PlanName,
 		CreatedAt:        r.CreatedAt,
 		DeletedAt:        canceledAt,
@@ -219,6 +392,7 @@ func ToBillingSubscription(r *SQLSubscription) *billing.Subscription {
 	subscription.Charge = &billing.Charge{
 		ID:               r.ChargeID,
 		LCOrganizationID: r.LcOrganizationID,
+		ApplicationID:    r.ApplicationID,
 		Type:             billing.ChargeType(r.Type),
 		Payload:          json.RawMessage(r.Payload),
 		CreatedAt:        r.ChargeCreatedAt,
@@ -237,28 +411,40 @@ func ToBillingCharge(c *SQLCharge) *billing.Charge {
 	return &billing.Charge{
 		ID:               c.ID,
 		LCOrganizationID: c.LcOrganizationID,
+		ApplicationID:    c.ApplicationID,
 		Type:             billing.ChargeType(c.Type),
 		Payload:          json.RawMessage(c.Payload),
 		CreatedAt:        c.CreatedAt,
 		CanceledAt:       canceledAt,
 		SyncErrorCount:   c.SyncErrorCount,
 		LastSyncErrorAt:  c.LastSyncErrorAt,
+		QuarantinedAt:    c.QuarantinedAt,
 	}
 }
 
-// GetChargesByStatuses returns charges with JSON payload status in the provided list.
-func (c *SQLClient) GetChargesByStatuses(ctx context.Context, statuses []string) ([]billing.Charge, error) {
+// GetChargesByStatuses returns charges with JSON payload status in the provided list
+// that are not backing off after their last sync error.
+func (c *SQLClient) GetChargesByStatuses(ctx context.Context, statuses []string, backoff billing.BackoffPolicy) ([]billing.Charge, error) {
 	if len(statuses) == 0 {
 		return []billing.Charge{}, nil
 	}
-	query, args, err := sqlx.In("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE JSON_UNQUOTE(JSON_EXTRACT(payload, '$.status')) IN (?) AND deleted_at IS NULL", statuses)
+	initial, multiplier, maxDelay, maxExponent := backoff.SQLArgs()
+	query, args, err := sqlx.In(`SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id
+		FROM charges
+		WHERE JSON_UNQUOTE(JSON_EXTRACT(payload, '$.status')) IN (?)
+		AND deleted_at IS NULL
+		AND (
+			sync_error_count = 0
+			OR last_sync_error_at IS NULL
+			OR DATE_ADD(last_sync_error_at, INTERVAL ROUND(LEAST(? * POW(?, LEAST(sync_error_count - 1, ?)), ?) * 1000000) MICROSECOND) <= ?
+		)`, statuses, initial, multiplier, maxExponent, maxDelay, c.clock.Now())
 	if err != nil {
 		return nil, fmt.Errorf("couldn't build query: %w", err)
 	}
 	// Ensure placeholders match driver; for mysql it's already '?'
 	query = c.db.Rebind(query)
 	var rows []*SQLCharge
-	if err := c.db.SelectContext(ctx, &rows, query, args...); err != nil {
+	if err := c.conn(ctx).SelectContext(ctx, &rows, query, args...); err != nil {
 		return nil, fmt.Errorf("couldn't select charges from DB: %w", err)
 	}
 	if len(rows) == 0 {
@@ -271,9 +457,9 @@ func (c *SQLClient) GetChargesByStatuses(ctx context.Context, statuses []string)
 	return charges, nil
 }
 
-// DeleteSubscription marks subscription as deleted by its id and organization id.
-func (c *SQLClient) DeleteSubscription(ctx context.Context, lcID, subID string) error {
-	res, err := c.db.ExecContext(ctx, "UPDATE subscriptions SET deleted_at = ? WHERE id = ? AND lc_organization_id = ?", c.clock.Now(), subID, lcID)
+// DeleteSubscription marks subscription as deleted by its id, organization id and application id.
+func (c *SQLClient) DeleteSubscription(ctx context.Context, applicationID, lcID, subID string) error {
+	res, err := c.conn(ctx).ExecContext(ctx, "UPDATE subscriptions SET deleted_at = ? WHERE id = ? AND lc_organization_id = ? AND application_id = ?", c.clock.Now(), subID, lcID, applicationID)
 	if err != nil {
 		return fmt.Errorf("couldn't delete subsctiption: %w", err)
 	}
@@ -284,25 +470,49 @@ func (c *SQLClient) DeleteSubscription(ctx context.Context, lcID, subID string)
 	return nil
 }
 
-// RecordTrialUsage records that an organization has used their trial
-func (c *SQLClient) RecordTrialUsage(ctx context.Context, lcOrganizationID string) error {
-	_, err := c.db.ExecContext(ctx, `
-		INSERT IGNORE INTO trial_usage (lc_organization_id)
-		VALUES (?)`,
-		lcOrganizationID)
+// RecordTrialUsage records that an organization has used their trial of the application
+func (c *SQLClient) RecordTrialUsage(ctx context.Context, applicationID, lcOrganizationID string) error {
+	_, err := c.conn(ctx).ExecContext(ctx, `
+		INSERT IGNORE INTO trial_usage (application_id, lc_organization_id)
+		VALUES (?, ?)`,
+		applicationID, lcOrganizationID)
 	if err != nil {
 		return fmt.Errorf("couldn't record trial usage: %w", err)
 	}
 	return nil
 }
 
-// HasUsedTrial checks if an organization has already used their trial
-func (c *SQLClient) HasUsedTrial(ctx context.Context, lcOrganizationID string) (bool, error) {
+// BackfillApplicationID tags the rows table by table, a failed backfill can be run again. UPDATE IGNORE skips the
+// trials the organization already has for applicationID.
+func (c *SQLClient) BackfillApplicationID(ctx context.Context, applicationID string) (int64, error) {
+	var total int64
+	for _, query := range []string{
+		"UPDATE charges SET application_id = ? WHERE application_id = ''",
+		"UPDATE subscriptions SET application_id = ? WHERE application_id = ''",
+		"UPDATE IGNORE trial_usage SET application_id = ? WHERE application_id = ''",
+	} {
+		res, err := c.conn(ctx).ExecContext(ctx, query, applicationID)
+		if err != nil {
+			return total, fmt.Errorf("couldn't backfill application id: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("couldn't backfill application id: %w", err)
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+// HasUsedTrial checks if an organization has already used their trial of the application
+func (c *SQLClient) HasUsedTrial(ctx context.Context, applicationID, lcOrganizationID string) (bool, error) {
 	var count int
-	err := c.db.GetContext(ctx, &count, `
+	err := c.conn(ctx).GetContext(ctx, &count, `
 		SELECT COUNT(*) FROM trial_usage
-		WHERE lc_organization_id = ?`,
-		lcOrganizationID)
+		WHERE application_id = ?
+		AND lc_organization_id = ?`,
+		applicationID, lcOrganizationID)
 	if err != nil {
 		return false, fmt.Errorf("couldn't check trial usage: %w", err)
 	}
@@ -310,7 +520,7 @@ func (c *SQLClient) HasUsedTrial(ctx context.Context, lcOrganizationID string) (
 }
 
 func (c *SQLClient) IncrementChargeSyncErrorCount(ctx context.Context, chargeID string) error {
-	_, err := c.db.ExecContext(ctx, `
+	_, err := c.conn(ctx).ExecContext(ctx, `
 		UPDATE charges
 		SET sync_error_count = sync_error_count + 1,
 		    last_sync_error_at = NOW()
@@ -323,13 +533,28 @@ func (c *SQLClient) IncrementChargeSyncErrorCount(ctx context.Context, chargeID
 	return nil
 }
 
+func (c *SQLClient) ResetChargeSyncErrorCount(ctx context.Context, chargeID string) error {
+	_, err := c.conn(ctx).ExecContext(ctx, `
+		UPDATE charges
+		SET sync_error_count = 0,
+		    last_sync_error_at = NULL,
+		    quarantined_at = NULL
+		WHERE id = ?`,
+		chargeID)
+	if err != nil {
+		return fmt.Errorf("couldn't reset charge sync error count: %w", err)
+	}
+	return nil
+}
+
 func (c *SQLClient) GetChargesWithHighErrorCount(ctx context.Context, threshold int) ([]billing.Charge, error) {
 	var chs []*SQLCharge
-	if err := c.db.SelectContext(ctx, &chs, `
-		SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at
+	if err := c.conn(ctx).SelectContext(ctx, &chs, `
+		SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id
 		FROM charges
 		WHERE sync_error_count >= ?
-		AND deleted_at IS NULL`,
+		AND deleted_at IS NULL
+		AND quarantined_at IS NULL`,
 		threshold); err != nil {
 		return nil, fmt.Errorf("couldn't select charges with high error count: %w", err)
 	}
@@ -342,3 +567,103 @@ func (c *SQLClient) GetChargesWithHighErrorCount(ctx context.Context, threshold
 	}
 	return charges, nil
 }
+
+func (c *SQLClient) QuarantineCharge(ctx context.Context, chargeID string) error {
+	_, err := c.conn(ctx).ExecContext(ctx, `
+		UPDATE charges
+		SET quarantined_at = ?
+		WHERE id = ?
+		AND deleted_at IS NULL
+		AND quarantined_at IS NULL`,
+		c.clock.Now(), chargeID)
+	if err != nil {
+		return fmt.Errorf("couldn't quarantine charge: %w", err)
+	}
+	return nil
+}
+
+func (c *SQLClient) GetQuarantinedCharges(ctx context.Context) ([]billing.Charge, error) {
+	var chs []*SQLCharge
+	if err := c.conn(ctx).SelectContext(ctx, &chs, `
+		SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id
+		FROM charges
+		WHERE quarantined_at IS NOT NULL
+		AND deleted_at IS NULL
+		ORDER BY quarantined_at`); err != nil {
+		return nil, fmt.Errorf("couldn't select quarantined charges: %w", err)
+	}
+	if len(chs) == 0 {
+		return []billing.Charge{}, nil
+	}
+	var charges []billing.Charge
+	for _, ch := range chs {
+		charges = append(charges, *ToBillingCharge(ch))
+	}
+	return charges, nil
+}
+
+// WithChargeLock holds a named MySQL lock on a dedicated connection while fn runs. The queries fn makes on this
+// storage run on that connection, so a locked charge never needs a second connection from the pool. The connection
+// is held for at most lock.HoldTimeout.
+func (c *SQLClient) WithChargeLock(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	if lock.Held(ctx, c, chargeLockName(id)) {
+		return fn(ctx)
+	}
+
+	conn, ok := lock.Conn[*sqlx.Conn](ctx, c)
+	if !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, lock.HoldTimeout)
+		defer cancel()
+
+		var err error
+		if conn, err = c.db.Connx(ctx); err != nil {
+			return fmt.Errorf("couldn't get connection for charge lock: %w", err)
+		}
+		defer conn.Close()
+	}
+
+	var acquired stdsql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", chargeLockName(id), chargeLockTimeout).Scan(&acquired); err != nil {
+		return fmt.Errorf("couldn't lock charge: %w", err)
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return fmt.Errorf("couldn't lock charge %s: timeout", id)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "DO RELEASE_LOCK(?)", chargeLockName(id))
+	}()
+
+	return fn(lock.With(ctx, c, conn, chargeLockName(id)))
+}
+
+// sqlxConn is implemented by both the pool and a single connection.
+type sqlxConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (stdsql.Result, error)
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// conn returns the connection holding the charge locks of ctx, or the pool outside of WithChargeLock.
+func (c *SQLClient) conn(ctx context.Context) sqlxConn {
+	if conn, ok := lock.Conn[*sqlx.Conn](ctx, c); ok {
+		return conn
+	}
+
+	return c.db
+}
+
+func ToEvent(e *SQLEvent) *events.Event {
+	return &events.Event{
+		ID:               e.ID,
+		CorrelationID:    e.CorrelationID,
+		ParentID:         e.ParentID,
+		LCOrganizationID: e.LcOrganizationID,
+		Type:             events.EventType(e.Type),
+		Action:           events.EventAction(e.Action),
+		Payload:          json.RawMessage(e.Payload),
+		Error:            e.Error.String,
+		TraceID:          e.TraceID,
+		CreatedAt:        e.CreatedAt,
+	}
+}
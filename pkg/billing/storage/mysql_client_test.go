@@ -13,9 +13,9 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
-	"github.com/livechat-integrations/go-billing-sdk/v2/internal/livechat"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/billing"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
 )
 
 var now, _ = time.Parse("2006-01-02 15:04:05", "2025-04-02 15:04:05")
@@ -44,15 +44,15 @@ func TestNewSQLClient_CreateCharge(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		bch := map[string]interface{}{"lorem": "ipsum"}
 		jsonPayload, _ := json.Marshal(bch)
-		charge := billing.Charge{ID: "id1", LCOrganizationID: "lcOrganizationID", Type: billing.ChargeTypeRecurring, Status: livechat.RecurrentChargeStatusPending, Payload: jsonPayload}
+		charge := billing.Charge{ID: "id1", LCOrganizationID: "lcOrganizationID", ApplicationID: "app1", Type: billing.ChargeTypeRecurring, Status: livechat.RecurrentChargeStatusPending, Payload: jsonPayload}
 		cm := new(clockMock)
 		db, mock, err := sqlmock.New()
 		assert.NoError(t, err)
 		client := NewSQLClient(db, cm)
 		rawPayload, _ := json.Marshal(charge.Payload)
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO charges(id, type, payload, lc_organization_id, created_at) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(charge.ID, string(charge.Type), rawPayload, charge.LCOrganizationID, now).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO charges(id, type, payload, lc_organization_id, application_id, created_at) VALUES (?, ?, ?, ?, ?, ?)")).
+			WithArgs(charge.ID, string(charge.Type), rawPayload, charge.LCOrganizationID, charge.ApplicationID, now).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectClose()
 		err = client.CreateCharge(context.Background(), charge)
@@ -65,15 +65,15 @@ func TestNewSQLClient_CreateCharge(t *testing.T) {
 	t.Run("no rows affected", func(t *testing.T) {
 		bch := map[string]interface{}{"lorem": "ipsum"}
 		jsonPayload, _ := json.Marshal(bch)
-		charge := billing.Charge{ID: "id1", LCOrganizationID: "lcOrganizationID", Type: billing.ChargeTypeRecurring, Payload: jsonPayload}
+		charge := billing.Charge{ID: "id1", LCOrganizationID: "lcOrganizationID", ApplicationID: "app1", Type: billing.ChargeTypeRecurring, Payload: jsonPayload}
 		cm := new(clockMock)
 		db, mock, err := sqlmock.New()
 		assert.NoError(t, err)
 		client := NewSQLClient(db, cm)
 		rawPayload, _ := json.Marshal(charge.Payload)
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO charges(id, type, payload, lc_organization_id, created_at) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(charge.ID, string(charge.Type), rawPayload, charge.LCOrganizationID, now).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO charges(id, type, payload, lc_organization_id, application_id, created_at) VALUES (?, ?, ?, ?, ?, ?)")).
+			WithArgs(charge.ID, string(charge.Type), rawPayload, charge.LCOrganizationID, charge.ApplicationID, now).
 			WillReturnResult(sqlmock.NewResult(1, 0))
 		mock.ExpectClose()
 		err = client.CreateCharge(context.Background(), charge)
@@ -86,15 +86,15 @@ func TestNewSQLClient_CreateCharge(t *testing.T) {
 	t.Run("error", func(t *testing.T) {
 		bch := map[string]interface{}{"lorem": "ipsum"}
 		jsonPayload, _ := json.Marshal(bch)
-		charge := billing.Charge{ID: "id1", LCOrganizationID: "lcOrganizationID", Type: billing.ChargeTypeRecurring, Payload: jsonPayload}
+		charge := billing.Charge{ID: "id1", LCOrganizationID: "lcOrganizationID", ApplicationID: "app1", Type: billing.ChargeTypeRecurring, Payload: jsonPayload}
 		cm := new(clockMock)
 		db, mock, err := sqlmock.New()
 		assert.NoError(t, err)
 		client := NewSQLClient(db, cm)
 		rawPayload, _ := json.Marshal(charge.Payload)
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO charges(id, type, payload, lc_organization_id, created_at) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(charge.ID, string(charge.Type), rawPayload, charge.LCOrganizationID, now).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO charges(id, type, payload, lc_organization_id, application_id, created_at) VALUES (?, ?, ?, ?, ?, ?)")).
+			WithArgs(charge.ID, string(charge.Type), rawPayload, charge.LCOrganizationID, charge.ApplicationID, now).
 			WillReturnError(assert.AnError)
 		mock.ExpectClose()
 		err = client.CreateCharge(context.Background(), charge)
@@ -116,12 +116,12 @@ func TestSQLClient_GetCharge(t *testing.T) {
 
 		rows := sqlmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at"}).
 			AddRow(id, "org1", string(billing.ChargeTypeRecurring), `{"foo":"bar"}`, now, nil, 0, nil)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE id = ? AND deleted_at IS NULL")).
-			WithArgs(id).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE application_id = ? AND id = ? AND deleted_at IS NULL")).
+			WithArgs("app1", id).
 			WillReturnRows(rows)
 		mock.ExpectClose()
 
-		ch, err := client.GetCharge(ctx, id)
+		ch, err := client.GetCharge(ctx, "app1", id)
 		assert.NoError(t, err)
 		assert.Equal(t, id, ch.ID)
 		assert.Equal(t, "org1", ch.LCOrganizationID)
@@ -135,11 +135,11 @@ func TestSQLClient_GetCharge(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		assert.NoError(t, err)
 		client := NewSQLClient(db, &clockMock{})
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE id = ? AND deleted_at IS NULL")).
-			WithArgs(id).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE application_id = ? AND id = ? AND deleted_at IS NULL")).
+			WithArgs("app1", id).
 			WillReturnError(stdsql.ErrNoRows)
 		mock.ExpectClose()
-		_, err = client.GetCharge(ctx, id)
+		_, err = client.GetCharge(ctx, "app1", id)
 		assert.ErrorIs(t, err, billing.ErrChargeNotFound)
 		require.NoError(t, db.Close())
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -150,10 +150,10 @@ func TestSQLClient_GetCharge(t *testing.T) {
 		assert.NoError(t, err)
 		defer db.Close()
 		client := NewSQLClient(db, &clockMock{})
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE id = ? AND deleted_at IS NULL")).
-			WithArgs(id).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE application_id = ? AND id = ? AND deleted_at IS NULL")).
+			WithArgs("app1", id).
 			WillReturnError(assert.AnError)
-		_, err = client.GetCharge(ctx, id)
+		_, err = client.GetCharge(ctx, "app1", id)
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -215,10 +215,10 @@ func TestSQLClient_DeleteCharge(t *testing.T) {
 		defer db.Close()
 		client := NewSQLClient(db, cm)
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET deleted_at = ? WHERE id = ?")).
-			WithArgs(now, id).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET deleted_at = ? WHERE application_id = ? AND id = ?")).
+			WithArgs(now, "app1", id).
 			WillReturnResult(sqlmock.NewResult(0, 1))
-		assert.NoError(t, client.DeleteCharge(ctx, id))
+		assert.NoError(t, client.DeleteCharge(ctx, "app1", id))
 		assert.NoError(t, mock.ExpectationsWereMet())
 		cm.AssertExpectations(t)
 	})
@@ -230,10 +230,10 @@ func TestSQLClient_DeleteCharge(t *testing.T) {
 		client := NewSQLClient(db, cm)
 		cm.ExpectedCalls = nil
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET deleted_at = ? WHERE id = ?")).
-			WithArgs(now, id).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET deleted_at = ? WHERE application_id = ? AND id = ?")).
+			WithArgs(now, "app1", id).
 			WillReturnResult(sqlmock.NewResult(0, 0))
-		err = client.DeleteCharge(ctx, id)
+		err = client.DeleteCharge(ctx, "app1", id)
 		assert.ErrorIs(t, err, billing.ErrChargeNotFound)
 		assert.NoError(t, mock.ExpectationsWereMet())
 		cm.AssertExpectations(t)
@@ -246,10 +246,10 @@ func TestSQLClient_DeleteCharge(t *testing.T) {
 		client := NewSQLClient(db, cm)
 		cm.ExpectedCalls = nil
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET deleted_at = ? WHERE id = ?")).
-			WithArgs(now, id).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET deleted_at = ? WHERE application_id = ? AND id = ?")).
+			WithArgs(now, "app1", id).
 			WillReturnError(assert.AnError)
-		err = client.DeleteCharge(ctx, id)
+		err = client.DeleteCharge(ctx, "app1", id)
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, mock.ExpectationsWereMet())
 		cm.AssertExpectations(t)
@@ -258,7 +258,7 @@ func TestSQLClient_DeleteCharge(t *testing.T) {
 
 func TestSQLClient_CreateSubscription(t *testing.T) {
 	ctx := context.Background()
-	sub := billing.Subscription{ID: "sub1", LCOrganizationID: "org1", PlanName: "pro", Charge: &billing.Charge{ID: "chg1"}}
+	sub := billing.Subscription{ID: "sub1", LCOrganizationID: "org1", ApplicationID: "app1", PlanName: "pro", Charge: &billing.Charge{ID: "chg1"}}
 	cm := new(clockMock)
 
 	t.Run("success", func(t *testing.T) {
@@ -267,8 +267,8 @@ func TestSQLClient_CreateSubscription(t *testing.T) {
 		defer db.Close()
 		client := NewSQLClient(db, cm)
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions(id, lc_organization_id, plan_name, charge_id, created_at) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(sub.ID, sub.LCOrganizationID, sub.PlanName, sub.Charge.ID, now).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions(id, lc_organization_id, application_id, plan_name, charge_id, created_at) VALUES (?, ?, ?, ?, ?, ?)")).
+			WithArgs(sub.ID, sub.LCOrganizationID, sub.ApplicationID, sub.PlanName, sub.Charge.ID, now).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		assert.NoError(t, client.CreateSubscription(ctx, sub))
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -282,8 +282,8 @@ func TestSQLClient_CreateSubscription(t *testing.T) {
 		client := NewSQLClient(db, cm)
 		cm.ExpectedCalls = nil
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions(id, lc_organization_id, plan_name, charge_id, created_at) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(sub.ID, sub.LCOrganizationID, sub.PlanName, sub.Charge.ID, now).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions(id, lc_organization_id, application_id, plan_name, charge_id, created_at) VALUES (?, ?, ?, ?, ?, ?)")).
+			WithArgs(sub.ID, sub.LCOrganizationID, sub.ApplicationID, sub.PlanName, sub.Charge.ID, now).
 			WillReturnResult(sqlmock.NewResult(1, 0))
 		err = client.CreateSubscription(ctx, sub)
 		assert.EqualError(t, err, "couldn't add new subscription")
@@ -298,8 +298,8 @@ func TestSQLClient_CreateSubscription(t *testing.T) {
 		client := NewSQLClient(db, cm)
 		cm.ExpectedCalls = nil
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions(id, lc_organization_id, plan_name, charge_id, created_at) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(sub.ID, sub.LCOrganizationID, sub.PlanName, sub.Charge.ID, now).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions(id, lc_organization_id, application_id, plan_name, charge_id, created_at) VALUES (?, ?, ?, ?, ?, ?)")).
+			WithArgs(sub.ID, sub.LCOrganizationID, sub.ApplicationID, sub.PlanName, sub.Charge.ID, now).
 			WillReturnError(assert.AnError)
 		err = client.CreateSubscription(ctx, sub)
 		assert.ErrorIs(t, err, assert.AnError)
@@ -311,6 +311,7 @@ func TestSQLClient_CreateSubscription(t *testing.T) {
 func TestSQLClient_GetSubscriptionsByOrganizationID(t *testing.T) {
 	ctx := context.Background()
 	lcID := "org1"
+	appID := "app1"
 
 	t.Run("success with and without charge", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
@@ -318,19 +319,21 @@ func TestSQLClient_GetSubscriptionsByOrganizationID(t *testing.T) {
 		defer db.Close()
 		client := NewSQLClient(db, &clockMock{})
 
-		cols := []string{"id", "lc_organization_id", "plan_name", "charge_id", "created_at", "deleted_at", "type", "payload", "charge_created_at", "charge_deleted_at"}
+		cols := []string{"id", "lc_organization_id", "plan_name", "charge_id", "created_at", "deleted_at", "application_id", "type", "payload", "charge_created_at", "charge_deleted_at"}
 		rows := sqlmock.NewRows(cols).
-			AddRow("sub1", lcID, "pro", "chg1", now, nil, string(billing.ChargeTypeRecurring), `{"a":1}`, now, nil).
-			AddRow("sub2", lcID, "free", "", now, nil, "", "", time.Time{}, nil)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT s.id, s.lc_organization_id, s.plan_name, s.charge_id, s.created_at, s.deleted_at, c.type, c.payload, c.created_at AS charge_created_at, c.deleted_at AS charge_deleted_at FROM active_subscriptions s LEFT JOIN charges c ON s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id WHERE s.lc_organization_id = ?")).
-			WithArgs(lcID).
+			AddRow("sub1", lcID, "pro", "chg1", now, nil, appID, string(billing.ChargeTypeRecurring), `{"a":1}`, now, nil).
+			AddRow("sub2", lcID, "free", "", now, nil, appID, "", "", time.Time{}, nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT s.id, s.lc_organization_id, s.plan_name, s.charge_id, s.created_at, s.deleted_at, s.application_id, c.type, c.payload, c.created_at AS charge_created_at, c.deleted_at AS charge_deleted_at FROM active_subscriptions s LEFT JOIN charges c ON s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id WHERE s.application_id = ? AND s.lc_organization_id = ?")).
+			WithArgs(appID, lcID).
 			WillReturnRows(rows)
 
-		subs, err := client.GetSubscriptionsByOrganizationID(ctx, lcID)
+		subs, err := client.GetSubscriptionsByOrganizationID(ctx, appID, lcID)
 		assert.NoError(t, err)
 		assert.Len(t, subs, 2)
 		assert.Equal(t, "sub1", subs[0].ID)
-		assert.NotNil(t, subs[0].Charge)
+		assert.Equal(t, appID, subs[0].ApplicationID)
+		require.NotNil(t, subs[0].Charge)
+		assert.Equal(t, appID, subs[0].Charge.ApplicationID)
 		assert.Equal(t, "sub2", subs[1].ID)
 		assert.Nil(t, subs[1].Charge)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -343,10 +346,10 @@ func TestSQLClient_GetSubscriptionsByOrganizationID(t *testing.T) {
 		client := NewSQLClient(db, &clockMock{})
 		cols := []string{"id", "lc_organization_id", "plan_name", "charge_id", "created_at", "deleted_at", "type", "payload", "charge_created_at", "charge_deleted_at"}
 		rows := sqlmock.NewRows(cols)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT s.id, s.lc_organization_id, s.plan_name, s.charge_id, s.created_at, s.deleted_at, c.type, c.payload, c.created_at AS charge_created_at, c.deleted_at AS charge_deleted_at FROM active_subscriptions s LEFT JOIN charges c ON s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id WHERE s.lc_organization_id = ?")).
-			WithArgs(lcID).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT s.id, s.lc_organization_id, s.plan_name, s.charge_id, s.created_at, s.deleted_at, s.application_id, c.type, c.payload, c.created_at AS charge_created_at, c.deleted_at AS charge_deleted_at FROM active_subscriptions s LEFT JOIN charges c ON s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id WHERE s.application_id = ? AND s.lc_organization_id = ?")).
+			WithArgs(appID, lcID).
 			WillReturnRows(rows)
-		subs, err := client.GetSubscriptionsByOrganizationID(ctx, lcID)
+		subs, err := client.GetSubscriptionsByOrganizationID(ctx, appID, lcID)
 		assert.NoError(t, err)
 		assert.Empty(t, subs)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -357,10 +360,10 @@ func TestSQLClient_GetSubscriptionsByOrganizationID(t *testing.T) {
 		assert.NoError(t, err)
 		defer db.Close()
 		client := NewSQLClient(db, &clockMock{})
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT s.id, s.lc_organization_id, s.plan_name, s.charge_id, s.created_at, s.deleted_at, c.type, c.payload, c.created_at AS charge_created_at, c.deleted_at AS charge_deleted_at FROM active_subscriptions s LEFT JOIN charges c ON s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id WHERE s.lc_organization_id = ?")).
-			WithArgs(lcID).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT s.id, s.lc_organization_id, s.plan_name, s.charge_id, s.created_at, s.deleted_at, s.application_id, c.type, c.payload, c.created_at AS charge_created_at, c.deleted_at AS charge_deleted_at FROM active_subscriptions s LEFT JOIN charges c ON s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id WHERE s.application_id = ? AND s.lc_organization_id = ?")).
+			WithArgs(appID, lcID).
 			WillReturnError(assert.AnError)
-		_, err = client.GetSubscriptionsByOrganizationID(ctx, lcID)
+		_, err = client.GetSubscriptionsByOrganizationID(ctx, appID, lcID)
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -369,6 +372,7 @@ func TestSQLClient_GetSubscriptionsByOrganizationID(t *testing.T) {
 func TestSQLClient_DeleteSubscriptionByChargeID(t *testing.T) {
 	ctx := context.Background()
 	lcID := "org1"
+	appID := "app1"
 	chgID := "chg1"
 	cm := new(clockMock)
 
@@ -378,10 +382,10 @@ func TestSQLClient_DeleteSubscriptionByChargeID(t *testing.T) {
 		defer db.Close()
 		client := NewSQLClient(db, cm)
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE charge_id = ? AND lc_organization_id = ?")).
-			WithArgs(now, chgID, lcID).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE charge_id = ? AND lc_organization_id = ? AND application_id = ?")).
+			WithArgs(now, chgID, lcID, appID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
-		assert.NoError(t, client.DeleteSubscriptionByChargeID(ctx, lcID, chgID))
+		assert.NoError(t, client.DeleteSubscriptionByChargeID(ctx, appID, lcID, chgID))
 		assert.NoError(t, mock.ExpectationsWereMet())
 		cm.AssertExpectations(t)
 	})
@@ -393,10 +397,10 @@ func TestSQLClient_DeleteSubscriptionByChargeID(t *testing.T) {
 		client := NewSQLClient(db, cm)
 		cm.ExpectedCalls = nil
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE charge_id = ? AND lc_organization_id = ?")).
-			WithArgs(now, chgID, lcID).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE charge_id = ? AND lc_organization_id = ? AND application_id = ?")).
+			WithArgs(now, chgID, lcID, appID).
 			WillReturnResult(sqlmock.NewResult(0, 0))
-		err = client.DeleteSubscriptionByChargeID(ctx, lcID, chgID)
+		err = client.DeleteSubscriptionByChargeID(ctx, appID, lcID, chgID)
 		assert.ErrorIs(t, err, billing.ErrSubscriptionNotFound)
 		assert.NoError(t, mock.ExpectationsWereMet())
 		cm.AssertExpectations(t)
@@ -409,10 +413,10 @@ func TestSQLClient_DeleteSubscriptionByChargeID(t *testing.T) {
 		client := NewSQLClient(db, cm)
 		cm.ExpectedCalls = nil
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE charge_id = ? AND lc_organization_id = ?")).
-			WithArgs(now, chgID, lcID).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE charge_id = ? AND lc_organization_id = ? AND application_id = ?")).
+			WithArgs(now, chgID, lcID, appID).
 			WillReturnError(assert.AnError)
-		err = client.DeleteSubscriptionByChargeID(ctx, lcID, chgID)
+		err = client.DeleteSubscriptionByChargeID(ctx, appID, lcID, chgID)
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, mock.ExpectationsWereMet())
 		cm.AssertExpectations(t)
@@ -422,6 +426,7 @@ func TestSQLClient_DeleteSubscriptionByChargeID(t *testing.T) {
 func TestSQLClient_GetChargesByOrganizationID(t *testing.T) {
 	ctx := context.Background()
 	lcID := "org1"
+	appID := "app1"
 
 	t.Run("success many", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
@@ -432,10 +437,10 @@ func TestSQLClient_GetChargesByOrganizationID(t *testing.T) {
 		rows := sqlmock.NewRows(cols).
 			AddRow("chg1", lcID, string(billing.ChargeTypeRecurring), `{"x":1}`, now, nil, 0, nil).
 			AddRow("chg2", lcID, string(billing.ChargeTypeRecurring), `{"y":2}`, now, &now, 0, nil)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE lc_organization_id = ?")).
-			WithArgs(lcID).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE application_id = ? AND lc_organization_id = ?")).
+			WithArgs(appID, lcID).
 			WillReturnRows(rows)
-		chs, err := client.GetChargesByOrganizationID(ctx, lcID)
+		chs, err := client.GetChargesByOrganizationID(ctx, appID, lcID)
 		assert.NoError(t, err)
 		assert.Len(t, chs, 2)
 		assert.NotNil(t, chs[1].CanceledAt)
@@ -449,10 +454,10 @@ func TestSQLClient_GetChargesByOrganizationID(t *testing.T) {
 		client := NewSQLClient(db, &clockMock{})
 		cols := []string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at"}
 		rows := sqlmock.NewRows(cols)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE lc_organization_id = ?")).
-			WithArgs(lcID).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE application_id = ? AND lc_organization_id = ?")).
+			WithArgs(appID, lcID).
 			WillReturnRows(rows)
-		chs, err := client.GetChargesByOrganizationID(ctx, lcID)
+		chs, err := client.GetChargesByOrganizationID(ctx, appID, lcID)
 		assert.NoError(t, err)
 		assert.Empty(t, chs)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -463,10 +468,10 @@ func TestSQLClient_GetChargesByOrganizationID(t *testing.T) {
 		assert.NoError(t, err)
 		defer db.Close()
 		client := NewSQLClient(db, &clockMock{})
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE lc_organization_id = ?")).
-			WithArgs(lcID).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE application_id = ? AND lc_organization_id = ?")).
+			WithArgs(appID, lcID).
 			WillReturnError(assert.AnError)
-		_, err = client.GetChargesByOrganizationID(ctx, lcID)
+		_, err = client.GetChargesByOrganizationID(ctx, appID, lcID)
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -475,7 +480,7 @@ func TestSQLClient_GetChargesByOrganizationID(t *testing.T) {
 func TestSQLClient_CreateEvent(t *testing.T) {
 	ctx := context.Background()
 	cm := new(clockMock)
-	baseEvt := events.Event{ID: "evt1", LCOrganizationID: "org1", Type: events.EventTypeInfo, Action: events.EventActionCreateCharge}
+	baseEvt := events.Event{ID: "evt1", LCOrganizationID: "org1", Type: events.EventTypeInfo, Action: events.EventActionCreateCharge, TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", CorrelationID: "corr1", ParentID: "evt0"}
 
 	t.Run("success", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
@@ -486,8 +491,8 @@ func TestSQLClient_CreateEvent(t *testing.T) {
 		evt := baseEvt
 		evt.SetPayload(map[string]any{"ok": true})
 		rawPayload, _ := json.Marshal(evt.Payload)
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)")).
-			WithArgs(evt.ID, evt.LCOrganizationID, string(evt.Type), string(evt.Action), rawPayload, evt.Error, now).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).
+			WithArgs(evt.ID, evt.LCOrganizationID, string(evt.Type), string(evt.Action), rawPayload, evt.Error, evt.TraceID, evt.CorrelationID, evt.ParentID, now).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		assert.NoError(t, client.CreateEvent(ctx, evt))
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -504,8 +509,8 @@ func TestSQLClient_CreateEvent(t *testing.T) {
 		evt := baseEvt
 		evt.SetPayload(map[string]any{"ok": true})
 		rawPayload, _ := json.Marshal(evt.Payload)
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)")).
-			WithArgs(evt.ID, evt.LCOrganizationID, string(evt.Type), string(evt.Action), rawPayload, evt.Error, now).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).
+			WithArgs(evt.ID, evt.LCOrganizationID, string(evt.Type), string(evt.Action), rawPayload, evt.Error, evt.TraceID, evt.CorrelationID, evt.ParentID, now).
 			WillReturnResult(sqlmock.NewResult(1, 0))
 		err = client.CreateEvent(ctx, evt)
 		assert.EqualError(t, err, "couldn't add new billing event")
@@ -523,8 +528,8 @@ func TestSQLClient_CreateEvent(t *testing.T) {
 		evt := baseEvt
 		evt.SetPayload(map[string]any{"ok": true})
 		rawPayload, _ := json.Marshal(evt.Payload)
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)")).
-			WithArgs(evt.ID, evt.LCOrganizationID, string(evt.Type), string(evt.Action), rawPayload, evt.Error, now).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).
+			WithArgs(evt.ID, evt.LCOrganizationID, string(evt.Type), string(evt.Action), rawPayload, evt.Error, evt.TraceID, evt.CorrelationID, evt.ParentID, now).
 			WillReturnError(assert.AnError)
 		err = client.CreateEvent(ctx, evt)
 		assert.ErrorIs(t, err, assert.AnError)
@@ -533,9 +538,183 @@ func TestSQLClient_CreateEvent(t *testing.T) {
 	})
 }
 
+func TestSQLClient_ListEvents(t *testing.T) {
+	columns := []string{"id", "lc_organization_id", "type", "action", "payload", "error", "trace_id", "correlation_id", "parent_id", "created_at"}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		from, to := now.Add(-time.Hour), now.Add(time.Hour)
+		rows := sqlmock.NewRows(columns).
+			AddRow("2", "org1", "error", "top_up", `{"a":1}`, "boom", "trace", "corr1", "1", now).
+			AddRow("1", "org1", "error", "top_up", `{}`, "boom", "", "corr1", "", now)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at FROM billing_events WHERE lc_organization_id = ? AND correlation_id = ? AND action = ? AND type = ? AND COALESCE(error, '') <> '' AND created_at >= ? AND created_at < ? ORDER BY created_at DESC, id DESC, action DESC LIMIT ?")).
+			WithArgs("org1", "corr1", "top_up", "error", from, to, 2).
+			WillReturnRows(rows)
+
+		page, err := client.ListEvents(context.Background(), events.ListEventsFilter{
+			OrganizationID: "org1",
+			CorrelationID:  "corr1",
+			Action:         events.EventActionTopUp,
+			Type:           events.EventTypeError,
+			ErrorsOnly:     true,
+			From:           from,
+			To:             to,
+			Limit:          1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []events.Event{{
+			ID:               "2",
+			CorrelationID:    "corr1",
+			ParentID:         "1",
+			LCOrganizationID: "org1",
+			Type:             events.EventTypeError,
+			Action:           events.EventActionTopUp,
+			Payload:          json.RawMessage(`{"a":1}`),
+			Error:            "boom",
+			TraceID:          "trace",
+			CreatedAt:        now,
+		}}, page.Events)
+		assert.NotEmpty(t, page.NextCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at FROM billing_events WHERE (created_at, id, action) < (?, ?, ?) ORDER BY created_at DESC, id DESC, action DESC LIMIT ?")).
+			WithArgs(now, "2", "top_up", 2).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("1", "org1", "info", "top_up", `{}`, nil, "", "corr1", "", now))
+
+		page, err = client.ListEvents(context.Background(), events.ListEventsFilter{Cursor: page.NextCursor, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, page.Events, 1)
+		assert.Equal(t, "", page.Events[0].Error)
+		assert.Empty(t, page.NextCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+
+		_, err = client.ListEvents(context.Background(), events.ListEventsFilter{Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, events.ErrInvalidCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectQuery(regexp.QuoteMeta("FROM billing_events ORDER BY created_at DESC, id DESC, action DESC LIMIT ?")).
+			WithArgs(events.DefaultListEventsLimit + 1).
+			WillReturnError(assert.AnError)
+
+		_, err = client.ListEvents(context.Background(), events.ListEventsFilter{})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLClient_ListExpiredEvents(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		rows := sqlmock.NewRows([]string{"id", "lc_organization_id", "type", "action", "payload", "error", "trace_id", "correlation_id", "parent_id", "created_at"}).
+			AddRow("1", "org1", "info", "create_charge", `{}`, nil, "", "1", "", now)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at FROM billing_events WHERE created_at < ? AND type = ? AND action NOT IN (?, ?) ORDER BY created_at LIMIT ?")).
+			WithArgs(now, "info", "top_up", "create_operation", 10).
+			WillReturnRows(rows)
+
+		evs, err := client.ListExpiredEvents(context.Background(), events.ExpiredEventsParams{
+			CreatedBefore:  now,
+			Type:           events.EventTypeInfo,
+			ExcludeActions: []events.EventAction{events.EventActionTopUp, events.EventActionCreateOperation},
+			Limit:          10,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []events.Event{{
+			ID:               "1",
+			CorrelationID:    "1",
+			LCOrganizationID: "org1",
+			Type:             events.EventTypeInfo,
+			Action:           events.EventActionCreateCharge,
+			Payload:          json.RawMessage(`{}`),
+			CreatedAt:        now,
+		}}, evs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectQuery(regexp.QuoteMeta("FROM billing_events WHERE created_at < ? AND action IN (?) ORDER BY created_at LIMIT ?")).
+			WithArgs(now, "top_up", 10).
+			WillReturnError(assert.AnError)
+
+		_, err = client.ListExpiredEvents(context.Background(), events.ExpiredEventsParams{
+			CreatedBefore: now,
+			Actions:       []events.EventAction{events.EventActionTopUp},
+			Limit:         10,
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLClient_DeleteEvents(t *testing.T) {
+	evs := []events.Event{{ID: "1", Action: events.EventActionCreateCharge}, {ID: "1", Action: events.EventActionTopUp}}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM billing_events WHERE (id, action) IN ((?, ?), (?, ?))")).
+			WithArgs("1", "create_charge", "1", "top_up").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		deleted, err := client.DeleteEvents(context.Background(), evs)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no events", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+
+		deleted, err := client.DeleteEvents(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), deleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM billing_events")).WillReturnError(assert.AnError)
+
+		_, err = client.DeleteEvents(context.Background(), evs)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestSQLClient_DeleteSubscription(t *testing.T) {
 	ctx := context.Background()
 	lcID := "org1"
+	appID := "app1"
 	subID := "sub_1"
 	cm := new(clockMock)
 
@@ -545,10 +724,10 @@ func TestSQLClient_DeleteSubscription(t *testing.T) {
 		defer db.Close()
 		client := NewSQLClient(db, cm)
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE id = ? AND lc_organization_id = ?")).
-			WithArgs(now, subID, lcID).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE id = ? AND lc_organization_id = ? AND application_id = ?")).
+			WithArgs(now, subID, lcID, appID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
-		assert.NoError(t, client.DeleteSubscription(ctx, lcID, subID))
+		assert.NoError(t, client.DeleteSubscription(ctx, appID, lcID, subID))
 		assert.NoError(t, mock.ExpectationsWereMet())
 		cm.AssertExpectations(t)
 	})
@@ -560,10 +739,10 @@ func TestSQLClient_DeleteSubscription(t *testing.T) {
 		client := NewSQLClient(db, cm)
 		cm.ExpectedCalls = nil
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE id = ? AND lc_organization_id = ?")).
-			WithArgs(now, subID, lcID).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE id = ? AND lc_organization_id = ? AND application_id = ?")).
+			WithArgs(now, subID, lcID, appID).
 			WillReturnResult(sqlmock.NewResult(0, 0))
-		err = client.DeleteSubscription(ctx, lcID, subID)
+		err = client.DeleteSubscription(ctx, appID, lcID, subID)
 		assert.ErrorIs(t, err, billing.ErrSubscriptionNotFound)
 		assert.NoError(t, mock.ExpectationsWereMet())
 		cm.AssertExpectations(t)
@@ -576,10 +755,10 @@ func TestSQLClient_DeleteSubscription(t *testing.T) {
 		client := NewSQLClient(db, cm)
 		cm.ExpectedCalls = nil
 		cm.On("Now").Return(now).Once()
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE id = ? AND lc_organization_id = ?")).
-			WithArgs(now, subID, lcID).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = ? WHERE id = ? AND lc_organization_id = ? AND application_id = ?")).
+			WithArgs(now, subID, lcID, appID).
 			WillReturnError(assert.AnError)
-		err = client.DeleteSubscription(ctx, lcID, subID)
+		err = client.DeleteSubscription(ctx, appID, lcID, subID)
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, mock.ExpectationsWereMet())
 		cm.AssertExpectations(t)
@@ -589,49 +768,347 @@ func TestSQLClient_DeleteSubscription(t *testing.T) {
 func TestSQLClient_GetChargesByStatuses(t *testing.T) {
 	ctx := context.Background()
 	statuses := []string{"active", "pending"}
+	backoff := billing.BackoffPolicy{Initial: 5 * time.Minute, Max: 24 * time.Hour, Multiplier: 2}
 
 	t.Run("success", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		assert.NoError(t, err)
 		defer db.Close()
-		client := NewSQLClient(db, &clockMock{})
+		cm := new(clockMock)
+		cm.On("Now").Return(now).Once()
+		client := NewSQLClient(db, cm)
 		cols := []string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at"}
 		rows := sqlmock.NewRows(cols).
 			AddRow("chg1", "org1", string(billing.ChargeTypeRecurring), `{"status":"active"}`, now, nil, 0, nil)
 		// The IN clause will expand to (?,?)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE JSON_UNQUOTE(JSON_EXTRACT(payload, '$.status')) IN (?, ?) AND deleted_at IS NULL")).
-			WithArgs(statuses[0], statuses[1]).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE JSON_UNQUOTE(JSON_EXTRACT(payload, '$.status')) IN (?, ?) AND deleted_at IS NULL AND (")).
+			WithArgs(statuses[0], statuses[1], float64(300), float64(2), 64, float64(86400), now).
 			WillReturnRows(rows)
-		res, err := client.GetChargesByStatuses(ctx, statuses)
+		res, err := client.GetChargesByStatuses(ctx, statuses, backoff)
 		assert.NoError(t, err)
 		assert.Len(t, res, 1)
 		assert.Equal(t, "chg1", res[0].ID)
 		assert.NoError(t, mock.ExpectationsWereMet())
+		cm.AssertExpectations(t)
 	})
 
 	t.Run("empty", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		assert.NoError(t, err)
 		defer db.Close()
-		client := NewSQLClient(db, &clockMock{})
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE JSON_UNQUOTE(JSON_EXTRACT(payload, '$.status')) IN (?, ?) AND deleted_at IS NULL")).
-			WithArgs(statuses[0], statuses[1]).
+		cm := new(clockMock)
+		cm.On("Now").Return(now).Once()
+		client := NewSQLClient(db, cm)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE JSON_UNQUOTE(JSON_EXTRACT(payload, '$.status')) IN (?, ?) AND deleted_at IS NULL AND (")).
+			WithArgs(statuses[0], statuses[1], float64(300), float64(2), 64, float64(86400), now).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at"}))
-		res, err := client.GetChargesByStatuses(ctx, statuses)
+		res, err := client.GetChargesByStatuses(ctx, statuses, backoff)
 		assert.NoError(t, err)
 		assert.Empty(t, res)
 		assert.NoError(t, mock.ExpectationsWereMet())
+		cm.AssertExpectations(t)
 	})
 
 	t.Run("db error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		cm := new(clockMock)
+		cm.On("Now").Return(now).Once()
+		client := NewSQLClient(db, cm)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE JSON_UNQUOTE(JSON_EXTRACT(payload, '$.status')) IN (?, ?) AND deleted_at IS NULL AND (")).
+			WithArgs(statuses[0], statuses[1], float64(300), float64(2), 64, float64(86400), now).
+			WillReturnError(assert.AnError)
+		_, err = client.GetChargesByStatuses(ctx, statuses, backoff)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		cm.AssertExpectations(t)
+	})
+}
+
+func TestSQLClient_ResetChargeSyncErrorCount(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		assert.NoError(t, err)
 		defer db.Close()
 		client := NewSQLClient(db, &clockMock{})
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges WHERE JSON_UNQUOTE(JSON_EXTRACT(payload, '$.status')) IN (?, ?) AND deleted_at IS NULL")).
-			WithArgs(statuses[0], statuses[1]).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET sync_error_count = 0, last_sync_error_at = NULL, quarantined_at = NULL WHERE id = ?")).
+			WithArgs("chg1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = client.ResetChargeSyncErrorCount(context.Background(), "chg1")
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectExec("UPDATE charges SET sync_error_count = 0").
+			WithArgs("chg1").
 			WillReturnError(assert.AnError)
-		_, err = client.GetChargesByStatuses(ctx, statuses)
+		err = client.ResetChargeSyncErrorCount(context.Background(), "chg1")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLClient_QuarantineCharge(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		cm := new(clockMock)
+		cm.On("Now").Return(now).Once()
+		client := NewSQLClient(db, cm)
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET quarantined_at = ? WHERE id = ? AND deleted_at IS NULL AND quarantined_at IS NULL")).
+			WithArgs(now, "chg1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err = client.QuarantineCharge(context.Background(), "chg1")
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		cm.AssertExpectations(t)
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		cm := new(clockMock)
+		cm.On("Now").Return(now).Once()
+		client := NewSQLClient(db, cm)
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET quarantined_at = ?")).
+			WithArgs(now, "chg1").
+			WillReturnError(assert.AnError)
+		err = client.QuarantineCharge(context.Background(), "chg1")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLClient_GetQuarantinedCharges(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		rows := sqlmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at", "quarantined_at"}).
+			AddRow("chg1", "org1", string(billing.ChargeTypeRecurring), `{"status":"active"}`, now, nil, 10, now, now)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE quarantined_at IS NOT NULL AND deleted_at IS NULL ORDER BY quarantined_at")).
+			WillReturnRows(rows)
+		res, err := client.GetQuarantinedCharges(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, &now, res[0].QuarantinedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE quarantined_at IS NOT NULL")).
+			WillReturnError(assert.AnError)
+		_, err = client.GetQuarantinedCharges(context.Background())
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLClient_GetCharges(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		rows := sqlmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at", "quarantined_at"}).
+			AddRow("chg1", "org1", string(billing.ChargeTypeRecurring), `{"status":"active"}`, now, nil, 0, nil, nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE deleted_at IS NULL ORDER BY created_at")).
+			WillReturnRows(rows)
+		res, err := client.GetCharges(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectQuery(regexp.QuoteMeta("FROM charges WHERE deleted_at IS NULL")).WillReturnError(assert.AnError)
+		_, err = client.GetCharges(context.Background())
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLClient_GetSubscriptions(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		rows := sqlmock.NewRows([]string{"id", "lc_organization_id", "plan_name", "charge_id", "created_at", "deleted_at", "type", "payload", "charge_created_at", "charge_deleted_at"}).
+			AddRow("sub1", "org1", "plan", "chg1", now, nil, string(billing.ChargeTypeRecurring), `{}`, now, now)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM active_subscriptions s LEFT JOIN charges c ON s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id ORDER BY s.created_at")).
+			WillReturnRows(rows)
+		res, err := client.GetSubscriptions(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, "chg1", res[0].Charge.ID)
+		assert.Equal(t, &now, res[0].Charge.CanceledAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectQuery(regexp.QuoteMeta("FROM active_subscriptions s")).WillReturnError(assert.AnError)
+		_, err = client.GetSubscriptions(context.Background())
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLClient_BackfillApplicationID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET application_id = ? WHERE application_id = ''")).
+			WithArgs("app1").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET application_id = ? WHERE application_id = ''")).
+			WithArgs("app1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE IGNORE trial_usage SET application_id = ? WHERE application_id = ''")).
+			WithArgs("app1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		n, err := client.BackfillApplicationID(context.Background(), "app1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET application_id = ? WHERE application_id = ''")).
+			WithArgs("app1").
+			WillReturnError(assert.AnError)
+
+		_, err = client.BackfillApplicationID(context.Background(), "app1")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLClient_WithChargeLock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, ?)")).
+			WithArgs("charge:id1", chargeLockTimeout).
+			WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta("DO RELEASE_LOCK(?)")).
+			WithArgs("charge:id1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		calls := 0
+		err = client.WithChargeLock(ctx, "id1", func(ctx context.Context) error {
+			calls++
+			return client.WithChargeLock(ctx, "id1", func(ctx context.Context) error {
+				calls++
+				return nil
+			})
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("queries of fn run on the lock connection", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		// a query on another connection would wait for the lock connection until the deadline
+		db.SetMaxOpenConns(1)
+		cm := new(clockMock)
+		cm.On("Now").Return(now).Once()
+		client := NewSQLClient(db, cm)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, ?)")).
+			WithArgs("charge:id1", chargeLockTimeout).
+			WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, ?)")).
+			WithArgs("charge:id2", chargeLockTimeout).
+			WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE charges SET quarantined_at = ? WHERE id = ? AND deleted_at IS NULL AND quarantined_at IS NULL")).
+			WithArgs(now, "id2").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("DO RELEASE_LOCK(?)")).
+			WithArgs("charge:id2").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("DO RELEASE_LOCK(?)")).
+			WithArgs("charge:id1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		err = client.WithChargeLock(timeoutCtx, "id1", func(ctx context.Context) error {
+			return client.WithChargeLock(ctx, "id2", func(ctx context.Context) error {
+				return client.QuarantineCharge(ctx, "id2")
+			})
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		cm.AssertExpectations(t)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, ?)")).
+			WithArgs("charge:id1", chargeLockTimeout).
+			WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(0))
+
+		err = client.WithChargeLock(ctx, "id1", func(ctx context.Context) error {
+			t.Fatal("fn should not be called")
+			return nil
+		})
+		assert.ErrorContains(t, err, "timeout")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fn error releases lock", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		client := NewSQLClient(db, &clockMock{})
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, ?)")).
+			WithArgs("charge:id1", chargeLockTimeout).
+			WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta("DO RELEASE_LOCK(?)")).
+			WithArgs("charge:id1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = client.WithChargeLock(ctx, "id1", func(ctx context.Context) error {
+			return assert.AnError
+		})
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
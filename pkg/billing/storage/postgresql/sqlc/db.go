@@ -4,8 +4,9 @@ package sqlc
 
 import (
 	"encoding/json"
-	"github.com/livechat-integrations/go-billing-sdk/v2/internal/livechat"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/billing"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
 	"time"
 )
 
@@ -20,6 +21,11 @@ func (c *Charge) ToBillingCharge() *billing.Charge {
 		lastSyncErrorAt = &c.LastSyncErrorAt.Time
 	}
 
+	var quarantinedAt *time.Time
+	if c.QuarantinedAt.Valid {
+		quarantinedAt = &c.QuarantinedAt.Time
+	}
+
 	var nextChargeAt, currentChargeAt *time.Time
 	if c.Type == string(billing.ChargeTypeRecurring) {
 		var p livechat.RecurrentCharge
@@ -35,6 +41,7 @@ func (c *Charge) ToBillingCharge() *billing.Charge {
 	return &billing.Charge{
 		ID:               c.ID,
 		LCOrganizationID: c.LcOrganizationID,
+		ApplicationID:    c.ApplicationID,
 		Type:             billing.ChargeType(c.Type),
 		Status:           b.Status,
 		Payload:          c.Payload,
@@ -44,6 +51,7 @@ func (c *Charge) ToBillingCharge() *billing.Charge {
 		CanceledAt:       canceledAt,
 		SyncErrorCount:   int(c.SyncErrorCount),
 		LastSyncErrorAt:  lastSyncErrorAt,
+		QuarantinedAt:    quarantinedAt,
 	}
 }
 
@@ -56,6 +64,7 @@ func (r *GetSubscriptionsByOrganizationIDRow) ToBillingSubscription() *billing.S
 	subscription := &billing.Subscription{
 		ID:               r.ID,
 		LCOrganizationID: r.LcOrganizationID,
+		ApplicationID:    r.ApplicationID,
 		PlanName:         r.PlanName,
 		CreatedAt:        r.CreatedAt.Time,
 		DeletedAt:        deletedAt,
@@ -75,12 +84,18 @@ func (r *GetSubscriptionsByOrganizationIDRow) ToBillingSubscription() *billing.S
 		lastSyncErrorAt = &r.LastSyncErrorAt.Time
 	}
 
+	var quarantinedAt *time.Time
+	if r.QuarantinedAt.Valid {
+		quarantinedAt = &r.QuarantinedAt.Time
+	}
+
 	var p livechat.RecurrentCharge
 	_ = json.Unmarshal(r.Payload, &p)
 
 	subscription.Charge = &billing.Charge{
 		ID:               r.ChargeID.String,
 		LCOrganizationID: r.LcOrganizationID_2.String,
+		ApplicationID:    r.ApplicationID_2.String,
 		Type:             billing.ChargeType(r.Type.String),
 		Payload:          r.Payload,
 		NextChargeAt:     p.NextChargeAt,
@@ -90,6 +105,7 @@ func (r *GetSubscriptionsByOrganizationIDRow) ToBillingSubscription() *billing.S
 		Status:           p.Status,
 		SyncErrorCount:   int(r.SyncErrorCount.Int32),
 		LastSyncErrorAt:  lastSyncErrorAt,
+		QuarantinedAt:    quarantinedAt,
 	}
 
 	var dunningEndDate time.Time
@@ -112,6 +128,11 @@ func (r *GetSubscriptionsByOrganizationIDRow) ToBillingSubscription() *billing.S
 	return subscription
 }
 
+func (r *GetSubscriptionsRow) ToBillingSubscription() *billing.Subscription {
+	row := GetSubscriptionsByOrganizationIDRow(*r)
+	return row.ToBillingSubscription()
+}
+
 func calculateNextDunningDate(start time.Time) time.Time {
 	dunningDate := start.AddDate(0, 0, 16)
 	for dunningDate.Before(time.Now()) {
@@ -119,3 +140,18 @@ func calculateNextDunningDate(start time.Time) time.Time {
 	}
 	return dunningDate
 }
+
+func (e *BillingEvent) ToEvent() *events.Event {
+	return &events.Event{
+		ID:               e.ID,
+		CorrelationID:    e.CorrelationID,
+		ParentID:         e.ParentID,
+		LCOrganizationID: e.LcOrganizationID,
+		Type:             events.EventType(e.Type),
+		Action:           events.EventAction(e.Action),
+		Payload:          e.Payload,
+		Error:            e.Error.String,
+		TraceID:          e.TraceID,
+		CreatedAt:        e.CreatedAt.Time,
+	}
+}
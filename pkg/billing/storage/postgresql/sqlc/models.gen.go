@@ -15,6 +15,7 @@ type ActiveSubscription struct {
 	ChargeID         pgtype.Text
 	CreatedAt        pgtype.Timestamptz
 	DeletedAt        pgtype.Timestamptz
+	ApplicationID    string
 }
 
 type BillingEvent struct {
@@ -25,6 +26,9 @@ type BillingEvent struct {
 	Payload          []byte
 	Error            pgtype.Text
 	CreatedAt        pgtype.Timestamptz
+	TraceID          string
+	CorrelationID    string
+	ParentID         string
 }
 
 type Charge struct {
@@ -36,6 +40,8 @@ type Charge struct {
 	DeletedAt        pgtype.Timestamptz
 	SyncErrorCount   int32
 	LastSyncErrorAt  pgtype.Timestamptz
+	QuarantinedAt    pgtype.Timestamptz
+	ApplicationID    string
 }
 
 type Subscription struct {
@@ -45,9 +51,11 @@ type Subscription struct {
 	ChargeID         pgtype.Text
 	CreatedAt        pgtype.Timestamptz
 	DeletedAt        pgtype.Timestamptz
+	ApplicationID    string
 }
 
 type TrialUsage struct {
 	LcOrganizationID string
 	UsedAt           pgtype.Timestamptz
+	ApplicationID    string
 }
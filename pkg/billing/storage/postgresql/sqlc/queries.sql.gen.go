@@ -11,9 +11,56 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const backfillChargesApplicationID = `-- name: BackfillChargesApplicationID :execrows
+UPDATE charges
+SET application_id = $1
+WHERE application_id = ''
+`
+
+func (q *Queries) BackfillChargesApplicationID(ctx context.Context, applicationID string) (int64, error) {
+	result, err := q.db.Exec(ctx, backfillChargesApplicationID, applicationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const backfillSubscriptionsApplicationID = `-- name: BackfillSubscriptionsApplicationID :execrows
+UPDATE subscriptions
+SET application_id = $1
+WHERE application_id = ''
+`
+
+func (q *Queries) BackfillSubscriptionsApplicationID(ctx context.Context, applicationID string) (int64, error) {
+	result, err := q.db.Exec(ctx, backfillSubscriptionsApplicationID, applicationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const backfillTrialUsageApplicationID = `-- name: BackfillTrialUsageApplicationID :execrows
+UPDATE trial_usage
+SET application_id = $1
+WHERE application_id = ''
+AND NOT EXISTS (
+    SELECT 1 FROM trial_usage t
+    WHERE t.application_id = $1
+    AND t.lc_organization_id = trial_usage.lc_organization_id
+)
+`
+
+func (q *Queries) BackfillTrialUsageApplicationID(ctx context.Context, applicationID string) (int64, error) {
+	result, err := q.db.Exec(ctx, backfillTrialUsageApplicationID, applicationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createCharge = `-- name: CreateCharge :exec
-INSERT INTO charges(id, type, payload, lc_organization_id, created_at)
-VALUES ($1, $2, $3, $4, NOW())
+INSERT INTO charges(id, type, payload, lc_organization_id, application_id, created_at)
+VALUES ($1, $2, $3, $4, $5, NOW())
 `
 
 type CreateChargeParams struct {
@@ -21,6 +68,7 @@ type CreateChargeParams struct {
 	Type             string
 	Payload          []byte
 	LcOrganizationID string
+	ApplicationID    string
 }
 
 func (q *Queries) CreateCharge(ctx context.Context, arg CreateChargeParams) error {
@@ -29,13 +77,14 @@ func (q *Queries) CreateCharge(ctx context.Context, arg CreateChargeParams) erro
 		arg.Type,
 		arg.Payload,
 		arg.LcOrganizationID,
+		arg.ApplicationID,
 	)
 	return err
 }
 
 const createEvent = `-- name: CreateEvent :exec
-INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, created_at)
-VALUES ($1, $2, $3, $4, $5, $6, NOW())
+INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
 `
 
 type CreateEventParams struct {
@@ -45,6 +94,9 @@ type CreateEventParams struct {
 	Action           string
 	Payload          []byte
 	Error            pgtype.Text
+	TraceID          string
+	CorrelationID    string
+	ParentID         string
 }
 
 func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) error {
@@ -55,13 +107,16 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) error
 		arg.Action,
 		arg.Payload,
 		arg.Error,
+		arg.TraceID,
+		arg.CorrelationID,
+		arg.ParentID,
 	)
 	return err
 }
 
 const createSubscription = `-- name: CreateSubscription :exec
-INSERT INTO subscriptions(id, lc_organization_id, plan_name, charge_id, created_at)
-VALUES ($1, $2, $3, $4, NOW())
+INSERT INTO subscriptions(id, lc_organization_id, plan_name, charge_id, application_id, created_at)
+VALUES ($1, $2, $3, $4, $5, NOW())
 `
 
 type CreateSubscriptionParams struct {
@@ -69,6 +124,7 @@ type CreateSubscriptionParams struct {
 	LcOrganizationID string
 	PlanName         string
 	ChargeID         pgtype.Text
+	ApplicationID    string
 }
 
 func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscriptionParams) error {
@@ -77,45 +133,76 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		arg.LcOrganizationID,
 		arg.PlanName,
 		arg.ChargeID,
+		arg.ApplicationID,
 	)
 	return err
 }
 
 const createTrialUsage = `-- name: CreateTrialUsage :exec
-INSERT INTO trial_usage (lc_organization_id)
-VALUES ($1)
-ON CONFLICT (lc_organization_id) DO NOTHING
+INSERT INTO trial_usage (application_id, lc_organization_id)
+VALUES ($1, $2)
+ON CONFLICT (application_id, lc_organization_id) DO NOTHING
 `
 
-func (q *Queries) CreateTrialUsage(ctx context.Context, lcOrganizationID string) error {
-	_, err := q.db.Exec(ctx, createTrialUsage, lcOrganizationID)
+type CreateTrialUsageParams struct {
+	ApplicationID    string
+	LcOrganizationID string
+}
+
+func (q *Queries) CreateTrialUsage(ctx context.Context, arg CreateTrialUsageParams) error {
+	_, err := q.db.Exec(ctx, createTrialUsage, arg.ApplicationID, arg.LcOrganizationID)
 	return err
 }
 
 const deleteCharge = `-- name: DeleteCharge :exec
 UPDATE charges
 SET deleted_at = now()
-WHERE id = $1
+WHERE application_id = $1
+AND id = $2
 `
 
-func (q *Queries) DeleteCharge(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteCharge, id)
+type DeleteChargeParams struct {
+	ApplicationID string
+	ID            string
+}
+
+func (q *Queries) DeleteCharge(ctx context.Context, arg DeleteChargeParams) error {
+	_, err := q.db.Exec(ctx, deleteCharge, arg.ApplicationID, arg.ID)
 	return err
 }
 
+const deleteEvents = `-- name: DeleteEvents :execrows
+DELETE FROM billing_events
+WHERE (id, action) IN (SELECT unnest($1::text[]), unnest($2::text[]))
+`
+
+type DeleteEventsParams struct {
+	Ids     []string
+	Actions []string
+}
+
+func (q *Queries) DeleteEvents(ctx context.Context, arg DeleteEventsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteEvents, arg.Ids, arg.Actions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteSubscription = `-- name: DeleteSubscription :exec
 UPDATE subscriptions
 SET deleted_at = NOW()
-WHERE id = $1 and lc_organization_id = $2
+WHERE id = $1 and lc_organization_id = $2 and application_id = $3
 `
 
 type DeleteSubscriptionParams struct {
 	ID               string
 	LcOrganizationID string
+	ApplicationID    string
 }
 
 func (q *Queries) DeleteSubscription(ctx context.Context, arg DeleteSubscriptionParams) error {
-	_, err := q.db.Exec(ctx, deleteSubscription, arg.ID, arg.LcOrganizationID)
+	_, err := q.db.Exec(ctx, deleteSubscription, arg.ID, arg.LcOrganizationID, arg.ApplicationID)
 	return err
 }
 
@@ -124,27 +211,35 @@ UPDATE subscriptions
 SET deleted_at = now()
 WHERE charge_id = $1
 AND lc_organization_id = $2
+AND application_id = $3
 `
 
 type DeleteSubscriptionByChargeIDParams struct {
 	ChargeID         pgtype.Text
 	LcOrganizationID string
+	ApplicationID    string
 }
 
 func (q *Queries) DeleteSubscriptionByChargeID(ctx context.Context, arg DeleteSubscriptionByChargeIDParams) error {
-	_, err := q.db.Exec(ctx, deleteSubscriptionByChargeID, arg.ChargeID, arg.LcOrganizationID)
+	_, err := q.db.Exec(ctx, deleteSubscriptionByChargeID, arg.ChargeID, arg.LcOrganizationID, arg.ApplicationID)
 	return err
 }
 
 const getChargeByID = `-- name: GetChargeByID :one
-SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at
+SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id
 FROM charges
-WHERE id = $1
+WHERE application_id = $1
+AND id = $2
 AND deleted_at IS NULL
 `
 
-func (q *Queries) GetChargeByID(ctx context.Context, id string) (Charge, error) {
-	row := q.db.QueryRow(ctx, getChargeByID, id)
+type GetChargeByIDParams struct {
+	ApplicationID string
+	ID            string
+}
+
+func (q *Queries) GetChargeByID(ctx context.Context, arg GetChargeByIDParams) (Charge, error) {
+	row := q.db.QueryRow(ctx, getChargeByID, arg.ApplicationID, arg.ID)
 	var i Charge
 	err := row.Scan(
 		&i.ID,
@@ -155,19 +250,27 @@ func (q *Queries) GetChargeByID(ctx context.Context, id string) (Charge, error)
 		&i.DeletedAt,
 		&i.SyncErrorCount,
 		&i.LastSyncErrorAt,
+		&i.QuarantinedAt,
+		&i.ApplicationID,
 	)
 	return i, err
 }
 
 const getChargeByOrganizationID = `-- name: GetChargeByOrganizationID :one
-SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at
+SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id
 FROM charges
-WHERE lc_organization_id = $1
+WHERE application_id = $1
+AND lc_organization_id = $2
 AND deleted_at IS NULL
 `
 
-func (q *Queries) GetChargeByOrganizationID(ctx context.Context, lcOrganizationID string) (Charge, error) {
-	row := q.db.QueryRow(ctx, getChargeByOrganizationID, lcOrganizationID)
+type GetChargeByOrganizationIDParams struct {
+	ApplicationID    string
+	LcOrganizationID string
+}
+
+func (q *Queries) GetChargeByOrganizationID(ctx context.Context, arg GetChargeByOrganizationIDParams) (Charge, error) {
+	row := q.db.QueryRow(ctx, getChargeByOrganizationID, arg.ApplicationID, arg.LcOrganizationID)
 	var i Charge
 	err := row.Scan(
 		&i.ID,
@@ -178,18 +281,64 @@ func (q *Queries) GetChargeByOrganizationID(ctx context.Context, lcOrganizationI
 		&i.DeletedAt,
 		&i.SyncErrorCount,
 		&i.LastSyncErrorAt,
+		&i.QuarantinedAt,
+		&i.ApplicationID,
 	)
 	return i, err
 }
 
+const getCharges = `-- name: GetCharges :many
+SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id
+FROM charges
+WHERE deleted_at IS NULL
+ORDER BY created_at
+`
+
+func (q *Queries) GetCharges(ctx context.Context) ([]Charge, error) {
+	rows, err := q.db.Query(ctx, getCharges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Charge
+	for rows.Next() {
+		var i Charge
+		if err := rows.Scan(
+			&i.ID,
+			&i.LcOrganizationID,
+			&i.Type,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.SyncErrorCount,
+			&i.LastSyncErrorAt,
+			&i.QuarantinedAt,
+			&i.ApplicationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChargesByOrganizationID = `-- name: GetChargesByOrganizationID :many
-SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at
+SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id
 FROM charges
-WHERE lc_organization_id = $1
+WHERE application_id = $1
+AND lc_organization_id = $2
 `
 
-func (q *Queries) GetChargesByOrganizationID(ctx context.Context, lcOrganizationID string) ([]Charge, error) {
-	rows, err := q.db.Query(ctx, getChargesByOrganizationID, lcOrganizationID)
+type GetChargesByOrganizationIDParams struct {
+	ApplicationID    string
+	LcOrganizationID string
+}
+
+func (q *Queries) GetChargesByOrganizationID(ctx context.Context, arg GetChargesByOrganizationIDParams) ([]Charge, error) {
+	rows, err := q.db.Query(ctx, getChargesByOrganizationID, arg.ApplicationID, arg.LcOrganizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -206,6 +355,8 @@ func (q *Queries) GetChargesByOrganizationID(ctx context.Context, lcOrganization
 			&i.DeletedAt,
 			&i.SyncErrorCount,
 			&i.LastSyncErrorAt,
+			&i.QuarantinedAt,
+			&i.ApplicationID,
 		); err != nil {
 			return nil, err
 		}
@@ -218,14 +369,33 @@ func (q *Queries) GetChargesByOrganizationID(ctx context.Context, lcOrganization
 }
 
 const getChargesByStatuses = `-- name: GetChargesByStatuses :many
-SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at
+SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id
 FROM charges
 WHERE payload->>'status' = ANY($1::text[])
 AND deleted_at IS NULL
+AND (
+    sync_error_count = 0
+    OR last_sync_error_at IS NULL
+    OR last_sync_error_at + make_interval(secs => LEAST($2::float8 * power($3::float8, LEAST(sync_error_count - 1, $4::int)), $5::float8)) <= NOW()
+)
 `
 
-func (q *Queries) GetChargesByStatuses(ctx context.Context, dollar_1 []string) ([]Charge, error) {
-	rows, err := q.db.Query(ctx, getChargesByStatuses, dollar_1)
+type GetChargesByStatusesParams struct {
+	Column1 []string
+	Column2 float64
+	Column3 float64
+	Column4 int32
+	Column5 float64
+}
+
+func (q *Queries) GetChargesByStatuses(ctx context.Context, arg GetChargesByStatusesParams) ([]Charge, error) {
+	rows, err := q.db.Query(ctx, getChargesByStatuses,
+		arg.Column1,
+		arg.Column2,
+		arg.Column3,
+		arg.Column4,
+		arg.Column5,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -242,6 +412,8 @@ func (q *Queries) GetChargesByStatuses(ctx context.Context, dollar_1 []string) (
 			&i.DeletedAt,
 			&i.SyncErrorCount,
 			&i.LastSyncErrorAt,
+			&i.QuarantinedAt,
+			&i.ApplicationID,
 		); err != nil {
 			return nil, err
 		}
@@ -254,10 +426,11 @@ func (q *Queries) GetChargesByStatuses(ctx context.Context, dollar_1 []string) (
 }
 
 const getChargesWithHighErrorCount = `-- name: GetChargesWithHighErrorCount :many
-SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at
+SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id
 FROM charges
 WHERE sync_error_count >= $1
 AND deleted_at IS NULL
+AND quarantined_at IS NULL
 `
 
 func (q *Queries) GetChargesWithHighErrorCount(ctx context.Context, syncErrorCount int32) ([]Charge, error) {
@@ -278,6 +451,47 @@ func (q *Queries) GetChargesWithHighErrorCount(ctx context.Context, syncErrorCou
 			&i.DeletedAt,
 			&i.SyncErrorCount,
 			&i.LastSyncErrorAt,
+			&i.QuarantinedAt,
+			&i.ApplicationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getQuarantinedCharges = `-- name: GetQuarantinedCharges :many
+SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id
+FROM charges
+WHERE quarantined_at IS NOT NULL
+AND deleted_at IS NULL
+ORDER BY quarantined_at
+`
+
+func (q *Queries) GetQuarantinedCharges(ctx context.Context) ([]Charge, error) {
+	rows, err := q.db.Query(ctx, getQuarantinedCharges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Charge
+	for rows.Next() {
+		var i Charge
+		if err := rows.Scan(
+			&i.ID,
+			&i.LcOrganizationID,
+			&i.Type,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.SyncErrorCount,
+			&i.LastSyncErrorAt,
+			&i.QuarantinedAt,
+			&i.ApplicationID,
 		); err != nil {
 			return nil, err
 		}
@@ -290,7 +504,7 @@ func (q *Queries) GetChargesWithHighErrorCount(ctx context.Context, syncErrorCou
 }
 
 const getSubscriptionByChargeID = `-- name: GetSubscriptionByChargeID :one
-SELECT id, lc_organization_id, plan_name, charge_id, created_at, deleted_at
+SELECT id, lc_organization_id, plan_name, charge_id, created_at, deleted_at, application_id
 FROM active_subscriptions
 WHERE charge_id = $1
 `
@@ -305,15 +519,82 @@ func (q *Queries) GetSubscriptionByChargeID(ctx context.Context, chargeID pgtype
 		&i.ChargeID,
 		&i.CreatedAt,
 		&i.DeletedAt,
+		&i.ApplicationID,
 	)
 	return i, err
 }
 
+const getSubscriptions = `-- name: GetSubscriptions :many
+SELECT s.id, s.lc_organization_id, plan_name, charge_id, s.created_at, s.deleted_at, s.application_id, c.id, c.lc_organization_id, type, payload, c.created_at, c.deleted_at, sync_error_count, last_sync_error_at, quarantined_at, c.application_id
+FROM active_subscriptions s
+LEFT JOIN charges c on s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id
+ORDER BY s.created_at
+`
+
+type GetSubscriptionsRow struct {
+	ID                 string
+	LcOrganizationID   string
+	PlanName           string
+	ChargeID           pgtype.Text
+	CreatedAt          pgtype.Timestamptz
+	DeletedAt          pgtype.Timestamptz
+	ApplicationID      string
+	ID_2               pgtype.Text
+	LcOrganizationID_2 pgtype.Text
+	Type               pgtype.Text
+	Payload            []byte
+	CreatedAt_2        pgtype.Timestamptz
+	DeletedAt_2        pgtype.Timestamptz
+	SyncErrorCount     pgtype.Int4
+	LastSyncErrorAt    pgtype.Timestamptz
+	QuarantinedAt      pgtype.Timestamptz
+	ApplicationID_2    pgtype.Text
+}
+
+func (q *Queries) GetSubscriptions(ctx context.Context) ([]GetSubscriptionsRow, error) {
+	rows, err := q.db.Query(ctx, getSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSubscriptionsRow
+	for rows.Next() {
+		var i GetSubscriptionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LcOrganizationID,
+			&i.PlanName,
+			&i.ChargeID,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.ApplicationID,
+			&i.ID_2,
+			&i.LcOrganizationID_2,
+			&i.Type,
+			&i.Payload,
+			&i.CreatedAt_2,
+			&i.DeletedAt_2,
+			&i.SyncErrorCount,
+			&i.LastSyncErrorAt,
+			&i.QuarantinedAt,
+			&i.ApplicationID_2,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSubscriptionsByOrganizationID = `-- name: GetSubscriptionsByOrganizationID :many
-SELECT s.id, s.lc_organization_id, plan_name, charge_id, s.created_at, s.deleted_at, c.id, c.lc_organization_id, type, payload, c.created_at, c.deleted_at, sync_error_count, last_sync_error_at
+SELECT s.id, s.lc_organization_id, plan_name, charge_id, s.created_at, s.deleted_at, s.application_id, c.id, c.lc_organization_id, type, payload, c.created_at, c.deleted_at, sync_error_count, last_sync_error_at, quarantined_at, c.application_id
 FROM active_subscriptions s
-LEFT JOIN charges c on s.charge_id = c.id
-WHERE s.lc_organization_id = $1
+LEFT JOIN charges c on s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id
+WHERE s.application_id = $1
+AND s.lc_organization_id = $2
 ORDER BY s.created_at DESC
 `
 
@@ -324,6 +605,7 @@ type GetSubscriptionsByOrganizationIDRow struct {
 	ChargeID           pgtype.Text
 	CreatedAt          pgtype.Timestamptz
 	DeletedAt          pgtype.Timestamptz
+	ApplicationID      string
 	ID_2               pgtype.Text
 	LcOrganizationID_2 pgtype.Text
 	Type               pgtype.Text
@@ -332,10 +614,17 @@ type GetSubscriptionsByOrganizationIDRow struct {
 	DeletedAt_2        pgtype.Timestamptz
 	SyncErrorCount     pgtype.Int4
 	LastSyncErrorAt    pgtype.Timestamptz
+	QuarantinedAt      pgtype.Timestamptz
+	ApplicationID_2    pgtype.Text
+}
+
+type GetSubscriptionsByOrganizationIDParams struct {
+	ApplicationID    string
+	LcOrganizationID string
 }
 
-func (q *Queries) GetSubscriptionsByOrganizationID(ctx context.Context, lcOrganizationID string) ([]GetSubscriptionsByOrganizationIDRow, error) {
-	rows, err := q.db.Query(ctx, getSubscriptionsByOrganizationID, lcOrganizationID)
+func (q *Queries) GetSubscriptionsByOrganizationID(ctx context.Context, arg GetSubscriptionsByOrganizationIDParams) ([]GetSubscriptionsByOrganizationIDRow, error) {
+	rows, err := q.db.Query(ctx, getSubscriptionsByOrganizationID, arg.ApplicationID, arg.LcOrganizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -350,6 +639,7 @@ func (q *Queries) GetSubscriptionsByOrganizationID(ctx context.Context, lcOrgani
 			&i.ChargeID,
 			&i.CreatedAt,
 			&i.DeletedAt,
+			&i.ApplicationID,
 			&i.ID_2,
 			&i.LcOrganizationID_2,
 			&i.Type,
@@ -358,6 +648,8 @@ func (q *Queries) GetSubscriptionsByOrganizationID(ctx context.Context, lcOrgani
 			&i.DeletedAt_2,
 			&i.SyncErrorCount,
 			&i.LastSyncErrorAt,
+			&i.QuarantinedAt,
+			&i.ApplicationID_2,
 		); err != nil {
 			return nil, err
 		}
@@ -372,12 +664,18 @@ func (q *Queries) GetSubscriptionsByOrganizationID(ctx context.Context, lcOrgani
 const hasTrialUsage = `-- name: HasTrialUsage :one
 SELECT EXISTS(
     SELECT 1 FROM trial_usage
-    WHERE lc_organization_id = $1
+    WHERE application_id = $1
+    AND lc_organization_id = $2
 )
 `
 
-func (q *Queries) HasTrialUsage(ctx context.Context, lcOrganizationID string) (bool, error) {
-	row := q.db.QueryRow(ctx, hasTrialUsage, lcOrganizationID)
+type HasTrialUsageParams struct {
+	ApplicationID    string
+	LcOrganizationID string
+}
+
+func (q *Queries) HasTrialUsage(ctx context.Context, arg HasTrialUsageParams) (bool, error) {
+	row := q.db.QueryRow(ctx, hasTrialUsage, arg.ApplicationID, arg.LcOrganizationID)
 	var exists bool
 	err := row.Scan(&exists)
 	return exists, err
@@ -396,6 +694,169 @@ func (q *Queries) IncrementChargeSyncErrorCount(ctx context.Context, id string)
 	return err
 }
 
+const listEvents = `-- name: ListEvents :many
+SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id
+FROM billing_events
+WHERE ($1::text = '' OR lc_organization_id = $1::text)
+AND ($2::text = '' OR correlation_id = $2::text)
+AND ($3::text = '' OR action = $3::text)
+AND ($4::text = '' OR type = $4::text)
+AND (NOT $5::bool OR COALESCE(error, '') <> '')
+AND ($6::timestamptz IS NULL OR created_at >= $6::timestamptz)
+AND ($7::timestamptz IS NULL OR created_at < $7::timestamptz)
+AND ($8::timestamptz IS NULL OR (created_at, id, action) < ($8::timestamptz, $9::text, $10::text))
+ORDER BY created_at DESC, id DESC, action DESC
+LIMIT $11::int
+`
+
+type ListEventsParams struct {
+	LcOrganizationID string
+	CorrelationID    string
+	Action           string
+	Type             string
+	ErrorsOnly       bool
+	CreatedFrom      pgtype.Timestamptz
+	CreatedTo        pgtype.Timestamptz
+	CursorCreatedAt  pgtype.Timestamptz
+	CursorID         string
+	CursorAction     string
+	RowLimit         int32
+}
+
+func (q *Queries) ListEvents(ctx context.Context, arg ListEventsParams) ([]BillingEvent, error) {
+	rows, err := q.db.Query(ctx, listEvents,
+		arg.LcOrganizationID,
+		arg.CorrelationID,
+		arg.Action,
+		arg.Type,
+		arg.ErrorsOnly,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.CursorAction,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BillingEvent
+	for rows.Next() {
+		var i BillingEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.LcOrganizationID,
+			&i.Type,
+			&i.Action,
+			&i.Payload,
+			&i.Error,
+			&i.CreatedAt,
+			&i.TraceID,
+			&i.CorrelationID,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredEvents = `-- name: ListExpiredEvents :many
+SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id
+FROM billing_events
+WHERE created_at < $1::timestamptz
+AND ($2::text = '' OR type = $2::text)
+AND (cardinality($3::text[]) = 0 OR action = ANY($3::text[]))
+AND action <> ALL($4::text[])
+ORDER BY created_at
+LIMIT $5::int
+`
+
+type ListExpiredEventsParams struct {
+	CreatedBefore  pgtype.Timestamptz
+	Type           string
+	Actions        []string
+	ExcludeActions []string
+	RowLimit       int32
+}
+
+func (q *Queries) ListExpiredEvents(ctx context.Context, arg ListExpiredEventsParams) ([]BillingEvent, error) {
+	rows, err := q.db.Query(ctx, listExpiredEvents,
+		arg.CreatedBefore,
+		arg.Type,
+		arg.Actions,
+		arg.ExcludeActions,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BillingEvent
+	for rows.Next() {
+		var i BillingEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.LcOrganizationID,
+			&i.Type,
+			&i.Action,
+			&i.Payload,
+			&i.Error,
+			&i.CreatedAt,
+			&i.TraceID,
+			&i.CorrelationID,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockCharge = `-- name: LockCharge :exec
+SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))
+`
+
+func (q *Queries) LockCharge(ctx context.Context, dollar_1 string) error {
+	_, err := q.db.Exec(ctx, lockCharge, dollar_1)
+	return err
+}
+
+const quarantineCharge = `-- name: QuarantineCharge :exec
+UPDATE charges
+SET quarantined_at = NOW()
+WHERE id = $1
+AND deleted_at IS NULL
+AND quarantined_at IS NULL
+`
+
+func (q *Queries) QuarantineCharge(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, quarantineCharge, id)
+	return err
+}
+
+const resetChargeSyncErrorCount = `-- name: ResetChargeSyncErrorCount :exec
+UPDATE charges
+SET sync_error_count = 0,
+    last_sync_error_at = NULL,
+    quarantined_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) ResetChargeSyncErrorCount(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, resetChargeSyncErrorCount, id)
+	return err
+}
+
 const updateCharge = `-- name: UpdateCharge :exec
 UPDATE charges
 SET payload = $2
@@ -0,0 +1,2 @@
+ALTER TABLE charges ADD COLUMN quarantined_at TIMESTAMPTZ;
+CREATE INDEX idx_charges_quarantined_at ON charges(quarantined_at) WHERE quarantined_at IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,8 @@
+ALTER TABLE charges ADD COLUMN application_id varchar(255) NOT NULL DEFAULT '';
+CREATE INDEX idx_charges_application_organization ON charges(application_id, lc_organization_id);
+ALTER TABLE subscriptions ADD COLUMN application_id varchar(255) NOT NULL DEFAULT '';
+CREATE INDEX idx_subscriptions_application_organization ON subscriptions(application_id, lc_organization_id);
+CREATE OR REPLACE VIEW active_subscriptions AS
+SELECT * FROM subscriptions WHERE deleted_at IS NULL;
+ALTER TABLE trial_usage ADD COLUMN application_id varchar(255) NOT NULL DEFAULT '';
+ALTER TABLE trial_usage DROP CONSTRAINT trial_usage_pkey, ADD PRIMARY KEY (application_id, lc_organization_id);
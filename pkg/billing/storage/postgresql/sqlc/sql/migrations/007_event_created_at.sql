@@ -0,0 +1 @@
+CREATE INDEX idx_billing_events_created_at ON billing_events(created_at);
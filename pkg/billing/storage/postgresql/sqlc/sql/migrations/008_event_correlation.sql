@@ -0,0 +1,3 @@
+ALTER TABLE billing_events ADD COLUMN correlation_id varchar(36) NOT NULL DEFAULT '', ADD COLUMN parent_id varchar(36) NOT NULL DEFAULT '';
+UPDATE billing_events SET correlation_id = id WHERE correlation_id = '';
+CREATE INDEX idx_billing_events_correlation_id ON billing_events(correlation_id);
@@ -1,19 +1,27 @@
 -- name: CreateCharge :exec
-INSERT INTO charges(id, type, payload, lc_organization_id, created_at)
-VALUES ($1, $2, $3, $4, NOW());
+INSERT INTO charges(id, type, payload, lc_organization_id, application_id, created_at)
+VALUES ($1, $2, $3, $4, $5, NOW());
 
 -- name: GetChargeByID :one
 SELECT *
 FROM charges
-WHERE id = $1
+WHERE application_id = $1
+AND id = $2
 AND deleted_at IS NULL;
 
 -- name: GetChargeByOrganizationID :one
 SELECT *
 FROM charges
-WHERE lc_organization_id = $1
+WHERE application_id = $1
+AND lc_organization_id = $2
 AND deleted_at IS NULL;
 
+-- name: GetCharges :many
+SELECT *
+FROM charges
+WHERE deleted_at IS NULL
+ORDER BY created_at;
+
 -- name: UpdateCharge :exec
 UPDATE charges
 SET payload = $2
@@ -22,16 +30,23 @@ AND deleted_at IS NULL;
 
 
 -- name: CreateSubscription :exec
-INSERT INTO subscriptions(id, lc_organization_id, plan_name, charge_id, created_at)
-VALUES ($1, $2, $3, $4, NOW());
+INSERT INTO subscriptions(id, lc_organization_id, plan_name, charge_id, application_id, created_at)
+VALUES ($1, $2, $3, $4, $5, NOW());
 
 -- name: GetSubscriptionsByOrganizationID :many
 SELECT *
 FROM active_subscriptions s
-LEFT JOIN charges c on s.charge_id = c.id
-WHERE s.lc_organization_id = $1
+LEFT JOIN charges c on s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id
+WHERE s.application_id = $1
+AND s.lc_organization_id = $2
 ORDER BY s.created_at DESC;
 
+-- name: GetSubscriptions :many
+SELECT *
+FROM active_subscriptions s
+LEFT JOIN charges c on s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id
+ORDER BY s.created_at;
+
 -- name: GetSubscriptionByChargeID :one
 SELECT *
 FROM active_subscriptions
@@ -41,42 +56,79 @@ WHERE charge_id = $1;
 UPDATE subscriptions
 SET deleted_at = now()
 WHERE charge_id = $1
-AND lc_organization_id = $2;
+AND lc_organization_id = $2
+AND application_id = $3;
 
 -- name: DeleteCharge :exec
 UPDATE charges
 SET deleted_at = now()
-WHERE id = $1;
+WHERE application_id = $1
+AND id = $2;
 
 -- name: GetChargesByOrganizationID :many
 SELECT *
 FROM charges
-WHERE lc_organization_id = $1;
+WHERE application_id = $1
+AND lc_organization_id = $2;
 
 -- name: CreateEvent :exec
-INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, created_at)
-VALUES ($1, $2, $3, $4, $5, $6, NOW());
+INSERT INTO billing_events(id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW());
+
+-- name: ListEvents :many
+SELECT *
+FROM billing_events
+WHERE (sqlc.arg(lc_organization_id)::text = '' OR lc_organization_id = sqlc.arg(lc_organization_id)::text)
+AND (sqlc.arg(correlation_id)::text = '' OR correlation_id = sqlc.arg(correlation_id)::text)
+AND (sqlc.arg(action)::text = '' OR action = sqlc.arg(action)::text)
+AND (sqlc.arg(type)::text = '' OR type = sqlc.arg(type)::text)
+AND (NOT sqlc.arg(errors_only)::bool OR COALESCE(error, '') <> '')
+AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from)::timestamptz)
+AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to)::timestamptz)
+AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id, action) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::text, sqlc.arg(cursor_action)::text))
+ORDER BY created_at DESC, id DESC, action DESC
+LIMIT sqlc.arg(row_limit)::int;
+
+-- name: ListExpiredEvents :many
+SELECT *
+FROM billing_events
+WHERE created_at < sqlc.arg(created_before)::timestamptz
+AND (sqlc.arg(type)::text = '' OR type = sqlc.arg(type)::text)
+AND (cardinality(sqlc.arg(actions)::text[]) = 0 OR action = ANY(sqlc.arg(actions)::text[]))
+AND action <> ALL(sqlc.arg(exclude_actions)::text[])
+ORDER BY created_at
+LIMIT sqlc.arg(row_limit)::int;
+
+-- name: DeleteEvents :execrows
+DELETE FROM billing_events
+WHERE (id, action) IN (SELECT unnest(sqlc.arg(ids)::text[]), unnest(sqlc.arg(actions)::text[]));
 
 -- name: GetChargesByStatuses :many
 SELECT *
 FROM charges
 WHERE payload->>'status' = ANY($1::text[])
-AND deleted_at IS NULL;
+AND deleted_at IS NULL
+AND (
+    sync_error_count = 0
+    OR last_sync_error_at IS NULL
+    OR last_sync_error_at + make_interval(secs => LEAST($2::float8 * power($3::float8, LEAST(sync_error_count - 1, $4::int)), $5::float8)) <= NOW()
+);
 
 -- name: DeleteSubscription :exec
 UPDATE subscriptions
 SET deleted_at = NOW()
-WHERE id = $1 and lc_organization_id = $2;
+WHERE id = $1 and lc_organization_id = $2 and application_id = $3;
 
 -- name: CreateTrialUsage :exec
-INSERT INTO trial_usage (lc_organization_id)
-VALUES ($1)
-ON CONFLICT (lc_organization_id) DO NOTHING;
+INSERT INTO trial_usage (application_id, lc_organization_id)
+VALUES ($1, $2)
+ON CONFLICT (application_id, lc_organization_id) DO NOTHING;
 
 -- name: HasTrialUsage :one
 SELECT EXISTS(
     SELECT 1 FROM trial_usage
-    WHERE lc_organization_id = $1
+    WHERE application_id = $1
+    AND lc_organization_id = $2
 );
 
 -- name: IncrementChargeSyncErrorCount :exec
@@ -86,8 +138,53 @@ SET sync_error_count = sync_error_count + 1,
 WHERE id = $1
 AND deleted_at IS NULL;
 
+-- name: ResetChargeSyncErrorCount :exec
+UPDATE charges
+SET sync_error_count = 0,
+    last_sync_error_at = NULL,
+    quarantined_at = NULL
+WHERE id = $1;
+
 -- name: GetChargesWithHighErrorCount :many
 SELECT *
 FROM charges
 WHERE sync_error_count >= $1
-AND deleted_at IS NULL;
\ No newline at end of file
+AND deleted_at IS NULL
+AND quarantined_at IS NULL;
+
+-- name: QuarantineCharge :exec
+UPDATE charges
+SET quarantined_at = NOW()
+WHERE id = $1
+AND deleted_at IS NULL
+AND quarantined_at IS NULL;
+
+-- name: GetQuarantinedCharges :many
+SELECT *
+FROM charges
+WHERE quarantined_at IS NOT NULL
+AND deleted_at IS NULL
+ORDER BY quarantined_at;
+
+-- name: LockCharge :exec
+SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0));
+
+-- name: BackfillChargesApplicationID :execrows
+UPDATE charges
+SET application_id = $1
+WHERE application_id = '';
+
+-- name: BackfillSubscriptionsApplicationID :execrows
+UPDATE subscriptions
+SET application_id = $1
+WHERE application_id = '';
+
+-- name: BackfillTrialUsageApplicationID :execrows
+UPDATE trial_usage
+SET application_id = $1
+WHERE application_id = ''
+AND NOT EXISTS (
+    SELECT 1 FROM trial_usage t
+    WHERE t.application_id = $1
+    AND t.lc_organization_id = trial_usage.lc_organization_id
+);
\ No newline at end of file
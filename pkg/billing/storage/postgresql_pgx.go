@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"github.com/livechat-integrations/go-billing-sdk/v2/internal/lock"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/billing"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/billing/storage/postgresql/sqlc"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
@@ -18,17 +20,23 @@ type PGXConn interface {
 	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
 	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
 	QueryRow(context.Context, string, ...interface{}) pgx.Row
+	Begin(context.Context) (pgx.Tx, error)
 }
 
 // Make sure its Storage implementation
 var _ billing.Storage = (*PostgresqlPGX)(nil)
+var _ events.Storage = (*PostgresqlPGX)(nil)
+var _ events.Reader = (*PostgresqlPGX)(nil)
+var _ events.RetentionStorage = (*PostgresqlPGX)(nil)
 
 type PostgresqlPGX struct {
+	conn    PGXConn
 	queries *sqlc.Queries
 }
 
 func NewPostgresqlPGX(conn PGXConn) *PostgresqlPGX {
 	return &PostgresqlPGX{
+		conn:    conn,
 		queries: sqlc.New(conn),
 	}
 }
@@ -39,10 +47,11 @@ func (r *PostgresqlPGX) CreateCharge(ctx context.Context, c billing.Charge) erro
 		return err
 	}
 
-	if err = r.queries.CreateCharge(ctx, sqlc.CreateChargeParams{
+	if err = r.q(ctx).CreateCharge(ctx, sqlc.CreateChargeParams{
 		ID:               c.ID,
 		Type:             string(c.Type),
 		LcOrganizationID: c.LCOrganizationID,
+		ApplicationID:    c.ApplicationID,
 		Payload:          rawPayload,
 	}); err != nil {
 		return err
@@ -51,8 +60,11 @@ func (r *PostgresqlPGX) CreateCharge(ctx context.Context, c billing.Charge) erro
 	return nil
 }
 
-func (r *PostgresqlPGX) GetCharge(ctx context.Context, id string) (*billing.Charge, error) {
-	row, err := r.queries.GetChargeByID(ctx, id)
+func (r *PostgresqlPGX) GetCharge(ctx context.Context, applicationID, id string) (*billing.Charge, error) {
+	row, err := r.q(ctx).GetChargeByID(ctx, sqlc.GetChargeByIDParams{
+		ApplicationID: applicationID,
+		ID:            id,
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -63,8 +75,11 @@ func (r *PostgresqlPGX) GetCharge(ctx context.Context, id string) (*billing.Char
 	return row.ToBillingCharge(), nil
 }
 
-func (r *PostgresqlPGX) GetChargeByOrganizationID(ctx context.Context, lcID string) (*billing.Charge, error) {
-	row, err := r.queries.GetChargeByOrganizationID(ctx, lcID)
+func (r *PostgresqlPGX) GetChargeByOrganizationID(ctx context.Context, applicationID, lcID string) (*billing.Charge, error) {
+	row, err := r.q(ctx).GetChargeByOrganizationID(ctx, sqlc.GetChargeByOrganizationIDParams{
+		ApplicationID:    applicationID,
+		LcOrganizationID: lcID,
+	})
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -77,18 +92,19 @@ func (r *PostgresqlPGX) GetChargeByOrganizationID(ctx context.Context, lcID stri
 }
 
 func (r *PostgresqlPGX) UpdateChargePayload(ctx context.Context, id string, payload json.RawMessage) error {
-	return r.queries.UpdateCharge(ctx, sqlc.UpdateChargeParams{
+	return r.q(ctx).UpdateCharge(ctx, sqlc.UpdateChargeParams{
 		ID:      id,
 		Payload: payload,
 	})
 }
 
 func (r *PostgresqlPGX) CreateSubscription(ctx context.Context, subscription billing.Subscription) error {
-	if err := r.queries.CreateSubscription(ctx, sqlc.CreateSubscriptionParams{
+	if err := r.q(ctx).CreateSubscription(ctx, sqlc.CreateSubscriptionParams{
 		ID:               subscription.ID,
 		LcOrganizationID: subscription.LCOrganizationID,
 		PlanName:         subscription.PlanName,
 		ChargeID:         pgtype.Text{String: subscription.Charge.ID, Valid: true},
+		ApplicationID:    subscription.ApplicationID,
 	}); err != nil {
 		return err
 	}
@@ -96,8 +112,11 @@ func (r *PostgresqlPGX) CreateSubscription(ctx context.Context, subscription bil
 	return nil
 }
 
-func (r *PostgresqlPGX) GetSubscriptionsByOrganizationID(ctx context.Context, lcID string) ([]billing.Subscription, error) {
-	rows, err := r.queries.GetSubscriptionsByOrganizationID(ctx, lcID)
+func (r *PostgresqlPGX) GetSubscriptionsByOrganizationID(ctx context.Context, applicationID, lcID string) ([]billing.Subscription, error) {
+	rows, err := r.q(ctx).GetSubscriptionsByOrganizationID(ctx, sqlc.GetSubscriptionsByOrganizationIDParams{
+		ApplicationID:    applicationID,
+		LcOrganizationID: lcID,
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -112,14 +131,31 @@ func (r *PostgresqlPGX) GetSubscriptionsByOrganizationID(ctx context.Context, lc
 	return subscriptions, nil
 }
 
-func (r *PostgresqlPGX) DeleteCharge(ctx context.Context, id string) error {
-	return r.queries.DeleteCharge(ctx, id)
+func (r *PostgresqlPGX) GetSubscriptions(ctx context.Context) ([]billing.Subscription, error) {
+	rows, err := r.q(ctx).GetSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscriptions []billing.Subscription
+	for _, row := range rows {
+		subscriptions = append(subscriptions, *row.ToBillingSubscription())
+	}
+	return subscriptions, nil
+}
+
+func (r *PostgresqlPGX) DeleteCharge(ctx context.Context, applicationID, id string) error {
+	return r.q(ctx).DeleteCharge(ctx, sqlc.DeleteChargeParams{
+		ApplicationID: applicationID,
+		ID:            id,
+	})
 }
 
-func (r *PostgresqlPGX) DeleteSubscriptionByChargeID(ctx context.Context, lcID string, id string) error {
-	err := r.queries.DeleteSubscriptionByChargeID(ctx, sqlc.DeleteSubscriptionByChargeIDParams{
+func (r *PostgresqlPGX) DeleteSubscriptionByChargeID(ctx context.Context, applicationID, lcID string, id string) error {
+	err := r.q(ctx).DeleteSubscriptionByChargeID(ctx, sqlc.DeleteSubscriptionByChargeIDParams{
 		ChargeID:         pgtype.Text{String: id, Valid: true},
 		LcOrganizationID: lcID,
+		ApplicationID:    applicationID,
 	})
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return billing.ErrSubscriptionNotFound
@@ -128,8 +164,24 @@ func (r *PostgresqlPGX) DeleteSubscriptionByChargeID(ctx context.Context, lcID s
 	return err
 }
 
-func (r *PostgresqlPGX) GetChargesByOrganizationID(ctx context.Context, lcID string) ([]billing.Charge, error) {
-	rows, err := r.queries.GetChargesByOrganizationID(ctx, lcID)
+func (r *PostgresqlPGX) GetCharges(ctx context.Context) ([]billing.Charge, error) {
+	rows, err := r.q(ctx).GetCharges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var charges []billing.Charge
+	for _, row := range rows {
+		charges = append(charges, *row.ToBillingCharge())
+	}
+	return charges, nil
+}
+
+func (r *PostgresqlPGX) GetChargesByOrganizationID(ctx context.Context, applicationID, lcID string) ([]billing.Charge, error) {
+	rows, err := r.q(ctx).GetChargesByOrganizationID(ctx, sqlc.GetChargesByOrganizationIDParams{
+		ApplicationID:    applicationID,
+		LcOrganizationID: lcID,
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -145,7 +197,7 @@ func (r *PostgresqlPGX) GetChargesByOrganizationID(ctx context.Context, lcID str
 }
 
 func (r *PostgresqlPGX) CreateEvent(ctx context.Context, e events.Event) error {
-	err := r.queries.CreateEvent(ctx, sqlc.CreateEventParams{
+	err := r.q(ctx).CreateEvent(ctx, sqlc.CreateEventParams{
 		ID:               e.ID,
 		LcOrganizationID: e.LCOrganizationID,
 		Type:             string(e.Type),
@@ -155,6 +207,9 @@ func (r *PostgresqlPGX) CreateEvent(ctx context.Context, e events.Event) error {
 			String: e.Error,
 			Valid:  true,
 		},
+		TraceID:       e.TraceID,
+		CorrelationID: e.CorrelationID,
+		ParentID:      e.ParentID,
 	})
 	if err != nil {
 		return err
@@ -162,8 +217,99 @@ func (r *PostgresqlPGX) CreateEvent(ctx context.Context, e events.Event) error {
 	return nil
 }
 
-func (r *PostgresqlPGX) GetChargesByStatuses(ctx context.Context, statuses []string) ([]billing.Charge, error) {
-	rows, err := r.queries.GetChargesByStatuses(ctx, statuses)
+// ListEvents returns a page of billing_events, newest first.
+func (r *PostgresqlPGX) ListEvents(ctx context.Context, filter events.ListEventsFilter) (*events.EventsPage, error) {
+	cursor, err := events.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := filter.PageLimit()
+	params := sqlc.ListEventsParams{
+		LcOrganizationID: filter.OrganizationID,
+		CorrelationID:    filter.CorrelationID,
+		Action:           string(filter.Action),
+		Type:             string(filter.Type),
+		ErrorsOnly:       filter.ErrorsOnly,
+		CreatedFrom:      toPGTimestamptz(filter.From),
+		CreatedTo:        toPGTimestamptz(filter.To),
+		RowLimit:         int32(limit + 1),
+	}
+	if cursor != nil {
+		params.CursorCreatedAt = toPGTimestamptz(cursor.CreatedAt)
+		params.CursorID = cursor.ID
+		params.CursorAction = string(cursor.Action)
+	}
+
+	rows, err := r.q(ctx).ListEvents(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	evs := make([]events.Event, 0, len(rows))
+	for _, row := range rows {
+		evs = append(evs, *row.ToEvent())
+	}
+	return events.NewEventsPage(evs, limit), nil
+}
+
+// ListExpiredEvents returns up to params.Limit billing_events matching params, oldest first.
+func (r *PostgresqlPGX) ListExpiredEvents(ctx context.Context, params events.ExpiredEventsParams) ([]events.Event, error) {
+	rows, err := r.q(ctx).ListExpiredEvents(ctx, sqlc.ListExpiredEventsParams{
+		CreatedBefore:  toPGTimestamptz(params.CreatedBefore),
+		Type:           string(params.Type),
+		Actions:        toActionStrings(params.Actions),
+		ExcludeActions: toActionStrings(params.ExcludeActions),
+		RowLimit:       int32(params.Limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	evs := make([]events.Event, 0, len(rows))
+	for _, row := range rows {
+		evs = append(evs, *row.ToEvent())
+	}
+	return evs, nil
+}
+
+// DeleteEvents deletes billing_events by their ID and action.
+func (r *PostgresqlPGX) DeleteEvents(ctx context.Context, evs []events.Event) (int64, error) {
+	params := sqlc.DeleteEventsParams{
+		Ids:     make([]string, 0, len(evs)),
+		Actions: make([]string, 0, len(evs)),
+	}
+	for _, e := range evs {
+		params.Ids = append(params.Ids, e.ID)
+		params.Actions = append(params.Actions, string(e.Action))
+	}
+
+	return r.q(ctx).DeleteEvents(ctx, params)
+}
+
+// toPGTimestamptz maps the zero time to NULL.
+func toPGTimestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: !t.IsZero()}
+}
+
+// toActionStrings never returns nil, a NULL array would not match any row.
+func toActionStrings(actions []events.EventAction) []string {
+	res := make([]string, 0, len(actions))
+	for _, a := range actions {
+		res = append(res, string(a))
+	}
+	return res
+}
+
+func (r *PostgresqlPGX) GetChargesByStatuses(ctx context.Context, statuses []string, backoff billing.BackoffPolicy) ([]billing.Charge, error) {
+	initial, multiplier, maxDelay, maxExponent := backoff.SQLArgs()
+	rows, err := r.q(ctx).GetChargesByStatuses(ctx, sqlc.GetChargesByStatusesParams{
+		Column1: statuses,
+		Column2: initial,
+		Column3: multiplier,
+		Column4: int32(maxExponent),
+		Column5: maxDelay,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -176,27 +322,56 @@ func (r *PostgresqlPGX) GetChargesByStatuses(ctx context.Context, statuses []str
 	return charges, nil
 }
 
-func (r *PostgresqlPGX) DeleteSubscription(ctx context.Context, lcID, subID string) error {
-	return r.queries.DeleteSubscription(ctx, sqlc.DeleteSubscriptionParams{
+func (r *PostgresqlPGX) DeleteSubscription(ctx context.Context, applicationID, lcID, subID string) error {
+	return r.q(ctx).DeleteSubscription(ctx, sqlc.DeleteSubscriptionParams{
 		ID:               subID,
 		LcOrganizationID: lcID,
+		ApplicationID:    applicationID,
 	})
 }
 
-func (r *PostgresqlPGX) RecordTrialUsage(ctx context.Context, lcOrganizationID string) error {
-	return r.queries.CreateTrialUsage(ctx, lcOrganizationID)
+func (r *PostgresqlPGX) RecordTrialUsage(ctx context.Context, applicationID, lcOrganizationID string) error {
+	return r.q(ctx).CreateTrialUsage(ctx, sqlc.CreateTrialUsageParams{
+		ApplicationID:    applicationID,
+		LcOrganizationID: lcOrganizationID,
+	})
 }
 
-func (r *PostgresqlPGX) HasUsedTrial(ctx context.Context, lcOrganizationID string) (bool, error) {
-	return r.queries.HasTrialUsage(ctx, lcOrganizationID)
+func (r *PostgresqlPGX) HasUsedTrial(ctx context.Context, applicationID, lcOrganizationID string) (bool, error) {
+	return r.q(ctx).HasTrialUsage(ctx, sqlc.HasTrialUsageParams{
+		ApplicationID:    applicationID,
+		LcOrganizationID: lcOrganizationID,
+	})
+}
+
+// BackfillApplicationID tags the rows table by table, a failed backfill can be run again.
+func (r *PostgresqlPGX) BackfillApplicationID(ctx context.Context, applicationID string) (int64, error) {
+	var total int64
+	for _, backfill := range []func(context.Context, string) (int64, error){
+		r.q(ctx).BackfillChargesApplicationID,
+		r.q(ctx).BackfillSubscriptionsApplicationID,
+		r.q(ctx).BackfillTrialUsageApplicationID,
+	} {
+		n, err := backfill(ctx, applicationID)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+
+	return total, nil
 }
 
 func (r *PostgresqlPGX) IncrementChargeSyncErrorCount(ctx context.Context, chargeID string) error {
-	return r.queries.IncrementChargeSyncErrorCount(ctx, chargeID)
+	return r.q(ctx).IncrementChargeSyncErrorCount(ctx, chargeID)
+}
+
+func (r *PostgresqlPGX) ResetChargeSyncErrorCount(ctx context.Context, chargeID string) error {
+	return r.q(ctx).ResetChargeSyncErrorCount(ctx, chargeID)
 }
 
 func (r *PostgresqlPGX) GetChargesWithHighErrorCount(ctx context.Context, threshold int) ([]billing.Charge, error) {
-	rows, err := r.queries.GetChargesWithHighErrorCount(ctx, int32(threshold))
+	rows, err := r.q(ctx).GetChargesWithHighErrorCount(ctx, int32(threshold))
 	if err != nil {
 		return nil, err
 	}
@@ -208,3 +383,38 @@ func (r *PostgresqlPGX) GetChargesWithHighErrorCount(ctx context.Context, thresh
 
 	return charges, nil
 }
+
+func (r *PostgresqlPGX) QuarantineCharge(ctx context.Context, chargeID string) error {
+	return r.q(ctx).QuarantineCharge(ctx, chargeID)
+}
+
+func (r *PostgresqlPGX) GetQuarantinedCharges(ctx context.Context) ([]billing.Charge, error) {
+	rows, err := r.q(ctx).GetQuarantinedCharges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var charges []billing.Charge
+	for _, row := range rows {
+		charges = append(charges, *row.ToBillingCharge())
+	}
+
+	return charges, nil
+}
+
+// WithChargeLock holds a transaction scoped advisory lock while fn runs. The queries fn makes on this storage run in
+// the lock transaction, so a locked charge never needs a second connection from the pool.
+func (r *PostgresqlPGX) WithChargeLock(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	return lock.WithTx(ctx, r, r.conn, chargeLockName(id), func(ctx context.Context, tx pgx.Tx) error {
+		return r.queries.WithTx(tx).LockCharge(ctx, chargeLockName(id))
+	}, fn)
+}
+
+// q returns the queries of the lock transaction held in ctx, or of the connection outside of WithChargeLock.
+func (r *PostgresqlPGX) q(ctx context.Context) *sqlc.Queries {
+	if tx, ok := lock.Conn[pgx.Tx](ctx, r); ok {
+		return r.queries.WithTx(tx)
+	}
+
+	return r.queries
+}
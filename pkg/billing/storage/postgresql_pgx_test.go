@@ -4,20 +4,34 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 
-	"github.com/livechat-integrations/go-billing-sdk/v2/internal/livechat"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/billing"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
 )
 
 var dbMock, _ = pgxmock.NewConn()
 var s = NewPostgresqlPGX(dbMock)
 
+// execCounter counts the statements executed outside of a transaction.
+type execCounter struct {
+	PGXConn
+	execs int
+}
+
+func (c *execCounter) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	c.execs++
+	return c.PGXConn.Exec(ctx, sql, args...)
+}
+
 func TestNewPostgresqlSQLC(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		assert.NotNil(t, NewPostgresqlPGX(dbMock))
@@ -28,7 +42,7 @@ func TestPostgresqlSQLC_CreateCharge(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		emptyRawPayload, _ := json.Marshal(json.RawMessage("{}"))
 		dbMock.ExpectExec("INSERT INTO charges").
-			WithArgs("1", "recurring", emptyRawPayload, "lcOrganizationID").
+			WithArgs("1", "recurring", emptyRawPayload, "lcOrganizationID", "app1").
 			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
 
 		err := s.CreateCharge(context.Background(), billing.Charge{
@@ -36,6 +50,7 @@ func TestPostgresqlSQLC_CreateCharge(t *testing.T) {
 			Type:             billing.ChargeTypeRecurring,
 			Payload:          json.RawMessage("{}"),
 			LCOrganizationID: "lcOrganizationID",
+			ApplicationID:    "app1",
 		})
 		assert.NoError(t, err)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
@@ -45,7 +60,7 @@ func TestPostgresqlSQLC_CreateCharge(t *testing.T) {
 		emptyRawPayload, _ := json.Marshal(json.RawMessage("{}"))
 		dbMock.
 			ExpectExec("INSERT INTO charges").
-			WithArgs("1", "recurring", emptyRawPayload, "lcOrganizationID").Times(1).
+			WithArgs("1", "recurring", emptyRawPayload, "lcOrganizationID", "app1").Times(1).
 			WillReturnError(assert.AnError)
 
 		err := s.CreateCharge(context.Background(), billing.Charge{
@@ -53,6 +68,7 @@ func TestPostgresqlSQLC_CreateCharge(t *testing.T) {
 			Type:             billing.ChargeTypeRecurring,
 			Payload:          json.RawMessage("{}"),
 			LCOrganizationID: "lcOrganizationID",
+			ApplicationID:    "app1",
 		})
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
@@ -63,13 +79,14 @@ func TestPostgresqlSQLC_CreateSubscription(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		dbMock.
 			ExpectExec("INSERT INTO subscriptions").
-			WithArgs("1", "lcOrganizationID", "planName", pgtype.Text{String: "chargeID", Valid: true}).
+			WithArgs("1", "lcOrganizationID", "planName", pgtype.Text{String: "chargeID", Valid: true}, "app1").
 			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
 
 		err := s.CreateSubscription(context.Background(), billing.Subscription{
 			ID:               "1",
 			LCOrganizationID: "lcOrganizationID",
 			PlanName:         "planName",
+			ApplicationID:    "app1",
 			Charge: &billing.Charge{
 				ID: "chargeID",
 			},
@@ -79,13 +96,14 @@ func TestPostgresqlSQLC_CreateSubscription(t *testing.T) {
 
 	t.Run("error", func(t *testing.T) {
 		dbMock.ExpectExec("INSERT INTO subscriptions").
-			WithArgs("1", "lcOrganizationID", "planName", pgtype.Text{String: "chargeID", Valid: true}).Times(1).
+			WithArgs("1", "lcOrganizationID", "planName", pgtype.Text{String: "chargeID", Valid: true}, "app1").Times(1).
 			WillReturnError(assert.AnError)
 
 		err := s.CreateSubscription(context.Background(), billing.Subscription{
 			ID:               "1",
 			LCOrganizationID: "lcOrganizationID",
 			PlanName:         "planName",
+			ApplicationID:    "app1",
 			Charge: &billing.Charge{
 				ID: "chargeID",
 			},
@@ -97,13 +115,13 @@ func TestPostgresqlSQLC_CreateSubscription(t *testing.T) {
 
 func TestPostgresqlSQLC_GetCharge(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges").
-			WithArgs("1").
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs("app1", "1").
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at"}).
-					AddRow("1", "lcOrganizationID", "recurring", []byte("{}"), nil, nil, int32(0), nil)).Times(1)
+				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at", "quarantined_at", "application_id"}).
+					AddRow("1", "lcOrganizationID", "recurring", []byte("{}"), nil, nil, int32(0), nil, nil, "app1")).Times(1)
 
-		c, err := s.GetCharge(context.Background(), "1")
+		c, err := s.GetCharge(context.Background(), "app1", "1")
 		assert.NoError(t, err)
 		assert.Equal(t, "1", c.ID)
 		assert.Equal(t, "lcOrganizationID", c.LCOrganizationID)
@@ -114,22 +132,22 @@ func TestPostgresqlSQLC_GetCharge(t *testing.T) {
 	})
 
 	t.Run("no rows", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges").
-			WithArgs("1").Times(1).
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs("app1", "1").Times(1).
 			WillReturnError(pgx.ErrNoRows)
 
-		c, err := s.GetCharge(context.Background(), "1")
+		c, err := s.GetCharge(context.Background(), "app1", "1")
 		assert.NoError(t, err)
 		assert.Nil(t, c)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 
 	t.Run("error", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges").
-			WithArgs("1").Times(1).
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs("app1", "1").Times(1).
 			WillReturnError(assert.AnError)
 
-		_, err := s.GetCharge(context.Background(), "1")
+		_, err := s.GetCharge(context.Background(), "app1", "1")
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
@@ -137,13 +155,13 @@ func TestPostgresqlSQLC_GetCharge(t *testing.T) {
 
 func TestPostgresqlSQLC_GetChargeByOrganizationID(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges").
-			WithArgs("lcOrganizationID").
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs("app1", "lcOrganizationID").
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at"}).
-					AddRow("1", "lcOrganizationID", "recurring", []byte("{}"), nil, nil, int32(0), nil)).Times(1)
+				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at", "quarantined_at", "application_id"}).
+					AddRow("1", "lcOrganizationID", "recurring", []byte("{}"), nil, nil, int32(0), nil, nil, "app1")).Times(1)
 
-		c, err := s.GetChargeByOrganizationID(context.Background(), "lcOrganizationID")
+		c, err := s.GetChargeByOrganizationID(context.Background(), "app1", "lcOrganizationID")
 		assert.NoError(t, err)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 		assert.Equal(t, "1", c.ID)
@@ -153,22 +171,22 @@ func TestPostgresqlSQLC_GetChargeByOrganizationID(t *testing.T) {
 	})
 
 	t.Run("no rows", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges").
-			WithArgs("lcOrganizationID").Times(1).
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs("app1", "lcOrganizationID").Times(1).
 			WillReturnError(pgx.ErrNoRows)
 
-		c, err := s.GetChargeByOrganizationID(context.Background(), "lcOrganizationID")
+		c, err := s.GetChargeByOrganizationID(context.Background(), "app1", "lcOrganizationID")
 		assert.NoError(t, err)
 		assert.Nil(t, c)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 
 	t.Run("error", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges").
-			WithArgs("lcOrganizationID").Times(1).
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs("app1", "lcOrganizationID").Times(1).
 			WillReturnError(assert.AnError)
 
-		_, err := s.GetChargeByOrganizationID(context.Background(), "lcOrganizationID")
+		_, err := s.GetChargeByOrganizationID(context.Background(), "app1", "lcOrganizationID")
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
@@ -176,37 +194,39 @@ func TestPostgresqlSQLC_GetChargeByOrganizationID(t *testing.T) {
 
 func TestPostgresqlSQLC_GetSubscriptionsByOrganizationID(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT s.id, s.lc_organization_id, plan_name, charge_id, s.created_at, s.deleted_at, c.id, c.lc_organization_id, type, payload, c.created_at, c.deleted_at, sync_error_count, last_sync_error_at FROM active_subscriptions s LEFT JOIN charges c on s.charge_id = c.id").
-			WithArgs("lcOrganizationID").
-			WillReturnRows(pgxmock.NewRows([]string{"id", "lc_organization_id", "plan_name", "charge_id", "created_at", "deleted_at", "id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at"}).
-				AddRow("1", "lcOrganizationID", "planName", "chargeID", "2024-10-20 13:31:27Z", nil, "chargeID", "lcOrganizationID", "recurring", []byte(`{"created_at": "2017-10-20T13:31:27Z"}`), "2024-10-20 13:31:27Z", nil, pgtype.Int4{Int32: 0, Valid: true}, nil)).Times(1)
+		dbMock.ExpectQuery("SELECT s.id, s.lc_organization_id, plan_name, charge_id, s.created_at, s.deleted_at, s.application_id, c.id, c.lc_organization_id, type, payload, c.created_at, c.deleted_at, sync_error_count, last_sync_error_at, quarantined_at, c.application_id FROM active_subscriptions s LEFT JOIN charges c on s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id").
+			WithArgs("app1", "lcOrganizationID").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "lc_organization_id", "plan_name", "charge_id", "created_at", "deleted_at", "application_id", "id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at", "quarantined_at", "application_id"}).
+				AddRow("1", "lcOrganizationID", "planName", "chargeID", "2024-10-20 13:31:27Z", nil, "app1", "chargeID", "lcOrganizationID", "recurring", []byte(`{"created_at": "2017-10-20T13:31:27Z"}`), "2024-10-20 13:31:27Z", nil, pgtype.Int4{Int32: 0, Valid: true}, nil, nil, pgtype.Text{String: "app1", Valid: true})).Times(1)
 
-		c, err := s.GetSubscriptionsByOrganizationID(context.Background(), "lcOrganizationID")
+		c, err := s.GetSubscriptionsByOrganizationID(context.Background(), "app1", "lcOrganizationID")
 		assert.NoError(t, err)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 		assert.Equal(t, "1", c[0].ID)
 		assert.Equal(t, "lcOrganizationID", c[0].LCOrganizationID)
 		assert.Equal(t, "planName", c[0].PlanName)
 		assert.Equal(t, "chargeID", c[0].Charge.ID)
+		assert.Equal(t, "app1", c[0].ApplicationID)
+		assert.Equal(t, "app1", c[0].Charge.ApplicationID)
 	})
 
 	t.Run("no rows", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT s.id, s.lc_organization_id, plan_name, charge_id, s.created_at, s.deleted_at, c.id, c.lc_organization_id, type, payload, c.created_at, c.deleted_at, sync_error_count, last_sync_error_at FROM active_subscriptions s LEFT JOIN charges c on s.charge_id = c.id").
-			WithArgs("lcOrganizationID").Times(1).
+		dbMock.ExpectQuery("SELECT s.id, s.lc_organization_id, plan_name, charge_id, s.created_at, s.deleted_at, s.application_id, c.id, c.lc_organization_id, type, payload, c.created_at, c.deleted_at, sync_error_count, last_sync_error_at, quarantined_at, c.application_id FROM active_subscriptions s LEFT JOIN charges c on s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id").
+			WithArgs("app1", "lcOrganizationID").Times(1).
 			WillReturnError(pgx.ErrNoRows)
 
-		c, err := s.GetSubscriptionsByOrganizationID(context.Background(), "lcOrganizationID")
+		c, err := s.GetSubscriptionsByOrganizationID(context.Background(), "app1", "lcOrganizationID")
 		assert.NoError(t, err)
 		assert.Nil(t, c)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 
 	t.Run("error", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT s.id, s.lc_organization_id, plan_name, charge_id, s.created_at, s.deleted_at, c.id, c.lc_organization_id, type, payload, c.created_at, c.deleted_at, sync_error_count, last_sync_error_at FROM active_subscriptions s LEFT JOIN charges c on s.charge_id = c.id").
-			WithArgs("lcOrganizationID").Times(1).
+		dbMock.ExpectQuery("SELECT s.id, s.lc_organization_id, plan_name, charge_id, s.created_at, s.deleted_at, s.application_id, c.id, c.lc_organization_id, type, payload, c.created_at, c.deleted_at, sync_error_count, last_sync_error_at, quarantined_at, c.application_id FROM active_subscriptions s LEFT JOIN charges c on s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id").
+			WithArgs("app1", "lcOrganizationID").Times(1).
 			WillReturnError(assert.AnError)
 
-		_, err := s.GetSubscriptionsByOrganizationID(context.Background(), "lcOrganizationID")
+		_, err := s.GetSubscriptionsByOrganizationID(context.Background(), "app1", "lcOrganizationID")
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
@@ -250,20 +270,20 @@ func TestPostgresqlSQLC_UpdateChargePayload(t *testing.T) {
 func TestPostgresqlPGX_DeleteCharge(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		dbMock.ExpectExec("UPDATE charges SET deleted_at").
-			WithArgs("1").
+			WithArgs("app1", "1").
 			WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
 
-		err := s.DeleteCharge(context.Background(), "1")
+		err := s.DeleteCharge(context.Background(), "app1", "1")
 		assert.NoError(t, err)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 
 	t.Run("error", func(t *testing.T) {
 		dbMock.ExpectExec("UPDATE charges SET deleted_at").
-			WithArgs("1").Times(1).
+			WithArgs("app1", "1").Times(1).
 			WillReturnError(assert.AnError)
 
-		err := s.DeleteCharge(context.Background(), "1")
+		err := s.DeleteCharge(context.Background(), "app1", "1")
 		assert.Error(t, err)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
@@ -272,20 +292,20 @@ func TestPostgresqlPGX_DeleteCharge(t *testing.T) {
 func TestPostgresqlPGX_DeleteSubscriptionByChargeID(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		dbMock.ExpectExec("UPDATE subscriptions SET deleted_at = now()").
-			WithArgs(pgtype.Text{String: "1", Valid: true}, "lcoid").
+			WithArgs(pgtype.Text{String: "1", Valid: true}, "lcoid", "app1").
 			WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
 
-		err := s.DeleteSubscriptionByChargeID(context.Background(), "lcoid", "1")
+		err := s.DeleteSubscriptionByChargeID(context.Background(), "app1", "lcoid", "1")
 		assert.NoError(t, err)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 
 	t.Run("error", func(t *testing.T) {
 		dbMock.ExpectExec("UPDATE subscriptions SET deleted_at = now()").
-			WithArgs(pgtype.Text{String: "1", Valid: true}, "lcoid").Times(1).
+			WithArgs(pgtype.Text{String: "1", Valid: true}, "lcoid", "app1").Times(1).
 			WillReturnError(assert.AnError)
 
-		err := s.DeleteSubscriptionByChargeID(context.Background(), "lcoid", "1")
+		err := s.DeleteSubscriptionByChargeID(context.Background(), "app1", "lcoid", "1")
 		assert.Error(t, err)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
@@ -293,13 +313,13 @@ func TestPostgresqlPGX_DeleteSubscriptionByChargeID(t *testing.T) {
 
 func TestPostgresqlPGX_GetChargesByOrganizationID(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges").
-			WithArgs("lcOrganizationID").
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs("app1", "lcOrganizationID").
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at"}).
-					AddRow("1", "lcOrganizationID", "recurring", []byte("{}"), nil, nil, int32(0), nil)).Times(1)
+				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at", "quarantined_at", "application_id"}).
+					AddRow("1", "lcOrganizationID", "recurring", []byte("{}"), nil, nil, int32(0), nil, nil, "app1")).Times(1)
 
-		c, err := s.GetChargesByOrganizationID(context.Background(), "lcOrganizationID")
+		c, err := s.GetChargesByOrganizationID(context.Background(), "app1", "lcOrganizationID")
 		assert.NoError(t, err)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 		assert.Equal(t, "1", c[0].ID)
@@ -309,22 +329,379 @@ func TestPostgresqlPGX_GetChargesByOrganizationID(t *testing.T) {
 	})
 
 	t.Run("no rows", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges").
-			WithArgs("lcOrganizationID").Times(1).
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs("app1", "lcOrganizationID").Times(1).
 			WillReturnError(pgx.ErrNoRows)
 
-		c, err := s.GetChargesByOrganizationID(context.Background(), "lcOrganizationID")
+		c, err := s.GetChargesByOrganizationID(context.Background(), "app1", "lcOrganizationID")
 		assert.NoError(t, err)
 		assert.Nil(t, c)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 
 	t.Run("error", func(t *testing.T) {
-		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at FROM charges").
-			WithArgs("lcOrganizationID").Times(1).
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs("app1", "lcOrganizationID").Times(1).
+			WillReturnError(assert.AnError)
+
+		_, err := s.GetChargesByOrganizationID(context.Background(), "app1", "lcOrganizationID")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_GetChargesByStatuses(t *testing.T) {
+	backoff := billing.BackoffPolicy{Initial: 5 * time.Minute, Max: 24 * time.Hour, Multiplier: 2}
+
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs([]string{"active"}, float64(300), float64(2), int32(64), float64(86400)).
+			WillReturnRows(
+				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at", "quarantined_at", "application_id"}).
+					AddRow("1", "lcOrganizationID", "recurring", []byte(`{"status":"active"}`), nil, nil, int32(0), nil, nil, "app1")).Times(1)
+
+		charges, err := s.GetChargesByStatuses(context.Background(), []string{"active"}, backoff)
+		assert.NoError(t, err)
+		assert.Len(t, charges, 1)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges").
+			WithArgs([]string{"active"}, float64(300), float64(2), int32(64), float64(86400)).Times(1).
+			WillReturnError(assert.AnError)
+
+		_, err := s.GetChargesByStatuses(context.Background(), []string{"active"}, backoff)
+		assert.Error(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_ResetChargeSyncErrorCount(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectExec("UPDATE charges SET sync_error_count = 0").
+			WithArgs("1").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+		err := s.ResetChargeSyncErrorCount(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_QuarantineCharge(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectExec("UPDATE charges SET quarantined_at = NOW()").
+			WithArgs("1").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+		err := s.QuarantineCharge(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_GetQuarantinedCharges(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		quarantinedAt := time.Now()
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE quarantined_at IS NOT NULL").
+			WillReturnRows(
+				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at", "quarantined_at", "application_id"}).
+					AddRow("1", "lcOrganizationID", "recurring", []byte("{}"), nil, nil, int32(10), nil, pgtype.Timestamptz{Time: quarantinedAt, Valid: true}, "app1")).Times(1)
+
+		charges, err := s.GetQuarantinedCharges(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, charges, 1)
+		assert.Equal(t, &quarantinedAt, charges[0].QuarantinedAt)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE quarantined_at IS NOT NULL").
+			Times(1).
+			WillReturnError(assert.AnError)
+
+		_, err := s.GetQuarantinedCharges(context.Background())
+		assert.Error(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_GetCharges(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, payload, created_at, deleted_at, sync_error_count, last_sync_error_at, quarantined_at, application_id FROM charges WHERE deleted_at IS NULL ORDER BY created_at").
+			WillReturnRows(
+				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at", "quarantined_at", "application_id"}).
+					AddRow("1", "lcOrganizationID", "recurring", []byte(`{"status":"active"}`), nil, nil, int32(0), nil, nil, "app1")).Times(1)
+
+		charges, err := s.GetCharges(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, charges, 1)
+		assert.Equal(t, livechat.RecurrentChargeStatusActive, charges[0].Status)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_GetSubscriptions(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT s.id, s.lc_organization_id, plan_name, charge_id, s.created_at, s.deleted_at, s.application_id, c.id, c.lc_organization_id, type, payload, c.created_at, c.deleted_at, sync_error_count, last_sync_error_at, quarantined_at, c.application_id FROM active_subscriptions s LEFT JOIN charges c on s.charge_id = c.id AND s.lc_organization_id = c.lc_organization_id ORDER BY s.created_at").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "lc_organization_id", "plan_name", "charge_id", "created_at", "deleted_at", "application_id", "id", "lc_organization_id", "type", "payload", "created_at", "deleted_at", "sync_error_count", "last_sync_error_at", "quarantined_at", "application_id"}).
+				AddRow("1", "lcOrganizationID", "planName", "chargeID", "2024-10-20 13:31:27Z", nil, "app1", "chargeID", "lcOrganizationID", "recurring", []byte(`{"created_at": "2017-10-20T13:31:27Z"}`), "2024-10-20 13:31:27Z", "2024-10-21 13:31:27Z", pgtype.Int4{Int32: 0, Valid: true}, nil, nil, pgtype.Text{String: "app1", Valid: true})).Times(1)
+
+		subs, err := s.GetSubscriptions(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, subs, 1)
+		assert.Equal(t, "chargeID", subs[0].Charge.ID)
+		assert.NotNil(t, subs[0].Charge.CanceledAt)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectQuery("FROM active_subscriptions s LEFT JOIN charges c").Times(1).
+			WillReturnError(assert.AnError)
+
+		_, err := s.GetSubscriptions(context.Background())
+		assert.Error(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_BackfillApplicationID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectExec("UPDATE charges SET application_id = \\$1 WHERE application_id = ''").
+			WithArgs("app1").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 2)).Times(1)
+		dbMock.ExpectExec("UPDATE subscriptions SET application_id = \\$1 WHERE application_id = ''").
+			WithArgs("app1").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+		dbMock.ExpectExec("UPDATE trial_usage SET application_id = \\$1 WHERE application_id = ''").
+			WithArgs("app1").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+
+		n, err := s.BackfillApplicationID(context.Background(), "app1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), n)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectExec("UPDATE charges SET application_id = \\$1 WHERE application_id = ''").
+			WithArgs("app1").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 2)).Times(1)
+		dbMock.ExpectExec("UPDATE subscriptions SET application_id = \\$1 WHERE application_id = ''").
+			WithArgs("app1").Times(1).
+			WillReturnError(assert.AnError)
+
+		_, err := s.BackfillApplicationID(context.Background(), "app1")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_WithChargeLock(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs("charge:1").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1)).Times(1)
+		dbMock.ExpectCommit()
+
+		calls := 0
+		err := s.WithChargeLock(context.Background(), "1", func(ctx context.Context) error {
+			calls++
+			// nested calls for the same charge reuse the lock
+			return s.WithChargeLock(ctx, "1", func(ctx context.Context) error {
+				calls++
+				return nil
+			})
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("fn error", func(t *testing.T) {
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs("charge:1").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1)).Times(1)
+		// the writes fn made before failing are kept
+		dbMock.ExpectCommit()
+
+		err := s.WithChargeLock(context.Background(), "1", func(ctx context.Context) error {
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("queries of fn run in the lock transaction", func(t *testing.T) {
+		conn := &execCounter{PGXConn: dbMock}
+		s := NewPostgresqlPGX(conn)
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs("charge:1").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1)).Times(1)
+		dbMock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs("charge:2").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1)).Times(1)
+		dbMock.ExpectExec("UPDATE charges SET quarantined_at = NOW()").
+			WithArgs("2").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1)).Times(1)
+		dbMock.ExpectCommit()
+
+		err := s.WithChargeLock(context.Background(), "1", func(ctx context.Context) error {
+			// another charge is locked in the same transaction
+			return s.WithChargeLock(ctx, "2", func(ctx context.Context) error {
+				return s.QuarantineCharge(ctx, "2")
+			})
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, conn.execs)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("lock error", func(t *testing.T) {
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs("charge:1").Times(1).
+			WillReturnError(assert.AnError)
+		dbMock.ExpectRollback()
+
+		err := s.WithChargeLock(context.Background(), "1", func(ctx context.Context) error {
+			t.Fatal("fn should not be called")
+			return nil
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_ListEvents(t *testing.T) {
+	createdAt := time.Date(2025, 4, 2, 15, 4, 5, 0, time.UTC)
+	columns := []string{"id", "lc_organization_id", "type", "action", "payload", "error", "created_at", "trace_id", "correlation_id", "parent_id"}
+
+	t.Run("success", func(t *testing.T) {
+		from := createdAt.Add(-time.Hour)
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id FROM billing_events").
+			WithArgs("lcOrganizationID", "corr", "", string(events.EventTypeError), true, pgtype.Timestamptz{Time: from, Valid: true}, pgtype.Timestamptz{}, pgtype.Timestamptz{}, "", "", int32(2)).
+			WillReturnRows(
+				pgxmock.NewRows(columns).
+					AddRow("2", "lcOrganizationID", "error", "top_up", []byte(`{"a":1}`), pgtype.Text{String: "boom", Valid: true}, pgtype.Timestamptz{Time: createdAt, Valid: true}, "trace", "corr", "1").
+					AddRow("1", "lcOrganizationID", "error", "top_up", []byte(`{}`), pgtype.Text{String: "boom", Valid: true}, pgtype.Timestamptz{Time: createdAt, Valid: true}, "", "corr", "")).Times(1)
+
+		page, err := s.ListEvents(context.Background(), events.ListEventsFilter{
+			OrganizationID: "lcOrganizationID",
+			CorrelationID:  "corr",
+			Type:           events.EventTypeError,
+			ErrorsOnly:     true,
+			From:           from,
+			Limit:          1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []events.Event{{
+			ID:               "2",
+			CorrelationID:    "corr",
+			ParentID:         "1",
+			LCOrganizationID: "lcOrganizationID",
+			Type:             events.EventTypeError,
+			Action:           events.EventActionTopUp,
+			Payload:          json.RawMessage(`{"a":1}`),
+			Error:            "boom",
+			TraceID:          "trace",
+			CreatedAt:        createdAt,
+		}}, page.Events)
+		assert.NotEmpty(t, page.NextCursor)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id FROM billing_events").
+			WithArgs("", "", "", "", false, pgtype.Timestamptz{}, pgtype.Timestamptz{}, pgtype.Timestamptz{Time: createdAt, Valid: true}, "2", "top_up", int32(2)).
+			WillReturnRows(pgxmock.NewRows(columns)).Times(1)
+
+		page, err = s.ListEvents(context.Background(), events.ListEventsFilter{Cursor: page.NextCursor, Limit: 1})
+		assert.NoError(t, err)
+		assert.Empty(t, page.Events)
+		assert.Empty(t, page.NextCursor)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, err := s.ListEvents(context.Background(), events.ListEventsFilter{Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, events.ErrInvalidCursor)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id FROM billing_events").
+			WithArgs("", "", "", "", false, pgtype.Timestamptz{}, pgtype.Timestamptz{}, pgtype.Timestamptz{}, "", "", int32(events.DefaultListEventsLimit+1)).
+			Times(1).
+			WillReturnError(assert.AnError)
+
+		_, err := s.ListEvents(context.Background(), events.ListEventsFilter{})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_ListExpiredEvents(t *testing.T) {
+	createdBefore := time.Date(2025, 4, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id FROM billing_events WHERE created_at <").
+			WithArgs(pgtype.Timestamptz{Time: createdBefore, Valid: true}, "info", []string{}, []string{"top_up"}, int32(10)).
+			WillReturnRows(
+				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "action", "payload", "error", "created_at", "trace_id", "correlation_id", "parent_id"}).
+					AddRow("1", "lcOrganizationID", "info", "create_charge", []byte(`{}`), pgtype.Text{}, pgtype.Timestamptz{Time: createdBefore, Valid: true}, "", "1", "")).Times(1)
+
+		evs, err := s.ListExpiredEvents(context.Background(), events.ExpiredEventsParams{
+			CreatedBefore:  createdBefore,
+			Type:           events.EventTypeInfo,
+			ExcludeActions: []events.EventAction{events.EventActionTopUp},
+			Limit:          10,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, evs, 1)
+		assert.Equal(t, events.EventActionCreateCharge, evs[0].Action)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectQuery("FROM billing_events WHERE created_at <").
+			WithArgs(pgtype.Timestamptz{Time: createdBefore, Valid: true}, "", []string{"top_up"}, []string{}, int32(10)).
+			Times(1).
+			WillReturnError(assert.AnError)
+
+		_, err := s.ListExpiredEvents(context.Background(), events.ExpiredEventsParams{
+			CreatedBefore: createdBefore,
+			Actions:       []events.EventAction{events.EventActionTopUp},
+			Limit:         10,
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_DeleteEvents(t *testing.T) {
+	evs := []events.Event{{ID: "1", Action: events.EventActionCreateCharge}, {ID: "1", Action: events.EventActionTopUp}}
+
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectExec("DELETE FROM billing_events").
+			WithArgs([]string{"1", "1"}, []string{"create_charge", "top_up"}).
+			WillReturnResult(pgxmock.NewResult("DELETE", 2)).Times(1)
+
+		deleted, err := s.DeleteEvents(context.Background(), evs)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectExec("DELETE FROM billing_events").
+			WithArgs([]string{"1", "1"}, []string{"create_charge", "top_up"}).
+			Times(1).
 			WillReturnError(assert.AnError)
 
-		_, err := s.GetChargesByOrganizationID(context.Background(), "lcOrganizationID")
+		_, err := s.DeleteEvents(context.Background(), evs)
 		assert.ErrorIs(t, err, assert.AnError)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
@@ -0,0 +1,84 @@
+package billing
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	DefaultMaxSyncErrors = 10
+
+	// maxBackoffExponent keeps the backoff curve finite for charges with a very high error count.
+	maxBackoffExponent = 64
+)
+
+// SyncPolicy controls how SyncCharges retries charges that fail to sync and when CleanupFailedCharges gives up on them.
+type SyncPolicy struct {
+	// MaxSyncErrors is the sync error count at which CleanupFailedCharges picks up a charge.
+	MaxSyncErrors int
+	Backoff       BackoffPolicy
+}
+
+// BackoffPolicy describes the wait after the last sync error before a charge is synced again:
+// Initial * Multiplier^(SyncErrorCount-1), capped at Max. The zero value disables backoff.
+type BackoffPolicy struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+func DefaultSyncPolicy() SyncPolicy {
+	return SyncPolicy{
+		MaxSyncErrors: DefaultMaxSyncErrors,
+		Backoff: BackoffPolicy{
+			Initial:    5 * time.Minute,
+			Max:        24 * time.Hour,
+			Multiplier: 2,
+		},
+	}
+}
+
+func (p SyncPolicy) maxSyncErrors() int {
+	if p.MaxSyncErrors <= 0 {
+		return DefaultMaxSyncErrors
+	}
+
+	return p.MaxSyncErrors
+}
+
+// Delay returns how long a charge with the given sync error count waits since its last sync error.
+func (b BackoffPolicy) Delay(syncErrorCount int) time.Duration {
+	if syncErrorCount <= 0 || b.Initial <= 0 {
+		return 0
+	}
+
+	exponent := math.Min(float64(syncErrorCount-1), maxBackoffExponent)
+	delay := float64(b.Initial) * math.Pow(b.multiplier(), exponent)
+	if delay > float64(b.max()) {
+		return b.max()
+	}
+
+	return time.Duration(delay)
+}
+
+func (b BackoffPolicy) multiplier() float64 {
+	if b.Multiplier < 1 {
+		return 1
+	}
+
+	return b.Multiplier
+}
+
+func (b BackoffPolicy) max() time.Duration {
+	if b.Max <= 0 {
+		// without a cap the curve still has to fit into a database interval
+		return 365 * 24 * time.Hour
+	}
+
+	return b.Max
+}
+
+// SQLArgs returns the curve as seconds for storage queries: initial delay, multiplier, max delay and the exponent cap.
+func (b BackoffPolicy) SQLArgs() (initialSeconds, multiplier, maxSeconds float64, maxExponent int) {
+	return b.Initial.Seconds(), b.multiplier(), b.max().Seconds(), maxBackoffExponent
+}
@@ -0,0 +1,44 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffPolicy_Delay(t *testing.T) {
+	policy := BackoffPolicy{Initial: time.Minute, Max: time.Hour, Multiplier: 2}
+
+	t.Run("no errors", func(t *testing.T) {
+		assert.Equal(t, time.Duration(0), policy.Delay(0))
+	})
+
+	t.Run("grows exponentially", func(t *testing.T) {
+		assert.Equal(t, time.Minute, policy.Delay(1))
+		assert.Equal(t, 2*time.Minute, policy.Delay(2))
+		assert.Equal(t, 8*time.Minute, policy.Delay(4))
+	})
+
+	t.Run("capped at max", func(t *testing.T) {
+		assert.Equal(t, time.Hour, policy.Delay(7))
+		assert.Equal(t, time.Hour, policy.Delay(1000))
+	})
+
+	t.Run("zero value disables backoff", func(t *testing.T) {
+		assert.Equal(t, time.Duration(0), BackoffPolicy{}.Delay(5))
+	})
+
+	t.Run("multiplier below one keeps a constant delay", func(t *testing.T) {
+		assert.Equal(t, time.Minute, BackoffPolicy{Initial: time.Minute, Multiplier: 0.5}.Delay(5))
+	})
+}
+
+func TestBackoffPolicy_SQLArgs(t *testing.T) {
+	initial, multiplier, maxDelay, maxExponent := DefaultSyncPolicy().Backoff.SQLArgs()
+
+	assert.Equal(t, float64(300), initial)
+	assert.Equal(t, float64(2), multiplier)
+	assert.Equal(t, float64(86400), maxDelay)
+	assert.Equal(t, maxBackoffExponent, maxExponent)
+}
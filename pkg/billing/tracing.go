@@ -0,0 +1,202 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+// WithTracerProvider starts a span for every Service method, storage call and LiveChat Billing API request with a
+// tracer of tp, a nil tp uses the global provider. Without it nothing is traced, the trace context of the caller is
+// still propagated to the API and its trace ID stored in the events.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Service) {
+		s.tracer = tracing.Tracer(tp)
+		s.apiOptions = append(s.apiOptions, livechat.WithTracerProvider(tp))
+	}
+}
+
+// WithHandlerTracerProvider starts a span for every handled webhook with a tracer of tp, a nil tp uses the global provider.
+func WithHandlerTracerProvider(tp trace.TracerProvider) HandlerOption {
+	return func(h *Handler) {
+		h.tracer = tracing.Tracer(tp)
+	}
+}
+
+// Make sure its Storage implementation
+var _ Storage = (*tracingStorage)(nil)
+
+// tracingStorage starts a span for every call of the wrapped Storage.
+type tracingStorage struct {
+	Storage
+	tracer trace.Tracer
+}
+
+func (t *tracingStorage) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, t.tracer, "billing.storage."+name, attrs...)
+}
+
+func (t *tracingStorage) CreateCharge(ctx context.Context, ic Charge) (err error) {
+	ctx, span := t.start(ctx, "CreateCharge", tracing.OrganizationIDKey.String(ic.LCOrganizationID), tracing.ChargeIDKey.String(ic.ID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.CreateCharge(ctx, ic)
+}
+
+func (t *tracingStorage) GetCharge(ctx context.Context, applicationID, id string) (_ *Charge, err error) {
+	ctx, span := t.start(ctx, "GetCharge", tracing.ChargeIDKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetCharge(ctx, applicationID, id)
+}
+
+func (t *tracingStorage) UpdateChargePayload(ctx context.Context, id string, payload json.RawMessage) (err error) {
+	ctx, span := t.start(ctx, "UpdateChargePayload", tracing.ChargeIDKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.UpdateChargePayload(ctx, id, payload)
+}
+
+func (t *tracingStorage) DeleteCharge(ctx context.Context, applicationID, id string) (err error) {
+	ctx, span := t.start(ctx, "DeleteCharge", tracing.ChargeIDKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.DeleteCharge(ctx, applicationID, id)
+}
+
+func (t *tracingStorage) GetChargesByOrganizationID(ctx context.Context, applicationID, lcID string) (_ []Charge, err error) {
+	ctx, span := t.start(ctx, "GetChargesByOrganizationID", tracing.OrganizationIDKey.String(lcID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetChargesByOrganizationID(ctx, applicationID, lcID)
+}
+
+func (t *tracingStorage) GetCharges(ctx context.Context) (_ []Charge, err error) {
+	ctx, span := t.start(ctx, "GetCharges")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetCharges(ctx)
+}
+
+func (t *tracingStorage) GetChargesByStatuses(ctx context.Context, statuses []string, backoff BackoffPolicy) (_ []Charge, err error) {
+	ctx, span := t.start(ctx, "GetChargesByStatuses")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetChargesByStatuses(ctx, statuses, backoff)
+}
+
+func (t *tracingStorage) IncrementChargeSyncErrorCount(ctx context.Context, chargeID string) (err error) {
+	ctx, span := t.start(ctx, "IncrementChargeSyncErrorCount", tracing.ChargeIDKey.String(chargeID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.IncrementChargeSyncErrorCount(ctx, chargeID)
+}
+
+func (t *tracingStorage) ResetChargeSyncErrorCount(ctx context.Context, chargeID string) (err error) {
+	ctx, span := t.start(ctx, "ResetChargeSyncErrorCount", tracing.ChargeIDKey.String(chargeID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.ResetChargeSyncErrorCount(ctx, chargeID)
+}
+
+func (t *tracingStorage) GetChargesWithHighErrorCount(ctx context.Context, threshold int) (_ []Charge, err error) {
+	ctx, span := t.start(ctx, "GetChargesWithHighErrorCount")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetChargesWithHighErrorCount(ctx, threshold)
+}
+
+func (t *tracingStorage) QuarantineCharge(ctx context.Context, chargeID string) (err error) {
+	ctx, span := t.start(ctx, "QuarantineCharge", tracing.ChargeIDKey.String(chargeID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.QuarantineCharge(ctx, chargeID)
+}
+
+func (t *tracingStorage) GetQuarantinedCharges(ctx context.Context) (_ []Charge, err error) {
+	ctx, span := t.start(ctx, "GetQuarantinedCharges")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetQuarantinedCharges(ctx)
+}
+
+// WithChargeLock spans the time the lock is held, fn included.
+func (t *tracingStorage) WithChargeLock(ctx context.Context, id string, fn func(ctx context.Context) error) (err error) {
+	ctx, span := t.start(ctx, "WithChargeLock", tracing.ChargeIDKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.WithChargeLock(ctx, id, fn)
+}
+
+func (t *tracingStorage) CreateSubscription(ctx context.Context, subscription Subscription) (err error) {
+	attrs := []attribute.KeyValue{tracing.OrganizationIDKey.String(subscription.LCOrganizationID)}
+	if subscription.Charge != nil {
+		attrs = append(attrs, tracing.ChargeIDKey.String(subscription.Charge.ID))
+	}
+	ctx, span := t.start(ctx, "CreateSubscription", attrs...)
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.CreateSubscription(ctx, subscription)
+}
+
+func (t *tracingStorage) GetSubscriptionsByOrganizationID(ctx context.Context, applicationID, lcID string) (_ []Subscription, err error) {
+	ctx, span := t.start(ctx, "GetSubscriptionsByOrganizationID", tracing.OrganizationIDKey.String(lcID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetSubscriptionsByOrganizationID(ctx, applicationID, lcID)
+}
+
+func (t *tracingStorage) GetSubscriptions(ctx context.Context) (_ []Subscription, err error) {
+	ctx, span := t.start(ctx, "GetSubscriptions")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetSubscriptions(ctx)
+}
+
+func (t *tracingStorage) DeleteSubscriptionByChargeID(ctx context.Context, applicationID, lcID string, id string) (err error) {
+	ctx, span := t.start(ctx, "DeleteSubscriptionByChargeID", tracing.OrganizationIDKey.String(lcID), tracing.ChargeIDKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.DeleteSubscriptionByChargeID(ctx, applicationID, lcID, id)
+}
+
+func (t *tracingStorage) DeleteSubscription(ctx context.Context, applicationID, lcID, subID string) (err error) {
+	ctx, span := t.start(ctx, "DeleteSubscription", tracing.OrganizationIDKey.String(lcID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.DeleteSubscription(ctx, applicationID, lcID, subID)
+}
+
+func (t *tracingStorage) CreateEvent(ctx context.Context, event events.Event) (err error) {
+	ctx, span := t.start(ctx, "CreateEvent", tracing.OrganizationIDKey.String(event.LCOrganizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.CreateEvent(ctx, event)
+}
+
+func (t *tracingStorage) RecordTrialUsage(ctx context.Context, applicationID, lcOrganizationID string) (err error) {
+	ctx, span := t.start(ctx, "RecordTrialUsage", tracing.OrganizationIDKey.String(lcOrganizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.RecordTrialUsage(ctx, applicationID, lcOrganizationID)
+}
+
+func (t *tracingStorage) HasUsedTrial(ctx context.Context, applicationID, lcOrganizationID string) (_ bool, err error) {
+	ctx, span := t.start(ctx, "HasUsedTrial", tracing.OrganizationIDKey.String(lcOrganizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.HasUsedTrial(ctx, applicationID, lcOrganizationID)
+}
+
+func (t *tracingStorage) BackfillApplicationID(ctx context.Context, applicationID string) (_ int64, err error) {
+	ctx, span := t.start(ctx, "BackfillApplicationID")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.BackfillApplicationID(ctx, applicationID)
+}
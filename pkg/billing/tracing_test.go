@@ -0,0 +1,70 @@
+package billing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+func TestNewService_WithTracerProvider(t *testing.T) {
+	tokenFn := func(ctx context.Context) (string, error) { return "", nil }
+	newService := NewService(nil, nil, nil, "labs", tokenFn, sm, nil, "returnURL", "masterOrgID",
+		WithTracerProvider(sdktrace.NewTracerProvider()))
+
+	assert.NotNil(t, newService.tracer)
+	assert.Equal(t, &tracingStorage{Storage: sm, tracer: newService.tracer}, newService.storage)
+	assert.NotNil(t, newService.billingAPI.(*livechat.Api).Tracer)
+
+	newService = NewService(nil, nil, nil, "labs", tokenFn, sm, nil, "returnURL", "masterOrgID")
+	assert.Nil(t, newService.tracer)
+	assert.Equal(t, sm, newService.storage)
+}
+
+func TestService_tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	ms := s
+	ms.tracer = tracing.Tracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	ms.storage = &tracingStorage{Storage: sm, tracer: ms.tracer}
+
+	t.Run("service and storage spans", func(t *testing.T) {
+		sm.On("GetCharge", mock.Anything, "", "1").Return(&Charge{ID: "1"}, nil).Once()
+
+		_, err := ms.GetCharge(ctx, "1")
+		assert.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "billing.storage.GetCharge", spans[0].Name())
+		assert.Equal(t, "billing.GetCharge", spans[1].Name())
+		assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+		assert.Contains(t, spans[1].Attributes(), tracing.ChargeIDKey.String("1"))
+		assert.Equal(t, codes.Unset, spans[1].Status().Code)
+
+		assertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		sm.On("GetChargesByOrganizationID", mock.Anything, "", lcoid).Return(nil, assert.AnError).Once()
+
+		_, err := ms.GetChargesByOrganizationID(ctx, lcoid)
+		assert.ErrorIs(t, err, assert.AnError)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 4)
+		assert.Equal(t, "billing.GetChargesByOrganizationID", spans[3].Name())
+		assert.Contains(t, spans[3].Attributes(), tracing.OrganizationIDKey.String(lcoid))
+		assert.Equal(t, codes.Error, spans[3].Status().Code)
+		assert.Equal(t, codes.Error, spans[2].Status().Code)
+
+		assertExpectations(t)
+	})
+}
@@ -5,7 +5,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/livechat-integrations/go-billing-sdk/v2/internal/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
 )
 
 type EventType string
@@ -32,22 +35,58 @@ const (
 	EventActionSyncTopUp                        EventAction = "sync_top_up_event"
 	EventActionActivateCharge                   EventAction = "activate_charge"
 	EventActionAddVoucherFunds                  EventAction = "add_voucher_funds"
+	EventActionConvertCurrency                  EventAction = "convert_currency"
 	EventActionCleanupFailedCharge              EventAction = "cleanup_failed_charge"
+	EventActionQuarantineCharge                 EventAction = "quarantine_charge"
+	EventActionReconcile                        EventAction = "reconcile"
+	EventActionVerifyBalances                   EventAction = "verify_balances"
+	EventActionRepairBalance                    EventAction = "repair_balance"
+	EventActionJobStarted                       EventAction = "job_started"
+	EventActionJobFinished                      EventAction = "job_finished"
 	EventActionUnknown                          EventAction = "unknown"
 )
 
 type Event struct {
-	ID               string
+	// ID is unique for every event.
+	ID string
+	// CorrelationID is shared by the events of one operation, e.g. a webhook or a sync run. It is the event ID
+	// context value of the Service, the parent correlation ID or the event's own ID, in that order.
+	CorrelationID string
+	// ParentID is the ID of the event set with WithParent, empty for the root event of an operation.
+	ParentID         string
 	LCOrganizationID string
 	Type             EventType
 	Action           EventAction
 	Payload          json.RawMessage
 	Error            string
-	CreatedAt        time.Time
+	// TraceID links the event to the OpenTelemetry trace it was created in, empty outside a trace.
+	TraceID   string
+	CreatedAt time.Time
+}
+
+// LogValue logs the event without its payload.
+func (e Event) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("id", e.ID),
+		slog.String("organization_id", e.LCOrganizationID),
+		slog.String("type", string(e.Type)),
+		slog.String("action", string(e.Action)),
+	}
+	if e.CorrelationID != "" && e.CorrelationID != e.ID {
+		attrs = append(attrs, slog.String("correlation_id", e.CorrelationID))
+	}
+	if e.ParentID != "" {
+		attrs = append(attrs, slog.String("parent_id", e.ParentID))
+	}
+	if e.TraceID != "" {
+		attrs = append(attrs, slog.String("trace_id", e.TraceID))
+	}
+
+	return slog.GroupValue(attrs...)
 }
 
 func (e *Event) SetPayload(payload any) {
-	jp, err := json.Marshal(payload)
+	jp, err := json.Marshal(withVersion(payload))
 	if err == nil {
 		e.Payload = jp
 	}
@@ -57,6 +96,11 @@ type Storage interface {
 	CreateEvent(context.Context, Event) error
 }
 
+// Reader is implemented by the storages that can list their events, see Service.ListEvents.
+type Reader interface {
+	ListEvents(context.Context, ListEventsFilter) (*EventsPage, error)
+}
+
 type EventService interface {
 	CreateEvent(ctx context.Context, event Event) error
 	ToError(ctx context.Context, params ToErrorParams) error
@@ -64,21 +108,39 @@ type EventService interface {
 }
 
 type Service struct {
-	storage       Storage
-	idProvider    IdProviderInterface
-	eventIdCtxKey interface{}
+	storage        Storage
+	idProvider     IdProviderInterface
+	eventIdCtxKey  interface{}
+	logger         *slog.Logger
+	redactionRules map[EventAction][]RedactionRule
+}
+
+// Option configures optional Service behaviour.
+type Option func(*Service)
+
+// WithLogger replaces slog.Default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Service) {
+		s.logger = logger
+	}
 }
 
-func NewService(storage Storage, idProvider IdProviderInterface, eventIdCtxKey interface{}) *Service {
-	return &Service{
+func NewService(storage Storage, idProvider IdProviderInterface, eventIdCtxKey interface{}, opts ...Option) *Service {
+	s := &Service{
 		storage:       storage,
 		idProvider:    idProvider,
 		eventIdCtxKey: eventIdCtxKey,
+		logger:        slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 func (s *Service) CreateEvent(ctx context.Context, event Event) error {
-	err := s.storage.CreateEvent(ctx, event)
+	err := s.storage.CreateEvent(ctx, s.redact(event))
 	if err != nil {
 		return err
 	}
@@ -92,24 +154,41 @@ type ToErrorParams struct {
 
 func (s *Service) ToError(ctx context.Context, params ToErrorParams) error {
 	params.Event.Error = params.Err.Error()
-	_ = s.CreateEvent(ctx, params.Event)
-	return fmt.Errorf("%s: %w", params.Event.ID, params.Err)
+	// The error is returned either way, a failed write must not hide it.
+	if err := s.CreateEvent(ctx, params.Event); err != nil {
+		s.logger.ErrorContext(ctx, "failed to store error event", "event", params.Event, "error", err, "event_error", params.Err)
+	}
+	// The correlation ID finds every event of the failed operation.
+	id := params.Event.CorrelationID
+	if id == "" {
+		id = params.Event.ID
+	}
+	return fmt.Errorf("%s: %w", id, params.Err)
 }
 
 func (s *Service) ToEvent(ctx context.Context, organizationID string, action EventAction, eventType EventType, payload any) Event {
-	id, ok := ctx.Value(s.eventIdCtxKey).(string)
-	if !ok {
-		id = s.idProvider.GenerateId()
-	}
-
 	event := Event{
-		ID:               id,
+		ID:               s.idProvider.GenerateId(),
 		LCOrganizationID: organizationID,
 		Type:             eventType,
 		Action:           action,
+		TraceID:          tracing.TraceID(ctx),
 		CreatedAt:        time.Time{},
 	}
-	jp, err := json.Marshal(payload)
+
+	parent, hasParent := ctx.Value(parentCtxKey{}).(eventRef)
+	if hasParent {
+		event.ParentID = parent.id
+	}
+	if correlationID, ok := ctx.Value(s.eventIdCtxKey).(string); ok && correlationID != "" {
+		event.CorrelationID = correlationID
+	} else if hasParent && parent.correlationID != "" {
+		event.CorrelationID = parent.correlationID
+	} else {
+		event.CorrelationID = event.ID
+	}
+
+	jp, err := json.Marshal(withVersion(payload))
 	if err == nil {
 		event.Payload = jp
 	}
@@ -1,14 +1,18 @@
 package events
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var sm = new(storageMock)
@@ -22,6 +26,7 @@ var s = Service{
 	idProvider:    xm,
 	storage:       sm,
 	eventIdCtxKey: TestEventIDCtxKey{},
+	logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
 }
 
 var assertExpectations = func(t *testing.T) {
@@ -51,13 +56,28 @@ func (m *storageMock) CreateEvent(ctx context.Context, event Event) error {
 	return args.Error(0)
 }
 
+func (m *storageMock) ListEvents(ctx context.Context, filter ListEventsFilter) (*EventsPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*EventsPage), args.Error(1)
+}
+
 func TestNewService(t *testing.T) {
 	t.Run("NewService", func(t *testing.T) {
 		newService := NewService(&storageMock{}, &xIdMock{}, TestEventIDCtxKey{})
 
 		assert.NotNil(t, newService)
+		assert.Equal(t, slog.Default(), newService.logger)
 		assertExpectations(t)
 	})
+	t.Run("WithLogger", func(t *testing.T) {
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		newService := NewService(&storageMock{}, &xIdMock{}, TestEventIDCtxKey{}, WithLogger(logger))
+
+		assert.Equal(t, logger, newService.logger)
+	})
 }
 
 func TestService_ToEvent(t *testing.T) {
@@ -69,10 +89,13 @@ func TestService_ToEvent(t *testing.T) {
 		eventType := EventTypeInfo
 		payload := map[string]interface{}{"lorem": "ipsum"}
 
+		xm.On("GenerateId").Return("event-id").Once()
 		event := s.ToEvent(localCtx, lcoid, action, eventType, payload)
 
 		assert.Equal(t, eventType, event.Type)
-		assert.Equal(t, id, event.ID)
+		assert.Equal(t, "event-id", event.ID)
+		assert.Equal(t, id, event.CorrelationID)
+		assert.Empty(t, event.ParentID)
 		assert.Equal(t, action, event.Action)
 		assert.Equal(t, lcoid, event.LCOrganizationID)
 
@@ -90,8 +113,36 @@ func TestService_ToEvent(t *testing.T) {
 
 		assert.Equal(t, eventType, event.Type)
 		assert.Equal(t, id, event.ID)
+		assert.Equal(t, id, event.CorrelationID)
 		assert.Equal(t, action, event.Action)
 		assert.Equal(t, lcoid, event.LCOrganizationID)
+		assert.Empty(t, event.TraceID)
+
+		assertExpectations(t)
+	})
+	t.Run("success trace id from context", func(t *testing.T) {
+		id := "id-from-context"
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		localCtx := context.WithValue(context.Background(), TestEventIDCtxKey{}, id)
+		localCtx = trace.ContextWithSpanContext(localCtx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+		xm.On("GenerateId").Return("event-id").Once()
+		event := s.ToEvent(localCtx, "lcOrganizationID", EventActionSyncTopUp, EventTypeInfo, nil)
+
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", event.TraceID)
+
+		assertExpectations(t)
+	})
+	t.Run("success parent from context", func(t *testing.T) {
+		parent := Event{ID: "parent-id", CorrelationID: "correlation-id"}
+
+		xm.On("GenerateId").Return("event-id").Once()
+		event := s.ToEvent(WithParent(context.Background(), parent), "lcOrganizationID", EventActionSyncTopUp, EventTypeInfo, nil)
+
+		assert.Equal(t, "event-id", event.ID)
+		assert.Equal(t, "parent-id", event.ParentID)
+		assert.Equal(t, "correlation-id", event.CorrelationID)
 
 		assertExpectations(t)
 	})
@@ -156,6 +207,20 @@ func TestService_ToError(t *testing.T) {
 
 		assertExpectations(t)
 	})
+	t.Run("success correlation id", func(t *testing.T) {
+		event := Event{ID: "id", CorrelationID: "correlation-id", Error: "assert.AnError general error for testing"}
+
+		sm.On("CreateEvent", context.Background(), event).Return(nil).Once()
+		err := s.ToError(context.Background(), ToErrorParams{
+			Event: event,
+			Err:   assert.AnError,
+		})
+
+		assert.Equal(t, "correlation-id: assert.AnError general error for testing", err.Error())
+		assert.ErrorIs(t, err, assert.AnError)
+
+		assertExpectations(t)
+	})
 	t.Run("error", func(t *testing.T) {
 		event := Event{
 			ID:               "id",
@@ -167,13 +232,19 @@ func TestService_ToError(t *testing.T) {
 			CreatedAt:        time.Time{},
 		}
 
+		var buf bytes.Buffer
+		ls := s
+		ls.logger = slog.New(slog.NewTextHandler(&buf, nil))
+
 		sm.On("CreateEvent", context.Background(), event).Return(assert.AnError).Once()
-		err := s.ToError(context.Background(), ToErrorParams{
+		err := ls.ToError(context.Background(), ToErrorParams{
 			Event: event,
 			Err:   fmt.Errorf("some error"),
 		})
 
 		assert.Equal(t, "id: some error", err.Error())
+		assert.Contains(t, buf.String(), `level=ERROR msg="failed to store error event" event.id=id event.organization_id=lcOrganizationID event.type=error event.action=force_cancel_charge_event`)
+		assert.Contains(t, buf.String(), `event_error="some error"`)
 
 		assertExpectations(t)
 	})
@@ -0,0 +1,247 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Sink receives a copy of every event stored by a FanOutStorage, e.g. to publish it to a message bus.
+type Sink interface {
+	WriteEvent(ctx context.Context, event Event) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, event Event) error
+
+func (f SinkFunc) WriteEvent(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+type SinkOption func(*fanOutSink)
+
+// WithSinkActions passes only the events of the actions to the sink.
+func WithSinkActions(actions ...EventAction) SinkOption {
+	return func(s *fanOutSink) {
+		s.actions = actions
+	}
+}
+
+// WithSinkTypes passes only the events of the types to the sink.
+func WithSinkTypes(types ...EventType) SinkOption {
+	return func(s *fanOutSink) {
+		s.types = types
+	}
+}
+
+// WithSinkBuffer writes to the sink in the background through a buffer of size events, the events that don't fit
+// are dropped and logged so a slow sink never delays CreateEvent.
+func WithSinkBuffer(size int) SinkOption {
+	return func(s *fanOutSink) {
+		s.buffer = size
+	}
+}
+
+type FanOutOption func(*FanOutStorage)
+
+// WithSink adds a sink, the name tells the sinks apart in the logs.
+func WithSink(name string, sink Sink, opts ...SinkOption) FanOutOption {
+	return func(f *FanOutStorage) {
+		s := &fanOutSink{name: name, sink: sink}
+		for _, opt := range opts {
+			opt(s)
+		}
+		f.sinks = append(f.sinks, s)
+	}
+}
+
+// WithFanOutLogger sets the logger of the failed sink writes, slog.Default is used otherwise.
+func WithFanOutLogger(logger *slog.Logger) FanOutOption {
+	return func(f *FanOutStorage) {
+		f.logger = logger
+	}
+}
+
+type fanOutSink struct {
+	name    string
+	sink    Sink
+	actions []EventAction
+	types   []EventType
+	buffer  int
+	queue   chan sinkWrite
+}
+
+type sinkWrite struct {
+	ctx   context.Context
+	event Event
+}
+
+func (s *fanOutSink) accepts(event Event) bool {
+	return (len(s.actions) == 0 || slices.Contains(s.actions, event.Action)) &&
+		(len(s.types) == 0 || slices.Contains(s.types, event.Type))
+}
+
+// FanOutStorage stores events in the primary Storage and mirrors the stored ones to its sinks. Sink failures are
+// logged and never fail or undo the primary write.
+type FanOutStorage struct {
+	primary Storage
+	sinks   []*fanOutSink
+	logger  *slog.Logger
+	now     func() time.Time
+	mu      sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+var _ Storage = (*FanOutStorage)(nil)
+var _ Reader = (*FanOutStorage)(nil)
+
+func NewFanOutStorage(primary Storage, opts ...FanOutOption) *FanOutStorage {
+	f := &FanOutStorage{
+		primary: primary,
+		logger:  slog.Default(),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	for _, s := range f.sinks {
+		if s.buffer <= 0 {
+			continue
+		}
+		s.queue = make(chan sinkWrite, s.buffer)
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			for w := range s.queue {
+				f.write(w.ctx, s, w.event)
+			}
+		}()
+	}
+
+	return f
+}
+
+// CreateEvent passes the event to the sinks once the primary storage stored it.
+func (f *FanOutStorage) CreateEvent(ctx context.Context, event Event) error {
+	if err := f.primary.CreateEvent(ctx, event); err != nil {
+		return err
+	}
+	// The storages set the creation time of the stored rows themselves.
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = f.now()
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, s := range f.sinks {
+		if !s.accepts(event) {
+			continue
+		}
+		if s.queue == nil {
+			f.write(ctx, s, event)
+			continue
+		}
+		if f.closed {
+			f.logger.WarnContext(ctx, "dropped event of closed sink", "sink", s.name, "event", event)
+			continue
+		}
+		select {
+		case s.queue <- sinkWrite{ctx: context.WithoutCancel(ctx), event: event}:
+		default:
+			f.logger.WarnContext(ctx, "dropped event of full sink buffer", "sink", s.name, "event", event)
+		}
+	}
+
+	return nil
+}
+
+// ListEvents lists the events of the primary storage, which must implement Reader.
+func (f *FanOutStorage) ListEvents(ctx context.Context, filter ListEventsFilter) (*EventsPage, error) {
+	reader, ok := f.primary.(Reader)
+	if !ok {
+		return nil, ErrListEventsUnsupported
+	}
+
+	return reader.ListEvents(ctx, filter)
+}
+
+// Close stops the buffered sinks once they wrote the queued events or ctx is done.
+func (f *FanOutStorage) Close(ctx context.Context) error {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		for _, s := range f.sinks {
+			if s.queue != nil {
+				close(s.queue)
+			}
+		}
+	}
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to flush event sinks: %w", ctx.Err())
+	}
+}
+
+func (f *FanOutStorage) write(ctx context.Context, s *fanOutSink, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			f.logger.ErrorContext(ctx, "event sink panicked", "sink", s.name, "event", event, "panic", r)
+		}
+	}()
+
+	if err := s.sink.WriteEvent(ctx, event); err != nil {
+		f.logger.ErrorContext(ctx, "failed to write event to sink", "sink", s.name, "event", event, "error", err)
+	}
+}
+
+// JSONLinesSink writes every event as a line of JSON, e.g. to a file or os.Stdout.
+type JSONLinesSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{enc: json.NewEncoder(w)}
+}
+
+type jsonLinesEvent struct {
+	ID               string          `json:"id"`
+	CorrelationID    string          `json:"correlation_id,omitempty"`
+	ParentID         string          `json:"parent_id,omitempty"`
+	LCOrganizationID string          `json:"lc_organization_id"`
+	Type             EventType       `json:"type"`
+	Action           EventAction     `json:"action"`
+	Payload          json.RawMessage `json:"payload,omitempty"`
+	Error            string          `json:"error,omitempty"`
+	TraceID          string          `json:"trace_id,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+func (s *JSONLinesSink) WriteEvent(_ context.Context, event Event) error {
+	line := jsonLinesEvent(event)
+	if !json.Valid(line.Payload) {
+		line.Payload = nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.enc.Encode(line)
+}
@@ -0,0 +1,189 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+	block  chan struct{}
+}
+
+func (r *recordingSink) WriteEvent(_ context.Context, event Event) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return r.err
+}
+
+func (r *recordingSink) written() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events
+}
+
+func TestFanOutStorage_CreateEvent(t *testing.T) {
+	createdAt := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	topUp := Event{ID: "1", Type: EventTypeInfo, Action: EventActionTopUp}
+	failed := Event{ID: "2", Type: EventTypeError, Action: EventActionCreateCharge}
+	stored := func(e Event) Event {
+		e.CreatedAt = createdAt
+		return e
+	}
+
+	t.Run("success", func(t *testing.T) {
+		all, actions, types := &recordingSink{}, &recordingSink{}, &recordingSink{}
+		f := NewFanOutStorage(sm,
+			WithSink("all", all),
+			WithSink("actions", actions, WithSinkActions(EventActionTopUp)),
+			WithSink("types", types, WithSinkTypes(EventTypeError)),
+		)
+		f.now = func() time.Time { return createdAt }
+
+		sm.On("CreateEvent", context.Background(), topUp).Return(nil).Once()
+		sm.On("CreateEvent", context.Background(), failed).Return(nil).Once()
+
+		assert.NoError(t, f.CreateEvent(context.Background(), topUp))
+		assert.NoError(t, f.CreateEvent(context.Background(), failed))
+
+		assert.Equal(t, []Event{stored(topUp), stored(failed)}, all.written())
+		assert.Equal(t, []Event{stored(topUp)}, actions.written())
+		assert.Equal(t, []Event{stored(failed)}, types.written())
+		assert.NoError(t, f.Close(context.Background()))
+
+		assertExpectations(t)
+	})
+	t.Run("primary error", func(t *testing.T) {
+		sink := &recordingSink{}
+		f := NewFanOutStorage(sm, WithSink("sink", sink))
+
+		sm.On("CreateEvent", context.Background(), topUp).Return(assert.AnError).Once()
+
+		assert.ErrorIs(t, f.CreateEvent(context.Background(), topUp), assert.AnError)
+		assert.Empty(t, sink.written())
+
+		assertExpectations(t)
+	})
+	t.Run("sink failures", func(t *testing.T) {
+		var buf bytes.Buffer
+		sink := &recordingSink{}
+		f := NewFanOutStorage(sm,
+			WithSink("failing", &recordingSink{err: assert.AnError}),
+			WithSink("panicking", SinkFunc(func(context.Context, Event) error { panic("boom") })),
+			WithSink("sink", sink),
+			WithFanOutLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+		)
+		f.now = func() time.Time { return createdAt }
+
+		sm.On("CreateEvent", context.Background(), topUp).Return(nil).Once()
+
+		assert.NoError(t, f.CreateEvent(context.Background(), topUp))
+		assert.Equal(t, []Event{stored(topUp)}, sink.written())
+		assert.Contains(t, buf.String(), `msg="failed to write event to sink" sink=failing`)
+		assert.Contains(t, buf.String(), `msg="event sink panicked" sink=panicking`)
+
+		assertExpectations(t)
+	})
+	t.Run("buffered", func(t *testing.T) {
+		var buf bytes.Buffer
+		sink := &recordingSink{block: make(chan struct{})}
+		f := NewFanOutStorage(sm,
+			WithSink("slow", sink, WithSinkBuffer(1)),
+			WithFanOutLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+		)
+		f.now = func() time.Time { return createdAt }
+
+		ctx, cancel := context.WithCancel(context.Background())
+		sm.On("CreateEvent", ctx, topUp).Return(nil).Times(3)
+
+		// The first event is taken by the blocked writer, the second is buffered and the third dropped.
+		assert.NoError(t, f.CreateEvent(ctx, topUp))
+		assert.Eventually(t, func() bool { return len(f.sinks[0].queue) == 0 }, time.Second, time.Millisecond)
+		assert.NoError(t, f.CreateEvent(ctx, topUp))
+		assert.NoError(t, f.CreateEvent(ctx, topUp))
+		assert.Contains(t, buf.String(), `msg="dropped event of full sink buffer" sink=slow`)
+
+		cancel()
+		close(sink.block)
+		assert.NoError(t, f.Close(context.Background()))
+		assert.Equal(t, []Event{stored(topUp), stored(topUp)}, sink.written())
+
+		sm.On("CreateEvent", context.Background(), topUp).Return(nil).Once()
+		assert.NoError(t, f.CreateEvent(context.Background(), topUp))
+		assert.Contains(t, buf.String(), `msg="dropped event of closed sink" sink=slow`)
+
+		assertExpectations(t)
+	})
+	t.Run("close timeout", func(t *testing.T) {
+		sink := &recordingSink{block: make(chan struct{})}
+		defer close(sink.block)
+		f := NewFanOutStorage(sm, WithSink("slow", sink, WithSinkBuffer(1)), WithFanOutLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+		sm.On("CreateEvent", context.Background(), topUp).Return(nil).Once()
+		assert.NoError(t, f.CreateEvent(context.Background(), topUp))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, f.Close(ctx), context.Canceled)
+
+		assertExpectations(t)
+	})
+}
+
+func TestFanOutStorage_ListEvents(t *testing.T) {
+	page := &EventsPage{Events: []Event{{ID: "1"}}}
+	f := NewFanOutStorage(sm, WithSink("sink", &recordingSink{}))
+
+	sm.On("ListEvents", context.Background(), ListEventsFilter{Limit: 1}).Return(page, nil).Once()
+
+	res, err := f.ListEvents(context.Background(), ListEventsFilter{Limit: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, page, res)
+	assertExpectations(t)
+}
+
+func TestFanOutStorage_ListEventsWithoutReader(t *testing.T) {
+	f := NewFanOutStorage(writeOnlyStorage{})
+
+	res, err := f.ListEvents(context.Background(), ListEventsFilter{})
+
+	assert.ErrorIs(t, err, ErrListEventsUnsupported)
+	assert.Nil(t, res)
+}
+
+func TestJSONLinesSink_WriteEvent(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONLinesSink(&buf)
+	createdAt := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, sink.WriteEvent(context.Background(), Event{
+		ID:               "2",
+		CorrelationID:    "1",
+		ParentID:         "1",
+		LCOrganizationID: "lcOrganizationID",
+		Type:             EventTypeInfo,
+		Action:           EventActionTopUp,
+		Payload:          json.RawMessage(`{"amount":1}`),
+		CreatedAt:        createdAt,
+	}))
+	assert.NoError(t, sink.WriteEvent(context.Background(), Event{ID: "3", Type: EventTypeError, Error: "boom", Payload: json.RawMessage(`{`), CreatedAt: createdAt}))
+
+	assert.Equal(t, `{"id":"2","correlation_id":"1","parent_id":"1","lc_organization_id":"lcOrganizationID","type":"info","action":"top_up","payload":{"amount":1},"created_at":"2025-03-14T12:00:00Z"}
+{"id":"3","lc_organization_id":"","type":"error","action":"","error":"boom","created_at":"2025-03-14T12:00:00Z"}
+`, buf.String())
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+var (
+	// ErrUnknownPayload is returned for the events of an action without a registered payload.
+	ErrUnknownPayload = errors.New("unknown event payload")
+	// ErrUnsupportedPayloadVersion is returned for a payload stored with a newer schema version than the registered one.
+	ErrUnsupportedPayloadVersion = errors.New("unsupported event payload version")
+	// ErrLegacyPayload is returned for a payload stored before the typed payloads, its keys (e.g. chargeID or planName)
+	// or a raw LiveChat charge don't match the typed payload, read Event.Payload instead.
+	ErrLegacyPayload = errors.New("legacy event payload")
+)
+
+// Payload is a typed event payload, a struct with a `Version int` field that ToEvent and Event.SetPayload set to
+// PayloadVersion. The version changes whenever the shape of the payload does. Payloads stored before the typed
+// payloads have version 0 and are not decoded, see ErrLegacyPayload.
+type Payload interface {
+	PayloadVersion() int
+}
+
+// PayloadRegistry maps the event actions to their payload types, e.g. to decode the events of an events table.
+type PayloadRegistry struct {
+	types map[EventAction]reflect.Type
+}
+
+func NewPayloadRegistry() *PayloadRegistry {
+	return &PayloadRegistry{types: map[EventAction]reflect.Type{}}
+}
+
+// Register panics when the action is already registered or the payload isn't a struct, the packages using the
+// same action for different payloads register them to the registries of their own events tables.
+func (r *PayloadRegistry) Register(action EventAction, payload Payload) {
+	t := reflect.TypeOf(payload)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("events: payload of %s is not a struct", action))
+	}
+	if _, ok := r.types[action]; ok {
+		panic(fmt.Sprintf("events: payload of %s registered twice", action))
+	}
+
+	r.types[action] = t
+}
+
+// Actions returns the registered actions, sorted.
+func (r *PayloadRegistry) Actions() []EventAction {
+	actions := make([]EventAction, 0, len(r.types))
+	for action := range r.types {
+		actions = append(actions, action)
+	}
+	slices.Sort(actions)
+
+	return actions
+}
+
+// Decode returns a pointer to the typed payload of the event, e.g. *billing.CreateChargeEventPayload.
+func (r *PayloadRegistry) Decode(e Event) (Payload, error) {
+	t, ok := r.types[e.Action]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPayload, e.Action)
+	}
+
+	p := reflect.New(t).Interface().(Payload)
+	if err := decodePayload(e, p, p.PayloadVersion()); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// DecodePayload decodes the payload of the event into T, the caller knows the payload type from the event action.
+func DecodePayload[T Payload](e Event) (T, error) {
+	var p T
+	if err := decodePayload(e, &p, p.PayloadVersion()); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func decodePayload(e Event, target any, current int) error {
+	if len(e.Payload) == 0 {
+		return nil
+	}
+
+	var v struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(e.Payload, &v); err != nil || v.Version == 0 {
+		return fmt.Errorf("%w: %s", ErrLegacyPayload, e.Action)
+	}
+	if v.Version > current {
+		return fmt.Errorf("%w: %s payload version %d, expected up to %d", ErrUnsupportedPayloadVersion, e.Action, v.Version, current)
+	}
+	if err := json.Unmarshal(e.Payload, target); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", e.Action, err)
+	}
+
+	return nil
+}
+
+// withVersion returns a copy of a typed payload with its version set, other payloads are returned as they are.
+func withVersion(payload any) any {
+	p, ok := payload.(Payload)
+	if !ok {
+		return payload
+	}
+
+	v := reflect.ValueOf(p)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return payload
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return payload
+	}
+
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	if f := c.FieldByName("Version"); f.IsValid() && f.CanSet() && f.Kind() == reflect.Int {
+		f.SetInt(int64(p.PayloadVersion()))
+	}
+
+	return c.Interface()
+}
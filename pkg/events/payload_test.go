@@ -0,0 +1,136 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPayload struct {
+	Version int    `json:"version"`
+	ID      string `json:"id"`
+	Amount  int    `json:"amount,omitempty"`
+}
+
+func (testPayload) PayloadVersion() int { return 2 }
+
+func TestPayloadRegistry_Register(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		r := NewPayloadRegistry()
+		r.Register(EventActionTopUp, testPayload{})
+		r.Register(EventActionCreateCharge, &testPayload{})
+
+		assert.Equal(t, []EventAction{EventActionCreateCharge, EventActionTopUp}, r.Actions())
+	})
+
+	t.Run("registered twice", func(t *testing.T) {
+		r := NewPayloadRegistry()
+		r.Register(EventActionTopUp, testPayload{})
+
+		assert.Panics(t, func() { r.Register(EventActionTopUp, testPayload{}) })
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		assert.Panics(t, func() { NewPayloadRegistry().Register(EventActionTopUp, nil) })
+	})
+}
+
+func TestPayloadRegistry_Decode(t *testing.T) {
+	r := NewPayloadRegistry()
+	r.Register(EventActionTopUp, testPayload{})
+
+	t.Run("success", func(t *testing.T) {
+		p, err := r.Decode(Event{Action: EventActionTopUp, Payload: []byte(`{"version":2,"id":"id","amount":5}`)})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &testPayload{Version: 2, ID: "id", Amount: 5}, p)
+	})
+
+	t.Run("success empty payload", func(t *testing.T) {
+		p, err := r.Decode(Event{Action: EventActionTopUp})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &testPayload{}, p)
+	})
+
+	t.Run("legacy payload", func(t *testing.T) {
+		p, err := r.Decode(Event{Action: EventActionTopUp, Payload: []byte(`{"id":"id"}`)})
+
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrLegacyPayload)
+	})
+
+	t.Run("legacy non object payload", func(t *testing.T) {
+		p, err := r.Decode(Event{Action: EventActionTopUp, Payload: []byte(`"id"`)})
+
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrLegacyPayload)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		p, err := r.Decode(Event{Action: EventActionCreateCharge, Payload: []byte(`{}`)})
+
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrUnknownPayload)
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		p, err := r.Decode(Event{Action: EventActionTopUp, Payload: []byte(`{"version":3,"id":"id"}`)})
+
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrUnsupportedPayloadVersion)
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		p, err := r.Decode(Event{Action: EventActionTopUp, Payload: []byte(`{"version":2,"id":1}`)})
+
+		assert.Nil(t, p)
+		assert.ErrorContains(t, err, "failed to decode top_up payload")
+	})
+}
+
+func TestDecodePayload(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		p, err := DecodePayload[testPayload](Event{Action: EventActionTopUp, Payload: []byte(`{"version":2,"id":"id"}`)})
+
+		assert.NoError(t, err)
+		assert.Equal(t, testPayload{Version: 2, ID: "id"}, p)
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		_, err := DecodePayload[testPayload](Event{Action: EventActionTopUp, Payload: []byte(`{"version":3}`)})
+
+		assert.ErrorIs(t, err, ErrUnsupportedPayloadVersion)
+	})
+
+	t.Run("legacy payload", func(t *testing.T) {
+		_, err := DecodePayload[testPayload](Event{Action: EventActionTopUp, Payload: []byte(`{"id":"id","amount":5}`)})
+
+		assert.ErrorIs(t, err, ErrLegacyPayload)
+	})
+}
+
+func TestEvent_SetPayload(t *testing.T) {
+	t.Run("typed payload", func(t *testing.T) {
+		p := testPayload{ID: "id"}
+		e := Event{}
+		e.SetPayload(p)
+
+		assert.JSONEq(t, `{"version":2,"id":"id"}`, string(e.Payload))
+		assert.Equal(t, 0, p.Version)
+	})
+
+	t.Run("typed payload pointer", func(t *testing.T) {
+		e := Event{}
+		e.SetPayload(&testPayload{ID: "id"})
+
+		assert.JSONEq(t, `{"version":2,"id":"id"}`, string(e.Payload))
+	})
+
+	t.Run("untyped payload", func(t *testing.T) {
+		e := Event{}
+		e.SetPayload(map[string]any{"id": "id"})
+
+		assert.JSONEq(t, `{"id":"id"}`, string(e.Payload))
+	})
+}
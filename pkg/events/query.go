@@ -0,0 +1,119 @@
+package events
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultListEventsLimit = 50
+	MaxListEventsLimit     = 500
+)
+
+var (
+	// ErrInvalidCursor is returned for a ListEventsFilter.Cursor that was not taken from an EventsPage.
+	ErrInvalidCursor = errors.New("invalid events cursor")
+	// ErrListEventsUnsupported is returned by ListEvents when the storage doesn't implement Reader.
+	ErrListEventsUnsupported = errors.New("events storage can't list events")
+)
+
+// ListEventsFilter selects the events returned by ListEvents, zero fields don't filter.
+type ListEventsFilter struct {
+	OrganizationID string
+	// CorrelationID lists the events of one operation, see BuildEventTree.
+	CorrelationID string
+	Action        EventAction
+	Type          EventType
+	// ErrorsOnly keeps the events with an error message.
+	ErrorsOnly bool
+	// From and To limit CreatedAt to [From, To).
+	From time.Time
+	To   time.Time
+	// Cursor continues after the last event of a previous page, see EventsPage.NextCursor.
+	Cursor string
+	// Limit defaults to DefaultListEventsLimit and is capped at MaxListEventsLimit.
+	Limit int
+}
+
+// PageLimit returns the number of events a page holds.
+func (f ListEventsFilter) PageLimit() int {
+	switch {
+	case f.Limit <= 0:
+		return DefaultListEventsLimit
+	case f.Limit > MaxListEventsLimit:
+		return MaxListEventsLimit
+	default:
+		return f.Limit
+	}
+}
+
+// EventsPage holds events newest first.
+type EventsPage struct {
+	Events []Event
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
+// Cursor is the position of an event in the newest first order, events with the same ID differ in their action.
+type Cursor struct {
+	CreatedAt time.Time   `json:"created_at"`
+	ID        string      `json:"id"`
+	Action    EventAction `json:"action"`
+}
+
+// DecodeCursor returns nil for an empty cursor.
+func DecodeCursor(cursor string) (*Cursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	var c Cursor
+	if err = json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	return &c, nil
+}
+
+func encodeCursor(e Event) string {
+	raw, _ := json.Marshal(Cursor{CreatedAt: e.CreatedAt, ID: e.ID, Action: e.Action})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// NewEventsPage builds the page from up to limit+1 events, the one past the limit only tells there is a next page.
+func NewEventsPage(events []Event, limit int) *EventsPage {
+	page := &EventsPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		page.NextCursor = encodeCursor(page.Events[limit-1])
+	}
+
+	return page
+}
+
+// ListEvents returns a page of the stored events, newest first. The storage must implement Reader.
+func (s *Service) ListEvents(ctx context.Context, filter ListEventsFilter) (*EventsPage, error) {
+	reader, ok := s.storage.(Reader)
+	if !ok {
+		return nil, ErrListEventsUnsupported
+	}
+	if _, err := DecodeCursor(filter.Cursor); err != nil {
+		return nil, err
+	}
+
+	page, err := reader.ListEvents(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEventsFilter_PageLimit(t *testing.T) {
+	assert.Equal(t, DefaultListEventsLimit, ListEventsFilter{}.PageLimit())
+	assert.Equal(t, 10, ListEventsFilter{Limit: 10}.PageLimit())
+	assert.Equal(t, MaxListEventsLimit, ListEventsFilter{Limit: MaxListEventsLimit + 1}.PageLimit())
+}
+
+func TestNewEventsPage(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	evs := []Event{
+		{ID: "3", Action: EventActionTopUp, CreatedAt: createdAt},
+		{ID: "2", Action: EventActionCreateTopUp, CreatedAt: createdAt},
+		{ID: "1", Action: EventActionTopUp, CreatedAt: createdAt},
+	}
+
+	t.Run("last page", func(t *testing.T) {
+		page := NewEventsPage(evs, 3)
+
+		assert.Equal(t, &EventsPage{Events: evs}, page)
+	})
+	t.Run("next page", func(t *testing.T) {
+		page := NewEventsPage(evs, 2)
+
+		assert.Equal(t, evs[:2], page.Events)
+		cursor, err := DecodeCursor(page.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, &Cursor{CreatedAt: createdAt, ID: "2", Action: EventActionCreateTopUp}, cursor)
+	})
+}
+
+func TestDecodeCursor(t *testing.T) {
+	cursor, err := DecodeCursor("")
+	assert.NoError(t, err)
+	assert.Nil(t, cursor)
+
+	_, err = DecodeCursor("not a cursor")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+// writeOnlyStorage is a Storage without Reader, as implemented outside of this module.
+type writeOnlyStorage struct{}
+
+func (writeOnlyStorage) CreateEvent(context.Context, Event) error {
+	return nil
+}
+
+func TestService_ListEvents(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		filter := ListEventsFilter{OrganizationID: "lcOrganizationID", ErrorsOnly: true}
+		page := &EventsPage{Events: []Event{{ID: "id", LCOrganizationID: "lcOrganizationID"}}}
+
+		sm.On("ListEvents", context.Background(), filter).Return(page, nil).Once()
+		res, err := s.ListEvents(context.Background(), filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, page, res)
+
+		assertExpectations(t)
+	})
+	t.Run("invalid cursor", func(t *testing.T) {
+		res, err := s.ListEvents(context.Background(), ListEventsFilter{Cursor: "not a cursor"})
+
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		assert.Nil(t, res)
+
+		assertExpectations(t)
+	})
+	t.Run("storage without reader", func(t *testing.T) {
+		ws := NewService(writeOnlyStorage{}, xm, TestEventIDCtxKey{})
+
+		res, err := ws.ListEvents(context.Background(), ListEventsFilter{})
+
+		assert.ErrorIs(t, err, ErrListEventsUnsupported)
+		assert.Nil(t, res)
+
+		assertExpectations(t)
+	})
+	t.Run("error", func(t *testing.T) {
+		sm.On("ListEvents", context.Background(), ListEventsFilter{}).Return(nil, assert.AnError).Once()
+		res, err := s.ListEvents(context.Background(), ListEventsFilter{})
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, res)
+
+		assertExpectations(t)
+	})
+}
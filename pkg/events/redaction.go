@@ -0,0 +1,201 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// RedactedValue replaces the masked payload fields.
+const RedactedValue = "[REDACTED]"
+
+// RedactAllActions is the WithRedaction key of the rules applied to the events of every action.
+const RedactAllActions EventAction = "*"
+
+type RedactionMode string
+
+const (
+	// RedactionMask replaces the field value with RedactedValue.
+	RedactionMask RedactionMode = "mask"
+	// RedactionDrop removes the field, array elements are set to null so the other indexes still match.
+	RedactionDrop RedactionMode = "drop"
+)
+
+// RedactionRule redacts the payload field at Path, a dot separated list of JSON object keys and array indexes
+// where * matches any key or index, e.g. "request.userID" or "charges.*.buyer_account_id".
+type RedactionRule struct {
+	Path string
+	Mode RedactionMode
+}
+
+// WithRedaction redacts the payloads of the events of an action with its rules and the RedactAllActions rules
+// before they are stored, see TaggedRedactionRules for the rules of the tagged payload structs.
+func WithRedaction(rules map[EventAction][]RedactionRule) Option {
+	return func(s *Service) {
+		s.redactionRules = rules
+	}
+}
+
+// redaction is a RedactionRule with its path split into segments.
+type redaction struct {
+	path []string
+	mode RedactionMode
+}
+
+func (s *Service) redact(event Event) Event {
+	var redactions []redaction
+	for _, action := range []EventAction{RedactAllActions, event.Action} {
+		for _, rule := range s.redactionRules[action] {
+			redactions = append(redactions, redaction{path: strings.Split(rule.Path, "."), mode: rule.Mode})
+		}
+	}
+	if len(redactions) == 0 || len(event.Payload) == 0 {
+		return event
+	}
+
+	if payload, ok := redactJSON(event.Payload, redactions); ok {
+		event.Payload = payload
+	}
+
+	return event
+}
+
+// redactJSON returns false when the payload can't be decoded or nothing matched.
+func redactJSON(payload json.RawMessage, redactions []redaction) (json.RawMessage, bool) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, false
+	}
+
+	var changed bool
+	for _, r := range redactions {
+		var c bool
+		tree, c = redactPath(tree, r.path, r.mode)
+		changed = changed || c
+	}
+	if !changed {
+		return nil, false
+	}
+
+	redacted, err := json.Marshal(tree)
+	if err != nil {
+		return nil, false
+	}
+
+	return redacted, true
+}
+
+func redactPath(node any, path []string, mode RedactionMode) (any, bool) {
+	segment, last := path[0], len(path) == 1
+
+	var changed bool
+	switch n := node.(type) {
+	case map[string]any:
+		for key, value := range n {
+			if segment != "*" && segment != key {
+				continue
+			}
+			if !last {
+				var c bool
+				n[key], c = redactPath(value, path[1:], mode)
+				changed = changed || c
+				continue
+			}
+			if mode == RedactionDrop {
+				delete(n, key)
+			} else {
+				n[key] = RedactedValue
+			}
+			changed = true
+		}
+	case []any:
+		for i, value := range n {
+			if segment != "*" && segment != strconv.Itoa(i) {
+				continue
+			}
+			if !last {
+				var c bool
+				n[i], c = redactPath(value, path[1:], mode)
+				changed = changed || c
+				continue
+			}
+			if mode == RedactionDrop {
+				n[i] = nil
+			} else {
+				n[i] = RedactedValue
+			}
+			changed = true
+		}
+	}
+
+	return node, changed
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// TaggedRedactionRules returns the rules of the fields of v tagged with `redact:"mask"` or `redact:"drop"`, found
+// the way encoding/json walks the type of v, with * for the map values and slice elements. The rules apply to
+// payloads of that type, e.g. WithRedaction(map[EventAction][]RedactionRule{action: TaggedRedactionRules(req)}).
+func TaggedRedactionRules(v any) []RedactionRule {
+	var rules []RedactionRule
+	if v != nil {
+		collectTagged(reflect.TypeOf(v), nil, map[reflect.Type]bool{}, &rules)
+	}
+
+	return rules
+}
+
+func collectTagged(t reflect.Type, path []string, visiting map[reflect.Type]bool, rules *[]RedactionRule) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) || visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			embeddedStruct := field.Anonymous && embedded.Kind() == reflect.Struct
+			if !field.IsExported() && !embeddedStruct {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if name == "" && embeddedStruct {
+				collectTagged(field.Type, path, visiting, rules)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			fieldPath := append(append([]string{}, path...), name)
+			if mode := RedactionMode(field.Tag.Get("redact")); mode == RedactionMask || mode == RedactionDrop {
+				*rules = append(*rules, RedactionRule{Path: strings.Join(fieldPath, "."), Mode: mode})
+				continue
+			}
+			collectTagged(field.Type, fieldPath, visiting, rules)
+		}
+	case reflect.Map, reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Map && t.Key().Kind() != reflect.String {
+			return
+		}
+		collectTagged(t.Elem(), append(append([]string{}, path...), "*"), visiting, rules)
+	}
+}
@@ -0,0 +1,112 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type redactedCharge struct {
+	ID             string `json:"id"`
+	BuyerAccountID string `json:"buyer_account_id" redact:"mask"`
+}
+
+type redactedRequest struct {
+	UserID  string           `json:"userID" redact:"mask"`
+	Secret  string           `redact:"drop"`
+	Date    time.Time        `json:"date"`
+	Charges []redactedCharge `json:"charges"`
+	redactedEmbedded
+	Next *redactedRequest `json:"next,omitempty"`
+}
+
+type redactedEmbedded struct {
+	Email string `json:"email" redact:"drop"`
+}
+
+func TestTaggedRedactionRules(t *testing.T) {
+	assert.Equal(t, []RedactionRule{
+		{Path: "userID", Mode: RedactionMask},
+		{Path: "Secret", Mode: RedactionDrop},
+		{Path: "charges.*.buyer_account_id", Mode: RedactionMask},
+		{Path: "email", Mode: RedactionDrop},
+	}, TaggedRedactionRules(&redactedRequest{}))
+	assert.Nil(t, TaggedRedactionRules(nil))
+	assert.Nil(t, TaggedRedactionRules(map[string]interface{}{"id": "id"}))
+}
+
+func TestService_CreateEvent_redaction(t *testing.T) {
+	rs := s
+	rs.redactionRules = map[EventAction][]RedactionRule{
+		RedactAllActions:                TaggedRedactionRules(redactedRequest{}),
+		EventActionDPSWebhookPayment:    {{Path: "payload.card", Mode: RedactionDrop}},
+		EventActionSyncRecurrentCharge:  {{Path: "items.0", Mode: RedactionDrop}},
+		EventActionCreateSubscription:   {{Path: "missing", Mode: RedactionMask}},
+		EventActionCancelRecurrentTopUp: {{Path: "*", Mode: RedactionMask}},
+	}
+	rs.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name     string
+		action   EventAction
+		payload  string
+		expected string
+	}{
+		{
+			name:     "tagged fields of every action",
+			action:   EventActionCreateCharge,
+			payload:  `{"userID":"u1","Secret":"s","charges":[{"id":"c1","buyer_account_id":"b1"},{"id":"c2","buyer_account_id":"b2"}],"email":"a@b.c","price":10.5}`,
+			expected: `{"charges":[{"buyer_account_id":"[REDACTED]","id":"c1"},{"buyer_account_id":"[REDACTED]","id":"c2"}],"price":10.5,"userID":"[REDACTED]"}`,
+		},
+		{
+			name:     "action rules",
+			action:   EventActionDPSWebhookPayment,
+			payload:  `{"userID":"u1","payload":{"card":"4242","amount":1}}`,
+			expected: `{"payload":{"amount":1},"userID":"[REDACTED]"}`,
+		},
+		{
+			name:     "dropped array element",
+			action:   EventActionSyncRecurrentCharge,
+			payload:  `{"items":["a","b"]}`,
+			expected: `{"items":[null,"b"]}`,
+		},
+		{
+			name:     "wildcard",
+			action:   EventActionCancelRecurrentTopUp,
+			payload:  `{"id":"id","amount":1}`,
+			expected: `{"amount":"[REDACTED]","id":"[REDACTED]"}`,
+		},
+		{
+			name:     "nothing matched keeps the payload",
+			action:   EventActionCreateSubscription,
+			payload:  `{"id": "id"}`,
+			expected: `{"id": "id"}`,
+		},
+		{
+			name:     "not a JSON object",
+			action:   EventActionCreateCharge,
+			payload:  `"id"`,
+			expected: `"id"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			event := Event{ID: "id", Action: tc.action, Payload: json.RawMessage(tc.payload)}
+			expected := event
+			expected.Payload = json.RawMessage(tc.expected)
+
+			sm.On("CreateEvent", context.Background(), expected).Return(nil).Once()
+
+			assert.NoError(t, rs.CreateEvent(context.Background(), event))
+			assert.Equal(t, json.RawMessage(tc.payload), event.Payload)
+
+			assertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	DefaultRetentionBatchSize  = 1000
+	DefaultRetentionBatchPause = 100 * time.Millisecond
+)
+
+// MoneyMovingActions are the actions of the events kept for RetentionPolicy.MoneyMovingRetention.
+var MoneyMovingActions = []EventAction{
+	EventActionTopUp,
+	EventActionCreateOperation,
+	EventActionAddVoucherFunds,
+}
+
+// RetentionPolicy tells how long events are kept, a zero retention keeps the events forever.
+type RetentionPolicy struct {
+	// InfoRetention applies to info events, except the money moving ones.
+	InfoRetention time.Duration
+	// ErrorRetention applies to error events, except the money moving ones.
+	ErrorRetention time.Duration
+	// MoneyMovingRetention applies to the events of MoneyMovingActions of any type.
+	MoneyMovingRetention time.Duration
+	// MoneyMovingActions defaults to the package MoneyMovingActions.
+	MoneyMovingActions []EventAction
+	// BatchSize is the number of events deleted at once, defaults to DefaultRetentionBatchSize.
+	BatchSize int
+	// BatchPause is the time waited between the batches so other writes are not starved of the table.
+	BatchPause time.Duration
+}
+
+// DefaultRetentionPolicy keeps info events for 30 days, errors for 180 days and money moving events for 2 years.
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		InfoRetention:        30 * 24 * time.Hour,
+		ErrorRetention:       180 * 24 * time.Hour,
+		MoneyMovingRetention: 2 * 365 * 24 * time.Hour,
+		BatchSize:            DefaultRetentionBatchSize,
+		BatchPause:           DefaultRetentionBatchPause,
+	}
+}
+
+// ExpiredEventsParams selects the events created before CreatedBefore, zero fields don't filter.
+type ExpiredEventsParams struct {
+	CreatedBefore  time.Time
+	Type           EventType
+	Actions        []EventAction
+	ExcludeActions []EventAction
+	Limit          int
+}
+
+type RetentionStorage interface {
+	// ListExpiredEvents returns up to params.Limit events, oldest first.
+	ListExpiredEvents(ctx context.Context, params ExpiredEventsParams) ([]Event, error)
+	// DeleteEvents deletes the events by their ID and action and returns the number of deleted rows.
+	DeleteEvents(ctx context.Context, events []Event) (int64, error)
+}
+
+// Archiver stores the expired events elsewhere before they are deleted.
+type Archiver interface {
+	Archive(ctx context.Context, events []Event) error
+}
+
+type RetentionOption func(*Retention)
+
+// WithArchiver archives every batch before deleting it, a failed archive stops the purge with the batch kept.
+func WithArchiver(archiver Archiver) RetentionOption {
+	return func(r *Retention) {
+		r.archiver = archiver
+	}
+}
+
+// WithRetentionLogger sets the logger of the purge summary, slog.Default is used otherwise.
+func WithRetentionLogger(logger *slog.Logger) RetentionOption {
+	return func(r *Retention) {
+		r.logger = logger
+	}
+}
+
+// Retention purges the events of a single events table according to its policy.
+type Retention struct {
+	storage  RetentionStorage
+	policy   RetentionPolicy
+	archiver Archiver
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+func NewRetention(storage RetentionStorage, policy RetentionPolicy, opts ...RetentionOption) *Retention {
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = DefaultRetentionBatchSize
+	}
+	if policy.MoneyMovingActions == nil {
+		policy.MoneyMovingActions = MoneyMovingActions
+	}
+
+	r := &Retention{
+		storage: storage,
+		policy:  policy,
+		logger:  slog.Default(),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Purge deletes the expired events in batches and returns the number of deleted events.
+func (r *Retention) Purge(ctx context.Context) (int64, error) {
+	var deleted int64
+	for _, params := range r.expiredEventsParams(r.now()) {
+		n, err := r.purge(ctx, params)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+
+	r.logger.InfoContext(ctx, "purged expired events", slog.Int64("deleted", deleted), slog.Bool("archived", r.archiver != nil))
+
+	return deleted, nil
+}
+
+// Run purges the expired events, it fits scheduler.Job.
+func (r *Retention) Run(ctx context.Context) error {
+	_, err := r.Purge(ctx)
+	return err
+}
+
+func (r *Retention) expiredEventsParams(now time.Time) []ExpiredEventsParams {
+	var params []ExpiredEventsParams
+	if r.policy.InfoRetention > 0 {
+		params = append(params, ExpiredEventsParams{
+			CreatedBefore:  now.Add(-r.policy.InfoRetention),
+			Type:           EventTypeInfo,
+			ExcludeActions: r.policy.MoneyMovingActions,
+			Limit:          r.policy.BatchSize,
+		})
+	}
+	if r.policy.ErrorRetention > 0 {
+		params = append(params, ExpiredEventsParams{
+			CreatedBefore:  now.Add(-r.policy.ErrorRetention),
+			Type:           EventTypeError,
+			ExcludeActions: r.policy.MoneyMovingActions,
+			Limit:          r.policy.BatchSize,
+		})
+	}
+	if r.policy.MoneyMovingRetention > 0 && len(r.policy.MoneyMovingActions) > 0 {
+		params = append(params, ExpiredEventsParams{
+			CreatedBefore: now.Add(-r.policy.MoneyMovingRetention),
+			Actions:       r.policy.MoneyMovingActions,
+			Limit:         r.policy.BatchSize,
+		})
+	}
+
+	return params
+}
+
+func (r *Retention) purge(ctx context.Context, params ExpiredEventsParams) (int64, error) {
+	var deleted int64
+	for {
+		evs, err := r.storage.ListExpiredEvents(ctx, params)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list expired events: %w", err)
+		}
+		if len(evs) == 0 {
+			return deleted, nil
+		}
+
+		if r.archiver != nil {
+			if err = r.archiver.Archive(ctx, evs); err != nil {
+				return deleted, fmt.Errorf("failed to archive expired events: %w", err)
+			}
+		}
+
+		n, err := r.storage.DeleteEvents(ctx, evs)
+		deleted += n
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete expired events: %w", err)
+		}
+
+		// nothing deleted means the same batch would be listed again
+		if len(evs) < params.Limit || n == 0 {
+			return deleted, nil
+		}
+
+		if r.policy.BatchPause > 0 {
+			select {
+			case <-ctx.Done():
+				return deleted, ctx.Err()
+			case <-time.After(r.policy.BatchPause):
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package events
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type retentionStorageMock struct {
+	mock.Mock
+}
+
+func (m *retentionStorageMock) ListExpiredEvents(ctx context.Context, params ExpiredEventsParams) ([]Event, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Event), args.Error(1)
+}
+
+func (m *retentionStorageMock) DeleteEvents(ctx context.Context, events []Event) (int64, error) {
+	args := m.Called(ctx, events)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type archiverMock struct {
+	mock.Mock
+}
+
+func (m *archiverMock) Archive(ctx context.Context, events []Event) error {
+	args := m.Called(ctx, events)
+	return args.Error(0)
+}
+
+func TestNewRetention(t *testing.T) {
+	r := NewRetention(&retentionStorageMock{}, RetentionPolicy{InfoRetention: time.Hour})
+
+	assert.Equal(t, DefaultRetentionBatchSize, r.policy.BatchSize)
+	assert.Equal(t, MoneyMovingActions, r.policy.MoneyMovingActions)
+	assert.Nil(t, r.archiver)
+	assert.Equal(t, slog.Default(), r.logger)
+}
+
+func TestRetention_Purge(t *testing.T) {
+	now := time.Date(2025, 4, 2, 15, 4, 5, 0, time.UTC)
+	ctx := context.Background()
+	newRetention := func(rsm *retentionStorageMock, policy RetentionPolicy, opts ...RetentionOption) *Retention {
+		opts = append(opts, WithRetentionLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+		r := NewRetention(rsm, policy, opts...)
+		r.now = func() time.Time { return now }
+		return r
+	}
+	infoParams := ExpiredEventsParams{CreatedBefore: now.Add(-time.Hour), Type: EventTypeInfo, ExcludeActions: MoneyMovingActions, Limit: 2}
+	errorParams := ExpiredEventsParams{CreatedBefore: now.Add(-2 * time.Hour), Type: EventTypeError, ExcludeActions: MoneyMovingActions, Limit: 2}
+	moneyParams := ExpiredEventsParams{CreatedBefore: now.Add(-3 * time.Hour), Actions: MoneyMovingActions, Limit: 2}
+	policy := RetentionPolicy{InfoRetention: time.Hour, ErrorRetention: 2 * time.Hour, MoneyMovingRetention: 3 * time.Hour, BatchSize: 2}
+
+	t.Run("deletes in batches", func(t *testing.T) {
+		rsm := &retentionStorageMock{}
+		first := []Event{{ID: "1", Action: EventActionSyncRecurrentCharge}, {ID: "2", Action: EventActionSyncRecurrentCharge}}
+		second := []Event{{ID: "3", Action: EventActionCreateCharge}}
+		rsm.On("ListExpiredEvents", ctx, infoParams).Return(first, nil).Once()
+		rsm.On("DeleteEvents", ctx, first).Return(int64(2), nil).Once()
+		rsm.On("ListExpiredEvents", ctx, infoParams).Return(second, nil).Once()
+		rsm.On("DeleteEvents", ctx, second).Return(int64(1), nil).Once()
+		rsm.On("ListExpiredEvents", ctx, errorParams).Return([]Event{}, nil).Once()
+		rsm.On("ListExpiredEvents", ctx, moneyParams).Return([]Event{}, nil).Once()
+
+		deleted, err := newRetention(rsm, policy).Purge(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), deleted)
+		rsm.AssertExpectations(t)
+	})
+
+	t.Run("zero retention keeps events", func(t *testing.T) {
+		rsm := &retentionStorageMock{}
+		rsm.On("ListExpiredEvents", ctx, infoParams).Return([]Event{}, nil).Once()
+
+		deleted, err := newRetention(rsm, RetentionPolicy{InfoRetention: time.Hour, BatchSize: 2}).Purge(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), deleted)
+		rsm.AssertExpectations(t)
+	})
+
+	t.Run("archives before delete", func(t *testing.T) {
+		rsm := &retentionStorageMock{}
+		am := &archiverMock{}
+		evs := []Event{{ID: "1", Action: EventActionTopUp}}
+		rsm.On("ListExpiredEvents", ctx, moneyParams).Return(evs, nil).Once()
+		am.On("Archive", ctx, evs).Return(nil).Once()
+		rsm.On("DeleteEvents", ctx, evs).Return(int64(1), nil).Once()
+
+		deleted, err := newRetention(rsm, RetentionPolicy{MoneyMovingRetention: 3 * time.Hour, BatchSize: 2}, WithArchiver(am)).Purge(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		mock.AssertExpectationsForObjects(t, rsm, am)
+	})
+
+	t.Run("failed archive keeps events", func(t *testing.T) {
+		rsm := &retentionStorageMock{}
+		am := &archiverMock{}
+		evs := []Event{{ID: "1", Action: EventActionCreateCharge}}
+		rsm.On("ListExpiredEvents", ctx, infoParams).Return(evs, nil).Once()
+		am.On("Archive", ctx, evs).Return(assert.AnError).Once()
+
+		deleted, err := newRetention(rsm, policy, WithArchiver(am)).Purge(ctx)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, int64(0), deleted)
+		mock.AssertExpectationsForObjects(t, rsm, am)
+	})
+
+	t.Run("list error", func(t *testing.T) {
+		rsm := &retentionStorageMock{}
+		rsm.On("ListExpiredEvents", ctx, infoParams).Return(nil, assert.AnError).Once()
+
+		_, err := newRetention(rsm, policy).Purge(ctx)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		rsm.AssertExpectations(t)
+	})
+
+	t.Run("delete error", func(t *testing.T) {
+		rsm := &retentionStorageMock{}
+		evs := []Event{{ID: "1", Action: EventActionCreateCharge}, {ID: "2", Action: EventActionCreateCharge}}
+		rsm.On("ListExpiredEvents", ctx, infoParams).Return(evs, nil).Once()
+		rsm.On("DeleteEvents", ctx, evs).Return(int64(0), assert.AnError).Once()
+
+		_, err := newRetention(rsm, policy).Purge(ctx)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		rsm.AssertExpectations(t)
+	})
+}
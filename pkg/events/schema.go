@@ -0,0 +1,176 @@
+package events
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDialect is the JSON Schema version of the exported schemas.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	rawMessageType     = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonLinesEventType = reflect.TypeOf(jsonLinesEvent{})
+)
+
+// PayloadJSONSchema returns the JSON Schema of the current version of the action payload.
+func (r *PayloadRegistry) PayloadJSONSchema(action EventAction) ([]byte, error) {
+	t, ok := r.types[action]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPayload, action)
+	}
+
+	schema := payloadSchema(t)
+	schema["$schema"] = JSONSchemaDialect
+	schema["title"] = string(action)
+
+	return json.Marshal(schema)
+}
+
+// JSONSchema returns the JSON Schema of the events as JSONLinesSink writes them, with the payload schema of every
+// registered action. The payloads of the other actions are not validated.
+func (r *PayloadRegistry) JSONSchema() ([]byte, error) {
+	schema := typeSchema(jsonLinesEventType, map[reflect.Type]bool{})
+	schema["$schema"] = JSONSchemaDialect
+	schema["title"] = "Event"
+
+	defs := map[string]any{}
+	var conditions []any
+	for _, action := range r.Actions() {
+		defs[string(action)] = payloadSchema(r.types[action])
+		conditions = append(conditions, map[string]any{
+			"if": map[string]any{
+				"properties": map[string]any{"action": map[string]any{"const": action}},
+				"required":   []string{"action"},
+			},
+			"then": map[string]any{
+				"properties": map[string]any{"payload": map[string]any{"$ref": "#/$defs/" + string(action)}},
+			},
+		})
+	}
+	if len(conditions) > 0 {
+		schema["$defs"] = defs
+		schema["allOf"] = conditions
+	}
+
+	return json.Marshal(schema)
+}
+
+func payloadSchema(t reflect.Type) map[string]any {
+	schema := typeSchema(t, map[reflect.Type]bool{})
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		if _, ok := properties["version"]; ok {
+			properties["version"] = map[string]any{"type": "integer", "const": reflect.New(t).Interface().(Payload).PayloadVersion()}
+		}
+	}
+
+	return schema
+}
+
+// typeSchema describes the JSON encoding/json produces for t.
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		return nullable(typeSchema(t.Elem(), visiting))
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType, t.Implements(jsonMarshalerType), reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return map[string]any{}
+	case t.Implements(textMarshalerType), reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": []string{"string", "null"}, "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": []string{"array", "null"}, "items": typeSchema(t.Elem(), visiting)}
+	case reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": typeSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]any{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]any{}
+		var required []string
+		structSchema(t, visiting, properties, &required)
+
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema adds the fields of t to the properties, the fields of the untagged embedded structs are promoted.
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		embedded := field.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		embeddedStruct := field.Anonymous && embedded.Kind() == reflect.Struct
+		if !field.IsExported() && !embeddedStruct {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if name == "" && embeddedStruct {
+			structSchema(embedded, visiting, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = typeSchema(field.Type, visiting)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func nullable(schema map[string]any) map[string]any {
+	switch s := schema["type"].(type) {
+	case string:
+		schema["type"] = []string{s, "null"}
+		return schema
+	case []string:
+		return schema
+	}
+	if len(schema) == 0 {
+		return schema
+	}
+
+	return map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testNestedPayload struct {
+	Name string `json:"name"`
+}
+
+type testSchemaPayload struct {
+	Version   int                `json:"version"`
+	ID        string             `json:"id"`
+	Nested    *testNestedPayload `json:"nested,omitempty"`
+	Tags      []string           `json:"tags"`
+	CreatedAt time.Time          `json:"created_at"`
+	Raw       json.RawMessage    `json:"raw,omitempty"`
+	Ignored   string             `json:"-"`
+	testNestedPayload
+}
+
+func (testSchemaPayload) PayloadVersion() int { return 1 }
+
+func TestPayloadRegistry_PayloadJSONSchema(t *testing.T) {
+	r := NewPayloadRegistry()
+	r.Register(EventActionTopUp, testSchemaPayload{})
+
+	t.Run("success", func(t *testing.T) {
+		schema, err := r.PayloadJSONSchema(EventActionTopUp)
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "top_up",
+			"type": "object",
+			"properties": {
+				"version": {"type": "integer", "const": 1},
+				"id": {"type": "string"},
+				"nested": {"type": ["object", "null"], "properties": {"name": {"type": "string"}}, "required": ["name"]},
+				"tags": {"type": ["array", "null"], "items": {"type": "string"}},
+				"created_at": {"type": "string", "format": "date-time"},
+				"raw": {},
+				"name": {"type": "string"}
+			},
+			"required": ["version", "id", "tags", "created_at", "name"]
+		}`, string(schema))
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		_, err := r.PayloadJSONSchema(EventActionCreateCharge)
+
+		assert.ErrorIs(t, err, ErrUnknownPayload)
+	})
+}
+
+func TestPayloadRegistry_JSONSchema(t *testing.T) {
+	r := NewPayloadRegistry()
+	r.Register(EventActionTopUp, testPayload{})
+
+	schema, err := r.JSONSchema()
+	assert.NoError(t, err)
+
+	var s struct {
+		Schema     string                     `json:"$schema"`
+		Title      string                     `json:"title"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+		Defs       map[string]json.RawMessage `json:"$defs"`
+		AllOf      []json.RawMessage          `json:"allOf"`
+	}
+	assert.NoError(t, json.Unmarshal(schema, &s))
+
+	assert.Equal(t, JSONSchemaDialect, s.Schema)
+	assert.Equal(t, "Event", s.Title)
+	assert.Contains(t, s.Properties, "action")
+	assert.Contains(t, s.Properties, "payload")
+	assert.Contains(t, s.Required, "id")
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"version": {"type": "integer", "const": 2},
+			"id": {"type": "string"},
+			"amount": {"type": "integer"}
+		},
+		"required": ["version", "id"]
+	}`, string(s.Defs["top_up"]))
+	assert.Len(t, s.AllOf, 1)
+	assert.JSONEq(t, `{
+		"if": {"properties": {"action": {"const": "top_up"}}, "required": ["action"]},
+		"then": {"properties": {"payload": {"$ref": "#/$defs/top_up"}}}
+	}`, string(s.AllOf[0]))
+}
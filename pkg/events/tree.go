@@ -0,0 +1,45 @@
+package events
+
+import "context"
+
+type parentCtxKey struct{}
+
+type eventRef struct {
+	id            string
+	correlationID string
+}
+
+// WithParent makes the events created with the returned context children of parent.
+func WithParent(ctx context.Context, parent Event) context.Context {
+	return context.WithValue(ctx, parentCtxKey{}, eventRef{id: parent.ID, correlationID: parent.CorrelationID})
+}
+
+// EventNode is an event with the events it caused.
+type EventNode struct {
+	Event    Event
+	Children []*EventNode
+}
+
+// BuildEventTree links the events of an operation, e.g. listed by their CorrelationID, by their ParentID. The events
+// without a parent among them are the returned roots, the order of the events is kept on every level.
+func BuildEventTree(evs []Event) []*EventNode {
+	nodes := make([]*EventNode, len(evs))
+	byID := make(map[string]*EventNode, len(evs))
+	for i, e := range evs {
+		nodes[i] = &EventNode{Event: e}
+		if _, ok := byID[e.ID]; !ok {
+			byID[e.ID] = nodes[i]
+		}
+	}
+
+	var roots []*EventNode
+	for _, node := range nodes {
+		if parent, ok := byID[node.Event.ParentID]; ok && parent != node {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+
+	return roots
+}
@@ -0,0 +1,30 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildEventTree(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		root := Event{ID: "1", CorrelationID: "1", Action: EventActionDPSWebhookPayment}
+		topUp := Event{ID: "2", CorrelationID: "1", ParentID: "1", Action: EventActionTopUp}
+		operation := Event{ID: "3", CorrelationID: "1", ParentID: "2", Action: EventActionCreateOperation}
+		sync := Event{ID: "4", CorrelationID: "1", ParentID: "1", Action: EventActionSyncTopUp}
+		orphan := Event{ID: "5", CorrelationID: "1", ParentID: "missing", Action: EventActionCreateTopUp}
+
+		roots := BuildEventTree([]Event{operation, root, topUp, sync, orphan})
+
+		assert.Equal(t, []*EventNode{
+			{Event: root, Children: []*EventNode{
+				{Event: topUp, Children: []*EventNode{{Event: operation}}},
+				{Event: sync},
+			}},
+			{Event: orphan},
+		}, roots)
+	})
+	t.Run("empty", func(t *testing.T) {
+		assert.Nil(t, BuildEventTree(nil))
+	})
+}
@@ -0,0 +1,124 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+// ErrBalanceDrift is returned by VerifyBalances when the latest snapshot has balances that differ from the sum of
+// the operations.
+var ErrBalanceDrift = errors.New("balance drift")
+
+// BalanceDrift is a materialized balance of the latest snapshot that differs from the sum of the operations.
+type BalanceDrift struct {
+	LCOrganizationID string    `json:"lc_organization_id"`
+	Currency         Currency  `json:"currency"`
+	Balance          Money     `json:"balance"`
+	OperationsSum    Money     `json:"operations_sum"`
+	SnapshotAt       time.Time `json:"snapshot_at"`
+}
+
+type BalanceVerificationReport struct {
+	Drifts []BalanceDrift `json:"drifts"`
+}
+
+// BalanceRepair is a materialized balance before and after it was rewritten from the sum of the operations.
+type BalanceRepair struct {
+	LCOrganizationID string   `json:"lc_organization_id"`
+	Currency         Currency `json:"currency"`
+	Before           Money    `json:"before"`
+	After            Money    `json:"after"`
+}
+
+// DefaultBalanceSnapshotRetention is the number of snapshots SnapshotBalances keeps of every balance.
+const DefaultBalanceSnapshotRetention = 30
+
+// WithBalanceSnapshotRetention keeps the latest keep snapshots of every balance instead of
+// DefaultBalanceSnapshotRetention. Values below 1 keep the default.
+func WithBalanceSnapshotRetention(keep int) Option {
+	return func(s *Service) {
+		s.snapshotKeep = keep
+	}
+}
+
+// SnapshotBalances stores a snapshot of every materialized balance together with the sum of the operations of its
+// organization and currency. Both are read at once, so operations stored meanwhile can't show up as drift. The
+// snapshots older than the retention are deleted with it, the ones kept date when a balance started to drift.
+func (s *Service) SnapshotBalances(ctx context.Context) (err error) {
+	ctx, end := s.start(ctx, "SnapshotBalances")
+	defer func() { end(err) }()
+
+	keep := s.snapshotKeep
+	if keep < 1 {
+		keep = DefaultBalanceSnapshotRetention
+	}
+	if _, err = s.storage.CreateBalanceSnapshots(ctx, keep); err != nil {
+		return fmt.Errorf("failed to create balance snapshots: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyBalances reports the balances of the latest snapshot that drifted from the sum of the operations, the drift
+// is returned as ErrBalanceDrift.
+func (s *Service) VerifyBalances(ctx context.Context) (_ *BalanceVerificationReport, err error) {
+	ctx, end := s.start(ctx, "VerifyBalances")
+	defer func() { end(err) }()
+
+	event := s.eventService.ToEvent(ctx, "", events.EventActionVerifyBalances, events.EventTypeInfo, VerifyBalancesEventPayload{})
+
+	drifts, err := s.storage.GetBalanceDrifts(ctx)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return nil, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to get balance drifts: %w", err),
+		})
+	}
+
+	report := &BalanceVerificationReport{Drifts: drifts}
+	if report.Drifts == nil {
+		report.Drifts = []BalanceDrift{}
+	}
+
+	event.SetPayload(VerifyBalancesEventPayload{Report: report})
+	if len(report.Drifts) > 0 {
+		event.Type = events.EventTypeError
+		return report, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("%w in %d balances", ErrBalanceDrift, len(report.Drifts)),
+		})
+	}
+
+	s.createEvent(ctx, event)
+
+	return report, nil
+}
+
+// RepairBalance rewrites the materialized balance of the organization and currency from the sum of its operations,
+// use it for the drifts reported by VerifyBalances.
+func (s *Service) RepairBalance(ctx context.Context, organizationID string, currency Currency) (_ *BalanceRepair, err error) {
+	ctx, end := s.start(ctx, "RepairBalance", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { end(err) }()
+
+	event := s.eventService.ToEvent(ctx, organizationID, events.EventActionRepairBalance, events.EventTypeInfo, RepairBalanceEventPayload{})
+
+	repair, err := s.storage.RepairBalance(ctx, organizationID, currency)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return nil, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to repair balance: %w", err),
+		})
+	}
+
+	event.SetPayload(RepairBalanceEventPayload{Repair: repair})
+	s.createEvent(ctx, event)
+
+	return repair, nil
+}
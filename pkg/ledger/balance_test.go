@@ -0,0 +1,137 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+func TestService_SnapshotBalances(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		sm.On("CreateBalanceSnapshots", ctx, DefaultBalanceSnapshotRetention).Return(int64(3), nil).Once()
+
+		err := s.SnapshotBalances(ctx)
+
+		assert.NoError(t, err)
+		assertExpectations(t)
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		ms := s
+		WithBalanceSnapshotRetention(7)(&ms)
+		sm.On("CreateBalanceSnapshots", ctx, 7).Return(int64(3), nil).Once()
+
+		err := ms.SnapshotBalances(ctx)
+
+		assert.NoError(t, err)
+		assertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		sm.On("CreateBalanceSnapshots", ctx, DefaultBalanceSnapshotRetention).Return(int64(0), assert.AnError).Once()
+
+		err := s.SnapshotBalances(ctx)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assertExpectations(t)
+	})
+}
+
+func TestService_VerifyBalances(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		em.On("ToEvent", ctx, "", events.EventActionVerifyBalances, events.EventTypeInfo, VerifyBalancesEventPayload{}).Return(events.Event{}).Once()
+		sm.On("GetBalanceDrifts", ctx).Return(nil, nil).Once()
+		em.On("CreateEvent", ctx, mock.MatchedBy(func(e events.Event) bool {
+			return e.Type == "" && string(e.Payload) == `{"version":1,"report":{"drifts":[]}}`
+		})).Return(nil).Once()
+
+		report, err := s.VerifyBalances(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &BalanceVerificationReport{Drifts: []BalanceDrift{}}, report)
+		assertExpectations(t)
+	})
+
+	t.Run("reports drift", func(t *testing.T) {
+		drifts := []BalanceDrift{{
+			LCOrganizationID: "lcOrganizationID",
+			Currency:         CurrencyUSD,
+			Balance:          5230,
+			OperationsSum:    4230,
+			SnapshotAt:       time.Date(2025, 3, 14, 12, 31, 56, 0, time.UTC),
+		}}
+		em.On("ToEvent", ctx, "", events.EventActionVerifyBalances, events.EventTypeInfo, VerifyBalancesEventPayload{}).Return(events.Event{}).Once()
+		sm.On("GetBalanceDrifts", ctx).Return(drifts, nil).Once()
+		em.On("ToError", ctx, mock.MatchedBy(func(params events.ToErrorParams) bool {
+			return params.Event.Type == events.EventTypeError && errors.Is(params.Err, ErrBalanceDrift)
+		})).Return(assert.AnError).Once()
+
+		report, err := s.VerifyBalances(ctx)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, &BalanceVerificationReport{Drifts: drifts}, report)
+		assertExpectations(t)
+	})
+
+	t.Run("error getting drifts", func(t *testing.T) {
+		event := events.Event{}
+		em.On("ToEvent", ctx, "", events.EventActionVerifyBalances, events.EventTypeInfo, VerifyBalancesEventPayload{}).Return(event).Once()
+		sm.On("GetBalanceDrifts", ctx).Return(nil, assert.AnError).Once()
+		event.Type = events.EventTypeError
+		em.On("ToError", ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to get balance drifts: %w", assert.AnError),
+		}).Return(assert.AnError).Once()
+
+		report, err := s.VerifyBalances(ctx)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, report)
+		assertExpectations(t)
+	})
+}
+
+func TestService_RepairBalance(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repair := &BalanceRepair{
+			LCOrganizationID: "lcOrganizationID",
+			Currency:         CurrencyUSD,
+			Before:           5230,
+			After:            4230,
+		}
+		em.On("ToEvent", ctx, "lcOrganizationID", events.EventActionRepairBalance, events.EventTypeInfo, RepairBalanceEventPayload{}).Return(events.Event{}).Once()
+		sm.On("RepairBalance", ctx, "lcOrganizationID", CurrencyUSD).Return(repair, nil).Once()
+		em.On("CreateEvent", ctx, mock.MatchedBy(func(e events.Event) bool {
+			return e.Type == "" && string(e.Payload) == `{"version":1,"repair":{"lc_organization_id":"lcOrganizationID","currency":"USD","before":5.23,"after":4.23}}`
+		})).Return(nil).Once()
+
+		res, err := s.RepairBalance(ctx, "lcOrganizationID", CurrencyUSD)
+
+		assert.NoError(t, err)
+		assert.Equal(t, repair, res)
+		assertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		event := events.Event{}
+		em.On("ToEvent", ctx, "lcOrganizationID", events.EventActionRepairBalance, events.EventTypeInfo, RepairBalanceEventPayload{}).Return(event).Once()
+		sm.On("RepairBalance", ctx, "lcOrganizationID", CurrencyUSD).Return(nil, assert.AnError).Once()
+		event.Type = events.EventTypeError
+		em.On("ToError", ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to repair balance: %w", assert.AnError),
+		}).Return(assert.AnError).Once()
+
+		res, err := s.RepairBalance(ctx, "lcOrganizationID", CurrencyUSD)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, res)
+		assertExpectations(t)
+	})
+}
@@ -0,0 +1,128 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+// Conversion moves funds of an organization between two currencies at the stored rate. It is booked as an
+// operation debiting FromAmount and an operation crediting ToAmount.
+type Conversion struct {
+	ID               string    `json:"id"`
+	LCOrganizationID string    `json:"lc_organization_id"`
+	FromCurrency     Currency  `json:"from_currency"`
+	FromAmount       Money     `json:"from_amount"`
+	ToCurrency       Currency  `json:"to_currency"`
+	ToAmount         Money     `json:"to_amount"`
+	Rate             Rate      `json:"rate"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (c Conversion) validate() error {
+	switch {
+	case c.FromAmount <= 0:
+		return fmt.Errorf("%w: amount %s is not positive", ErrInvalidConversion, c.FromAmount)
+	case c.Rate <= 0:
+		return fmt.Errorf("%w: rate %s is not positive", ErrInvalidConversion, c.Rate)
+	case c.FromCurrency == c.ToCurrency:
+		return fmt.Errorf("%w: %s to %s", ErrInvalidConversion, c.FromCurrency, c.ToCurrency)
+	case c.ToAmount == 0:
+		return fmt.Errorf("%w: %s %s converts to zero", ErrInvalidConversion, c.FromAmount, c.FromCurrency)
+	}
+
+	return nil
+}
+
+// operations returns the debit and the credit operations of the conversion, both with the conversion as payload.
+func (c Conversion) operations(payload json.RawMessage) []Operation {
+	return []Operation{
+		{
+			ID:               c.ID + "-from",
+			LCOrganizationID: c.LCOrganizationID,
+			Amount:           -c.FromAmount,
+			Currency:         c.FromCurrency,
+			Payload:          payload,
+		},
+		{
+			ID:               c.ID + "-to",
+			LCOrganizationID: c.LCOrganizationID,
+			Amount:           c.ToAmount,
+			Currency:         c.ToCurrency,
+			Payload:          payload,
+		},
+	}
+}
+
+type ConvertParams struct {
+	OrganizationID string
+	// Amount is the amount of From to convert.
+	Amount Money
+	From   Currency
+	To     Currency
+	// Rate is the amount of To for a unit of From.
+	Rate Rate
+}
+
+// Convert moves the amount from a currency of the organization to another. The converted amount is rounded half
+// away from zero to Money, the conversion and both of its operations are stored together.
+func (s *Service) Convert(ctx context.Context, params ConvertParams) (_ *Conversion, err error) {
+	ctx, end := s.start(ctx, "Convert", tracing.OrganizationIDKey.String(params.OrganizationID))
+	defer func() { end(err) }()
+
+	conversion := Conversion{
+		ID:               s.idProvider.GenerateId(),
+		LCOrganizationID: params.OrganizationID,
+		FromCurrency:     params.From.orDefault(),
+		FromAmount:       params.Amount,
+		ToCurrency:       params.To.orDefault(),
+		Rate:             params.Rate,
+	}
+	event := s.eventService.ToEvent(ctx, params.OrganizationID, events.EventActionConvertCurrency, events.EventTypeInfo, ConvertCurrencyEventPayload{Conversion: conversion})
+
+	conversion.ToAmount, err = conversion.Rate.Convert(conversion.FromAmount)
+	if err == nil {
+		err = conversion.validate()
+	}
+	if err != nil {
+		event.Type = events.EventTypeError
+		return nil, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to convert funds: %w", err),
+		})
+	}
+
+	payload, err := json.Marshal(conversion)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return nil, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   err,
+		})
+	}
+	operations := conversion.operations(payload)
+	if s.doubleEntry {
+		for i, o := range operations {
+			operations[i] = o.post(AccountCurrencyExchange)
+		}
+	}
+	if err = s.storage.CreateConversion(ctx, CreateConversionParams{
+		Conversion: conversion,
+		Operations: operations,
+	}); err != nil {
+		event.Type = events.EventTypeError
+		return nil, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to create conversion in database: %w", err),
+		})
+	}
+
+	event.SetPayload(ConvertCurrencyEventPayload{Conversion: conversion})
+	s.createEvent(ctx, event)
+
+	return &conversion, nil
+}
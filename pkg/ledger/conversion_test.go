@@ -0,0 +1,108 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+func TestService_Convert(t *testing.T) {
+	lcoid := "lcOrganizationID"
+	params := ConvertParams{
+		OrganizationID: lcoid,
+		Amount:         Money(10000),
+		From:           CurrencyUSD,
+		To:             CurrencyEUR,
+		Rate:           Rate(923400),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		xm.On("GenerateId").Return(xid, nil).Once()
+		conversion := Conversion{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			FromCurrency:     CurrencyUSD,
+			FromAmount:       10000,
+			ToCurrency:       CurrencyEUR,
+			Rate:             923400,
+		}
+		event := events.Event{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			Type:             events.EventTypeInfo,
+			Action:           events.EventActionConvertCurrency,
+		}
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionConvertCurrency, events.EventTypeInfo, ConvertCurrencyEventPayload{Conversion: conversion}).Return(event).Once()
+
+		conversion.ToAmount = 9234
+		payload, _ := json.Marshal(conversion)
+		sm.On("CreateConversion", ctx, CreateConversionParams{
+			Conversion: conversion,
+			Operations: []Operation{
+				{ID: xid + "-from", LCOrganizationID: lcoid, Amount: -10000, Currency: CurrencyUSD, Payload: payload},
+				{ID: xid + "-to", LCOrganizationID: lcoid, Amount: 9234, Currency: CurrencyEUR, Payload: payload},
+			},
+		}).Return(nil).Once()
+		jp, _ := json.Marshal(ConvertCurrencyEventPayload{Version: 1, Conversion: conversion})
+		event.Payload = jp
+		em.On("CreateEvent", context.Background(), event).Return(nil).Once()
+
+		c, err := s.Convert(ctx, params)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &conversion, c)
+
+		assertExpectations(t)
+	})
+
+	t.Run("error same currency", func(t *testing.T) {
+		xm.On("GenerateId").Return(xid, nil).Once()
+		event := events.Event{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			Type:             events.EventTypeError,
+			Action:           events.EventActionConvertCurrency,
+		}
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionConvertCurrency, events.EventTypeInfo, mock.Anything).Return(event).Once()
+		em.On("ToError", context.Background(), mock.MatchedBy(func(p events.ToErrorParams) bool {
+			return p.Event.ID == xid && errors.Is(p.Err, ErrInvalidConversion)
+		})).Return(assert.AnError).Once()
+
+		c, err := s.Convert(ctx, ConvertParams{OrganizationID: lcoid, Amount: 10000, From: CurrencyUSD, Rate: 1000000})
+
+		assert.Nil(t, c)
+		assert.ErrorIs(t, err, assert.AnError)
+
+		assertExpectations(t)
+	})
+
+	t.Run("error storage", func(t *testing.T) {
+		xm.On("GenerateId").Return(xid, nil).Once()
+		event := events.Event{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			Type:             events.EventTypeError,
+			Action:           events.EventActionConvertCurrency,
+		}
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionConvertCurrency, events.EventTypeInfo, mock.Anything).Return(event).Once()
+		sm.On("CreateConversion", ctx, mock.Anything).Return(assert.AnError).Once()
+		em.On("ToError", context.Background(), events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to create conversion in database: %w", assert.AnError),
+		}).Return(assert.AnError).Once()
+
+		c, err := s.Convert(ctx, params)
+
+		assert.Nil(t, c)
+		assert.ErrorIs(t, err, assert.AnError)
+
+		assertExpectations(t)
+	})
+}
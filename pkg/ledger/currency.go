@@ -0,0 +1,96 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrInvalidConversion is returned for conversions that would not move funds between two currencies.
+	ErrInvalidConversion = errors.New("invalid conversion")
+	// ErrUnsupportedCurrency is returned for top ups in a currency LiveChat does not bill in, convert the funds instead.
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+)
+
+// Currency is the currency or unit of the ledger amounts, amounts in different currencies are never summed up.
+type Currency string
+
+const (
+	CurrencyUSD Currency = "USD"
+	CurrencyEUR Currency = "EUR"
+	// CurrencyCredits is the unit of the voucher funds.
+	CurrencyCredits Currency = "credits"
+)
+
+// DefaultCurrency is used for the operations and top ups without a currency, it is the currency of the operations
+// stored before the ledger had currencies. Voucher funds default to CurrencyCredits.
+const DefaultCurrency = CurrencyUSD
+
+// BillingCurrency is the currency of the LiveChat charges, the only one top up requests accept.
+const BillingCurrency = CurrencyUSD
+
+func (c Currency) orDefault() Currency {
+	if c == "" {
+		return DefaultCurrency
+	}
+
+	return c
+}
+
+// Balances are the balances of an organization by currency, currencies without operations are missing.
+type Balances map[Currency]Money
+
+// rateDigits is the number of decimal places of Rate, the scale of the numeric(18,6) rate column.
+const rateDigits = 6
+
+// Rate is an exact conversion rate in millionths, e.g. Rate(923400) is 0.9234 units of the target currency for a
+// unit of the source currency. It is encoded in JSON as a decimal number.
+type Rate int64
+
+// ParseRate parses a decimal rate, e.g. "0.9234". Rates with more than 6 decimal places are rejected.
+func ParseRate(s string) (Rate, error) {
+	v, ok := parseDecimal(s, rateDigits)
+	if !ok {
+		return 0, fmt.Errorf("%w: invalid rate %q", ErrInvalidConversion, s)
+	}
+
+	return Rate(v), nil
+}
+
+// Convert returns the amount in the target currency, rounded half away from zero to Money.
+func (r Rate) Convert(m Money) (Money, error) {
+	v := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(int64(r)))
+	scale := big.NewInt(1_000_000)
+	q, rem := new(big.Int).QuoRem(v, scale, new(big.Int))
+	if rem.Abs(rem).Mul(rem, big.NewInt(2)).Cmp(scale) >= 0 {
+		q.Add(q, big.NewInt(int64(v.Sign())))
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("%w: %s converted at %s is out of range", ErrInvalidMoney, m, r)
+	}
+
+	return Money(q.Int64()), nil
+}
+
+func (r Rate) String() string {
+	return formatDecimal(int64(r), rateDigits)
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	v, err := ParseRate(string(data))
+	if err != nil {
+		return err
+	}
+	*r = v
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package ledger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrency_orDefault(t *testing.T) {
+	assert.Equal(t, CurrencyUSD, Currency("").orDefault())
+	assert.Equal(t, CurrencyCredits, CurrencyCredits.orDefault())
+}
+
+func TestParseRate(t *testing.T) {
+	for s, expected := range map[string]Rate{
+		"0.9234":   923400,
+		"1":        1000000,
+		"0.000001": 1,
+		"100.5":    100500000,
+	} {
+		r, err := ParseRate(s)
+
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, r, s)
+		assert.Equal(t, s, r.String(), s)
+	}
+
+	for _, s := range []string{"", "0.0000001", "1e3", "abc"} {
+		_, err := ParseRate(s)
+
+		assert.ErrorIs(t, err, ErrInvalidConversion, s)
+	}
+}
+
+func TestRate_Convert(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		for _, c := range []struct {
+			rate     Rate
+			amount   Money
+			expected Money
+		}{
+			{rate: 923400, amount: 10000, expected: 9234},
+			{rate: 1000000, amount: 5230, expected: 5230},
+			{rate: 500000, amount: 1, expected: 1},
+			{rate: 500000, amount: -1, expected: -1},
+			{rate: 400000, amount: 1, expected: 0},
+			{rate: 100000000, amount: 5230, expected: 523000},
+		} {
+			m, err := c.rate.Convert(c.amount)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, m, "%s at %s", c.amount, c.rate)
+		}
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		_, err := Rate(100000000000).Convert(Money(1 << 60))
+
+		assert.ErrorIs(t, err, ErrInvalidMoney)
+	})
+}
+
+func TestRate_JSON(t *testing.T) {
+	jc, err := json.Marshal(Conversion{ID: "id", Rate: 923400})
+	assert.NoError(t, err)
+	assert.Contains(t, string(jc), `"rate":0.9234`)
+
+	var c Conversion
+	assert.NoError(t, json.Unmarshal(jc, &c))
+	assert.Equal(t, Rate(923400), c.Rate)
+}
@@ -0,0 +1,158 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+// ErrUnbalancedEntries is returned by the storage for operations whose entries don't balance.
+var ErrUnbalancedEntries = errors.New("unbalanced ledger entries")
+
+// Account is a named account of the double-entry ledger.
+type Account string
+
+const (
+	// AccountCustomerWallet holds the funds of the organization, its balance is the GetBalance balance.
+	AccountCustomerWallet Account = "customer_wallet"
+	// AccountRevenue is the counterpart of the charges.
+	AccountRevenue Account = "revenue"
+	// AccountPromo is the counterpart of the voucher funds.
+	AccountPromo Account = "promo"
+	// AccountLiveChatClearing is the counterpart of the top ups paid through LiveChat.
+	AccountLiveChatClearing Account = "livechat_clearing"
+	// AccountCurrencyExchange is the counterpart of both operations of a conversion.
+	AccountCurrencyExchange Account = "currency_exchange"
+)
+
+func (a Account) valid() bool {
+	switch a {
+	case AccountCustomerWallet, AccountRevenue, AccountPromo, AccountLiveChatClearing, AccountCurrencyExchange:
+		return true
+	}
+
+	return false
+}
+
+// Entry is a signed amount posted to an account by an operation. The entries of an operation sum up to zero in
+// each currency.
+type Entry struct {
+	OperationID      string   `json:"operation_id"`
+	LCOrganizationID string   `json:"lc_organization_id"`
+	Account          Account  `json:"account"`
+	Currency         Currency `json:"currency"`
+	Amount           Money    `json:"amount"`
+}
+
+// WithDoubleEntry makes every operation post balanced entries between the customer wallet and its counterpart
+// account. Trial balances only cover the operations created with double-entry enabled.
+func WithDoubleEntry() Option {
+	return func(s *Service) {
+		s.doubleEntry = true
+	}
+}
+
+// counterAccount returns the account balancing the customer wallet entry of the operation.
+func (o Operation) counterAccount() Account {
+	switch {
+	case o.IsVoucher:
+		return AccountPromo
+	case o.Amount < 0:
+		return AccountRevenue
+	default:
+		return AccountLiveChatClearing
+	}
+}
+
+// post returns the operation with the customer wallet entry of its amount balanced by the counter account.
+func (o Operation) post(counter Account) Operation {
+	o.Entries = []Entry{
+		{
+			OperationID:      o.ID,
+			LCOrganizationID: o.LCOrganizationID,
+			Account:          AccountCustomerWallet,
+			Currency:         o.Currency,
+			Amount:           o.Amount,
+		},
+		{
+			OperationID:      o.ID,
+			LCOrganizationID: o.LCOrganizationID,
+			Account:          counter,
+			Currency:         o.Currency,
+			Amount:           -o.Amount,
+		},
+	}
+
+	return o
+}
+
+// ValidateEntries checks the invariants of the operation entries: they belong to the operation, post to known
+// accounts, sum up to zero in each currency and move the customer wallet by the operation amount. Operations
+// without entries are valid. Storages call it before storing the entries.
+func (o Operation) ValidateEntries() error {
+	if len(o.Entries) == 0 {
+		return nil
+	}
+
+	sums := map[Currency]Money{}
+	var wallet Money
+	for _, e := range o.Entries {
+		switch {
+		case e.OperationID != o.ID || e.LCOrganizationID != o.LCOrganizationID:
+			return fmt.Errorf("%w: entry of %s in operation %s", ErrUnbalancedEntries, e.OperationID, o.ID)
+		case !e.Account.valid():
+			return fmt.Errorf("%w: unknown account %q in operation %s", ErrUnbalancedEntries, e.Account, o.ID)
+		case e.Account == AccountCustomerWallet && e.Currency != o.Currency:
+			return fmt.Errorf("%w: wallet entry in %s in operation %s in %s", ErrUnbalancedEntries, e.Currency, o.ID, o.Currency)
+		}
+		sums[e.Currency] += e.Amount
+		if e.Account == AccountCustomerWallet {
+			wallet += e.Amount
+		}
+	}
+	for c, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%w: entries of operation %s sum up to %s %s", ErrUnbalancedEntries, o.ID, sum, c)
+		}
+	}
+	if wallet != o.Amount {
+		return fmt.Errorf("%w: wallet entries of operation %s sum up to %s instead of %s", ErrUnbalancedEntries, o.ID, wallet, o.Amount)
+	}
+
+	return nil
+}
+
+// TrialBalanceLine is the balance of an account in a currency.
+type TrialBalanceLine struct {
+	Account  Account  `json:"account"`
+	Currency Currency `json:"currency"`
+	Balance  Money    `json:"balance"`
+}
+
+// TrialBalance lists the balances of the accounts with entries, ordered by account and currency.
+type TrialBalance []TrialBalanceLine
+
+// Balanced reports whether the balances sum up to zero in each currency.
+func (t TrialBalance) Balanced() bool {
+	sums := map[Currency]Money{}
+	for _, l := range t {
+		sums[l.Currency] += l.Balance
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetTrialBalance returns the trial balance of the organization, or of the whole ledger for an empty organizationID.
+func (s *Service) GetTrialBalance(ctx context.Context, organizationID string) (_ TrialBalance, err error) {
+	ctx, end := s.start(ctx, "GetTrialBalance", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { end(err) }()
+
+	return s.storage.GetTrialBalance(ctx, organizationID)
+}
@@ -0,0 +1,122 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+func TestOperation_counterAccount(t *testing.T) {
+	assert.Equal(t, AccountRevenue, Operation{Amount: -5230}.counterAccount())
+	assert.Equal(t, AccountLiveChatClearing, Operation{Amount: 5230}.counterAccount())
+	assert.Equal(t, AccountPromo, Operation{Amount: 5230, IsVoucher: true}.counterAccount())
+}
+
+func TestOperation_ValidateEntries(t *testing.T) {
+	operation := Operation{ID: "id", LCOrganizationID: "lcOrganizationID", Amount: -5230, Currency: CurrencyUSD}
+
+	t.Run("success", func(t *testing.T) {
+		assert.NoError(t, operation.ValidateEntries())
+		assert.NoError(t, operation.post(AccountRevenue).ValidateEntries())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		posted := operation.post(AccountRevenue)
+		for name, entries := range map[string][]Entry{
+			"unbalanced":      {posted.Entries[0]},
+			"unknown account": {posted.Entries[0], {OperationID: "id", LCOrganizationID: "lcOrganizationID", Account: "cash", Currency: CurrencyUSD, Amount: 5230}},
+			"other operation": {posted.Entries[0], {OperationID: "other", LCOrganizationID: "lcOrganizationID", Account: AccountRevenue, Currency: CurrencyUSD, Amount: 5230}},
+			"wallet currency": Operation{ID: "id", LCOrganizationID: "lcOrganizationID", Amount: -5230, Currency: CurrencyEUR}.post(AccountRevenue).Entries,
+			"wallet amount":   Operation{ID: "id", LCOrganizationID: "lcOrganizationID", Amount: -100, Currency: CurrencyUSD}.post(AccountRevenue).Entries,
+		} {
+			o := operation
+			o.Entries = entries
+
+			assert.ErrorIs(t, o.ValidateEntries(), ErrUnbalancedEntries, name)
+		}
+	})
+}
+
+func TestTrialBalance_Balanced(t *testing.T) {
+	assert.True(t, TrialBalance{}.Balanced())
+	assert.True(t, TrialBalance{
+		{Account: AccountCustomerWallet, Currency: CurrencyUSD, Balance: 1000},
+		{Account: AccountLiveChatClearing, Currency: CurrencyUSD, Balance: -1000},
+		{Account: AccountCustomerWallet, Currency: CurrencyCredits, Balance: 500},
+		{Account: AccountPromo, Currency: CurrencyCredits, Balance: -500},
+	}.Balanced())
+	assert.False(t, TrialBalance{
+		{Account: AccountCustomerWallet, Currency: CurrencyUSD, Balance: 1000},
+		{Account: AccountPromo, Currency: CurrencyCredits, Balance: -1000},
+	}.Balanced())
+}
+
+func TestService_CreateCharge_doubleEntry(t *testing.T) {
+	des := s
+	WithDoubleEntry()(&des)
+	amount := Money(5230)
+	lcoid := "lcOrganizationID"
+
+	xm.On("GenerateId").Return(xid, nil).Once()
+	operation := Operation{
+		ID:               xid,
+		LCOrganizationID: lcoid,
+		Amount:           -amount,
+		Currency:         CurrencyUSD,
+	}
+	posted := operation
+	posted.Entries = []Entry{
+		{OperationID: xid, LCOrganizationID: lcoid, Account: AccountCustomerWallet, Currency: CurrencyUSD, Amount: -amount},
+		{OperationID: xid, LCOrganizationID: lcoid, Account: AccountRevenue, Currency: CurrencyUSD, Amount: amount},
+	}
+	sm.On("CreateLedgerOperation", ctx, posted).Return(nil).Once()
+	sc, _ := json.Marshal(operation)
+	levent := events.Event{
+		ID:               xid,
+		LCOrganizationID: lcoid,
+		Type:             events.EventTypeInfo,
+		Action:           events.EventActionCreateOperation,
+		Payload:          sc,
+	}
+	em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation}).Return(levent).Once()
+	em.On("CreateEvent", context.Background(), levent).Return(nil).Once()
+
+	id, err := des.CreateCharge(context.Background(), CreateChargeParams{Name: "name", Amount: amount, OrganizationID: lcoid})
+
+	assert.NoError(t, err)
+	assert.Equal(t, xid, id)
+
+	assertExpectations(t)
+}
+
+func TestService_GetTrialBalance(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		balance := TrialBalance{
+			{Account: AccountCustomerWallet, Currency: CurrencyUSD, Balance: -5230},
+			{Account: AccountRevenue, Currency: CurrencyUSD, Balance: 5230},
+		}
+		sm.On("GetTrialBalance", ctx, "lcOrganizationID").Return(balance, nil).Once()
+
+		b, err := s.GetTrialBalance(ctx, "lcOrganizationID")
+
+		assert.NoError(t, err)
+		assert.Equal(t, balance, b)
+
+		assertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		sm.On("GetTrialBalance", ctx, "").Return(nil, assert.AnError).Once()
+
+		b, err := s.GetTrialBalance(ctx, "")
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, b)
+
+		assertExpectations(t)
+	})
+}
@@ -0,0 +1,122 @@
+package ledger
+
+import (
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+// RegisterEventPayloads registers the payloads of the ledger events, the events of the ledger events table.
+func RegisterEventPayloads(r *events.PayloadRegistry) {
+	r.Register(events.EventActionTopUp, TopUpEventPayload{})
+	r.Register(events.EventActionSyncTopUp, TopUpEventPayload{})
+	r.Register(events.EventActionActivateCharge, TopUpEventPayload{})
+	r.Register(events.EventActionCreateTopUp, CreateTopUpEventPayload{})
+	r.Register(events.EventActionAddVoucherFunds, AddVoucherFundsEventPayload{})
+	r.Register(events.EventActionCancelRecurrentTopUp, CancelRecurrentTopUpEventPayload{})
+	r.Register(events.EventActionForceCancelCharge, ForceCancelTopUpEventPayload{})
+	r.Register(events.EventActionCreateOperation, CreateOperationEventPayload{})
+	r.Register(events.EventActionConvertCurrency, ConvertCurrencyEventPayload{})
+	r.Register(events.EventActionReconcile, ReconcileEventPayload{})
+	r.Register(events.EventActionVerifyBalances, VerifyBalancesEventPayload{})
+	r.Register(events.EventActionRepairBalance, RepairBalanceEventPayload{})
+	r.Register(events.EventActionDPSWebhookApplicationUninstalled, DPSWebhookEventPayload{})
+	r.Register(events.EventActionDPSWebhookPayment, DPSWebhookEventPayload{})
+}
+
+// TopUpEventPayload is the payload of the events of a single top up, synced ones have the top up after the sync.
+// Version 2 top ups have a currency.
+type TopUpEventPayload struct {
+	Version int   `json:"version"`
+	TopUp   TopUp `json:"top_up"`
+}
+
+func (TopUpEventPayload) PayloadVersion() int { return 2 }
+
+// CreateTopUpEventPayload has the top up once it is stored. Version 2 requests and top ups have a currency.
+type CreateTopUpEventPayload struct {
+	Version int                      `json:"version"`
+	Request CreateTopUpRequestParams `json:"request"`
+	TopUp   *TopUp                   `json:"top_up,omitempty"`
+}
+
+func (CreateTopUpEventPayload) PayloadVersion() int { return 2 }
+
+// AddVoucherFundsEventPayload has the currency of the funds since version 2.
+type AddVoucherFundsEventPayload struct {
+	Version     int      `json:"version"`
+	OperationID string   `json:"operation_id"`
+	Amount      Money    `json:"amount"`
+	Currency    Currency `json:"currency"`
+	Namespace   string   `json:"namespace"`
+	// Result is set once the funds are added or found.
+	Result string `json:"result,omitempty"`
+}
+
+func (AddVoucherFundsEventPayload) PayloadVersion() int { return 2 }
+
+type CancelRecurrentTopUpEventPayload struct {
+	Version int    `json:"version"`
+	TopUpID string `json:"top_up_id"`
+	// Result is set once the top up is cancelled or not found.
+	Result string `json:"result,omitempty"`
+}
+
+func (CancelRecurrentTopUpEventPayload) PayloadVersion() int { return 1 }
+
+type ForceCancelTopUpEventPayload struct {
+	Version int         `json:"version"`
+	TopUpID string      `json:"top_up_id"`
+	Status  TopUpStatus `json:"status"`
+	// Result is set when the top up is not found or was not cancelled because its status changed.
+	Result string `json:"result,omitempty"`
+}
+
+func (ForceCancelTopUpEventPayload) PayloadVersion() int { return 1 }
+
+// CreateOperationEventPayload has the currency of the operation since version 2.
+type CreateOperationEventPayload struct {
+	Version   int       `json:"version"`
+	Operation Operation `json:"operation"`
+}
+
+func (CreateOperationEventPayload) PayloadVersion() int { return 2 }
+
+// ConvertCurrencyEventPayload has the converted amount once the conversion is stored.
+type ConvertCurrencyEventPayload struct {
+	Version    int        `json:"version"`
+	Conversion Conversion `json:"conversion"`
+}
+
+func (ConvertCurrencyEventPayload) PayloadVersion() int { return 1 }
+
+// ReconcileEventPayload has the report once the reconciliation is done.
+type ReconcileEventPayload struct {
+	Version int                   `json:"version"`
+	AutoFix bool                  `json:"auto_fix"`
+	Report  *ReconciliationReport `json:"report,omitempty"`
+}
+
+func (ReconcileEventPayload) PayloadVersion() int { return 1 }
+
+// VerifyBalancesEventPayload has the report once the latest snapshot is verified.
+type VerifyBalancesEventPayload struct {
+	Version int                        `json:"version"`
+	Report  *BalanceVerificationReport `json:"report,omitempty"`
+}
+
+func (VerifyBalancesEventPayload) PayloadVersion() int { return 1 }
+
+// RepairBalanceEventPayload has the balance before and after the repair.
+type RepairBalanceEventPayload struct {
+	Version int            `json:"version"`
+	Repair  *BalanceRepair `json:"repair,omitempty"`
+}
+
+func (RepairBalanceEventPayload) PayloadVersion() int { return 1 }
+
+// DPSWebhookEventPayload is the webhook request, its fields are promoted to the payload.
+type DPSWebhookEventPayload struct {
+	Version int `json:"version"`
+	DPSWebhookRequest
+}
+
+func (DPSWebhookEventPayload) PayloadVersion() int { return 1 }
@@ -0,0 +1,33 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+func TestRegisterEventPayloads(t *testing.T) {
+	r := events.NewPayloadRegistry()
+	RegisterEventPayloads(r)
+
+	p, err := r.Decode(events.Event{
+		Action:  events.EventActionAddVoucherFunds,
+		Payload: []byte(`{"version":1,"operation_id":"id","amount":5.5,"namespace":"ns","result":"success"}`),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &AddVoucherFundsEventPayload{Version: 1, OperationID: "id", Amount: Money(5500), Namespace: "ns", Result: "success"}, p)
+
+	p, err = r.Decode(events.Event{
+		Action:  events.EventActionCreateTopUp,
+		Payload: []byte(`{"version":2,"request":{"name":"n","amount":5,"currency":"USD","organizationId":"lcoid","type":"direct"}}`),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &CreateTopUpEventPayload{Version: 2, Request: CreateTopUpRequestParams{Name: "n", Amount: Money(5000), Currency: CurrencyUSD, OrganizationID: "lcoid", Type: TopUpTypeDirect}}, p)
+
+	_, err = r.JSONSchema()
+	assert.NoError(t, err)
+}
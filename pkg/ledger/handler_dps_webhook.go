@@ -3,48 +3,98 @@ package ledger
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
 )
 
 type DPSWebhookRequest struct {
 	ApplicationID    string                 `json:"applicationID"`
 	ApplicationName  string                 `json:"applicationName"`
-	ClientID         string                 `json:"clientID"`
+	ClientID         string                 `json:"clientID" redact:"mask"`
 	Date             time.Time              `json:"date"`
 	Event            string                 `json:"event"`
 	License          int32                  `json:"licenseID"`
 	LCOrganizationID string                 `json:"organizationID"`
 	Payload          map[string]interface{} `json:"payload"`
-	UserID           string                 `json:"userID"`
+	UserID           string                 `json:"userID" redact:"mask"`
 }
 
 type Handler struct {
 	ledger       LedgerInterface
 	idProvider   events.IdProviderInterface
 	eventService events.EventService
+	metrics      metrics.Metrics
+	tracer       trace.Tracer
+	logger       *slog.Logger
+}
+
+// HandlerOption configures optional Handler behaviour.
+type HandlerOption func(*Handler)
+
+// WithHandlerMetrics reports every handled webhook to m, labelled with its event.
+func WithHandlerMetrics(m metrics.Metrics) HandlerOption {
+	return func(h *Handler) {
+		h.metrics = m
+	}
 }
 
 type HandlerInterface interface {
 	HandleDPSWebhook(ctx context.Context, req DPSWebhookRequest) error
 }
 
-func NewHandler(eventService events.EventService, ledger LedgerInterface, idProvider events.IdProviderInterface) *Handler {
-	return &Handler{
+func NewHandler(eventService events.EventService, ledger LedgerInterface, idProvider events.IdProviderInterface, opts ...HandlerOption) *Handler {
+	h := &Handler{
 		ledger:       ledger,
 		idProvider:   idProvider,
 		eventService: eventService,
+		metrics:      metrics.Noop{},
+		logger:       slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	return h
 }
 
 func (h *Handler) HandleDPSWebhook(ctx context.Context, req DPSWebhookRequest) error {
-	ctx = context.WithValue(ctx, LedgerEventIDCtxKey{}, h.idProvider.GenerateId())
+	start := time.Now()
+	eventID := h.idProvider.GenerateId()
+	ctx = context.WithValue(ctx, LedgerEventIDCtxKey{}, eventID)
+
+	attrs := []attribute.KeyValue{tracing.WebhookEventKey.String(req.Event), tracing.OrganizationIDKey.String(req.LCOrganizationID), tracing.EventIDKey.String(eventID)}
+	if chargeID, ok := req.Payload["paymentID"].(string); ok {
+		attrs = append(attrs, tracing.ChargeIDKey.String(chargeID))
+	}
+	ctx, span := tracing.Start(ctx, h.tracer, "ledger.HandleDPSWebhook", attrs...)
+	err := h.handleDPSWebhook(ctx, req)
+	tracing.End(span, err)
+	if err != nil {
+		h.logger.LogAttrs(ctx, slog.LevelError, "ledger.HandleDPSWebhook failed", append(tracing.LogAttrs(attrs), slog.Any("error", err))...)
+	}
+
+	labels := metrics.Labels{Action: req.Event, Status: metrics.Status(err)}
+	h.metrics.IncCounter(metrics.WebhooksTotal, labels)
+	metrics.ObserveSince(h.metrics, metrics.WebhookDurationSeconds, labels, start)
+
+	return err
+}
+
+// handleDPSWebhook expects the LedgerEventIDCtxKey set by HandleDPSWebhook.
+func (h *Handler) handleDPSWebhook(ctx context.Context, req DPSWebhookRequest) error {
 	ctx = context.WithValue(ctx, LedgerOrganizationIDCtxKey{}, req.LCOrganizationID)
 
 	switch req.Event {
 	case "application_uninstalled":
-		event := h.eventService.ToEvent(ctx, req.LCOrganizationID, events.EventActionDPSWebhookApplicationUninstalled, events.EventTypeInfo, req)
+		event := h.eventService.ToEvent(ctx, req.LCOrganizationID, events.EventActionDPSWebhookApplicationUninstalled, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req})
+		ctx = events.WithParent(ctx, event)
 		topUps, err := h.ledger.GetTopUpsByOrganizationIDAndStatus(ctx, req.LCOrganizationID, TopUpStatusActive)
 		if err != nil {
 			event.Type = events.EventTypeError
@@ -62,9 +112,10 @@ func (h *Handler) HandleDPSWebhook(ctx context.Context, req DPSWebhookRequest) e
 				})
 			}
 		}
-		_ = h.eventService.CreateEvent(ctx, event)
+		h.createEvent(ctx, event)
 	case "payment_collected", "payment_activated", "payment_cancelled", "payment_declined":
-		event := h.eventService.ToEvent(ctx, req.LCOrganizationID, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req)
+		event := h.eventService.ToEvent(ctx, req.LCOrganizationID, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req})
+		ctx = events.WithParent(ctx, event)
 		paymentID, ok := req.Payload["paymentID"].(string)
 		if !ok {
 			event.Type = events.EventTypeError
@@ -73,36 +124,45 @@ func (h *Handler) HandleDPSWebhook(ctx context.Context, req DPSWebhookRequest) e
 				Err:   fmt.Errorf("payment id field not found in payload"),
 			})
 		}
-		topUp, err := h.ledger.GetTopUpByIDAndOrganizationID(ctx, req.LCOrganizationID, paymentID)
-		if err != nil {
-			event.Type = events.EventTypeError
-			return h.eventService.ToError(ctx, events.ToErrorParams{
-				Event: event,
-				Err:   err,
-			})
-		}
-		if topUp == nil {
-			event.Error = "top up not found"
-			_ = h.eventService.CreateEvent(ctx, event)
-			return nil
-		}
+		// the top up is read, synced and credited under one lock, so a sync job cannot rewrite it in between
+		return h.ledger.WithTopUpLock(ctx, paymentID, func(ctx context.Context) error {
+			return h.handlePayment(ctx, req, paymentID, event)
+		})
+	}
 
-		if topUp, err = h.syncTopUp(ctx, req.LCOrganizationID, paymentID, req.Event, *topUp); err != nil {
-			event.Type = events.EventTypeError
-			return h.eventService.ToError(ctx, events.ToErrorParams{
-				Event: event,
-				Err:   err,
-			})
-		}
+	return nil
+}
 
-		if req.Event == "payment_collected" {
-			_, err := h.ledger.TopUp(ctx, *topUp)
-			if err != nil {
-				return fmt.Errorf("top up: %w", err)
-			}
+func (h *Handler) handlePayment(ctx context.Context, req DPSWebhookRequest, paymentID string, event events.Event) error {
+	topUp, err := h.ledger.GetTopUpByIDAndOrganizationID(ctx, req.LCOrganizationID, paymentID)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return h.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   err,
+		})
+	}
+	if topUp == nil {
+		event.Error = "top up not found"
+		h.createEvent(ctx, event)
+		return nil
+	}
+
+	if topUp, err = h.syncTopUp(ctx, req.LCOrganizationID, paymentID, req.Event, *topUp); err != nil {
+		event.Type = events.EventTypeError
+		return h.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   err,
+		})
+	}
+
+	if req.Event == "payment_collected" {
+		_, err := h.ledger.TopUp(ctx, *topUp)
+		if err != nil {
+			return fmt.Errorf("top up: %w", err)
 		}
-		_ = h.eventService.CreateEvent(ctx, event)
 	}
+	h.createEvent(ctx, event)
 
 	return nil
 }
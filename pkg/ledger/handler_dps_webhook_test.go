@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
 )
 
 var lm = new(ledgerMock)
@@ -19,6 +20,8 @@ var h = Handler{
 	eventService: em,
 	ledger:       lm,
 	idProvider:   xm,
+	metrics:      metrics.Noop{},
+	logger:       discardLogger,
 }
 
 var lcoid = "lcOrganizationID"
@@ -34,6 +37,13 @@ func initCtx() context.Context {
 
 type ledgerMock struct {
 	mock.Mock
+	// lockedTopUps records WithTopUpLock calls, the lock is a pass-through so it does not need expectations
+	lockedTopUps []string
+}
+
+func (l *ledgerMock) WithTopUpLock(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	l.lockedTopUps = append(l.lockedTopUps, id)
+	return fn(ctx)
 }
 
 func (l *ledgerMock) RecentlyAddedFunds(ctx context.Context, OrganizationID, Namespace string) (*Operation, error) {
@@ -41,7 +51,32 @@ func (l *ledgerMock) RecentlyAddedFunds(ctx context.Context, OrganizationID, Nam
 	panic("implement me")
 }
 
-func (l *ledgerMock) AddVoucherFunds(ctx context.Context, Amount float32, OrganizationID, Namespace string, Payload *json.RawMessage) error {
+func (l *ledgerMock) AddVoucherFunds(ctx context.Context, Amount Money, Currency Currency, OrganizationID, Namespace string, Payload *json.RawMessage) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (l *ledgerMock) Convert(ctx context.Context, params ConvertParams) (*Conversion, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (l *ledgerMock) GetTrialBalance(ctx context.Context, organizationID string) (TrialBalance, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (l *ledgerMock) SnapshotBalances(ctx context.Context) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (l *ledgerMock) VerifyBalances(ctx context.Context) (*BalanceVerificationReport, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (l *ledgerMock) RepairBalance(ctx context.Context, organizationID string, currency Currency) (*BalanceRepair, error) {
 	//TODO implement me
 	panic("implement me")
 }
@@ -80,6 +115,14 @@ func (l *ledgerMock) SyncOrCancelTopUpRequests(ctx context.Context) error {
 	panic("implement me")
 }
 
+func (l *ledgerMock) Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconciliationReport, error) {
+	args := l.Called(ctx, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ReconciliationReport), args.Error(1)
+}
+
 func (l *ledgerMock) CreateTopUpRequest(ctx context.Context, params CreateTopUpRequestParams) (*TopUp, error) {
 	//TODO implement me
 	panic("implement me")
@@ -95,7 +138,7 @@ func (l *ledgerMock) CreateCharge(ctx context.Context, params CreateChargeParams
 	panic("implement me")
 }
 
-func (l *ledgerMock) GetBalance(ctx context.Context, organizationID string) (float32, error) {
+func (l *ledgerMock) GetBalance(ctx context.Context, organizationID string) (Balances, error) {
 	//TODO implement me
 	panic("implement me")
 }
@@ -145,7 +188,7 @@ func TestNewHandler(t *testing.T) {
 
 func TestService_HandleDPSWebhook(t *testing.T) {
 	t.Run("success application_uninstalled", func(t *testing.T) {
-		amount := float32(5.234)
+		amount := Money(5234)
 		eventType := "application_uninstalled"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		someDate2, _ := time.Parse(time.DateTime, "2025-06-14 12:31:56")
@@ -193,11 +236,11 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("GetTopUpsByOrganizationIDAndStatus", ledgerCtx, lcoid, TopUpStatusActive).Return([]TopUp{top1, top2}, nil).Once()
-		lm.On("ForceCancelTopUp", ledgerCtx, top1).Return(nil).Once()
-		lm.On("ForceCancelTopUp", ledgerCtx, top2).Return(nil).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookApplicationUninstalled, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", ledgerCtx, levent).Return(nil).Once()
+		lm.On("GetTopUpsByOrganizationIDAndStatus", events.WithParent(ledgerCtx, levent), lcoid, TopUpStatusActive).Return([]TopUp{top1, top2}, nil).Once()
+		lm.On("ForceCancelTopUp", events.WithParent(ledgerCtx, levent), top1).Return(nil).Once()
+		lm.On("ForceCancelTopUp", events.WithParent(ledgerCtx, levent), top2).Return(nil).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookApplicationUninstalled, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(ledgerCtx, levent), levent).Return(nil).Once()
 
 		err := h.HandleDPSWebhook(context.Background(), req)
 
@@ -236,9 +279,9 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("GetTopUpsByOrganizationIDAndStatus", ledgerCtx, lcoid, TopUpStatusActive).Return([]TopUp{}, nil).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookApplicationUninstalled, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", ledgerCtx, levent).Return(nil).Once()
+		lm.On("GetTopUpsByOrganizationIDAndStatus", events.WithParent(ledgerCtx, levent), lcoid, TopUpStatusActive).Return([]TopUp{}, nil).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookApplicationUninstalled, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(ledgerCtx, levent), levent).Return(nil).Once()
 
 		err := h.HandleDPSWebhook(context.Background(), req)
 
@@ -277,9 +320,9 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("GetTopUpsByOrganizationIDAndStatus", ledgerCtx, lcoid, TopUpStatusActive).Return(nil, assert.AnError).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookApplicationUninstalled, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", ledgerCtx, events.ToErrorParams{
+		lm.On("GetTopUpsByOrganizationIDAndStatus", events.WithParent(ledgerCtx, levent), lcoid, TopUpStatusActive).Return(nil, assert.AnError).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookApplicationUninstalled, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(ledgerCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
 		}).Return(assert.AnError).Once()
@@ -292,7 +335,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 	})
 
 	t.Run("application_uninstalled force cancel error", func(t *testing.T) {
-		amount := float32(5.234)
+		amount := Money(5234)
 		eventType := "application_uninstalled"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		someDate2, _ := time.Parse(time.DateTime, "2025-06-14 12:31:56")
@@ -340,11 +383,11 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("GetTopUpsByOrganizationIDAndStatus", ledgerCtx, lcoid, TopUpStatusActive).Return([]TopUp{top1, top2}, nil).Once()
-		lm.On("ForceCancelTopUp", ledgerCtx, top1).Return(nil).Once()
-		lm.On("ForceCancelTopUp", ledgerCtx, top2).Return(assert.AnError).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookApplicationUninstalled, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", ledgerCtx, events.ToErrorParams{
+		lm.On("GetTopUpsByOrganizationIDAndStatus", events.WithParent(ledgerCtx, levent), lcoid, TopUpStatusActive).Return([]TopUp{top1, top2}, nil).Once()
+		lm.On("ForceCancelTopUp", events.WithParent(ledgerCtx, levent), top1).Return(nil).Once()
+		lm.On("ForceCancelTopUp", events.WithParent(ledgerCtx, levent), top2).Return(assert.AnError).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookApplicationUninstalled, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(ledgerCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
 		}).Return(assert.AnError).Once()
@@ -357,7 +400,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 	})
 
 	t.Run("success payment_collected", func(t *testing.T) {
-		amount := float32(5.234)
+		amount := Money(5234)
 		eventType := "payment_collected"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		paymentID := "x1c2v3"
@@ -394,15 +437,16 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("SyncTopUp", ledgerCtx, top1).Return(&top1, nil).Once()
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(&top1, nil).Once()
-		lm.On("TopUp", ledgerCtx, top1).Return(top1.ID, nil).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", ledgerCtx, levent).Return(nil).Once()
+		lm.On("SyncTopUp", events.WithParent(ledgerCtx, levent), top1).Return(&top1, nil).Once()
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(&top1, nil).Once()
+		lm.On("TopUp", events.WithParent(ledgerCtx, levent), top1).Return(top1.ID, nil).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(ledgerCtx, levent), levent).Return(nil).Once()
 
 		err := h.HandleDPSWebhook(context.Background(), req)
 
 		assert.Nil(t, err)
+		assert.Equal(t, []string{paymentID}, lm.lockedTopUps)
 
 		assertExpectations(t)
 	})
@@ -437,8 +481,8 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", ledgerCtx, events.ToErrorParams{
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(ledgerCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("payment id field not found in payload"),
 		}).Return(assert.AnError).Once()
@@ -480,9 +524,9 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(nil, assert.AnError).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", ledgerCtx, events.ToErrorParams{
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(nil, assert.AnError).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(ledgerCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
 		}).Return(assert.AnError).Once()
@@ -525,9 +569,9 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Error:            "top up not found",
 		}
 
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(nil, nil).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", ledgerCtx, levent).Return(nil).Once()
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(nil, nil).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(ledgerCtx, levent), levent).Return(nil).Once()
 
 		err := h.HandleDPSWebhook(context.Background(), req)
 
@@ -556,7 +600,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			},
 			UserID: userID,
 		}
-		amount := float32(5.234)
+		amount := Money(5234)
 		top1 := TopUp{
 			ID:               "id1",
 			LCOrganizationID: lcoid,
@@ -574,10 +618,10 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("SyncTopUp", ledgerCtx, top1).Return(nil, assert.AnError).Once()
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(&top1, nil).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", ledgerCtx, events.ToErrorParams{
+		lm.On("SyncTopUp", events.WithParent(ledgerCtx, levent), top1).Return(nil, assert.AnError).Once()
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(&top1, nil).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(ledgerCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("syncing top up: %w", assert.AnError),
 		}).Return(assert.AnError).Once()
@@ -591,7 +635,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 
 	t.Run("success payment_activated", func(t *testing.T) {
 		eventType := "payment_activated"
-		amount := float32(5.234)
+		amount := Money(5234)
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		paymentID := "x1c2v3"
 		userID := "s98f"
@@ -627,10 +671,10 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("SyncTopUp", ledgerCtx, top1).Return(&top1, nil).Once()
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(&top1, nil).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", ledgerCtx, levent).Return(nil).Once()
+		lm.On("SyncTopUp", events.WithParent(ledgerCtx, levent), top1).Return(&top1, nil).Once()
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(&top1, nil).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(ledgerCtx, levent), levent).Return(nil).Once()
 
 		err := h.HandleDPSWebhook(context.Background(), req)
 
@@ -640,7 +684,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 	})
 
 	t.Run("success payment_declined", func(t *testing.T) {
-		amount := float32(5.234)
+		amount := Money(5234)
 		eventType := "payment_declined"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		paymentID := "x1c2v3"
@@ -677,10 +721,10 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("SyncTopUp", ledgerCtx, top1).Return(&top1, nil).Once()
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(&top1, nil).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", ledgerCtx, levent).Return(nil).Once()
+		lm.On("SyncTopUp", events.WithParent(ledgerCtx, levent), top1).Return(&top1, nil).Once()
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(&top1, nil).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(ledgerCtx, levent), levent).Return(nil).Once()
 
 		err := h.HandleDPSWebhook(context.Background(), req)
 
@@ -690,7 +734,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 	})
 
 	t.Run("success payment_cancelled", func(t *testing.T) {
-		amount := float32(5.234)
+		amount := Money(5234)
 		eventType := "payment_cancelled"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		paymentID := "x1c2v3"
@@ -727,10 +771,10 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("SyncTopUp", ledgerCtx, top1).Return(&top1, nil).Once()
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(&top1, nil).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("CreateEvent", ledgerCtx, levent).Return(nil).Once()
+		lm.On("SyncTopUp", events.WithParent(ledgerCtx, levent), top1).Return(&top1, nil).Once()
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(&top1, nil).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("CreateEvent", events.WithParent(ledgerCtx, levent), levent).Return(nil).Once()
 
 		err := h.HandleDPSWebhook(context.Background(), req)
 
@@ -740,7 +784,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 	})
 
 	t.Run("success force cancel on payment_cancelled sync error", func(t *testing.T) {
-		amount := float32(5.234)
+		amount := Money(5234)
 		eventType := "payment_cancelled"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		paymentID := "x1c2v3"
@@ -777,12 +821,12 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(&top1, nil).Once()
-		lm.On("SyncTopUp", ledgerCtx, top1).Return(nil, assert.AnError).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		lm.On("GetTopUps", ledgerCtx, lcoid).Return([]TopUp{top1}, nil).Once()
-		lm.On("ForceCancelTopUp", ledgerCtx, top1).Return(nil).Once()
-		em.On("ToError", ledgerCtx, events.ToErrorParams{
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(&top1, nil).Once()
+		lm.On("SyncTopUp", events.WithParent(ledgerCtx, levent), top1).Return(nil, assert.AnError).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		lm.On("GetTopUps", events.WithParent(ledgerCtx, levent), lcoid).Return([]TopUp{top1}, nil).Once()
+		lm.On("ForceCancelTopUp", events.WithParent(ledgerCtx, levent), top1).Return(nil).Once()
+		em.On("ToError", events.WithParent(ledgerCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("syncing top up: %w", assert.AnError),
 		}).Return(assert.AnError).Once()
@@ -795,7 +839,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 	})
 
 	t.Run("error force cancel on payment_cancelled sync error payment id not equals top up", func(t *testing.T) {
-		amount := float32(5.234)
+		amount := Money(5234)
 		eventType := "payment_cancelled"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		paymentID := "x1c2v3"
@@ -832,11 +876,11 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(&top1, nil).Once()
-		lm.On("SyncTopUp", ledgerCtx, top1).Return(nil, assert.AnError).Once()
-		lm.On("GetTopUps", ledgerCtx, lcoid).Return([]TopUp{top1}, nil).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		em.On("ToError", ledgerCtx, events.ToErrorParams{
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(&top1, nil).Once()
+		lm.On("SyncTopUp", events.WithParent(ledgerCtx, levent), top1).Return(nil, assert.AnError).Once()
+		lm.On("GetTopUps", events.WithParent(ledgerCtx, levent), lcoid).Return([]TopUp{top1}, nil).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		em.On("ToError", events.WithParent(ledgerCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("syncing top up: %w", assert.AnError),
 		}).Return(assert.AnError).Once()
@@ -849,7 +893,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 	})
 
 	t.Run("error force cancel on payment_cancelled sync error", func(t *testing.T) {
-		amount := float32(5.234)
+		amount := Money(5234)
 		eventType := "payment_cancelled"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		paymentID := "x1c2v3"
@@ -894,12 +938,12 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Payload:          sc,
 		}
 
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(&top1, nil).Once()
-		lm.On("SyncTopUp", ledgerCtx, top1).Return(nil, assert.AnError).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		lm.On("GetTopUps", ledgerCtx, lcoid).Return([]TopUp{top1, top2}, nil).Once()
-		lm.On("ForceCancelTopUp", ledgerCtx, top1).Return(assert.AnError).Once()
-		em.On("ToError", ledgerCtx, events.ToErrorParams{
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(&top1, nil).Once()
+		lm.On("SyncTopUp", events.WithParent(ledgerCtx, levent), top1).Return(nil, assert.AnError).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		lm.On("GetTopUps", events.WithParent(ledgerCtx, levent), lcoid).Return([]TopUp{top1, top2}, nil).Once()
+		lm.On("ForceCancelTopUp", events.WithParent(ledgerCtx, levent), top1).Return(assert.AnError).Once()
+		em.On("ToError", events.WithParent(ledgerCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("force cancel top up: %w", assert.AnError),
 		}).Return(assert.AnError).Once()
@@ -939,7 +983,7 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			Action:           events.EventActionDPSWebhookPayment,
 			Payload:          sc,
 		}
-		amount := float32(5.234)
+		amount := Money(5234)
 		top1 := TopUp{
 			ID:               paymentID,
 			LCOrganizationID: lcoid,
@@ -949,11 +993,11 @@ func TestService_HandleDPSWebhook(t *testing.T) {
 			ConfirmationUrl:  "url",
 		}
 
-		lm.On("GetTopUpByIDAndOrganizationID", ledgerCtx, lcoid, paymentID).Return(&top1, nil).Once()
-		lm.On("SyncTopUp", ledgerCtx, top1).Return(nil, assert.AnError).Once()
-		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, req).Return(levent).Once()
-		lm.On("GetTopUps", ledgerCtx, lcoid).Return(nil, assert.AnError).Once()
-		em.On("ToError", ledgerCtx, events.ToErrorParams{
+		lm.On("GetTopUpByIDAndOrganizationID", events.WithParent(ledgerCtx, levent), lcoid, paymentID).Return(&top1, nil).Once()
+		lm.On("SyncTopUp", events.WithParent(ledgerCtx, levent), top1).Return(nil, assert.AnError).Once()
+		em.On("ToEvent", ledgerCtx, lcoid, events.EventActionDPSWebhookPayment, events.EventTypeInfo, DPSWebhookEventPayload{DPSWebhookRequest: req}).Return(levent).Once()
+		lm.On("GetTopUps", events.WithParent(ledgerCtx, levent), lcoid).Return(nil, assert.AnError).Once()
+		em.On("ToError", events.WithParent(ledgerCtx, levent), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("getting top ups: %w", assert.AnError),
 		}).Return(assert.AnError).Once()
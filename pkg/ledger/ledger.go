@@ -5,12 +5,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/livechat-integrations/go-billing-sdk/v2/common"
-	"github.com/livechat-integrations/go-billing-sdk/v2/internal/livechat"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
 )
 
 type LedgerInterface interface {
@@ -18,7 +23,7 @@ type LedgerInterface interface {
 	CreateCharge(ctx context.Context, params CreateChargeParams) (string, error)
 	TopUp(ctx context.Context, topUp TopUp) (string, error)
 	CreateTopUpRequest(ctx context.Context, params CreateTopUpRequestParams) (*TopUp, error)
-	GetBalance(ctx context.Context, organizationID string) (float32, error)
+	GetBalance(ctx context.Context, organizationID string) (Balances, error)
 	GetTopUps(ctx context.Context, organizationID string) ([]TopUp, error)
 	CancelTopUpRequest(ctx context.Context, organizationID string, ID string) error
 	ForceCancelTopUp(ctx context.Context, topUp TopUp) error
@@ -26,12 +31,21 @@ type LedgerInterface interface {
 	GetTopUpByIDAndOrganizationID(ctx context.Context, organizationID string, ID string) (*TopUp, error)
 	SyncTopUp(ctx context.Context, topUp TopUp) (*TopUp, error)
 	SyncOrCancelTopUpRequests(ctx context.Context) error
-	AddVoucherFunds(ctx context.Context, Amount float32, OrganizationID, Namespace string, Payload *json.RawMessage) error
+	AddVoucherFunds(ctx context.Context, Amount Money, Currency Currency, OrganizationID, Namespace string, Payload *json.RawMessage) error
+	Convert(ctx context.Context, params ConvertParams) (*Conversion, error)
+	Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconciliationReport, error)
+	GetTrialBalance(ctx context.Context, organizationID string) (TrialBalance, error)
+	SnapshotBalances(ctx context.Context) error
+	VerifyBalances(ctx context.Context) (*BalanceVerificationReport, error)
+	RepairBalance(ctx context.Context, organizationID string, currency Currency) (*BalanceRepair, error)
+	WithTopUpLock(ctx context.Context, id string, fn func(ctx context.Context) error) error
 }
 
 var (
 	ErrChargeNotFound = fmt.Errorf("charge not found")
 	ErrTopUpNotFound  = fmt.Errorf("top up not found")
+	// ErrBillingAPIUnavailable is returned while the LiveChat Billing API circuit breaker is open.
+	ErrBillingAPIUnavailable = livechat.ErrBillingAPIUnavailable
 )
 
 type (
@@ -46,52 +60,131 @@ type Service struct {
 	storage      Storage
 	returnURL    string
 	masterOrgID  string
+	retryPolicy  RetryPolicy
+	breaker      CircuitBreakerPolicy
+	apiOptions   []livechat.Option
+	metrics      metrics.Metrics
+	tracer       trace.Tracer
+	logger       *slog.Logger
+	doubleEntry  bool
+	snapshotKeep int
+}
+
+// Option configures optional Service behaviour.
+type Option func(*Service)
+
+// RetryPolicy configures retries of LiveChat Billing API calls.
+type RetryPolicy = livechat.RetryPolicy
+
+// WithRetryPolicy replaces livechat.DefaultRetryPolicy. Charge creation is never retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *Service) {
+		s.retryPolicy = policy
+	}
+}
+
+// CircuitBreakerPolicy configures when LiveChat Billing API calls fail fast with ErrBillingAPIUnavailable.
+type CircuitBreakerPolicy = livechat.CircuitBreakerPolicy
+
+// WithCircuitBreaker replaces livechat.DefaultCircuitBreakerPolicy. A zero FailureRate disables the breaker.
+func WithCircuitBreaker(policy CircuitBreakerPolicy) Option {
+	return func(s *Service) {
+		s.breaker = policy
+	}
 }
 
-func NewService(eventService events.EventService, idProvider events.IdProviderInterface, httpClient *http.Client, livechatEnvironment string, tokenFn common.TokenFn, storage Storage, returnUrl, masterOrgID string) *Service {
-	a := &livechat.Api{
-		HttpClient: httpClient,
-		ApiBaseURL: events.EnvURL(livechat.BillingAPIBaseURL, livechatEnvironment),
-		TokenFn:    tokenFn,
+// WithAPIOptions configures the LiveChat Billing API client, e.g. with livechat.WithTimeout or livechat.WithUserAgent.
+func WithAPIOptions(opts ...livechat.Option) Option {
+	return func(s *Service) {
+		s.apiOptions = append(s.apiOptions, opts...)
 	}
+}
+
+// WithMetrics reports the SyncOrCancelTopUpRequests runs and the LiveChat Billing API calls to m.
+func WithMetrics(m metrics.Metrics) Option {
+	return func(s *Service) {
+		s.metrics = m
+	}
+}
 
-	return &Service{
+func NewService(eventService events.EventService, idProvider events.IdProviderInterface, httpClient *http.Client, livechatEnvironment string, tokenFn common.TokenFn, storage Storage, returnUrl, masterOrgID string, opts ...Option) *Service {
+	s := &Service{
 		idProvider:   idProvider,
-		billingAPI:   a,
 		eventService: eventService,
 		storage:      storage,
 		returnURL:    returnUrl,
 		masterOrgID:  masterOrgID,
+		retryPolicy:  livechat.DefaultRetryPolicy(),
+		breaker:      livechat.DefaultCircuitBreakerPolicy(),
+		metrics:      metrics.Noop{},
+		logger:       slog.Default(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.tracer != nil {
+		s.storage = &tracingStorage{Storage: s.storage, tracer: s.tracer}
+	}
+
+	apiOptions := append([]livechat.Option{
+		livechat.WithRetryPolicy(s.retryPolicy),
+		livechat.WithCircuitBreaker(s.breaker),
+		livechat.WithMetrics(s.metrics),
+	}, s.apiOptions...)
+	s.billingAPI = livechat.NewApi(httpClient, events.EnvURL(livechat.BillingAPIBaseURL, livechatEnvironment), tokenFn, apiOptions...)
+
+	return s
 }
 
 type CreateChargeParams struct {
-	Test           bool    `json:"test"`
-	Name           string  `json:"name"`
-	Amount         float32 `json:"amount"`
-	OrganizationID string  `json:"organizationId"`
+	Test           bool     `json:"test"`
+	Name           string   `json:"name"`
+	Amount         Money    `json:"amount"`
+	Currency       Currency `json:"currency"`
+	OrganizationID string   `json:"organizationId"`
 }
 
-func (s *Service) GetOperations(ctx context.Context, organizationID string, isVoucher bool) ([]Operation, error) {
+func (s *Service) GetOperations(ctx context.Context, organizationID string, isVoucher bool) (_ []Operation, err error) {
+	ctx, end := s.start(ctx, "GetOperations", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { end(err) }()
+
 	return s.storage.GetLedgerOperations(ctx, organizationID, isVoucher)
 }
 
-func (s *Service) CreateCharge(ctx context.Context, params CreateChargeParams) (string, error) {
+func (s *Service) CreateCharge(ctx context.Context, params CreateChargeParams) (_ string, err error) {
+	ctx, end := s.start(ctx, "CreateCharge", tracing.OrganizationIDKey.String(params.OrganizationID))
+	defer func() { end(err) }()
+
 	operation := Operation{
 		ID:               s.idProvider.GenerateId(),
 		Amount:           -params.Amount,
+		Currency:         params.Currency.orDefault(),
 		LCOrganizationID: params.OrganizationID,
 		IsVoucher:        false,
 	}
-	_, err := s.createOperation(ctx, operation)
+	_, err = s.createOperation(ctx, operation)
 	if err != nil {
 		return "", err
 	}
 	return operation.ID, nil
 }
 
-func (s *Service) TopUp(ctx context.Context, topUp TopUp) (string, error) {
-	event := s.eventService.ToEvent(ctx, topUp.LCOrganizationID, events.EventActionTopUp, events.EventTypeInfo, topUp)
+func (s *Service) TopUp(ctx context.Context, topUp TopUp) (_ string, err error) {
+	ctx, end := s.start(ctx, "TopUp", tracing.OrganizationIDKey.String(topUp.LCOrganizationID), tracing.ChargeIDKey.String(topUp.ID))
+	defer func() { end(err) }()
+
+	var id string
+	err = s.storage.WithTopUpLock(ctx, topUp.ID, func(ctx context.Context) error {
+		var err error
+		id, err = s.topUp(ctx, topUp)
+		return err
+	})
+
+	return id, err
+}
+
+func (s *Service) topUp(ctx context.Context, topUp TopUp) (string, error) {
+	event := s.eventService.ToEvent(ctx, topUp.LCOrganizationID, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp})
 	dbTopUp, err := s.storage.GetTopUpByIDAndType(ctx, GetTopUpByIDAndTypeParams{
 		ID:   topUp.ID,
 		Type: topUp.Type,
@@ -161,6 +254,7 @@ func (s *Service) TopUp(ctx context.Context, topUp TopUp) (string, error) {
 	operation := Operation{
 		ID:               id,
 		Amount:           dbTopUp.Amount,
+		Currency:         dbTopUp.Currency.orDefault(),
 		LCOrganizationID: dbTopUp.LCOrganizationID,
 		Payload:          dbTopUp.LCCharge,
 		IsVoucher:        false,
@@ -174,18 +268,20 @@ func (s *Service) TopUp(ctx context.Context, topUp TopUp) (string, error) {
 		})
 	}
 
-	_ = s.eventService.CreateEvent(ctx, event)
+	s.createEvent(ctx, event)
 
 	return operation.ID, nil
 }
 
 type CreateTopUpRequestParams struct {
-	Test           bool
-	Name           string
-	Amount         float32
-	OrganizationID string
-	Type           TopUpType
-	Config         TopUpConfig
+	Test   bool   `json:"test"`
+	Name   string `json:"name"`
+	Amount Money  `json:"amount"`
+	// Currency must be BillingCurrency or empty, LiveChat charges the amount in USD.
+	Currency       Currency    `json:"currency"`
+	OrganizationID string      `json:"organizationId"`
+	Type           TopUpType   `json:"type"`
+	Config         TopUpConfig `json:"config"`
 }
 
 type TopUpConfig struct {
@@ -194,8 +290,19 @@ type TopUpConfig struct {
 	ReturnUrl *string `json:"returnUrl"`
 }
 
-func (s *Service) CreateTopUpRequest(ctx context.Context, params CreateTopUpRequestParams) (*TopUp, error) {
-	event := s.eventService.ToEvent(ctx, params.OrganizationID, events.EventActionCreateTopUp, events.EventTypeInfo, params)
+func (s *Service) CreateTopUpRequest(ctx context.Context, params CreateTopUpRequestParams) (_ *TopUp, err error) {
+	ctx, end := s.start(ctx, "CreateTopUpRequest", tracing.OrganizationIDKey.String(params.OrganizationID))
+	defer func() { end(err) }()
+
+	eventPayload := CreateTopUpEventPayload{Request: params}
+	event := s.eventService.ToEvent(ctx, params.OrganizationID, events.EventActionCreateTopUp, events.EventTypeInfo, eventPayload)
+	if currency := params.Currency.orDefault(); currency != BillingCurrency {
+		event.Type = events.EventTypeError
+		return nil, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("%w: LiveChat bills top ups in %s, not %s", ErrUnsupportedCurrency, BillingCurrency, currency),
+		})
+	}
 	isTest := params.Test || params.OrganizationID == s.masterOrgID
 	config := ChargeConfig{
 		ReturnUrl: &s.returnURL,
@@ -238,7 +345,8 @@ func (s *Service) CreateTopUpRequest(ctx context.Context, params CreateTopUpRequ
 		ID:               *cr.ChargeID,
 		LCOrganizationID: params.OrganizationID,
 		Status:           TopUpStatusPending,
-		Amount:           float32(params.Amount),
+		Amount:           params.Amount,
+		Currency:         params.Currency.orDefault(),
 		Type:             params.Type,
 		ConfirmationUrl:  *cr.ConfirmationUrl,
 		LCCharge:         *cr.RawCharge,
@@ -258,27 +366,42 @@ func (s *Service) CreateTopUpRequest(ctx context.Context, params CreateTopUpRequ
 			Err:   fmt.Errorf("failed to create database top up: %w", err),
 		})
 	}
-	event.SetPayload(tu)
-	_ = s.eventService.CreateEvent(ctx, event)
+	eventPayload.TopUp = tu
+	event.SetPayload(eventPayload)
+	s.createEvent(ctx, event)
 	return tu, nil
 }
 
-func (s *Service) GetBalance(ctx context.Context, organizationID string) (float32, error) {
+// GetBalance returns the balances of the organization by currency.
+func (s *Service) GetBalance(ctx context.Context, organizationID string) (_ Balances, err error) {
+	ctx, end := s.start(ctx, "GetBalance", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { end(err) }()
+
 	balance, err := s.storage.GetBalance(ctx, organizationID)
 	if err != nil {
-		return float32(0), fmt.Errorf("failed to get balance: %w", err)
+		return nil, fmt.Errorf("failed to get balance: %w", err)
 	}
 
 	return balance, nil
 }
 
-func (s *Service) GetTopUps(ctx context.Context, organizationID string) ([]TopUp, error) {
+func (s *Service) GetTopUps(ctx context.Context, organizationID string) (_ []TopUp, err error) {
+	ctx, end := s.start(ctx, "GetTopUps", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { end(err) }()
+
 	return s.storage.GetTopUpsByOrganizationID(ctx, organizationID)
 }
 
-func (s *Service) AddVoucherFunds(ctx context.Context, Amount float32, OrganizationID, Namespace string, Payload *json.RawMessage) error {
-	event := s.eventService.ToEvent(ctx, OrganizationID, events.EventActionAddVoucherFunds, events.EventTypeInfo, map[string]interface{}{"amount": Amount, "namespace": Namespace})
+func (s *Service) AddVoucherFunds(ctx context.Context, Amount Money, Currency Currency, OrganizationID, Namespace string, Payload *json.RawMessage) (err error) {
+	ctx, end := s.start(ctx, "AddVoucherFunds", tracing.OrganizationIDKey.String(OrganizationID))
+	defer func() { end(err) }()
+
+	if Currency == "" {
+		Currency = CurrencyCredits
+	}
 	key := getFundsKey(Namespace, OrganizationID)
+	eventPayload := AddVoucherFundsEventPayload{OperationID: key, Amount: Amount, Currency: Currency, Namespace: Namespace}
+	event := s.eventService.ToEvent(ctx, OrganizationID, events.EventActionAddVoucherFunds, events.EventTypeInfo, eventPayload)
 	operation, err := s.storage.GetLedgerOperation(ctx, GetLedgerOperationParams{
 		ID:             key,
 		OrganizationID: OrganizationID,
@@ -291,14 +414,16 @@ func (s *Service) AddVoucherFunds(ctx context.Context, Amount float32, Organizat
 		})
 	}
 	if operation != nil {
-		event.SetPayload(map[string]interface{}{"id": key, "amount": Amount, "namespace": Namespace, "result": "already exists"})
-		_ = s.eventService.CreateEvent(ctx, event)
+		eventPayload.Result = "already exists"
+		event.SetPayload(eventPayload)
+		s.createEvent(ctx, event)
 		return nil
 	}
 	operation = &Operation{
 		ID:               key,
 		LCOrganizationID: OrganizationID,
 		Amount:           Amount,
+		Currency:         Currency,
 		IsVoucher:        true,
 	}
 	if Payload != nil {
@@ -312,13 +437,25 @@ func (s *Service) AddVoucherFunds(ctx context.Context, Amount float32, Organizat
 			Err:   err,
 		})
 	}
-	_ = s.eventService.CreateEvent(ctx, event)
+	eventPayload.Result = "success"
+	event.SetPayload(eventPayload)
+	s.createEvent(ctx, event)
 
 	return nil
 }
 
-func (s *Service) CancelTopUpRequest(ctx context.Context, organizationID string, ID string) error {
-	event := s.eventService.ToEvent(ctx, organizationID, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, map[string]interface{}{"id": ID})
+func (s *Service) CancelTopUpRequest(ctx context.Context, organizationID string, ID string) (err error) {
+	ctx, end := s.start(ctx, "CancelTopUpRequest", tracing.OrganizationIDKey.String(organizationID), tracing.ChargeIDKey.String(ID))
+	defer func() { end(err) }()
+
+	return s.storage.WithTopUpLock(ctx, ID, func(ctx context.Context) error {
+		return s.cancelTopUpRequest(ctx, organizationID, ID)
+	})
+}
+
+func (s *Service) cancelTopUpRequest(ctx context.Context, organizationID string, ID string) error {
+	eventPayload := CancelRecurrentTopUpEventPayload{TopUpID: ID}
+	event := s.eventService.ToEvent(ctx, organizationID, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, eventPayload)
 	topUp, err := s.storage.GetTopUpByIDAndType(ctx, GetTopUpByIDAndTypeParams{
 		ID:   ID,
 		Type: TopUpTypeRecurrent,
@@ -331,12 +468,19 @@ func (s *Service) CancelTopUpRequest(ctx context.Context, organizationID string,
 		})
 	}
 	if topUp == nil {
-		event.SetPayload(map[string]interface{}{"id": ID, "result": "top up not found"})
-		_ = s.eventService.CreateEvent(ctx, event)
+		eventPayload.Result = "top up not found"
+		event.SetPayload(eventPayload)
+		s.createEvent(ctx, event)
 		return ErrTopUpNotFound
 	}
 
 	_, err = s.billingAPI.CancelRecurrentCharge(ctx, ID)
+	switch {
+	case errors.Is(err, livechat.ErrNotFound):
+		err = fmt.Errorf("recurrent top up not found in LiveChat: %w", err)
+	case errors.Is(err, livechat.ErrUnprocessableEntity):
+		err = fmt.Errorf("top up cannot be cancelled: %w", err)
+	}
 	if err != nil {
 		event.Type = events.EventTypeError
 		return s.eventService.ToError(ctx, events.ToErrorParams{
@@ -351,8 +495,9 @@ func (s *Service) CancelTopUpRequest(ctx context.Context, organizationID string,
 	})
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
-			event.SetPayload(map[string]interface{}{"id": ID, "result": "top up not found"})
-			_ = s.eventService.CreateEvent(ctx, event)
+			eventPayload.Result = "top up not found"
+			event.SetPayload(eventPayload)
+			s.createEvent(ctx, event)
 			return ErrTopUpNotFound
 		}
 
@@ -363,21 +508,61 @@ func (s *Service) CancelTopUpRequest(ctx context.Context, organizationID string,
 		})
 	}
 
-	event.SetPayload(map[string]interface{}{"id": ID, "result": "success"})
-	_ = s.eventService.CreateEvent(ctx, event)
+	eventPayload.Result = "success"
+	event.SetPayload(eventPayload)
+	s.createEvent(ctx, event)
 	return nil
 }
 
-func (s *Service) ForceCancelTopUp(ctx context.Context, topUp TopUp) error {
-	event := s.eventService.ToEvent(ctx, topUp.LCOrganizationID, events.EventActionForceCancelCharge, events.EventTypeInfo, map[string]interface{}{"id": topUp.ID, "status": TopUpStatusCancelled})
-	err := s.storage.UpdateTopUpStatus(ctx, UpdateTopUpStatusParams{
+func (s *Service) ForceCancelTopUp(ctx context.Context, topUp TopUp) (err error) {
+	ctx, end := s.start(ctx, "ForceCancelTopUp", tracing.OrganizationIDKey.String(topUp.LCOrganizationID), tracing.ChargeIDKey.String(topUp.ID))
+	defer func() { end(err) }()
+
+	return s.storage.WithTopUpLock(ctx, topUp.ID, func(ctx context.Context) error {
+		return s.forceCancelTopUp(ctx, topUp)
+	})
+}
+
+// forceCancelTopUp re-reads the top up under its lock and cancels it only while it still has the status the caller
+// decided on, so a top up a webhook activated or finished in the meantime is not overwritten.
+func (s *Service) forceCancelTopUp(ctx context.Context, topUp TopUp) error {
+	eventPayload := ForceCancelTopUpEventPayload{TopUpID: topUp.ID, Status: TopUpStatusCancelled}
+	event := s.eventService.ToEvent(ctx, topUp.LCOrganizationID, events.EventActionForceCancelCharge, events.EventTypeInfo, eventPayload)
+	dbTopUp, err := s.storage.GetTopUpByIDAndOrganizationID(ctx, topUp.LCOrganizationID, topUp.ID)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   err,
+		})
+	}
+	if dbTopUp == nil {
+		eventPayload.Result = "top up not found"
+		event.SetPayload(eventPayload)
+		s.createEvent(ctx, event)
+		return ErrTopUpNotFound
+	}
+	switch {
+	case dbTopUp.Status == TopUpStatusSuccess,
+		dbTopUp.Status == TopUpStatusCancelled,
+		dbTopUp.Status == TopUpStatusFailed,
+		dbTopUp.Status == TopUpStatusDeclined,
+		dbTopUp.Status != topUp.Status:
+		eventPayload.Result = fmt.Sprintf("skipped, top up is %s", dbTopUp.Status)
+		event.SetPayload(eventPayload)
+		s.createEvent(ctx, event)
+		return nil
+	}
+
+	err = s.storage.UpdateTopUpStatus(ctx, UpdateTopUpStatusParams{
 		ID:     topUp.ID,
 		Status: TopUpStatusCancelled,
 	})
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
-			event.SetPayload(map[string]interface{}{"id": topUp.ID, "result": "top up not found"})
-			_ = s.eventService.CreateEvent(ctx, event)
+			eventPayload.Result = "top up not found"
+			event.SetPayload(eventPayload)
+			s.createEvent(ctx, event)
 			return ErrTopUpNotFound
 		}
 
@@ -387,91 +572,91 @@ func (s *Service) ForceCancelTopUp(ctx context.Context, topUp TopUp) error {
 			Err:   err,
 		})
 	}
-	_ = s.eventService.CreateEvent(ctx, event)
+	s.createEvent(ctx, event)
 	return nil
 }
 
-func (s *Service) GetTopUpsByOrganizationIDAndStatus(ctx context.Context, organizationID string, status TopUpStatus) ([]TopUp, error) {
+// WithTopUpLock runs fn while holding the lock of the top up, so a webhook can sync and credit the top up without a
+// sync job changing it in between. The service methods fn calls with its ctx for the same top up reuse the lock.
+func (s *Service) WithTopUpLock(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	return s.storage.WithTopUpLock(ctx, id, fn)
+}
+
+func (s *Service) GetTopUpsByOrganizationIDAndStatus(ctx context.Context, organizationID string, status TopUpStatus) (_ []TopUp, err error) {
+	ctx, end := s.start(ctx, "GetTopUpsByOrganizationIDAndStatus", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { end(err) }()
+
 	return s.storage.GetTopUpsByOrganizationIDAndStatus(ctx, organizationID, status)
 }
 
-func (s *Service) GetTopUpByIDAndOrganizationID(ctx context.Context, organizationID string, ID string) (*TopUp, error) {
+func (s *Service) GetTopUpByIDAndOrganizationID(ctx context.Context, organizationID string, ID string) (_ *TopUp, err error) {
+	ctx, end := s.start(ctx, "GetTopUpByIDAndOrganizationID", tracing.OrganizationIDKey.String(organizationID), tracing.ChargeIDKey.String(ID))
+	defer func() { end(err) }()
+
 	return s.storage.GetTopUpByIDAndOrganizationID(ctx, organizationID, ID)
 }
 
-func (s *Service) SyncTopUp(ctx context.Context, topUp TopUp) (*TopUp, error) {
-	event := s.eventService.ToEvent(ctx, topUp.LCOrganizationID, events.EventActionSyncTopUp, events.EventTypeInfo, topUp)
+func (s *Service) SyncTopUp(ctx context.Context, topUp TopUp) (_ *TopUp, err error) {
+	ctx, end := s.start(ctx, "SyncTopUp", tracing.OrganizationIDKey.String(topUp.LCOrganizationID), tracing.ChargeIDKey.String(topUp.ID))
+	defer func() { end(err) }()
+
+	var synced *TopUp
+	err = s.storage.WithTopUpLock(ctx, topUp.ID, func(ctx context.Context) error {
+		var err error
+		synced, err = s.syncTopUp(ctx, topUp)
+		return err
+	})
+
+	return synced, err
+}
+
+func (s *Service) syncTopUp(ctx context.Context, topUp TopUp) (*TopUp, error) {
+	event := s.eventService.ToEvent(ctx, topUp.LCOrganizationID, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp})
 	var baseCharge livechat.BaseCharge
 	var fullCharge any
 
 	switch topUp.Type {
 	case TopUpTypeDirect:
 		c, err := s.billingAPI.GetDirectCharge(ctx, topUp.ID)
-		if err != nil {
+		if errors.Is(err, livechat.ErrNotFound) {
 			event.Type = events.EventTypeError
 			return nil, s.eventService.ToError(ctx, events.ToErrorParams{
 				Event: event,
-				Err:   err,
+				Err:   fmt.Errorf("failed to get LC API direct top up by id: %s: %w", topUp.ID, err),
 			})
 		}
-		if c == nil {
+		if err != nil {
 			event.Type = events.EventTypeError
 			return nil, s.eventService.ToError(ctx, events.ToErrorParams{
 				Event: event,
-				Err:   fmt.Errorf("failed to get LC API direct top up by id: %s", topUp.ID),
+				Err:   err,
 			})
 		}
 		fullCharge = c
 		baseCharge = c.BaseCharge
-		switch baseCharge.Status {
-		case "success":
-			topUp.Status = TopUpStatusSuccess
-		case "accepted":
-			topUp.Status = TopUpStatusAccepted
-		case "processed":
-			topUp.Status = TopUpStatusProcessing
-		case "failed":
-			topUp.Status = TopUpStatusFailed
-		case "cancelled":
-			topUp.Status = TopUpStatusCancelled
-		case "declined":
-			topUp.Status = TopUpStatusDeclined
-		case "frozen":
-			topUp.Status = TopUpStatusFrozen
+		if status, ok := topUpStatus(topUp.Type, baseCharge.Status); ok {
+			topUp.Status = status
 		}
 	case TopUpTypeRecurrent:
 		c, err := s.billingAPI.GetRecurrentCharge(ctx, topUp.ID)
-		if err != nil {
+		if errors.Is(err, livechat.ErrNotFound) {
 			event.Type = events.EventTypeError
 			return nil, s.eventService.ToError(ctx, events.ToErrorParams{
 				Event: event,
-				Err:   err,
+				Err:   fmt.Errorf("failed to get LC API recurrent top up by id: %s: %w", topUp.ID, err),
 			})
 		}
-		if c == nil {
+		if err != nil {
 			event.Type = events.EventTypeError
 			return nil, s.eventService.ToError(ctx, events.ToErrorParams{
 				Event: event,
-				Err:   fmt.Errorf("failed to get LC API recurrent top up by id: %s", topUp.ID),
+				Err:   err,
 			})
 		}
 		fullCharge = c
 		baseCharge = c.BaseCharge
-		switch baseCharge.Status {
-		case "active":
-			topUp.Status = TopUpStatusActive
-		case "processed":
-			topUp.Status = TopUpStatusProcessing
-		case "accepted":
-			topUp.Status = TopUpStatusAccepted
-		case "cancelled":
-			topUp.Status = TopUpStatusCancelled
-		case "declined":
-			topUp.Status = TopUpStatusDeclined
-		case "frozen":
-			topUp.Status = TopUpStatusFrozen
-		case "past_due":
-			topUp.Status = TopUpStatusPastDue
+		if status, ok := topUpStatus(topUp.Type, baseCharge.Status); ok {
+			topUp.Status = status
 		}
 	}
 
@@ -493,7 +678,7 @@ func (s *Service) SyncTopUp(ctx context.Context, topUp TopUp) (*TopUp, error) {
 	}
 	topUp.LCCharge = p
 	if baseCharge.Price > 0 {
-		topUp.Amount = float32(baseCharge.Price) / 100
+		topUp.Amount = NewMoneyFromCents(baseCharge.Price)
 	}
 	topUp.ConfirmationUrl = baseCharge.ConfirmationURL
 
@@ -505,13 +690,23 @@ func (s *Service) SyncTopUp(ctx context.Context, topUp TopUp) (*TopUp, error) {
 			Err:   err,
 		})
 	}
-	event.SetPayload(topUp)
-	_ = s.eventService.CreateEvent(ctx, event)
+	event.SetPayload(TopUpEventPayload{TopUp: topUp})
+	s.createEvent(ctx, event)
 
 	return uTopUp, nil
 }
 
-func (s *Service) SyncOrCancelTopUpRequests(ctx context.Context) error {
+func (s *Service) SyncOrCancelTopUpRequests(ctx context.Context) (err error) {
+	ctx, end := s.start(ctx, "SyncOrCancelTopUpRequests")
+	defer func() { end(err) }()
+
+	start := time.Now()
+	picked := 0
+	defer func() {
+		s.metrics.SetGauge(metrics.RunCharges, metrics.Labels{Action: metrics.ActionSyncOrCancelTopUpRequests}, float64(picked))
+		metrics.ObserveSince(s.metrics, metrics.RunDurationSeconds, metrics.Labels{Action: metrics.ActionSyncOrCancelTopUpRequests, Status: metrics.Status(err)}, start)
+	}()
+
 	topUps, err := s.storage.GetTopUpsByTypeWhereStatusNotIn(ctx, GetTopUpsByTypeWhereStatusNotInParams{
 		Type: TopUpTypeDirect,
 		Statuses: []TopUpStatus{
@@ -524,6 +719,7 @@ func (s *Service) SyncOrCancelTopUpRequests(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	picked += len(topUps)
 
 	err = s.syncOrCancelDirectTopUpRequests(ctx, topUps)
 	if err != nil {
@@ -538,6 +734,7 @@ func (s *Service) SyncOrCancelTopUpRequests(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	picked += len(topUps)
 
 	err = s.syncOrCancelRecurrentTopUpRequests(ctx, topUps)
 	if err != nil {
@@ -548,10 +745,12 @@ func (s *Service) SyncOrCancelTopUpRequests(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	picked += len(topUps)
 	for _, topUp := range topUps {
 		organizationCtx := context.WithValue(ctx, LedgerOrganizationIDCtxKey{}, topUp.LCOrganizationID)
 		organizationCtx = context.WithValue(organizationCtx, LedgerEventIDCtxKey{}, s.idProvider.GenerateId())
 		_, err := s.TopUp(organizationCtx, topUp)
+		s.metrics.IncCounter(metrics.ChargesTotal, metrics.Labels{Action: metrics.ActionSyncOrCancelTopUpRequests, Status: metrics.Status(err)})
 		if err != nil {
 			return err
 		}
@@ -564,7 +763,20 @@ func (s *Service) syncOrCancelDirectTopUpRequests(ctx context.Context, topUps []
 	for _, topUp := range topUps {
 		organizationCtx := context.WithValue(ctx, LedgerOrganizationIDCtxKey{}, topUp.LCOrganizationID)
 		organizationCtx = context.WithValue(organizationCtx, LedgerEventIDCtxKey{}, s.idProvider.GenerateId())
-		tu, err := s.SyncTopUp(organizationCtx, topUp)
+		err := s.syncOrCancelDirectTopUpRequest(organizationCtx, topUp)
+		s.metrics.IncCounter(metrics.ChargesTotal, metrics.Labels{Action: metrics.ActionSyncOrCancelTopUpRequests, Status: metrics.Status(err)})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncOrCancelDirectTopUpRequest syncs the top up and activates or cancels it under one lock, so a webhook cannot
+// change its status between the sync and the decision.
+func (s *Service) syncOrCancelDirectTopUpRequest(ctx context.Context, topUp TopUp) error {
+	return s.storage.WithTopUpLock(ctx, topUp.ID, func(ctx context.Context) error {
+		tu, err := s.syncTopUp(ctx, topUp)
 		if err != nil {
 			return err
 		}
@@ -577,31 +789,44 @@ func (s *Service) syncOrCancelDirectTopUpRequests(ctx context.Context, topUps []
 			// do nothing
 
 		case TopUpStatusAccepted:
-			_, err = s.billingAPI.ActivateDirectCharge(organizationCtx, tu.ID)
+			_, err = s.billingAPI.ActivateDirectCharge(ctx, tu.ID)
+			s.metrics.IncCounter(metrics.ChargesTotal, metrics.Labels{Action: metrics.ActionActivateCharge, Status: metrics.Status(err)})
 			if err != nil {
 				return err
 			}
-			if err = s.eventService.CreateEvent(organizationCtx, s.eventService.ToEvent(organizationCtx, topUp.LCOrganizationID, events.EventActionActivateCharge, events.EventTypeInfo, tu)); err != nil {
+			if err = s.eventService.CreateEvent(ctx, s.eventService.ToEvent(ctx, topUp.LCOrganizationID, events.EventActionActivateCharge, events.EventTypeInfo, TopUpEventPayload{TopUp: *tu})); err != nil {
 				return err
 			}
 		default:
 			monthAgo := time.Now().AddDate(0, -1, 0)
 			if tu.Type == TopUpTypeDirect && monthAgo.After(tu.CreatedAt) {
-				err = s.ForceCancelTopUp(organizationCtx, *tu)
-				if err != nil {
-					return err
-				}
+				return s.forceCancelTopUp(ctx, *tu)
 			}
 		}
-	}
-	return nil
+
+		return nil
+	})
 }
 
 func (s *Service) syncOrCancelRecurrentTopUpRequests(ctx context.Context, topUps []TopUp) error {
 	for _, topUp := range topUps {
 		organizationCtx := context.WithValue(ctx, LedgerOrganizationIDCtxKey{}, topUp.LCOrganizationID)
 		organizationCtx = context.WithValue(organizationCtx, LedgerEventIDCtxKey{}, s.idProvider.GenerateId())
-		tu, err := s.SyncTopUp(organizationCtx, topUp)
+		err := s.syncOrCancelRecurrentTopUpRequest(organizationCtx, topUp)
+		s.metrics.IncCounter(metrics.ChargesTotal, metrics.Labels{Action: metrics.ActionSyncOrCancelTopUpRequests, Status: metrics.Status(err)})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncOrCancelRecurrentTopUpRequest syncs the top up and activates or cancels it under one lock, so a webhook cannot
+// change its status between the sync and the decision.
+func (s *Service) syncOrCancelRecurrentTopUpRequest(ctx context.Context, topUp TopUp) error {
+	return s.storage.WithTopUpLock(ctx, topUp.ID, func(ctx context.Context) error {
+		tu, err := s.syncTopUp(ctx, topUp)
 		if err != nil {
 			return err
 		}
@@ -615,31 +840,29 @@ func (s *Service) syncOrCancelRecurrentTopUpRequests(ctx context.Context, topUps
 
 		case TopUpStatusAccepted,
 			TopUpStatusFrozen:
-			_, err = s.billingAPI.ActivateRecurrentCharge(organizationCtx, tu.ID)
+			_, err = s.billingAPI.ActivateRecurrentCharge(ctx, tu.ID)
+			s.metrics.IncCounter(metrics.ChargesTotal, metrics.Labels{Action: metrics.ActionActivateCharge, Status: metrics.Status(err)})
 			if err != nil {
 				return err
 			}
-			if err = s.eventService.CreateEvent(organizationCtx, s.eventService.ToEvent(organizationCtx, topUp.LCOrganizationID, events.EventActionActivateCharge, events.EventTypeInfo, tu)); err != nil {
+			if err = s.eventService.CreateEvent(ctx, s.eventService.ToEvent(ctx, topUp.LCOrganizationID, events.EventActionActivateCharge, events.EventTypeInfo, TopUpEventPayload{TopUp: *tu})); err != nil {
 				return err
 			}
 		default:
 			monthAgo := time.Now().AddDate(0, -1, 0)
 			if tu.Type == TopUpTypeRecurrent && tu.CurrentToppedUpAt != nil && monthAgo.After(*tu.CurrentToppedUpAt) {
-				err = s.ForceCancelTopUp(organizationCtx, *tu)
-				if err != nil {
-					return err
-				}
+				return s.forceCancelTopUp(ctx, *tu)
 			}
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
 type createBillingChargeParams struct {
 	Test           bool
 	Name           string
-	Amount         float32
+	Amount         Money
 	OrganizationID string
 	Type           TopUpType
 	Config         ChargeConfig
@@ -665,15 +888,19 @@ func (s *Service) createBillingCharge(ctx context.Context, params createBillingC
 	if params.Config.ReturnUrl != nil {
 		returnUrl = *params.Config.ReturnUrl
 	}
+	// LC is using integer prices (1 = 1 cent)
+	price, err := params.Amount.Cents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create charge price: %w", err)
+	}
 
 	var result createBillingChargeResult
 	switch params.Type {
 	case TopUpTypeDirect:
-		// multiply price by 100 because LC is using integer (1 = 1 cent)
 		lcCharge, err := s.billingAPI.CreateDirectCharge(ctx, livechat.CreateDirectChargeParams{
 			Name:      params.Name,
 			ReturnURL: returnUrl,
-			Price:     int(params.Amount * 100),
+			Price:     price,
 			Test:      isTest,
 		})
 
@@ -695,11 +922,10 @@ func (s *Service) createBillingCharge(ctx context.Context, params createBillingC
 		if params.Config.Months == nil {
 			return nil, fmt.Errorf("failed to create recurrent charge V3 via lc: charge config months is nil")
 		}
-		// multiply price by 100 because LC is using integer (1 = 1 cent)
 		recurrentChargeParams := livechat.CreateRecurrentChargeParams{
 			Name:      params.Name,
 			ReturnURL: returnUrl,
-			Price:     int(params.Amount * 100),
+			Price:     price,
 			Test:      isTest,
 			Months:    *params.Config.Months,
 		}
@@ -730,7 +956,10 @@ func (s *Service) createBillingCharge(ctx context.Context, params createBillingC
 }
 
 func (s *Service) createOperation(ctx context.Context, operation Operation) (*Operation, error) {
-	event := s.eventService.ToEvent(ctx, operation.LCOrganizationID, events.EventActionCreateOperation, events.EventTypeInfo, operation)
+	event := s.eventService.ToEvent(ctx, operation.LCOrganizationID, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation})
+	if s.doubleEntry {
+		operation = operation.post(operation.counterAccount())
+	}
 	err := s.storage.CreateLedgerOperation(ctx, operation)
 	if err != nil {
 		event.Type = events.EventTypeError
@@ -739,7 +968,7 @@ func (s *Service) createOperation(ctx context.Context, operation Operation) (*Op
 			Err:   fmt.Errorf("failed to create ledger operation in database: %w", err),
 		})
 	}
-	_ = s.eventService.CreateEvent(ctx, event)
+	s.createEvent(ctx, event)
 	return &operation, nil
 }
 
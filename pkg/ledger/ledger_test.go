@@ -3,15 +3,20 @@ package ledger
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
-	"github.com/livechat-integrations/go-billing-sdk/v2/internal/livechat"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics/metricstest"
 )
 
 var am = new(apiMock)
@@ -26,8 +31,11 @@ var s = Service{
 	storage:      sm,
 	returnURL:    "returnURL",
 	masterOrgID:  "masterOrgID",
+	metrics:      metrics.Noop{},
+	logger:       discardLogger,
 }
 var ctx = context.Background()
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
 
 var assertExpectations = func(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, em, xm, am, sm, lm)
@@ -35,7 +43,10 @@ var assertExpectations = func(t *testing.T) {
 	xm.Calls = nil
 	am.Calls = nil
 	sm.Calls = nil
+	sm.lockedTopUps = nil
+	sm.lockErr = nil
 	lm.Calls = nil
+	lm.lockedTopUps = nil
 
 	em.ExpectedCalls = nil
 	xm.ExpectedCalls = nil
@@ -148,6 +159,17 @@ func (m *apiMock) ActivateDirectCharge(ctx context.Context, id string) (*livecha
 
 type storageMock struct {
 	mock.Mock
+	// lockedTopUps records WithTopUpLock calls, the lock is a pass-through so it does not need expectations
+	lockedTopUps []string
+	lockErr      error
+}
+
+func (m *storageMock) WithTopUpLock(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	m.lockedTopUps = append(m.lockedTopUps, id)
+	if m.lockErr != nil {
+		return m.lockErr
+	}
+	return fn(ctx)
 }
 
 func (m *storageMock) GetLedgerOperation(ctx context.Context, params GetLedgerOperationParams) (*Operation, error) {
@@ -223,14 +245,59 @@ func (m *storageMock) GetTopUpByIDAndType(ctx context.Context, params GetTopUpBy
 	return args.Get(0).(*TopUp), args.Error(1)
 }
 
+func (m *storageMock) GetTopUps(ctx context.Context) ([]TopUp, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]TopUp), args.Error(1)
+}
+
 func (m *storageMock) GetTopUpsByOrganizationID(ctx context.Context, organizationID string) ([]TopUp, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (m *storageMock) GetBalance(ctx context.Context, organizationID string) (float32, error) {
+func (m *storageMock) GetBalance(ctx context.Context, organizationID string) (Balances, error) {
+	args := m.Called(ctx, organizationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(Balances), args.Error(1)
+}
+
+func (m *storageMock) GetTrialBalance(ctx context.Context, organizationID string) (TrialBalance, error) {
 	args := m.Called(ctx, organizationID)
-	return args.Get(0).(float32), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(TrialBalance), args.Error(1)
+}
+
+func (m *storageMock) CreateBalanceSnapshots(ctx context.Context, keep int) (int64, error) {
+	args := m.Called(ctx, keep)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *storageMock) GetBalanceDrifts(ctx context.Context) ([]BalanceDrift, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]BalanceDrift), args.Error(1)
+}
+
+func (m *storageMock) RepairBalance(ctx context.Context, organizationID string, currency Currency) (*BalanceRepair, error) {
+	args := m.Called(ctx, organizationID, currency)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*BalanceRepair), args.Error(1)
+}
+
+func (m *storageMock) CreateConversion(ctx context.Context, params CreateConversionParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
 }
 
 func (m *storageMock) UpdateTopUpStatus(ctx context.Context, params UpdateTopUpStatusParams) error {
@@ -243,13 +310,21 @@ func TestNewService(t *testing.T) {
 		newService := NewService(nil, nil, nil, "labs", func(ctx context.Context) (string, error) { return "", nil }, &storageMock{}, "returnURL", "masterOrgID")
 
 		assert.NotNil(t, newService)
+		assert.Equal(t, livechat.DefaultRetryPolicy(), newService.billingAPI.(*livechat.Api).Retry)
 		assertExpectations(t)
 	})
+
+	t.Run("WithRetryPolicy", func(t *testing.T) {
+		policy := RetryPolicy{MaxAttempts: 5}
+		newService := NewService(nil, nil, nil, "labs", func(ctx context.Context) (string, error) { return "", nil }, &storageMock{}, "returnURL", "masterOrgID", WithRetryPolicy(policy))
+
+		assert.Equal(t, policy, newService.billingAPI.(*livechat.Api).Retry)
+	})
 }
 
 func TestService_CreateCharge(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 
 		xm.On("GenerateId").Return(xid, nil)
@@ -257,6 +332,7 @@ func TestService_CreateCharge(t *testing.T) {
 			ID:               xid,
 			LCOrganizationID: lcoid,
 			Amount:           -amount,
+			Currency:         CurrencyUSD,
 		}
 
 		sm.On("CreateLedgerOperation", ctx, operation).Return(nil).Once()
@@ -275,7 +351,7 @@ func TestService_CreateCharge(t *testing.T) {
 			Amount:         amount,
 			OrganizationID: lcoid,
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, operation).Return(levent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation}).Return(levent).Once()
 
 		id, err := s.CreateCharge(context.Background(), params)
 
@@ -286,7 +362,7 @@ func TestService_CreateCharge(t *testing.T) {
 	})
 
 	t.Run("error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		params := CreateChargeParams{
 			Test:           false,
@@ -300,6 +376,7 @@ func TestService_CreateCharge(t *testing.T) {
 			ID:               xid,
 			LCOrganizationID: lcoid,
 			Amount:           -amount,
+			Currency:         CurrencyUSD,
 		}
 
 		sm.On("CreateLedgerOperation", ctx, operation).Return(assert.AnError).Once()
@@ -312,7 +389,7 @@ func TestService_CreateCharge(t *testing.T) {
 			Payload:          sc,
 			Error:            "failed to create charge in database: assert.AnError general error for testing",
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, operation).Return(levent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("failed to create ledger operation in database: %w", assert.AnError),
@@ -329,7 +406,7 @@ func TestService_CreateCharge(t *testing.T) {
 
 func TestService_TopUp(t *testing.T) {
 	t.Run("success direct", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		payload := map[string]interface{}{"some": "payload"}
 		jp, _ := json.Marshal(payload)
@@ -338,6 +415,7 @@ func TestService_TopUp(t *testing.T) {
 			ID:               xid,
 			LCOrganizationID: lcoid,
 			Amount:           amount,
+			Currency:         CurrencyUSD,
 			Payload:          jp,
 		}
 
@@ -364,7 +442,7 @@ func TestService_TopUp(t *testing.T) {
 			Payload:          sc,
 		}
 		em.On("CreateEvent", context.Background(), opEvent).Return(nil).Once()
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, operation).Return(opEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation}).Return(opEvent).Once()
 
 		sct, _ := json.Marshal(topUp)
 		topEvent := events.Event{
@@ -375,17 +453,18 @@ func TestService_TopUp(t *testing.T) {
 			Payload:          sct,
 		}
 		em.On("CreateEvent", context.Background(), topEvent).Return(nil).Once()
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp).Return(topEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(topEvent).Once()
 
 		id, err := s.TopUp(context.Background(), topUp)
 
 		assert.Equal(t, xid, id)
+		assert.Equal(t, []string{topUp.ID}, sm.lockedTopUps)
 		assert.Nil(t, err)
 
 		assertExpectations(t)
 	})
 	t.Run("direct wrong status", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		payload := map[string]interface{}{"some": "payload"}
 		jp, _ := json.Marshal(payload)
@@ -412,7 +491,7 @@ func TestService_TopUp(t *testing.T) {
 			Action:           events.EventActionTopUp,
 			Payload:          sct,
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp).Return(topEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(topEvent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: topEvent,
 			Err:   fmt.Errorf("top up has wrong status: processing"),
@@ -426,7 +505,7 @@ func TestService_TopUp(t *testing.T) {
 		assertExpectations(t)
 	})
 	t.Run("success recurrent", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		someDate, _ := time.Parse("2006-01-02", "1999-01-01")
 		payload := livechat.RecurrentCharge{
@@ -443,6 +522,7 @@ func TestService_TopUp(t *testing.T) {
 			ID:               "2341-915148800000000",
 			LCOrganizationID: lcoid,
 			Amount:           amount,
+			Currency:         CurrencyUSD,
 			Payload:          jp,
 		}
 
@@ -472,7 +552,7 @@ func TestService_TopUp(t *testing.T) {
 			Payload:          sc,
 		}
 		em.On("CreateEvent", context.Background(), opEvent).Return(nil).Once()
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, operation).Return(opEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation}).Return(opEvent).Once()
 
 		sct, _ := json.Marshal(topUp)
 		topEvent := events.Event{
@@ -483,7 +563,7 @@ func TestService_TopUp(t *testing.T) {
 			Payload:          sct,
 		}
 		em.On("CreateEvent", context.Background(), topEvent).Return(nil).Once()
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp).Return(topEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(topEvent).Once()
 
 		id, err := s.TopUp(context.Background(), topUp)
 
@@ -493,7 +573,7 @@ func TestService_TopUp(t *testing.T) {
 		assertExpectations(t)
 	})
 	t.Run("recurrent upsert error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		someDate, _ := time.Parse("2006-01-02", "1999-01-01")
 		payload := livechat.RecurrentCharge{
@@ -530,7 +610,7 @@ func TestService_TopUp(t *testing.T) {
 			Action:           events.EventActionTopUp,
 			Payload:          sct,
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp).Return(topEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(topEvent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: topEvent,
 			Err:   assert.AnError,
@@ -544,7 +624,7 @@ func TestService_TopUp(t *testing.T) {
 		assertExpectations(t)
 	})
 	t.Run("recurrent upsert returns nothing", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		someDate, _ := time.Parse("2006-01-02", "1999-01-01")
 		payload := livechat.RecurrentCharge{
@@ -581,7 +661,7 @@ func TestService_TopUp(t *testing.T) {
 			Action:           events.EventActionTopUp,
 			Payload:          sct,
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp).Return(topEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(topEvent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: topEvent,
 			Err:   fmt.Errorf("upsert top up error"),
@@ -595,7 +675,7 @@ func TestService_TopUp(t *testing.T) {
 		assertExpectations(t)
 	})
 	t.Run("recurrent no LC charge dates error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		payload := map[string]interface{}{"some": "payload"}
 		jp, _ := json.Marshal(payload)
@@ -625,7 +705,7 @@ func TestService_TopUp(t *testing.T) {
 			Action:           events.EventActionTopUp,
 			Payload:          sct,
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp).Return(topEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(topEvent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: topEvent,
 			Err:   fmt.Errorf("no charge at current time"),
@@ -639,7 +719,7 @@ func TestService_TopUp(t *testing.T) {
 		assertExpectations(t)
 	})
 	t.Run("recurrent wrong status", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		payload := map[string]interface{}{"some": "payload"}
 		jp, _ := json.Marshal(payload)
@@ -666,7 +746,7 @@ func TestService_TopUp(t *testing.T) {
 			Action:           events.EventActionTopUp,
 			Payload:          sct,
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp).Return(topEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(topEvent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: topEvent,
 			Err:   fmt.Errorf("top up has wrong status: past_due"),
@@ -681,7 +761,7 @@ func TestService_TopUp(t *testing.T) {
 	})
 
 	t.Run("get top up error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		payload := map[string]interface{}{"some": "payload"}
 		jp, _ := json.Marshal(payload)
@@ -708,7 +788,7 @@ func TestService_TopUp(t *testing.T) {
 			Action:           events.EventActionTopUp,
 			Payload:          sct,
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp).Return(topEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(topEvent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: topEvent,
 			Err:   assert.AnError,
@@ -723,7 +803,7 @@ func TestService_TopUp(t *testing.T) {
 	})
 
 	t.Run("no top up error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		payload := map[string]interface{}{"some": "payload"}
 		jp, _ := json.Marshal(payload)
@@ -750,7 +830,7 @@ func TestService_TopUp(t *testing.T) {
 			Action:           events.EventActionTopUp,
 			Payload:          sct,
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp).Return(topEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(topEvent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: topEvent,
 			Err:   fmt.Errorf("no existing top up in database"),
@@ -764,7 +844,7 @@ func TestService_TopUp(t *testing.T) {
 		assertExpectations(t)
 	})
 	t.Run("storage error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		someDate, _ := time.Parse("2006-01-02", "1999-01-01")
 		lcoid := "lcOrganizationID"
 		payload := livechat.RecurrentCharge{
@@ -781,6 +861,7 @@ func TestService_TopUp(t *testing.T) {
 			ID:               "2341-915148800000000",
 			LCOrganizationID: lcoid,
 			Amount:           amount,
+			Currency:         CurrencyUSD,
 			Payload:          jp,
 		}
 
@@ -809,7 +890,7 @@ func TestService_TopUp(t *testing.T) {
 			Action:           events.EventActionCreateOperation,
 			Payload:          sc,
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, operation).Return(opEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation}).Return(opEvent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: opEvent,
 			Err:   fmt.Errorf("failed to create ledger operation in database: %w", assert.AnError),
@@ -823,7 +904,7 @@ func TestService_TopUp(t *testing.T) {
 			Action:           events.EventActionTopUp,
 			Payload:          sct,
 		}
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp).Return(topEvent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(topEvent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: topEvent,
 			Err:   assert.AnError,
@@ -840,7 +921,7 @@ func TestService_TopUp(t *testing.T) {
 
 func TestService_CreateTopUpRequest(t *testing.T) {
 	t.Run("success recurrent", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		months := 0
 		confUrl := "http://livechat.com/confirmation"
@@ -849,7 +930,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 				ID:              "id",
 				Name:            "name",
 				Test:            false,
-				Price:           int(amount * 100),
+				Price:           523,
 				ConfirmationURL: confUrl,
 			},
 			TrialDays: 0,
@@ -861,6 +942,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 			ID:               "id",
 			LCOrganizationID: lcoid,
 			Amount:           amount,
+			Currency:         CurrencyUSD,
 			Type:             TopUpTypeRecurrent,
 			Status:           TopUpStatusPending,
 			LCCharge:         rawRC,
@@ -869,21 +951,12 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 		am.On("CreateRecurrentCharge", ctx, livechat.CreateRecurrentChargeParams{
 			Name:      "name",
 			ReturnURL: "returnURL",
-			Price:     int(amount * 100),
+			Price:     523,
 			Test:      false,
 			TrialDays: 0,
 			Months:    months,
 		}).Return(rc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
-		levent := events.Event{
-			ID:               xid,
-			LCOrganizationID: lcoid,
-			Type:             events.EventTypeInfo,
-			Action:           events.EventActionCreateTopUp,
-			Payload:          sc,
-		}
-		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 		params := CreateTopUpRequestParams{
 			Test:           false,
 			Name:           "name",
@@ -894,7 +967,16 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 				Months: &months,
 			},
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, params).Return(levent).Once()
+		sc, _ := json.Marshal(CreateTopUpEventPayload{Version: 2, Request: params, TopUp: &topUp})
+		levent := events.Event{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			Type:             events.EventTypeInfo,
+			Action:           events.EventActionCreateTopUp,
+			Payload:          sc,
+		}
+		em.On("CreateEvent", ctx, levent).Return(nil).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, CreateTopUpEventPayload{Request: params}).Return(levent).Once()
 
 		tu, err := s.CreateTopUpRequest(ctx, params)
 
@@ -905,7 +987,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 	})
 
 	t.Run("error recurrent no months", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		params := CreateTopUpRequestParams{
 			Test:           false,
@@ -925,7 +1007,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 			Payload:          sc,
 			Error:            "failed to create top up billing charge: failed to create recurrent charge V3 via lc: charge config months is nil",
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, params).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, CreateTopUpEventPayload{Request: params}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("failed to create top up billing charge: %w", fmt.Errorf("failed to create recurrent charge V3 via lc: charge config months is nil")),
@@ -940,7 +1022,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 	})
 
 	t.Run("error recurrent api error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		months := 0
 		params := CreateTopUpRequestParams{
@@ -957,7 +1039,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 		am.On("CreateRecurrentCharge", ctx, livechat.CreateRecurrentChargeParams{
 			Name:      "name",
 			ReturnURL: "returnURL",
-			Price:     int(amount * 100),
+			Price:     523,
 			Test:      false,
 			TrialDays: 0,
 			Months:    months,
@@ -972,7 +1054,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 			Payload:          sc,
 			Error:            "failed to create top up billing charge: failed to create recurrent charge V3 via lc: assert.AnError general error for testing",
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, params).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, CreateTopUpEventPayload{Request: params}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("failed to create top up billing charge: %w", fmt.Errorf("failed to create recurrent charge V3 via lc: %w", assert.AnError)),
@@ -987,7 +1069,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 	})
 
 	t.Run("error recurrent no api charge returned", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		months := 0
 		params := CreateTopUpRequestParams{
@@ -1004,7 +1086,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 		am.On("CreateRecurrentCharge", ctx, livechat.CreateRecurrentChargeParams{
 			Name:      "name",
 			ReturnURL: "returnURL",
-			Price:     int(amount * 100),
+			Price:     523,
 			Test:      false,
 			TrialDays: 0,
 			Months:    months,
@@ -1019,7 +1101,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 			Payload:          sc,
 			Error:            "failed to create top up billing charge: failed to create recurrent charge V3 via lc: ",
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, params).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, CreateTopUpEventPayload{Request: params}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("failed to create top up billing charge: %w", fmt.Errorf("failed to create recurrent charge V3 via lc: charge is nil")),
@@ -1034,14 +1116,14 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 	})
 
 	t.Run("success direct", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		rc := &livechat.DirectCharge{
 			BaseCharge: livechat.BaseCharge{
 				ID:    "id",
 				Name:  "name",
 				Test:  false,
-				Price: int(amount * 100),
+				Price: 523,
 			},
 			Quantity: 1,
 		}
@@ -1051,6 +1133,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 			ID:               "id",
 			LCOrganizationID: lcoid,
 			Amount:           amount,
+			Currency:         CurrencyUSD,
 			Type:             TopUpTypeDirect,
 			Status:           TopUpStatusPending,
 			LCCharge:         rawRC,
@@ -1058,12 +1141,11 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 		am.On("CreateDirectCharge", ctx, livechat.CreateDirectChargeParams{
 			Name:      "name",
 			ReturnURL: "returnURL",
-			Price:     int(amount * 100),
+			Price:     523,
 			Test:      false,
 		}).Return(rc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
 
-		sc, _ := json.Marshal(topUp)
 		params := CreateTopUpRequestParams{
 			Test:           false,
 			Name:           "name",
@@ -1072,6 +1154,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 			Type:           TopUpTypeDirect,
 			Config:         TopUpConfig{},
 		}
+		sc, _ := json.Marshal(CreateTopUpEventPayload{Version: 2, Request: params, TopUp: &topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1079,7 +1162,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 			Action:           events.EventActionCreateTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, params).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, CreateTopUpEventPayload{Request: params}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tu, err := s.CreateTopUpRequest(context.Background(), params)
@@ -1091,7 +1174,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 	})
 
 	t.Run("error direct api error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		params := CreateTopUpRequestParams{
 			Test:           false,
@@ -1105,7 +1188,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 		am.On("CreateDirectCharge", ctx, livechat.CreateDirectChargeParams{
 			Name:      "name",
 			ReturnURL: "returnURL",
-			Price:     int(amount * 100),
+			Price:     523,
 			Test:      false,
 		}).Return(nil, assert.AnError).Once()
 
@@ -1118,7 +1201,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 			Payload:          sc,
 			Error:            "failed to create top up billing charge: failed to create direct charge via lc: assert.AnError general error for testing",
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, params).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, CreateTopUpEventPayload{Request: params}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("failed to create top up billing charge: %w", fmt.Errorf("failed to create direct charge via lc: %w", assert.AnError)),
@@ -1133,7 +1216,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 	})
 
 	t.Run("error direct no api charge returned", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		params := CreateTopUpRequestParams{
 			Test:           false,
@@ -1147,7 +1230,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 		am.On("CreateDirectCharge", ctx, livechat.CreateDirectChargeParams{
 			Name:      "name",
 			ReturnURL: "returnURL",
-			Price:     int(amount * 100),
+			Price:     523,
 			Test:      false,
 		}).Return(nil, nil).Once()
 
@@ -1160,7 +1243,7 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 			Payload:          sc,
 			Error:            "failed to create top up billing charge: failed to create direct charge via lc: charge is nil",
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, params).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, CreateTopUpEventPayload{Request: params}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   fmt.Errorf("failed to create top up billing charge: %w", fmt.Errorf("failed to create direct charge via lc: charge is nil")),
@@ -1173,11 +1256,73 @@ func TestService_CreateTopUpRequest(t *testing.T) {
 
 		assertExpectations(t)
 	})
+
+	t.Run("error fraction of a cent", func(t *testing.T) {
+		amount := Money(5234)
+		lcoid := "lcOrganizationID"
+		params := CreateTopUpRequestParams{
+			Test:           false,
+			Name:           "name",
+			Amount:         amount,
+			OrganizationID: lcoid,
+			Type:           TopUpTypeDirect,
+			Config:         TopUpConfig{},
+		}
+
+		sc, _ := json.Marshal(params)
+		levent := events.Event{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			Type:             events.EventTypeError,
+			Action:           events.EventActionCreateTopUp,
+			Payload:          sc,
+		}
+		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, CreateTopUpEventPayload{Request: params}).Return(levent).Once()
+		em.On("ToError", context.Background(), mock.MatchedBy(func(p events.ToErrorParams) bool {
+			return p.Event.ID == xid && errors.Is(p.Err, ErrInvalidMoney)
+		})).Return(assert.AnError).Once()
+
+		tu, err := s.CreateTopUpRequest(context.Background(), params)
+
+		assert.Nil(t, tu)
+		assert.ErrorIs(t, err, assert.AnError)
+
+		assertExpectations(t)
+	})
+
+	t.Run("error unsupported currency", func(t *testing.T) {
+		lcoid := "lcOrganizationID"
+		params := CreateTopUpRequestParams{
+			Name:           "name",
+			Amount:         Money(5230),
+			Currency:       CurrencyEUR,
+			OrganizationID: lcoid,
+			Type:           TopUpTypeDirect,
+		}
+
+		levent := events.Event{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			Type:             events.EventTypeError,
+			Action:           events.EventActionCreateTopUp,
+		}
+		em.On("ToEvent", ctx, lcoid, events.EventActionCreateTopUp, events.EventTypeInfo, CreateTopUpEventPayload{Request: params}).Return(levent).Once()
+		em.On("ToError", context.Background(), mock.MatchedBy(func(p events.ToErrorParams) bool {
+			return p.Event.ID == xid && errors.Is(p.Err, ErrUnsupportedCurrency)
+		})).Return(assert.AnError).Once()
+
+		tu, err := s.CreateTopUpRequest(context.Background(), params)
+
+		assert.Nil(t, tu)
+		assert.ErrorIs(t, err, assert.AnError)
+
+		assertExpectations(t)
+	})
 }
 
 func TestService_AddVoucherFunds(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		namespace := "namespace"
 		key := fmt.Sprintf("add-funds-%s-%s", namespace, lcoid)
@@ -1189,12 +1334,13 @@ func TestService_AddVoucherFunds(t *testing.T) {
 			ID:               key,
 			LCOrganizationID: lcoid,
 			Amount:           amount,
+			Currency:         CurrencyCredits,
 			IsVoucher:        true,
 		}
 		sm.On("CreateLedgerOperation", ctx, operation).Return(nil).Once()
 		sc, _ := json.Marshal(operation)
-		p := map[string]interface{}{"amount": amount, "namespace": namespace}
-		jp, _ := json.Marshal(p)
+		p := AddVoucherFundsEventPayload{OperationID: key, Amount: amount, Currency: CurrencyCredits, Namespace: namespace}
+		jp, _ := json.Marshal(AddVoucherFundsEventPayload{Version: 2, OperationID: key, Amount: amount, Currency: CurrencyCredits, Namespace: namespace, Result: "success"})
 		event := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1210,18 +1356,19 @@ func TestService_AddVoucherFunds(t *testing.T) {
 			Payload:          sc,
 		}
 		em.On("CreateEvent", context.Background(), levent).Return(nil).Once()
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, operation).Return(levent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation}).Return(levent).Once()
 		em.On("CreateEvent", context.Background(), event).Return(nil).Once()
 		em.On("ToEvent", context.Background(), lcoid, events.EventActionAddVoucherFunds, events.EventTypeInfo, p).Return(event).Once()
 
-		err := s.AddVoucherFunds(ctx, amount, lcoid, namespace, nil)
+		// voucher funds are credits unless the caller picks a currency
+		err := s.AddVoucherFunds(ctx, amount, "", lcoid, namespace, nil)
 
 		assert.Nil(t, err)
 
 		assertExpectations(t)
 	})
 	t.Run("success with payload", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		namespace := "namespace"
 		key := fmt.Sprintf("add-funds-%s-%s", namespace, lcoid)
@@ -1238,13 +1385,14 @@ func TestService_AddVoucherFunds(t *testing.T) {
 			ID:               key,
 			LCOrganizationID: lcoid,
 			Amount:           amount,
+			Currency:         CurrencyCredits,
 			Payload:          rawPayload,
 			IsVoucher:        true,
 		}
 		sm.On("CreateLedgerOperation", ctx, operation).Return(nil).Once()
 		sc, _ := json.Marshal(operation)
-		p := map[string]interface{}{"amount": amount, "namespace": namespace}
-		jp, _ := json.Marshal(p)
+		p := AddVoucherFundsEventPayload{OperationID: key, Amount: amount, Currency: CurrencyCredits, Namespace: namespace}
+		jp, _ := json.Marshal(AddVoucherFundsEventPayload{Version: 2, OperationID: key, Amount: amount, Currency: CurrencyCredits, Namespace: namespace, Result: "success"})
 		event := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1260,18 +1408,18 @@ func TestService_AddVoucherFunds(t *testing.T) {
 			Payload:          sc,
 		}
 		em.On("CreateEvent", context.Background(), levent).Return(nil).Once()
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, operation).Return(levent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation}).Return(levent).Once()
 		em.On("CreateEvent", context.Background(), event).Return(nil).Once()
 		em.On("ToEvent", context.Background(), lcoid, events.EventActionAddVoucherFunds, events.EventTypeInfo, p).Return(event).Once()
 
-		err := s.AddVoucherFunds(ctx, amount, lcoid, namespace, &rawPayload)
+		err := s.AddVoucherFunds(ctx, amount, CurrencyCredits, lcoid, namespace, &rawPayload)
 
 		assert.Nil(t, err)
 
 		assertExpectations(t)
 	})
 	t.Run("existing operation", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		namespace := "namespace"
 		key := fmt.Sprintf("add-funds-%s-%s", namespace, lcoid)
@@ -1279,13 +1427,14 @@ func TestService_AddVoucherFunds(t *testing.T) {
 			ID:               key,
 			LCOrganizationID: lcoid,
 			Amount:           amount,
+			Currency:         CurrencyUSD,
 		}
 		sm.On("GetLedgerOperation", ctx, GetLedgerOperationParams{
 			ID:             key,
 			OrganizationID: lcoid,
 		}).Return(&operation, nil).Once()
-		p := map[string]interface{}{"amount": amount, "namespace": namespace}
-		jp, _ := json.Marshal(map[string]interface{}{"id": key, "amount": amount, "namespace": namespace, "result": "already exists"})
+		p := AddVoucherFundsEventPayload{OperationID: key, Amount: amount, Currency: CurrencyCredits, Namespace: namespace}
+		jp, _ := json.Marshal(AddVoucherFundsEventPayload{Version: 2, OperationID: key, Amount: amount, Currency: CurrencyCredits, Namespace: namespace, Result: "already exists"})
 		event := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1297,14 +1446,14 @@ func TestService_AddVoucherFunds(t *testing.T) {
 		em.On("CreateEvent", context.Background(), event).Return(nil).Once()
 		em.On("ToEvent", context.Background(), lcoid, events.EventActionAddVoucherFunds, events.EventTypeInfo, p).Return(event).Once()
 
-		err := s.AddVoucherFunds(ctx, amount, lcoid, namespace, nil)
+		err := s.AddVoucherFunds(ctx, amount, CurrencyCredits, lcoid, namespace, nil)
 
 		assert.Nil(t, err)
 
 		assertExpectations(t)
 	})
 	t.Run("get operation error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		namespace := "namespace"
 		key := fmt.Sprintf("add-funds-%s-%s", namespace, lcoid)
@@ -1312,8 +1461,8 @@ func TestService_AddVoucherFunds(t *testing.T) {
 			ID:             key,
 			OrganizationID: lcoid,
 		}).Return(nil, assert.AnError).Once()
-		p := map[string]interface{}{"amount": amount, "namespace": namespace}
-		jp, _ := json.Marshal(map[string]interface{}{"amount": amount, "namespace": namespace})
+		p := AddVoucherFundsEventPayload{OperationID: key, Amount: amount, Currency: CurrencyCredits, Namespace: namespace}
+		jp, _ := json.Marshal(p)
 		event := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1328,14 +1477,14 @@ func TestService_AddVoucherFunds(t *testing.T) {
 		}).Return(assert.AnError).Once()
 		em.On("ToEvent", ctx, lcoid, events.EventActionAddVoucherFunds, events.EventTypeInfo, p).Return(event).Once()
 
-		err := s.AddVoucherFunds(ctx, amount, lcoid, namespace, nil)
+		err := s.AddVoucherFunds(ctx, amount, CurrencyCredits, lcoid, namespace, nil)
 
 		assert.ErrorIs(t, err, assert.AnError)
 
 		assertExpectations(t)
 	})
 	t.Run("store operation error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		namespace := "namespace"
 		key := fmt.Sprintf("add-funds-%s-%s", namespace, lcoid)
@@ -1347,11 +1496,12 @@ func TestService_AddVoucherFunds(t *testing.T) {
 			ID:               key,
 			LCOrganizationID: lcoid,
 			Amount:           amount,
+			Currency:         CurrencyCredits,
 			IsVoucher:        true,
 		}
 		sm.On("CreateLedgerOperation", ctx, operation).Return(assert.AnError).Once()
 		sc, _ := json.Marshal(operation)
-		p := map[string]interface{}{"amount": amount, "namespace": namespace}
+		p := AddVoucherFundsEventPayload{OperationID: key, Amount: amount, Currency: CurrencyCredits, Namespace: namespace}
 		jp, _ := json.Marshal(p)
 		event := events.Event{
 			ID:               xid,
@@ -1371,14 +1521,14 @@ func TestService_AddVoucherFunds(t *testing.T) {
 			Event: levent,
 			Err:   fmt.Errorf("failed to create ledger operation in database: %w", assert.AnError),
 		}).Return(assert.AnError).Once()
-		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, operation).Return(levent).Once()
+		em.On("ToEvent", context.Background(), lcoid, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation}).Return(levent).Once()
 		em.On("ToError", ctx, events.ToErrorParams{
 			Event: event,
 			Err:   assert.AnError,
 		}).Return(assert.AnError).Once()
 		em.On("ToEvent", context.Background(), lcoid, events.EventActionAddVoucherFunds, events.EventTypeInfo, p).Return(event).Once()
 
-		err := s.AddVoucherFunds(ctx, amount, lcoid, namespace, nil)
+		err := s.AddVoucherFunds(ctx, amount, CurrencyCredits, lcoid, namespace, nil)
 
 		assert.ErrorIs(t, err, assert.AnError)
 
@@ -1388,14 +1538,14 @@ func TestService_AddVoucherFunds(t *testing.T) {
 
 func TestService_GetBalance(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(5.23)
+		balances := Balances{CurrencyUSD: 5230, CurrencyCredits: -100}
 		lcoid := "lcOrganizationID"
 
-		sm.On("GetBalance", ctx, lcoid).Return(amount, nil).Once()
+		sm.On("GetBalance", ctx, lcoid).Return(balances, nil).Once()
 
 		balance, err := s.GetBalance(context.Background(), lcoid)
 
-		assert.Equal(t, amount, balance)
+		assert.Equal(t, balances, balance)
 		assert.Nil(t, err)
 
 		assertExpectations(t)
@@ -1404,11 +1554,11 @@ func TestService_GetBalance(t *testing.T) {
 	t.Run("error", func(t *testing.T) {
 		lcoid := "lcOrganizationID"
 
-		sm.On("GetBalance", ctx, lcoid).Return(float32(0), assert.AnError).Once()
+		sm.On("GetBalance", ctx, lcoid).Return(nil, assert.AnError).Once()
 
 		balance, err := s.GetBalance(context.Background(), lcoid)
 
-		assert.Equal(t, float32(0), balance)
+		assert.Nil(t, balance)
 		assert.ErrorIs(t, err, assert.AnError)
 
 		assertExpectations(t)
@@ -1417,7 +1567,7 @@ func TestService_GetBalance(t *testing.T) {
 
 func TestService_CancelTopUpRequest(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		months := 0
 		rc := &livechat.RecurrentCharge{
@@ -1425,7 +1575,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 				ID:    "id",
 				Name:  "name",
 				Test:  false,
-				Price: int(amount * 100),
+				Price: 523,
 			},
 			TrialDays: 0,
 			Months:    months,
@@ -1450,7 +1600,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			Status: TopUpStatusCancelled,
 		}).Return(nil).Once()
 
-		sc, _ := json.Marshal(map[string]interface{}{"id": "id", "result": "success"})
+		sc, _ := json.Marshal(CancelRecurrentTopUpEventPayload{Version: 1, TopUpID: "id", Result: "success"})
 
 		levent := events.Event{
 			ID:               xid,
@@ -1459,12 +1609,36 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			Action:           events.EventActionCancelRecurrentTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, map[string]interface{}{"id": "id"}).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, CancelRecurrentTopUpEventPayload{TopUpID: "id"}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		err := s.CancelTopUpRequest(context.Background(), lcoid, "id")
 
 		assert.Nil(t, err)
+		assert.Equal(t, []string{"id"}, sm.lockedTopUps)
+
+		assertExpectations(t)
+	})
+
+	t.Run("charge not found in LiveChat", func(t *testing.T) {
+		lcoid := "lcOrganizationID"
+		topUp := TopUp{ID: "id", LCOrganizationID: lcoid, Type: TopUpTypeRecurrent, Status: TopUpStatusActive}
+		am.On("CancelRecurrentCharge", ctx, "id").Return(nil, livechat.ErrNotFound).Once()
+		sm.On("GetTopUpByIDAndType", ctx, GetTopUpByIDAndTypeParams{
+			ID:   "id",
+			Type: TopUpTypeRecurrent,
+		}).Return(&topUp, nil).Once()
+
+		levent := events.Event{ID: xid, LCOrganizationID: lcoid, Type: events.EventTypeInfo, Action: events.EventActionCancelRecurrentTopUp}
+		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, CancelRecurrentTopUpEventPayload{TopUpID: "id"}).Return(levent).Once()
+		levent.Type = events.EventTypeError
+		em.On("ToError", ctx, events.ToErrorParams{
+			Event: levent,
+			Err:   fmt.Errorf("recurrent top up not found in LiveChat: %w", livechat.ErrNotFound),
+		}).Return(livechat.ErrNotFound).Once()
+
+		err := s.CancelTopUpRequest(context.Background(), lcoid, "id")
+		assert.ErrorIs(t, err, livechat.ErrNotFound)
 
 		assertExpectations(t)
 	})
@@ -1475,7 +1649,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			ID:   "id",
 			Type: TopUpTypeRecurrent,
 		}).Return(nil, nil).Once()
-		sc, _ := json.Marshal(map[string]interface{}{"id": "id", "result": "top up not found"})
+		sc, _ := json.Marshal(CancelRecurrentTopUpEventPayload{Version: 1, TopUpID: "id", Result: "top up not found"})
 
 		levent := events.Event{
 			ID:               xid,
@@ -1484,7 +1658,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			Action:           events.EventActionCancelRecurrentTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, map[string]interface{}{"id": "id"}).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, CancelRecurrentTopUpEventPayload{TopUpID: "id"}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		err := s.CancelTopUpRequest(context.Background(), lcoid, "id")
@@ -1500,7 +1674,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			ID:   "id",
 			Type: TopUpTypeRecurrent,
 		}).Return(nil, assert.AnError).Once()
-		sc, _ := json.Marshal(map[string]interface{}{"id": "id"})
+		sc, _ := json.Marshal(CancelRecurrentTopUpEventPayload{TopUpID: "id"})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1509,7 +1683,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			Payload:          sc,
 			Error:            "assert.AnError general error for testing",
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, map[string]interface{}{"id": "id"}).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, CancelRecurrentTopUpEventPayload{TopUpID: "id"}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
@@ -1523,7 +1697,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 	})
 
 	t.Run("api error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		months := 0
 		rc := &livechat.RecurrentCharge{
@@ -1531,7 +1705,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 				ID:    "id",
 				Name:  "name",
 				Test:  false,
-				Price: int(amount * 100),
+				Price: 523,
 			},
 			TrialDays: 0,
 			Months:    months,
@@ -1552,7 +1726,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			Type: TopUpTypeRecurrent,
 		}).Return(&topUp, nil).Once()
 
-		sc, _ := json.Marshal(map[string]interface{}{"id": "id"})
+		sc, _ := json.Marshal(CancelRecurrentTopUpEventPayload{TopUpID: "id"})
 
 		levent := events.Event{
 			ID:               xid,
@@ -1562,7 +1736,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			Payload:          sc,
 			Error:            "assert.AnError general error for testing",
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, map[string]interface{}{"id": "id"}).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, CancelRecurrentTopUpEventPayload{TopUpID: "id"}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
@@ -1576,7 +1750,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 	})
 
 	t.Run("update status error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		months := 0
 		rc := &livechat.RecurrentCharge{
@@ -1584,7 +1758,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 				ID:    "id",
 				Name:  "name",
 				Test:  false,
-				Price: int(amount * 100),
+				Price: 523,
 			},
 			TrialDays: 0,
 			Months:    months,
@@ -1609,7 +1783,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			Status: TopUpStatusCancelled,
 		}).Return(assert.AnError).Once()
 
-		sc, _ := json.Marshal(map[string]interface{}{"id": "id"})
+		sc, _ := json.Marshal(CancelRecurrentTopUpEventPayload{TopUpID: "id"})
 
 		levent := events.Event{
 			ID:               xid,
@@ -1619,7 +1793,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			Payload:          sc,
 			Error:            "assert.AnError general error for testing",
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, map[string]interface{}{"id": "id"}).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, CancelRecurrentTopUpEventPayload{TopUpID: "id"}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
@@ -1633,7 +1807,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 	})
 
 	t.Run("update status not found error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		months := 0
 		rc := &livechat.RecurrentCharge{
@@ -1641,7 +1815,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 				ID:    "id",
 				Name:  "name",
 				Test:  false,
-				Price: int(amount * 100),
+				Price: 523,
 			},
 			TrialDays: 0,
 			Months:    months,
@@ -1666,7 +1840,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			Status: TopUpStatusCancelled,
 		}).Return(ErrNotFound).Once()
 
-		sc, _ := json.Marshal(map[string]interface{}{"id": "id", "result": "top up not found"})
+		sc, _ := json.Marshal(CancelRecurrentTopUpEventPayload{Version: 1, TopUpID: "id", Result: "top up not found"})
 
 		levent := events.Event{
 			ID:               xid,
@@ -1675,7 +1849,7 @@ func TestService_CancelTopUpRequest(t *testing.T) {
 			Action:           events.EventActionCancelRecurrentTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, map[string]interface{}{"id": "id"}).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionCancelRecurrentTopUp, events.EventTypeInfo, CancelRecurrentTopUpEventPayload{TopUpID: "id"}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		err := s.CancelTopUpRequest(context.Background(), lcoid, "id")
@@ -1695,7 +1869,7 @@ func TestService_ForceCancelTopUp(t *testing.T) {
 			ID:     "id",
 			Status: status,
 		}).Return(nil).Once()
-		sc, _ := json.Marshal(map[string]interface{}{"id": "id", "status": status})
+		sc, _ := json.Marshal(ForceCancelTopUpEventPayload{TopUpID: "id", Status: status})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1703,18 +1877,31 @@ func TestService_ForceCancelTopUp(t *testing.T) {
 			Action:           events.EventActionForceCancelCharge,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, map[string]interface{}{"id": "id", "status": TopUpStatusCancelled}).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, ForceCancelTopUpEventPayload{TopUpID: "id", Status: TopUpStatusCancelled}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		topUp := TopUp{
 			ID:               "id",
 			LCOrganizationID: lcoid,
-			Status:           status,
+			Status:           TopUpStatusActive,
 		}
+		sm.On("GetTopUpByIDAndOrganizationID", ctx, lcoid, "id").Return(&topUp, nil).Once()
 
 		err := s.ForceCancelTopUp(context.Background(), topUp)
 
 		assert.Nil(t, err)
+		assert.Equal(t, []string{"id"}, sm.lockedTopUps)
+
+		assertExpectations(t)
+	})
+
+	t.Run("error locking top up", func(t *testing.T) {
+		sm.lockErr = assert.AnError
+
+		err := s.ForceCancelTopUp(context.Background(), TopUp{ID: "id", LCOrganizationID: "lcOrganizationID"})
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, []string{"id"}, sm.lockedTopUps)
 
 		assertExpectations(t)
 	})
@@ -1727,7 +1914,7 @@ func TestService_ForceCancelTopUp(t *testing.T) {
 			ID:     "id",
 			Status: status,
 		}).Return(ErrNotFound).Once()
-		sc, _ := json.Marshal(map[string]interface{}{"id": "id", "result": "top up not found"})
+		sc, _ := json.Marshal(ForceCancelTopUpEventPayload{Version: 1, TopUpID: "id", Status: status, Result: "top up not found"})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1735,14 +1922,15 @@ func TestService_ForceCancelTopUp(t *testing.T) {
 			Action:           events.EventActionForceCancelCharge,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, map[string]interface{}{"id": "id", "status": TopUpStatusCancelled}).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, ForceCancelTopUpEventPayload{TopUpID: "id", Status: TopUpStatusCancelled}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		topUp := TopUp{
 			ID:               "id",
 			LCOrganizationID: lcoid,
-			Status:           status,
+			Status:           TopUpStatusActive,
 		}
+		sm.On("GetTopUpByIDAndOrganizationID", ctx, lcoid, "id").Return(&topUp, nil).Once()
 
 		err := s.ForceCancelTopUp(context.Background(), topUp)
 
@@ -1759,7 +1947,7 @@ func TestService_ForceCancelTopUp(t *testing.T) {
 			ID:     "id",
 			Status: status,
 		}).Return(assert.AnError).Once()
-		sc, _ := json.Marshal(map[string]interface{}{"id": "id", "status": status})
+		sc, _ := json.Marshal(ForceCancelTopUpEventPayload{TopUpID: "id", Status: status})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1768,7 +1956,7 @@ func TestService_ForceCancelTopUp(t *testing.T) {
 			Payload:          sc,
 			Error:            "assert.AnError general error for testing",
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, map[string]interface{}{"id": "id", "status": TopUpStatusCancelled}).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, ForceCancelTopUpEventPayload{TopUpID: "id", Status: TopUpStatusCancelled}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
@@ -1777,8 +1965,9 @@ func TestService_ForceCancelTopUp(t *testing.T) {
 		topUp := TopUp{
 			ID:               "id",
 			LCOrganizationID: lcoid,
-			Status:           status,
+			Status:           TopUpStatusActive,
 		}
+		sm.On("GetTopUpByIDAndOrganizationID", ctx, lcoid, "id").Return(&topUp, nil).Once()
 
 		err := s.ForceCancelTopUp(context.Background(), topUp)
 
@@ -1786,11 +1975,77 @@ func TestService_ForceCancelTopUp(t *testing.T) {
 
 		assertExpectations(t)
 	})
+
+	t.Run("skips a top up whose status changed", func(t *testing.T) {
+		lcoid := "lcOrganizationID"
+
+		sm.On("GetTopUpByIDAndOrganizationID", ctx, lcoid, "id").Return(&TopUp{ID: "id", LCOrganizationID: lcoid, Status: TopUpStatusActive}, nil).Once()
+		sc, _ := json.Marshal(ForceCancelTopUpEventPayload{Version: 1, TopUpID: "id", Status: TopUpStatusCancelled, Result: "skipped, top up is active"})
+		levent := events.Event{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			Type:             events.EventTypeInfo,
+			Action:           events.EventActionForceCancelCharge,
+			Payload:          sc,
+		}
+		em.On("ToEvent", ctx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, ForceCancelTopUpEventPayload{TopUpID: "id", Status: TopUpStatusCancelled}).Return(levent).Once()
+		em.On("CreateEvent", ctx, levent).Return(nil).Once()
+
+		err := s.ForceCancelTopUp(context.Background(), TopUp{ID: "id", LCOrganizationID: lcoid, Status: TopUpStatusPending})
+
+		assert.Nil(t, err)
+
+		assertExpectations(t)
+	})
+
+	t.Run("skips a cancelled top up", func(t *testing.T) {
+		lcoid := "lcOrganizationID"
+
+		sm.On("GetTopUpByIDAndOrganizationID", ctx, lcoid, "id").Return(&TopUp{ID: "id", LCOrganizationID: lcoid, Status: TopUpStatusCancelled}, nil).Once()
+		sc, _ := json.Marshal(ForceCancelTopUpEventPayload{Version: 1, TopUpID: "id", Status: TopUpStatusCancelled, Result: "skipped, top up is cancelled"})
+		levent := events.Event{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			Type:             events.EventTypeInfo,
+			Action:           events.EventActionForceCancelCharge,
+			Payload:          sc,
+		}
+		em.On("ToEvent", ctx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, ForceCancelTopUpEventPayload{TopUpID: "id", Status: TopUpStatusCancelled}).Return(levent).Once()
+		em.On("CreateEvent", ctx, levent).Return(nil).Once()
+
+		err := s.ForceCancelTopUp(context.Background(), TopUp{ID: "id", LCOrganizationID: lcoid, Status: TopUpStatusCancelled})
+
+		assert.Nil(t, err)
+
+		assertExpectations(t)
+	})
+
+	t.Run("top up not found", func(t *testing.T) {
+		lcoid := "lcOrganizationID"
+
+		sm.On("GetTopUpByIDAndOrganizationID", ctx, lcoid, "id").Return(nil, nil).Once()
+		sc, _ := json.Marshal(ForceCancelTopUpEventPayload{Version: 1, TopUpID: "id", Status: TopUpStatusCancelled, Result: "top up not found"})
+		levent := events.Event{
+			ID:               xid,
+			LCOrganizationID: lcoid,
+			Type:             events.EventTypeInfo,
+			Action:           events.EventActionForceCancelCharge,
+			Payload:          sc,
+		}
+		em.On("ToEvent", ctx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, ForceCancelTopUpEventPayload{TopUpID: "id", Status: TopUpStatusCancelled}).Return(levent).Once()
+		em.On("CreateEvent", ctx, levent).Return(nil).Once()
+
+		err := s.ForceCancelTopUp(context.Background(), TopUp{ID: "id", LCOrganizationID: lcoid, Status: TopUpStatusActive})
+
+		assert.ErrorIs(t, err, ErrTopUpNotFound)
+
+		assertExpectations(t)
+	})
 }
 
 func TestService_SyncTopUp(t *testing.T) {
 	t.Run("success direct cancelled", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 
@@ -1804,7 +2059,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -1829,7 +2084,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetDirectCharge", ctx, "id").Return(&dc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1837,12 +2092,13 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
 
 		assert.Nil(t, err)
+		assert.Equal(t, []string{topUp.ID}, sm.lockedTopUps)
 		assert.Equal(t, topUp.ID, tp.ID)
 		assert.Equal(t, topUp.Amount, tp.Amount)
 		assert.Equal(t, topUp.Type, tp.Type)
@@ -1856,7 +2112,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success direct failed", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 
@@ -1870,7 +2126,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -1895,7 +2151,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetDirectCharge", ctx, "id").Return(&dc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1903,7 +2159,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -1922,7 +2178,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success direct declined", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 
@@ -1936,7 +2192,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -1961,7 +2217,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetDirectCharge", ctx, "id").Return(&dc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -1969,7 +2225,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -1988,7 +2244,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success direct success", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 
@@ -2002,7 +2258,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2027,7 +2283,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetDirectCharge", ctx, "id").Return(&dc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2035,7 +2291,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2054,7 +2310,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success direct processed", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 
@@ -2068,7 +2324,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2093,7 +2349,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetDirectCharge", ctx, "id").Return(&dc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2101,7 +2357,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2119,7 +2375,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success direct accepted", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 
@@ -2133,7 +2389,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2158,7 +2414,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetDirectCharge", ctx, "id").Return(&dc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2166,7 +2422,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2184,7 +2440,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success direct pending", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 
@@ -2198,7 +2454,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2223,7 +2479,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetDirectCharge", ctx, "id").Return(&dc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2231,7 +2487,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2249,7 +2505,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success recurrent declined", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		months := 1
@@ -2266,7 +2522,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2296,7 +2552,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetRecurrentCharge", ctx, "id").Return(&rc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2304,7 +2560,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2322,7 +2578,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success recurrent cancelled", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		months := 1
@@ -2339,7 +2595,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2369,7 +2625,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetRecurrentCharge", ctx, "id").Return(&rc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2377,7 +2633,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2395,7 +2651,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success recurrent active", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		months := 1
@@ -2412,7 +2668,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2442,7 +2698,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetRecurrentCharge", ctx, "id").Return(&rc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2450,7 +2706,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2468,7 +2724,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success recurrent accepted", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		months := 1
@@ -2485,7 +2741,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2515,7 +2771,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetRecurrentCharge", ctx, "id").Return(&rc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2523,7 +2779,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2541,7 +2797,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("success recurrent pending", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		months := 1
@@ -2558,7 +2814,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2588,7 +2844,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetRecurrentCharge", ctx, "id").Return(&rc, nil).Once()
 		sm.On("UpsertTopUp", ctx, topUp).Return(&topUp, nil).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2596,7 +2852,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("CreateEvent", ctx, levent).Return(nil).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2614,7 +2870,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("recurrent api error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
@@ -2633,7 +2889,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 		am.On("GetRecurrentCharge", ctx, "id").Return(nil, assert.AnError).Once()
 
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2641,7 +2897,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
@@ -2655,7 +2911,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("direct api error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 
@@ -2669,7 +2925,7 @@ func TestService_SyncTopUp(t *testing.T) {
 		}
 
 		am.On("GetDirectCharge", ctx, "id").Return(nil, assert.AnError).Once()
-		sc, _ := json.Marshal(topUp)
+		sc, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp})
 		levent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2677,7 +2933,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
@@ -2692,7 +2948,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 	t.Run("no direct api charge error", func(t *testing.T) {
 		lcoid := "lcOrganizationID"
-		amount := float32(5.23)
+		amount := Money(5230)
 		confUrl := "http://www.google.com/confirmation"
 
 		topUp := TopUp{
@@ -2704,7 +2960,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			ConfirmationUrl:  confUrl,
 		}
 
-		am.On("GetDirectCharge", ctx, "id").Return(nil, nil).Once()
+		am.On("GetDirectCharge", ctx, "id").Return(nil, livechat.ErrNotFound).Once()
 
 		sc, _ := json.Marshal(map[string]interface{}{"id": "id"})
 		levent := events.Event{
@@ -2714,10 +2970,10 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
-			Err:   fmt.Errorf("failed to get LC API direct top up by id: id"),
+			Err:   fmt.Errorf("failed to get LC API direct top up by id: id: %w", livechat.ErrNotFound),
 		}).Return(assert.AnError).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2729,7 +2985,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 	t.Run("no recurrent api charge error", func(t *testing.T) {
 		lcoid := "lcOrganizationID"
-		amount := float32(5.23)
+		amount := Money(5230)
 		confUrl := "http://www.google.com/confirmation"
 
 		topUp := TopUp{
@@ -2741,7 +2997,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			ConfirmationUrl:  confUrl,
 		}
 
-		am.On("GetRecurrentCharge", ctx, "id").Return(nil, nil).Once()
+		am.On("GetRecurrentCharge", ctx, "id").Return(nil, livechat.ErrNotFound).Once()
 
 		sc, _ := json.Marshal(map[string]interface{}{"id": "id"})
 		levent := events.Event{
@@ -2751,10 +3007,10 @@ func TestService_SyncTopUp(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc,
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
-			Err:   fmt.Errorf("failed to get LC API recurrent top up by id: id"),
+			Err:   fmt.Errorf("failed to get LC API recurrent top up by id: id: %w", livechat.ErrNotFound),
 		}).Return(assert.AnError).Once()
 
 		tp, err := s.SyncTopUp(context.Background(), topUp)
@@ -2765,7 +3021,7 @@ func TestService_SyncTopUp(t *testing.T) {
 	})
 
 	t.Run("upsert error", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 
@@ -2779,7 +3035,7 @@ func TestService_SyncTopUp(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2813,7 +3069,7 @@ func TestService_SyncTopUp(t *testing.T) {
 			Payload:          sc,
 			Error:            "assert.AnError general error for testing",
 		}
-		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp).Return(levent).Once()
+		em.On("ToEvent", ctx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(levent).Once()
 		em.On("ToError", context.Background(), events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
@@ -2829,7 +3085,7 @@ func TestService_SyncTopUp(t *testing.T) {
 
 func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 	t.Run("success recurrent and direct active", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		months := 1
@@ -2849,7 +3105,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2872,7 +3128,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2927,8 +3183,8 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 		am.On("GetDirectCharge", orgCtx, "id2").Return(&rc2, nil).Once()
 		sm.On("UpsertTopUp", orgCtx, topUp1).Return(&topUp1, nil).Once()
 		sm.On("UpsertTopUp", orgCtx, topUp2).Return(&topUp2, nil).Once()
-		sc1, _ := json.Marshal(topUp1)
-		sc2, _ := json.Marshal(topUp2)
+		sc1, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp1})
+		sc2, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp2})
 		levent1 := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -2936,7 +3192,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc1,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp1).Return(levent1).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp1}).Return(levent1).Once()
 		em.On("CreateEvent", orgCtx, levent1).Return(nil).Once()
 		levent2 := events.Event{
 			ID:               xid,
@@ -2945,18 +3201,25 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc2,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp2).Return(levent2).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp2}).Return(levent2).Once()
 		em.On("CreateEvent", orgCtx, levent2).Return(nil).Once()
+		recorder := metricstest.NewRecorder()
+		ms := s
+		ms.metrics = recorder
 
-		err := s.SyncOrCancelTopUpRequests(context.Background())
+		err := ms.SyncOrCancelTopUpRequests(context.Background())
 
 		assert.Nil(t, err)
+		picked, _ := recorder.Gauge(metrics.RunCharges, metrics.ActionSyncOrCancelTopUpRequests, "")
+		assert.Equal(t, float64(2), picked)
+		assert.Equal(t, 2, recorder.Counter(metrics.ChargesTotal, metrics.ActionSyncOrCancelTopUpRequests, metrics.StatusSuccess))
+		assert.Len(t, recorder.Observations(metrics.RunDurationSeconds, metrics.ActionSyncOrCancelTopUpRequests, metrics.StatusSuccess), 1)
 
 		assertExpectations(t)
 	})
 
 	t.Run("success direct without operations", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		orgCtx := context.WithValue(ctx, LedgerOrganizationIDCtxKey{}, lcoid)
@@ -2973,7 +3236,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -2990,6 +3253,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			ID:               "id2",
 			LCOrganizationID: lcoid,
 			Amount:           amount,
+			Currency:         CurrencyUSD,
 			Payload:          jrc2,
 		}
 
@@ -3033,9 +3297,9 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Payload:          sc,
 		}
 		em.On("CreateEvent", orgCtx, opEvent).Return(nil).Once()
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionCreateOperation, events.EventTypeInfo, operation).Return(opEvent).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionCreateOperation, events.EventTypeInfo, CreateOperationEventPayload{Operation: operation}).Return(opEvent).Once()
 
-		sct, _ := json.Marshal(topUp2)
+		sct, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp2})
 		topEvent := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -3044,7 +3308,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Payload:          sct,
 		}
 		em.On("CreateEvent", orgCtx, topEvent).Return(nil).Once()
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionTopUp, events.EventTypeInfo, topUp2).Return(topEvent).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp2}).Return(topEvent).Once()
 
 		err := s.SyncOrCancelTopUpRequests(context.Background())
 
@@ -3054,7 +3318,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 	})
 
 	t.Run("success recurrent and direct pending", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		months := 1
@@ -3074,7 +3338,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -3097,7 +3361,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -3156,8 +3420,8 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 		am.On("GetDirectCharge", orgCtx, "id2").Return(&rc2, nil).Once()
 		sm.On("UpsertTopUp", orgCtx, topUp1).Return(&topUp1, nil).Once()
 		sm.On("UpsertTopUp", orgCtx, topUp2).Return(&topUp2, nil).Once()
-		sc1, _ := json.Marshal(topUp1)
-		sc2, _ := json.Marshal(topUp2)
+		sc1, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp1})
+		sc2, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp2})
 		levent1 := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -3165,7 +3429,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc1,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp1).Return(levent1).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp1}).Return(levent1).Once()
 		em.On("CreateEvent", orgCtx, levent1).Return(nil).Once()
 		levent2 := events.Event{
 			ID:               xid,
@@ -3174,7 +3438,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc2,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp2).Return(levent2).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp2}).Return(levent2).Once()
 		em.On("CreateEvent", orgCtx, levent2).Return(nil).Once()
 
 		err := s.SyncOrCancelTopUpRequests(context.Background())
@@ -3185,7 +3449,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 	})
 
 	t.Run("force cancel all old pending", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		months := 1
@@ -3205,7 +3469,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -3228,7 +3492,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -3297,6 +3561,8 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 		sm.On("UpsertTopUp", orgCtx, topUp1).Return(&topUp1, nil).Once()
 		sm.On("UpsertTopUp", orgCtx, topUp11).Return(&topUp11, nil).Once()
 		sm.On("UpsertTopUp", orgCtx, topUp2).Return(&topUp2, nil).Once()
+		sm.On("GetTopUpByIDAndOrganizationID", orgCtx, topUp1.LCOrganizationID, "id1").Return(&topUp1, nil).Once()
+		sm.On("GetTopUpByIDAndOrganizationID", orgCtx, topUp2.LCOrganizationID, "id2").Return(&topUp2, nil).Once()
 		sm.On("UpdateTopUpStatus", orgCtx, UpdateTopUpStatusParams{
 			ID:     "id1",
 			Status: TopUpStatusCancelled,
@@ -3306,9 +3572,9 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Status: TopUpStatusCancelled,
 		}).Return(nil).Once()
 
-		sc1, _ := json.Marshal(topUp1)
-		sc1_1, _ := json.Marshal(topUp11)
-		sc2, _ := json.Marshal(topUp2)
+		sc1, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp1})
+		sc1_1, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp11})
+		sc2, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp2})
 		sc11, _ := json.Marshal(map[string]interface{}{"id": "id1", "status": TopUpStatusCancelled})
 		sc22, _ := json.Marshal(map[string]interface{}{"id": "id2", "status": TopUpStatusCancelled})
 
@@ -3319,7 +3585,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc1,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp1).Return(levent1).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp1}).Return(levent1).Once()
 		em.On("CreateEvent", orgCtx, levent1).Return(nil).Once()
 
 		levent1_1 := events.Event{
@@ -3329,7 +3595,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc1_1,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp11).Return(levent1_1).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp11}).Return(levent1_1).Once()
 		em.On("CreateEvent", orgCtx, levent1_1).Return(nil).Once()
 
 		levent2 := events.Event{
@@ -3339,7 +3605,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc2,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp2).Return(levent2).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp2}).Return(levent2).Once()
 		em.On("CreateEvent", orgCtx, levent2).Return(nil).Once()
 
 		levent11 := events.Event{
@@ -3349,7 +3615,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionForceCancelCharge,
 			Payload:          sc11,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, map[string]interface{}{"id": "id1", "status": TopUpStatusCancelled}).Return(levent11).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, ForceCancelTopUpEventPayload{TopUpID: "id1", Status: TopUpStatusCancelled}).Return(levent11).Once()
 		em.On("CreateEvent", orgCtx, levent11).Return(nil).Once()
 
 		levent22 := events.Event{
@@ -3359,18 +3625,20 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionForceCancelCharge,
 			Payload:          sc22,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, map[string]interface{}{"id": "id2", "status": TopUpStatusCancelled}).Return(levent22).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionForceCancelCharge, events.EventTypeInfo, ForceCancelTopUpEventPayload{TopUpID: "id2", Status: TopUpStatusCancelled}).Return(levent22).Once()
 		em.On("CreateEvent", orgCtx, levent22).Return(nil).Once()
 
 		err := s.SyncOrCancelTopUpRequests(context.Background())
 
 		assert.Nil(t, err)
+		// each top up is synced and cancelled under a single lock
+		assert.Equal(t, []string{"id2", "id1", "id11"}, sm.lockedTopUps)
 
 		assertExpectations(t)
 	})
 
 	t.Run("should not cancel old top ups", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		months := 1
@@ -3390,7 +3658,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -3413,7 +3681,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -3519,7 +3787,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 		am.On("GetDirectCharge", orgCtx, "id22").Return(&rc2, nil).Once()
 		am.On("GetDirectCharge", orgCtx, "id222").Return(&rc2, nil).Once()
 
-		sc1, _ := json.Marshal(topUp1)
+		sc1, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp1})
 		levent1 := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -3527,10 +3795,10 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc1,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp1).Return(levent1).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp1}).Return(levent1).Once()
 		em.On("CreateEvent", orgCtx, levent1).Return(nil).Once()
 
-		sc11, _ := json.Marshal(topUp11)
+		sc11, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp11})
 		levent11 := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -3538,10 +3806,10 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc11,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp11).Return(levent11).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp11}).Return(levent11).Once()
 		em.On("CreateEvent", orgCtx, levent11).Return(nil).Once()
 
-		sc111, _ := json.Marshal(topUp111)
+		sc111, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp111})
 		levent111 := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -3549,10 +3817,10 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc111,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp111).Return(levent111).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp111}).Return(levent111).Once()
 		em.On("CreateEvent", orgCtx, levent111).Return(nil).Once()
 
-		sc2, _ := json.Marshal(topUp2)
+		sc2, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp2})
 		levent2 := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -3560,10 +3828,10 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc2,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp2).Return(levent2).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp2}).Return(levent2).Once()
 		em.On("CreateEvent", orgCtx, levent2).Return(nil).Once()
 
-		sc22, _ := json.Marshal(topUp22)
+		sc22, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp22})
 		levent22 := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -3571,10 +3839,10 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc22,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp22).Return(levent22).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp22}).Return(levent22).Once()
 		em.On("CreateEvent", orgCtx, levent22).Return(nil).Once()
 
-		sc222, _ := json.Marshal(topUp222)
+		sc222, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: topUp222})
 		levent222 := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -3582,7 +3850,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Action:           events.EventActionSyncTopUp,
 			Payload:          sc222,
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp222).Return(levent222).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp222}).Return(levent222).Once()
 		em.On("CreateEvent", orgCtx, levent222).Return(nil).Once()
 
 		err := s.SyncOrCancelTopUpRequests(context.Background())
@@ -3593,7 +3861,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 	})
 
 	t.Run("success recurrent and error direct", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		orgCtx := context.WithValue(ctx, LedgerOrganizationIDCtxKey{}, lcoid)
@@ -3610,7 +3878,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -3653,7 +3921,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Payload:          sc2,
 			Error:            "assert.AnError general error for testing",
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, topUp2).Return(levent).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp2}).Return(levent).Once()
 		em.On("ToError", orgCtx, events.ToErrorParams{
 			Event: levent,
 			Err:   assert.AnError,
@@ -3667,7 +3935,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 	})
 
 	t.Run("activate accepted charges", func(t *testing.T) {
-		amount := float32(5.23)
+		amount := Money(5230)
 		lcoid := "lcOrganizationID"
 		confUrl := "http://www.google.com/confirmation"
 		orgCtx := context.WithValue(ctx, LedgerOrganizationIDCtxKey{}, lcoid)
@@ -3684,7 +3952,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -3715,7 +3983,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 				OrderLicenseID:    "123",
 				OrderEntityID:     "123",
 				Name:              "some",
-				Price:             int(amount * 100),
+				Price:             523,
 				ReturnURL:         "http://www.google.com",
 				Test:              false,
 				PerAccount:        false,
@@ -3751,7 +4019,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 		am.On("GetDirectCharge", orgCtx, "id2").Return(&dc, nil).Once()
 		sm.On("UpsertTopUp", orgCtx, dTopUp).Return(&dTopUp, nil).Once()
 
-		dp, _ := json.Marshal(dTopUp)
+		dp, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: dTopUp})
 		dEv := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -3760,10 +4028,10 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Payload:          dp,
 			Error:            "",
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, dTopUp).Return(dEv).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: dTopUp}).Return(dEv).Once()
 		em.On("CreateEvent", orgCtx, dEv).Return(nil).Once()
 
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionActivateCharge, events.EventTypeInfo, &dTopUp).Return(dEv).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionActivateCharge, events.EventTypeInfo, TopUpEventPayload{TopUp: dTopUp}).Return(dEv).Once()
 		em.On("CreateEvent", orgCtx, dEv).Return(nil).Once()
 
 		am.On("ActivateDirectCharge", orgCtx, "id2").Return(&dc, nil).Once()
@@ -3778,7 +4046,7 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 		am.On("GetRecurrentCharge", orgCtx, "id1").Return(&rc, nil).Once()
 		sm.On("UpsertTopUp", orgCtx, rTopUp).Return(&rTopUp, nil).Once()
 
-		rp, _ := json.Marshal(rTopUp)
+		rp, _ := json.Marshal(TopUpEventPayload{Version: 2, TopUp: rTopUp})
 		rEv := events.Event{
 			ID:               xid,
 			LCOrganizationID: lcoid,
@@ -3787,10 +4055,10 @@ func TestService_SyncOrCancelTopUpRequests(t *testing.T) {
 			Payload:          rp,
 			Error:            "",
 		}
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, rTopUp).Return(rEv).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: rTopUp}).Return(rEv).Once()
 		em.On("CreateEvent", orgCtx, rEv).Return(nil).Once()
 
-		em.On("ToEvent", orgCtx, lcoid, events.EventActionActivateCharge, events.EventTypeInfo, &rTopUp).Return(rEv).Once()
+		em.On("ToEvent", orgCtx, lcoid, events.EventActionActivateCharge, events.EventTypeInfo, TopUpEventPayload{TopUp: rTopUp}).Return(rEv).Once()
 		em.On("CreateEvent", orgCtx, rEv).Return(nil).Once()
 
 		am.On("ActivateRecurrentCharge", orgCtx, "id1").Return(&rc, nil).Once()
@@ -0,0 +1,42 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+func TestService_logging(t *testing.T) {
+	var buf bytes.Buffer
+	ms := s
+	ms.logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+	t.Run("failed call", func(t *testing.T) {
+		buf.Reset()
+		eventCtx := context.WithValue(ctx, LedgerEventIDCtxKey{}, "eid")
+		sm.On("GetBalance", eventCtx, "lcoid").Return(nil, assert.AnError).Once()
+
+		_, err := ms.GetBalance(eventCtx, "lcoid")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Contains(t, buf.String(), `level=ERROR msg="ledger.GetBalance failed" livechat.organization_id=lcoid livechat.event_id=eid error="failed to get balance: assert.AnError`)
+
+		assertExpectations(t)
+	})
+
+	t.Run("failed event write", func(t *testing.T) {
+		buf.Reset()
+		event := events.Event{ID: "eid", LCOrganizationID: "lcoid", Type: events.EventTypeInfo, Action: events.EventActionTopUp}
+
+		em.On("CreateEvent", ctx, event).Return(assert.AnError).Once()
+		ms.createEvent(ctx, event)
+
+		assert.Contains(t, buf.String(), `level=ERROR msg="failed to store ledger event" event.id=eid event.organization_id=lcoid event.type=info event.action=top_up`)
+
+		assertExpectations(t)
+	})
+}
@@ -0,0 +1,127 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMoney is returned for amounts that can't be represented exactly as Money.
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+const (
+	// moneyScale is the number of Money units in a unit of the currency, the scale of the numeric(9,3) amount columns.
+	moneyScale  = 1000
+	moneyDigits = 3
+)
+
+// Money is an exact amount in thousandths of a currency unit, e.g. Money(5230) is 5.23. It is encoded in JSON as a
+// decimal number, the same as the float amounts it replaces.
+type Money int64
+
+// NewMoneyFromCents returns the amount of a LiveChat charge price, which is in cents.
+func NewMoneyFromCents(cents int) Money {
+	return Money(cents) * (moneyScale / 100)
+}
+
+// ParseMoney parses a decimal amount, e.g. "5.23" or "-10". Amounts with more than 3 decimal places are rejected
+// rather than rounded.
+func ParseMoney(s string) (Money, error) {
+	v, ok := parseDecimal(s, moneyDigits)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	return Money(v), nil
+}
+
+// Cents returns the amount as a LiveChat charge price. Amounts with fractions of a cent can't be charged exactly
+// and return ErrInvalidMoney.
+func (m Money) Cents() (int, error) {
+	if m%(moneyScale/100) != 0 {
+		return 0, fmt.Errorf("%w: %s is not a whole number of cents", ErrInvalidMoney, m)
+	}
+
+	return int(m / (moneyScale / 100)), nil
+}
+
+// String returns the amount as a decimal without trailing zeros, e.g. "5.23".
+func (m Money) String() string {
+	return formatDecimal(int64(m), moneyDigits)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	v, err := ParseMoney(string(data))
+	if err != nil {
+		return err
+	}
+	*m = v
+
+	return nil
+}
+
+// parseDecimal parses a decimal with up to digits decimal places into an integer of 10^-digits units.
+func parseDecimal(s string, digits int) (int64, bool) {
+	unsigned := strings.TrimPrefix(s, "-")
+	negative := len(unsigned) < len(s)
+
+	whole, fraction, _ := strings.Cut(unsigned, ".")
+	if whole == "" || len(fraction) > digits || !isDigits(whole) || !isDigits(fraction) {
+		return 0, false
+	}
+
+	scale := int64(math.Pow10(digits))
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units >= math.MaxInt64/scale {
+		return 0, false
+	}
+	var fractionUnits int64
+	if fraction != "" {
+		fractionUnits, _ = strconv.ParseInt(fraction+strings.Repeat("0", digits-len(fraction)), 10, 64)
+	}
+
+	v := units*scale + fractionUnits
+	if negative {
+		v = -v
+	}
+
+	return v, true
+}
+
+// formatDecimal formats an integer of 10^-digits units as a decimal without trailing zeros.
+func formatDecimal(v int64, digits int) string {
+	sign := ""
+	u := uint64(v)
+	if v < 0 {
+		sign = "-"
+		u = -u
+	}
+
+	scale := uint64(math.Pow10(digits))
+	s := sign + strconv.FormatUint(u/scale, 10)
+	if fraction := u % scale; fraction != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%0*d", digits, fraction), "0")
+	}
+
+	return s
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,89 @@
+package ledger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	for s, expected := range map[string]Money{
+		"5.23":   5230,
+		"-5.23":  -5230,
+		"10":     10000,
+		"0.001":  1,
+		"0.1":    100,
+		"-0.005": -5,
+	} {
+		m, err := ParseMoney(s)
+
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, m, s)
+	}
+
+	for _, s := range []string{"", "-", ".5", "5.2345", "1e3", "5,23", "abc", "99999999999999999999"} {
+		_, err := ParseMoney(s)
+
+		assert.ErrorIs(t, err, ErrInvalidMoney, s)
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	for m, expected := range map[Money]string{
+		5230:  "5.23",
+		-5230: "-5.23",
+		10000: "10",
+		1:     "0.001",
+		-5:    "-0.005",
+		0:     "0",
+	} {
+		assert.Equal(t, expected, m.String())
+	}
+}
+
+func TestMoney_Cents(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cents, err := Money(5230).Cents()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 523, cents)
+		assert.Equal(t, Money(5230), NewMoneyFromCents(cents))
+	})
+
+	t.Run("fraction of a cent", func(t *testing.T) {
+		_, err := Money(5234).Cents()
+
+		assert.ErrorIs(t, err, ErrInvalidMoney)
+	})
+}
+
+func TestMoney_JSON(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		jo, err := json.Marshal(Operation{ID: "id", Amount: -5230})
+		assert.NoError(t, err)
+		assert.Contains(t, string(jo), `"amount":-5.23`)
+
+		var o Operation
+		assert.NoError(t, json.Unmarshal(jo, &o))
+		assert.Equal(t, Money(-5230), o.Amount)
+	})
+
+	t.Run("sums without drift", func(t *testing.T) {
+		var total Money
+		for i := 0; i < 10; i++ {
+			var o Operation
+			assert.NoError(t, json.Unmarshal([]byte(`{"amount":0.1}`), &o))
+			total += o.Amount
+		}
+
+		assert.Equal(t, Money(1000), total)
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		var o Operation
+		err := json.Unmarshal([]byte(`{"amount":0.0001}`), &o)
+
+		assert.ErrorIs(t, err, ErrInvalidMoney)
+	})
+}
@@ -8,8 +8,11 @@ import (
 type Operation struct {
 	ID               string          `json:"id"`
 	LCOrganizationID string          `json:"lc_organization_id"`
-	Amount           float32         `json:"amount"`
+	Amount           Money           `json:"amount"`
+	Currency         Currency        `json:"currency"`
 	Payload          json.RawMessage `json:"payload"`
 	IsVoucher        bool            `json:"is_voucher"`
 	CreatedAt        time.Time       `json:"created_at"`
+	// Entries are posted with the operation in double-entry mode, see WithDoubleEntry.
+	Entries []Entry `json:"-"`
 }
@@ -0,0 +1,210 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+)
+
+// DriftKind classifies a difference between stored top ups and the LiveChat Billing API.
+type DriftKind string
+
+const (
+	DriftKindStatusMismatch DriftKind = "status_mismatch"
+	DriftKindAmountMismatch DriftKind = "amount_mismatch"
+	// DriftKindOrphanTopUp is a stored top up LiveChat does not know (404/422).
+	DriftKindOrphanTopUp DriftKind = "orphan_top_up"
+	// DriftKindMissingOperation is a successful direct top up that never reached the ledger.
+	DriftKindMissingOperation DriftKind = "missing_operation"
+)
+
+// DriftFix is the action ReconcileOptions.AutoFix takes for a drift.
+type DriftFix string
+
+const (
+	DriftFixSyncTopUp       DriftFix = "sync_top_up"
+	DriftFixCreateOperation DriftFix = "create_operation"
+)
+
+type Drift struct {
+	Kind             DriftKind `json:"kind"`
+	LCOrganizationID string    `json:"lc_organization_id"`
+	TopUpID          string    `json:"top_up_id"`
+	// Local and Remote hold the stored and the LiveChat value for mismatches.
+	Local  string `json:"local,omitempty"`
+	Remote string `json:"remote,omitempty"`
+	// Fix is empty when the drift needs a human.
+	Fix      DriftFix `json:"fix,omitempty"`
+	Fixed    bool     `json:"fixed"`
+	FixError string   `json:"fix_error,omitempty"`
+}
+
+type ReconcileOptions struct {
+	AutoFix bool `json:"auto_fix"`
+}
+
+type ReconciliationReport struct {
+	CheckedTopUps int     `json:"checked_top_ups"`
+	Drifts        []Drift `json:"drifts"`
+	// Errors lists top ups that could not be checked against LiveChat.
+	Errors []string `json:"errors"`
+}
+
+// Reconcile compares every stored top up with the LiveChat Billing API and reports the drift.
+// With AutoFix it also applies the fix of every drift that has one.
+func (s *Service) Reconcile(ctx context.Context, opts ReconcileOptions) (_ *ReconciliationReport, err error) {
+	ctx, end := s.start(ctx, "Reconcile")
+	defer func() { end(err) }()
+
+	event := s.eventService.ToEvent(ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{AutoFix: opts.AutoFix})
+
+	topUps, err := s.storage.GetTopUps(ctx)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return nil, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to get top ups: %w", err),
+		})
+	}
+
+	withoutOperations, err := s.storage.GetDirectTopUpsWithoutOperations(ctx)
+	if err != nil {
+		event.Type = events.EventTypeError
+		return nil, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to get top ups without operations: %w", err),
+		})
+	}
+
+	report := &ReconciliationReport{
+		CheckedTopUps: len(topUps),
+		Drifts:        []Drift{},
+		Errors:        []string{},
+	}
+	byID := make(map[string]TopUp, len(topUps))
+
+	for _, topUp := range topUps {
+		byID[topUp.ID] = topUp
+		drifts, err := s.reconcileTopUp(ctx, topUp)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("top up %s: %v", topUp.ID, err))
+			if errors.Is(err, ErrBillingAPIUnavailable) {
+				break
+			}
+			continue
+		}
+		report.Drifts = append(report.Drifts, drifts...)
+	}
+
+	for _, topUp := range withoutOperations {
+		byID[topUp.ID] = topUp
+		report.Drifts = append(report.Drifts, Drift{
+			Kind:             DriftKindMissingOperation,
+			LCOrganizationID: topUp.LCOrganizationID,
+			TopUpID:          topUp.ID,
+			Fix:              DriftFixCreateOperation,
+		})
+	}
+
+	if opts.AutoFix {
+		s.fixDrifts(ctx, report.Drifts, byID)
+	}
+
+	event.SetPayload(ReconcileEventPayload{AutoFix: opts.AutoFix, Report: report})
+	if len(report.Errors) > 0 {
+		event.Type = events.EventTypeError
+		return report, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to check %d top ups", len(report.Errors)),
+		})
+	}
+
+	s.createEvent(ctx, event)
+
+	return report, nil
+}
+
+func (s *Service) reconcileTopUp(ctx context.Context, topUp TopUp) ([]Drift, error) {
+	drift := Drift{LCOrganizationID: topUp.LCOrganizationID, TopUpID: topUp.ID}
+
+	var baseCharge *livechat.BaseCharge
+	var err error
+	switch topUp.Type {
+	case TopUpTypeDirect:
+		var c *livechat.DirectCharge
+		if c, err = s.billingAPI.GetDirectCharge(ctx, topUp.ID); c != nil {
+			baseCharge = &c.BaseCharge
+		}
+	case TopUpTypeRecurrent:
+		var c *livechat.RecurrentCharge
+		if c, err = s.billingAPI.GetRecurrentCharge(ctx, topUp.ID); c != nil {
+			baseCharge = &c.BaseCharge
+		}
+	default:
+		return nil, fmt.Errorf("unknown top up type %q", topUp.Type)
+	}
+	// 404 and 422 mean LiveChat does not know the charge, anything else is a failed check.
+	if err != nil && !errors.Is(err, livechat.ErrNotFound) && !errors.Is(err, livechat.ErrUnprocessableEntity) {
+		return nil, err
+	}
+	if baseCharge == nil {
+		drift.Kind = DriftKindOrphanTopUp
+		return []Drift{drift}, nil
+	}
+
+	var drifts []Drift
+	if status, ok := topUpStatus(topUp.Type, baseCharge.Status); ok && status != topUp.Status {
+		d := drift
+		d.Kind, d.Local, d.Remote, d.Fix = DriftKindStatusMismatch, string(topUp.Status), string(status), DriftFixSyncTopUp
+		drifts = append(drifts, d)
+	}
+	if amount := NewMoneyFromCents(baseCharge.Price); baseCharge.Price > 0 && amount != topUp.Amount {
+		d := drift
+		d.Kind, d.Local, d.Remote, d.Fix = DriftKindAmountMismatch, topUp.Amount.String(), amount.String(), DriftFixSyncTopUp
+		drifts = append(drifts, d)
+	}
+
+	return drifts, nil
+}
+
+// fixDrifts applies every fix once, a top up with both a status and an amount mismatch is synced a single time.
+func (s *Service) fixDrifts(ctx context.Context, drifts []Drift, topUps map[string]TopUp) {
+	results := make(map[string]error)
+	for i := range drifts {
+		d := &drifts[i]
+		if d.Fix == "" {
+			continue
+		}
+
+		key := string(d.Fix) + ":" + d.TopUpID
+		err, done := results[key]
+		if !done {
+			err = s.fixDrift(ctx, *d, topUps[d.TopUpID])
+			results[key] = err
+		}
+
+		d.Fixed = err == nil
+		if err != nil {
+			d.FixError = err.Error()
+		}
+	}
+}
+
+func (s *Service) fixDrift(ctx context.Context, drift Drift, topUp TopUp) error {
+	organizationCtx := context.WithValue(ctx, LedgerOrganizationIDCtxKey{}, drift.LCOrganizationID)
+	organizationCtx = context.WithValue(organizationCtx, LedgerEventIDCtxKey{}, s.idProvider.GenerateId())
+
+	switch drift.Fix {
+	case DriftFixSyncTopUp:
+		_, err := s.SyncTopUp(organizationCtx, topUp)
+		return err
+	case DriftFixCreateOperation:
+		_, err := s.TopUp(organizationCtx, topUp)
+		return err
+	default:
+		return fmt.Errorf("unknown fix %q", drift.Fix)
+	}
+}
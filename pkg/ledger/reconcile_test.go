@@ -0,0 +1,116 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+)
+
+func TestService_Reconcile(t *testing.T) {
+	direct := func(status livechat.ChargeStatus, price int) *livechat.DirectCharge {
+		return &livechat.DirectCharge{BaseCharge: livechat.BaseCharge{Status: status, Price: price}}
+	}
+	recurrent := func(status livechat.ChargeStatus, price int) *livechat.RecurrentCharge {
+		return &livechat.RecurrentCharge{BaseCharge: livechat.BaseCharge{Status: status, Price: price}}
+	}
+
+	t.Run("reports drift", func(t *testing.T) {
+		em.On("ToEvent", ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{}).Return(events.Event{}).Once()
+		sm.On("GetTopUps", ctx).Return([]TopUp{
+			{ID: "in-sync", LCOrganizationID: "lcOrganizationID", Type: TopUpTypeDirect, Status: TopUpStatusSuccess, Amount: 1500},
+			{ID: "drifted", LCOrganizationID: "lcOrganizationID", Type: TopUpTypeRecurrent, Status: TopUpStatusActive, Amount: 1500},
+			{ID: "orphan", LCOrganizationID: "lcOrganizationID", Type: TopUpTypeDirect, Status: TopUpStatusPending, Amount: 1500},
+		}, nil).Once()
+		sm.On("GetDirectTopUpsWithoutOperations", ctx).Return([]TopUp{
+			{ID: "in-sync", LCOrganizationID: "lcOrganizationID", Type: TopUpTypeDirect, Status: TopUpStatusSuccess, Amount: 1500},
+		}, nil).Once()
+		am.On("GetDirectCharge", ctx, "in-sync").Return(direct(livechat.DirectChargeStatusSuccess, 150), nil).Once()
+		am.On("GetRecurrentCharge", ctx, "drifted").Return(recurrent(livechat.RecurrentChargeStatusCancelled, 250), nil).Once()
+		am.On("GetDirectCharge", ctx, "orphan").Return(nil, livechat.ErrNotFound).Once()
+		em.On("CreateEvent", ctx, mock.Anything).Return(nil).Once()
+
+		report, err := s.Reconcile(ctx, ReconcileOptions{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &ReconciliationReport{
+			CheckedTopUps: 3,
+			Drifts: []Drift{
+				{Kind: DriftKindStatusMismatch, LCOrganizationID: "lcOrganizationID", TopUpID: "drifted", Local: "active", Remote: "cancelled", Fix: DriftFixSyncTopUp},
+				{Kind: DriftKindAmountMismatch, LCOrganizationID: "lcOrganizationID", TopUpID: "drifted", Local: "1.5", Remote: "2.5", Fix: DriftFixSyncTopUp},
+				{Kind: DriftKindOrphanTopUp, LCOrganizationID: "lcOrganizationID", TopUpID: "orphan"},
+				{Kind: DriftKindMissingOperation, LCOrganizationID: "lcOrganizationID", TopUpID: "in-sync", Fix: DriftFixCreateOperation},
+			},
+			Errors: []string{},
+		}, report)
+		assertExpectations(t)
+	})
+
+	t.Run("auto fix", func(t *testing.T) {
+		topUp := TopUp{ID: "drifted", LCOrganizationID: "lcOrganizationID", Type: TopUpTypeDirect, Status: TopUpStatusPending, Amount: 1500}
+		synced := topUp
+		synced.Status = TopUpStatusFailed
+		orgCtx := context.WithValue(ctx, LedgerOrganizationIDCtxKey{}, "lcOrganizationID")
+		orgCtx = context.WithValue(orgCtx, LedgerEventIDCtxKey{}, "xid")
+		em.On("ToEvent", ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{AutoFix: true}).Return(events.Event{}).Once()
+		sm.On("GetTopUps", ctx).Return([]TopUp{topUp}, nil).Once()
+		sm.On("GetDirectTopUpsWithoutOperations", ctx).Return([]TopUp{}, nil).Once()
+		am.On("GetDirectCharge", ctx, "drifted").Return(direct(livechat.DirectChargeStatusFailed, 150), nil).Once()
+
+		xm.On("GenerateId").Return("xid").Once()
+		em.On("ToEvent", orgCtx, "lcOrganizationID", events.EventActionSyncTopUp, events.EventTypeInfo, TopUpEventPayload{TopUp: topUp}).Return(events.Event{}).Once()
+		am.On("GetDirectCharge", orgCtx, "drifted").Return(direct(livechat.DirectChargeStatusFailed, 150), nil).Once()
+		sm.On("UpsertTopUp", orgCtx, mock.MatchedBy(func(tu TopUp) bool {
+			return tu.ID == "drifted" && tu.Status == TopUpStatusFailed
+		})).Return(&synced, nil).Once()
+		em.On("CreateEvent", orgCtx, mock.Anything).Return(nil).Once()
+		em.On("CreateEvent", ctx, mock.Anything).Return(nil).Once()
+
+		report, err := s.Reconcile(ctx, ReconcileOptions{AutoFix: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Drift{
+			{Kind: DriftKindStatusMismatch, LCOrganizationID: "lcOrganizationID", TopUpID: "drifted", Local: "pending", Remote: "failed", Fix: DriftFixSyncTopUp, Fixed: true},
+		}, report.Drifts)
+		assert.Equal(t, []string{"drifted"}, sm.lockedTopUps)
+		assertExpectations(t)
+	})
+
+	t.Run("top up could not be checked", func(t *testing.T) {
+		em.On("ToEvent", ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{}).Return(events.Event{}).Once()
+		sm.On("GetTopUps", ctx).Return([]TopUp{{ID: "id", Type: TopUpTypeRecurrent}}, nil).Once()
+		sm.On("GetDirectTopUpsWithoutOperations", ctx).Return([]TopUp{}, nil).Once()
+		am.On("GetRecurrentCharge", ctx, "id").Return(nil, assert.AnError).Once()
+		em.On("ToError", ctx, mock.MatchedBy(func(params events.ToErrorParams) bool {
+			return params.Event.Type == events.EventTypeError && params.Err.Error() == "failed to check 1 top ups"
+		})).Return(assert.AnError).Once()
+
+		report, err := s.Reconcile(ctx, ReconcileOptions{})
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, []string{fmt.Sprintf("top up id: %v", assert.AnError)}, report.Errors)
+		assertExpectations(t)
+	})
+
+	t.Run("error getting top ups", func(t *testing.T) {
+		event := events.Event{}
+		em.On("ToEvent", ctx, "", events.EventActionReconcile, events.EventTypeInfo, ReconcileEventPayload{}).Return(event).Once()
+		sm.On("GetTopUps", ctx).Return(nil, assert.AnError).Once()
+		event.Type = events.EventTypeError
+		em.On("ToError", ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("failed to get top ups: %w", assert.AnError),
+		}).Return(assert.AnError).Once()
+
+		report, err := s.Reconcile(ctx, ReconcileOptions{})
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, report)
+		assertExpectations(t)
+	})
+}
@@ -38,11 +38,33 @@ type GetLedgerOperationParams struct {
 	OrganizationID string
 }
 
+type CreateConversionParams struct {
+	Conversion Conversion
+	// Operations are stored in the same transaction as the conversion.
+	Operations []Operation
+}
+
 type Storage interface {
+	// CreateLedgerOperation stores the operation and adds its amount to the materialized balance of the organization
+	// in the same transaction.
 	CreateLedgerOperation(ctx context.Context, c Operation) error
 	GetLedgerOperations(ctx context.Context, organizationID string, isVoucher bool) ([]Operation, error)
 	GetLedgerOperation(ctx context.Context, params GetLedgerOperationParams) (*Operation, error)
-	GetBalance(ctx context.Context, organizationID string) (float32, error)
+	// GetBalance returns the materialized balances of the organization.
+	GetBalance(ctx context.Context, organizationID string) (Balances, error)
+	// CreateBalanceSnapshots stores a snapshot of every materialized balance, deletes all but the latest keep
+	// snapshots of every organization and currency and returns the number of balances.
+	CreateBalanceSnapshots(ctx context.Context, keep int) (int64, error)
+	// GetBalanceDrifts returns the balances of the latest snapshot that differ from the sum of the operations.
+	GetBalanceDrifts(ctx context.Context) ([]BalanceDrift, error)
+	// RepairBalance sets the materialized balance of the organization and currency to the sum of its operations.
+	RepairBalance(ctx context.Context, organizationID string, currency Currency) (*BalanceRepair, error)
+	CreateConversion(ctx context.Context, params CreateConversionParams) error
+	// GetTrialBalance returns the balances of the accounts of the organization, or of every organization for an
+	// empty organizationID.
+	GetTrialBalance(ctx context.Context, organizationID string) (TrialBalance, error)
+	// GetTopUps returns every stored top up.
+	GetTopUps(ctx context.Context) ([]TopUp, error)
 	GetTopUpsByOrganizationID(ctx context.Context, organizationID string) ([]TopUp, error)
 	GetTopUpByIDAndOrganizationID(ctx context.Context, organizationID string, id string) (*TopUp, error)
 	GetTopUpsByTypeWhereStatusNotIn(ctx context.Context, params GetTopUpsByTypeWhereStatusNotInParams) ([]TopUp, error)
@@ -53,4 +75,9 @@ type Storage interface {
 	CreateEvent(ctx context.Context, event events.Event) error
 	GetTopUpsByOrganizationIDAndStatus(ctx context.Context, organizationID string, status TopUpStatus) ([]TopUp, error)
 	UpsertTopUp(ctx context.Context, topUp TopUp) (*TopUp, error)
+	// WithTopUpLock runs fn while holding an exclusive lock on the top up, so a webhook and a sync job
+	// cannot interleave reading and rewriting its status. Nested calls for the same top up reuse the held lock.
+	// The calls fn makes on the storage with its ctx run on the connection holding the lock, they must not be
+	// made concurrently. Implementations may bound how long the lock is held with a deadline on fn's ctx.
+	WithTopUpLock(ctx context.Context, id string, fn func(ctx context.Context) error) error
 }
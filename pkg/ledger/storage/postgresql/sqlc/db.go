@@ -3,11 +3,17 @@
 package sqlc
 
 import (
+	"fmt"
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/ledger"
 )
 
 func (o *LedgerLedger) ToLedgerOperation() (*ledger.Operation, error) {
-	v, err := o.Amount.Float64Value()
+	amount, err := ToLedgerMoney(o.Amount)
 	if err != nil {
 		return nil, err
 	}
@@ -15,7 +21,8 @@ func (o *LedgerLedger) ToLedgerOperation() (*ledger.Operation, error) {
 	return &ledger.Operation{
 		ID:               o.ID,
 		LCOrganizationID: o.LcOrganizationID,
-		Amount:           float32(v.Float64),
+		Amount:           amount,
+		Currency:         ledger.Currency(o.Currency),
 		Payload:          o.Payload,
 		IsVoucher:        o.IsVoucher,
 		CreatedAt:        o.CreatedAt.Time,
@@ -23,7 +30,7 @@ func (o *LedgerLedger) ToLedgerOperation() (*ledger.Operation, error) {
 }
 
 func (t *LedgerTopUp) ToLedgerTopUp() (*ledger.TopUp, error) {
-	v, err := t.Amount.Float64Value()
+	amount, err := ToLedgerMoney(t.Amount)
 	if err != nil {
 		return nil, err
 	}
@@ -32,7 +39,8 @@ func (t *LedgerTopUp) ToLedgerTopUp() (*ledger.TopUp, error) {
 		ID:               t.ID,
 		LCOrganizationID: t.LcOrganizationID,
 		Status:           ledger.TopUpStatus(t.Status),
-		Amount:           float32(v.Float64),
+		Amount:           amount,
+		Currency:         ledger.Currency(t.Currency),
 		Type:             ledger.TopUpType(t.Type),
 		ConfirmationUrl:  t.ConfirmationUrl,
 		LCCharge:         t.LcCharge,
@@ -49,3 +57,45 @@ func (t *LedgerTopUp) ToLedgerTopUp() (*ledger.TopUp, error) {
 
 	return tu, nil
 }
+
+func (e *LedgerEvent) ToEvent() *events.Event {
+	return &events.Event{
+		ID:               e.ID,
+		CorrelationID:    e.CorrelationID,
+		ParentID:         e.ParentID,
+		LCOrganizationID: e.LcOrganizationID,
+		Type:             events.EventType(e.Type),
+		Action:           events.EventAction(e.Action),
+		Payload:          e.Payload,
+		Error:            e.Error.String,
+		TraceID:          e.TraceID,
+		CreatedAt:        e.CreatedAt.Time,
+	}
+}
+
+// ToLedgerMoney converts a numeric amount without going through floats, NULL is zero.
+func ToLedgerMoney(n pgtype.Numeric) (ledger.Money, error) {
+	if !n.Valid || n.Int == nil {
+		return 0, nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return 0, fmt.Errorf("%w: not a finite number", ledger.ErrInvalidMoney)
+	}
+
+	// the money scale is 3 decimal places, numeric values are Int * 10^Exp
+	v := new(big.Int).Set(n.Int)
+	if exp := n.Exp + 3; exp >= 0 {
+		v.Mul(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+	} else {
+		var rem big.Int
+		v.QuoRem(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-exp)), nil), &rem)
+		if rem.Sign() != 0 {
+			return 0, fmt.Errorf("%w: more than 3 decimal places", ledger.ErrInvalidMoney)
+		}
+	}
+	if !v.IsInt64() {
+		return 0, fmt.Errorf("%w: out of range", ledger.ErrInvalidMoney)
+	}
+
+	return ledger.Money(v.Int64()), nil
+}
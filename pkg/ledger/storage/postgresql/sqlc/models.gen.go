@@ -8,6 +8,43 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type LedgerBalance struct {
+	LcOrganizationID string
+	Currency         string
+	Amount           pgtype.Numeric
+	UpdatedAt        pgtype.Timestamptz
+}
+
+type LedgerBalanceSnapshot struct {
+	ID               int64
+	LcOrganizationID string
+	Currency         string
+	Balance          pgtype.Numeric
+	OperationsSum    pgtype.Numeric
+	CreatedAt        pgtype.Timestamptz
+}
+
+type LedgerConversion struct {
+	ID               string
+	LcOrganizationID string
+	FromCurrency     string
+	FromAmount       pgtype.Numeric
+	ToCurrency       string
+	ToAmount         pgtype.Numeric
+	Rate             pgtype.Numeric
+	CreatedAt        pgtype.Timestamptz
+}
+
+type LedgerEntry struct {
+	ID               int64
+	OperationID      string
+	LcOrganizationID string
+	Account          string
+	Currency         string
+	Amount           pgtype.Numeric
+	CreatedAt        pgtype.Timestamptz
+}
+
 type LedgerEvent struct {
 	ID               string
 	LcOrganizationID string
@@ -16,6 +53,9 @@ type LedgerEvent struct {
 	Payload          []byte
 	Error            pgtype.Text
 	CreatedAt        pgtype.Timestamptz
+	TraceID          string
+	CorrelationID    string
+	ParentID         string
 }
 
 type LedgerLedger struct {
@@ -25,6 +65,7 @@ type LedgerLedger struct {
 	Payload          []byte
 	IsVoucher        bool
 	CreatedAt        pgtype.Timestamptz
+	Currency         string
 }
 
 type LedgerTopUp struct {
@@ -39,4 +80,5 @@ type LedgerTopUp struct {
 	NextTopUpAt       pgtype.Timestamptz
 	CreatedAt         pgtype.Timestamptz
 	UpdatedAt         pgtype.Timestamptz
+	Currency          string
 }
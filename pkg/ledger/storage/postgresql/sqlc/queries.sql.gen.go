@@ -11,9 +11,70 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addToOrganizationBalance = `-- name: AddToOrganizationBalance :exec
+INSERT INTO ledger_balances(lc_organization_id, currency, amount, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (lc_organization_id, currency) DO UPDATE SET amount = ledger_balances.amount + EXCLUDED.amount, updated_at = NOW()
+`
+
+type AddToOrganizationBalanceParams struct {
+	LcOrganizationID string
+	Currency         string
+	Amount           pgtype.Numeric
+}
+
+func (q *Queries) AddToOrganizationBalance(ctx context.Context, arg AddToOrganizationBalanceParams) error {
+	_, err := q.db.Exec(ctx, addToOrganizationBalance, arg.LcOrganizationID, arg.Currency, arg.Amount)
+	return err
+}
+
+const createBalanceSnapshots = `-- name: CreateBalanceSnapshots :execrows
+INSERT INTO ledger_balance_snapshots(lc_organization_id, currency, balance, operations_sum, created_at)
+SELECT COALESCE(b.lc_organization_id, o.lc_organization_id), COALESCE(b.currency, o.currency), COALESCE(b.amount, 0), COALESCE(o.amount, 0), NOW()
+FROM ledger_balances b
+FULL JOIN (SELECT lc_organization_id, currency, SUM(amount) AS amount FROM ledger_ledger GROUP BY lc_organization_id, currency) o
+ON o.lc_organization_id = b.lc_organization_id AND o.currency = b.currency
+`
+
+func (q *Queries) CreateBalanceSnapshots(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, createBalanceSnapshots)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createConversion = `-- name: CreateConversion :exec
+INSERT INTO ledger_conversions(id, lc_organization_id, from_currency, from_amount, to_currency, to_amount, rate, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+`
+
+type CreateConversionParams struct {
+	ID               string
+	LcOrganizationID string
+	FromCurrency     string
+	FromAmount       pgtype.Numeric
+	ToCurrency       string
+	ToAmount         pgtype.Numeric
+	Rate             pgtype.Numeric
+}
+
+func (q *Queries) CreateConversion(ctx context.Context, arg CreateConversionParams) error {
+	_, err := q.db.Exec(ctx, createConversion,
+		arg.ID,
+		arg.LcOrganizationID,
+		arg.FromCurrency,
+		arg.FromAmount,
+		arg.ToCurrency,
+		arg.ToAmount,
+		arg.Rate,
+	)
+	return err
+}
+
 const createEvent = `-- name: CreateEvent :exec
-INSERT INTO ledger_events(id, lc_organization_id, type, action, payload, error, created_at)
-VALUES ($1, $2, $3, $4, $5, $6, NOW())
+INSERT INTO ledger_events(id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
 `
 
 type CreateEventParams struct {
@@ -23,6 +84,9 @@ type CreateEventParams struct {
 	Action           string
 	Payload          []byte
 	Error            pgtype.Text
+	TraceID          string
+	CorrelationID    string
+	ParentID         string
 }
 
 func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) error {
@@ -33,21 +97,49 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) error
 		arg.Action,
 		arg.Payload,
 		arg.Error,
+		arg.TraceID,
+		arg.CorrelationID,
+		arg.ParentID,
 	)
 	return err
 }
 
-const createLedgerOperation = `-- name: CreateLedgerOperation :exec
-INSERT INTO ledger_ledger(id, amount, lc_organization_id, payload, is_voucher, created_at)
+const createLedgerEntry = `-- name: CreateLedgerEntry :exec
+INSERT INTO ledger_entries(operation_id, lc_organization_id, account, currency, amount, created_at)
 VALUES ($1, $2, $3, $4, $5, NOW())
 `
 
+type CreateLedgerEntryParams struct {
+	OperationID      string
+	LcOrganizationID string
+	Account          string
+	Currency         string
+	Amount           pgtype.Numeric
+}
+
+func (q *Queries) CreateLedgerEntry(ctx context.Context, arg CreateLedgerEntryParams) error {
+	_, err := q.db.Exec(ctx, createLedgerEntry,
+		arg.OperationID,
+		arg.LcOrganizationID,
+		arg.Account,
+		arg.Currency,
+		arg.Amount,
+	)
+	return err
+}
+
+const createLedgerOperation = `-- name: CreateLedgerOperation :exec
+INSERT INTO ledger_ledger(id, amount, lc_organization_id, payload, is_voucher, currency, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, NOW())
+`
+
 type CreateLedgerOperationParams struct {
 	ID               string
 	Amount           pgtype.Numeric
 	LcOrganizationID string
 	Payload          []byte
 	IsVoucher        bool
+	Currency         string
 }
 
 func (q *Queries) CreateLedgerOperation(ctx context.Context, arg CreateLedgerOperationParams) error {
@@ -57,12 +149,92 @@ func (q *Queries) CreateLedgerOperation(ctx context.Context, arg CreateLedgerOpe
 		arg.LcOrganizationID,
 		arg.Payload,
 		arg.IsVoucher,
+		arg.Currency,
 	)
 	return err
 }
 
+const deleteEvents = `-- name: DeleteEvents :execrows
+DELETE FROM ledger_events
+WHERE (id, action) IN (SELECT unnest($1::text[]), unnest($2::text[]))
+`
+
+type DeleteEventsParams struct {
+	Ids     []string
+	Actions []string
+}
+
+func (q *Queries) DeleteEvents(ctx context.Context, arg DeleteEventsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteEvents, arg.Ids, arg.Actions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredBalanceSnapshots = `-- name: DeleteExpiredBalanceSnapshots :execrows
+DELETE FROM ledger_balance_snapshots
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY lc_organization_id, currency ORDER BY created_at DESC, id DESC) AS n
+        FROM ledger_balance_snapshots
+    ) s
+    WHERE s.n > $1::int
+)
+`
+
+func (q *Queries) DeleteExpiredBalanceSnapshots(ctx context.Context, keep int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredBalanceSnapshots, keep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getBalanceDrifts = `-- name: GetBalanceDrifts :many
+SELECT lc_organization_id, currency, balance, operations_sum, created_at
+FROM ledger_balance_snapshots
+WHERE created_at = (SELECT MAX(created_at) FROM ledger_balance_snapshots)
+  AND balance <> operations_sum
+ORDER BY lc_organization_id, currency
+`
+
+type GetBalanceDriftsRow struct {
+	LcOrganizationID string
+	Currency         string
+	Balance          pgtype.Numeric
+	OperationsSum    pgtype.Numeric
+	CreatedAt        pgtype.Timestamptz
+}
+
+func (q *Queries) GetBalanceDrifts(ctx context.Context) ([]GetBalanceDriftsRow, error) {
+	rows, err := q.db.Query(ctx, getBalanceDrifts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBalanceDriftsRow
+	for rows.Next() {
+		var i GetBalanceDriftsRow
+		if err := rows.Scan(
+			&i.LcOrganizationID,
+			&i.Currency,
+			&i.Balance,
+			&i.OperationsSum,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getDirectTopUpsWithoutOperations = `-- name: GetDirectTopUpsWithoutOperations :many
-SELECT tups.id, tups.amount, tups.lc_organization_id, tups.type, tups.status, tups.lc_charge, tups.confirmation_url, tups.current_topped_up_at, tups.next_top_up_at, tups.created_at, tups.updated_at
+SELECT tups.id, tups.amount, tups.lc_organization_id, tups.type, tups.status, tups.lc_charge, tups.confirmation_url, tups.current_topped_up_at, tups.next_top_up_at, tups.created_at, tups.updated_at, tups.currency
 FROM ledger_top_ups tups
 LEFT JOIN ledger_ledger lgr ON tups.id = lgr.id AND tups.lc_organization_id = lgr.lc_organization_id
 WHERE tups.type = 'direct'
@@ -92,6 +264,7 @@ func (q *Queries) GetDirectTopUpsWithoutOperations(ctx context.Context) ([]Ledge
 			&i.NextTopUpAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -104,7 +277,7 @@ func (q *Queries) GetDirectTopUpsWithoutOperations(ctx context.Context) ([]Ledge
 }
 
 const getLedgerOperation = `-- name: GetLedgerOperation :one
-SELECT id, amount, lc_organization_id, payload, is_voucher, created_at
+SELECT id, amount, lc_organization_id, payload, is_voucher, created_at, currency
 FROM ledger_ledger
 WHERE lc_organization_id = $1
   AND id = $2
@@ -126,12 +299,13 @@ func (q *Queries) GetLedgerOperation(ctx context.Context, arg GetLedgerOperation
 		&i.Payload,
 		&i.IsVoucher,
 		&i.CreatedAt,
+		&i.Currency,
 	)
 	return i, err
 }
 
 const getLedgerOperationsByOrganizationID = `-- name: GetLedgerOperationsByOrganizationID :many
-SELECT id, amount, lc_organization_id, payload, is_voucher, created_at
+SELECT id, amount, lc_organization_id, payload, is_voucher, created_at, currency
 FROM ledger_ledger
 WHERE lc_organization_id = $1
   AND is_voucher = $2
@@ -159,6 +333,7 @@ func (q *Queries) GetLedgerOperationsByOrganizationID(ctx context.Context, arg G
 			&i.Payload,
 			&i.IsVoucher,
 			&i.CreatedAt,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -170,19 +345,38 @@ func (q *Queries) GetLedgerOperationsByOrganizationID(ctx context.Context, arg G
 	return items, nil
 }
 
-const getOrganizationBalance = `-- name: GetOrganizationBalance :one
-SELECT COALESCE(SUM(amount), 0)::numeric AS amount FROM ledger_ledger WHERE lc_organization_id = $1
+const getOrganizationBalances = `-- name: GetOrganizationBalances :many
+SELECT currency, amount FROM ledger_balances WHERE lc_organization_id = $1
+ORDER BY currency
 `
 
-func (q *Queries) GetOrganizationBalance(ctx context.Context, lcOrganizationID string) (pgtype.Numeric, error) {
-	row := q.db.QueryRow(ctx, getOrganizationBalance, lcOrganizationID)
-	var amount pgtype.Numeric
-	err := row.Scan(&amount)
-	return amount, err
+type GetOrganizationBalancesRow struct {
+	Currency string
+	Amount   pgtype.Numeric
+}
+
+func (q *Queries) GetOrganizationBalances(ctx context.Context, lcOrganizationID string) ([]GetOrganizationBalancesRow, error) {
+	rows, err := q.db.Query(ctx, getOrganizationBalances, lcOrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOrganizationBalancesRow
+	for rows.Next() {
+		var i GetOrganizationBalancesRow
+		if err := rows.Scan(&i.Currency, &i.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getRecurrentTopUpsWhereStatusNotIn = `-- name: GetRecurrentTopUpsWhereStatusNotIn :many
-SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at
+SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency
 FROM ledger_top_ups
 WHERE type = 'recurrent'
   AND NOT (status = ANY($1::text[]))
@@ -212,6 +406,7 @@ func (q *Queries) GetRecurrentTopUpsWhereStatusNotIn(ctx context.Context, dollar
 			&i.NextTopUpAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -224,7 +419,7 @@ func (q *Queries) GetRecurrentTopUpsWhereStatusNotIn(ctx context.Context, dollar
 }
 
 const getTopUpByIDAndOrganizationID = `-- name: GetTopUpByIDAndOrganizationID :one
-SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at
+SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency
 FROM ledger_top_ups
 WHERE id = $1
     AND lc_organization_id = $2
@@ -250,12 +445,13 @@ func (q *Queries) GetTopUpByIDAndOrganizationID(ctx context.Context, arg GetTopU
 		&i.NextTopUpAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
 	)
 	return i, err
 }
 
 const getTopUpByIDAndTypeWhereStatusIsNot = `-- name: GetTopUpByIDAndTypeWhereStatusIsNot :one
-SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at
+SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency
 FROM ledger_top_ups
 WHERE id = $1
   AND type = $2
@@ -284,12 +480,52 @@ func (q *Queries) GetTopUpByIDAndTypeWhereStatusIsNot(ctx context.Context, arg G
 		&i.NextTopUpAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
 	)
 	return i, err
 }
 
+const getTopUps = `-- name: GetTopUps :many
+SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency
+FROM ledger_top_ups
+ORDER BY created_at
+`
+
+func (q *Queries) GetTopUps(ctx context.Context) ([]LedgerTopUp, error) {
+	rows, err := q.db.Query(ctx, getTopUps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LedgerTopUp
+	for rows.Next() {
+		var i LedgerTopUp
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.LcOrganizationID,
+			&i.Type,
+			&i.Status,
+			&i.LcCharge,
+			&i.ConfirmationUrl,
+			&i.CurrentToppedUpAt,
+			&i.NextTopUpAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTopUpsByOrganizationID = `-- name: GetTopUpsByOrganizationID :many
-SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at
+SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency
 FROM ledger_top_ups
 WHERE lc_organization_id = $1
 `
@@ -315,6 +551,7 @@ func (q *Queries) GetTopUpsByOrganizationID(ctx context.Context, lcOrganizationI
 			&i.NextTopUpAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -327,7 +564,7 @@ func (q *Queries) GetTopUpsByOrganizationID(ctx context.Context, lcOrganizationI
 }
 
 const getTopUpsByOrganizationIDAndStatus = `-- name: GetTopUpsByOrganizationIDAndStatus :many
-SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at
+SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency
 FROM ledger_top_ups
 WHERE lc_organization_id = $1
   AND status = $2
@@ -359,6 +596,7 @@ func (q *Queries) GetTopUpsByOrganizationIDAndStatus(ctx context.Context, arg Ge
 			&i.NextTopUpAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -371,7 +609,7 @@ func (q *Queries) GetTopUpsByOrganizationIDAndStatus(ctx context.Context, arg Ge
 }
 
 const getTopUpsByTypeWhereStatusNotIn = `-- name: GetTopUpsByTypeWhereStatusNotIn :many
-SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at
+SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency
 FROM ledger_top_ups
 WHERE type = $1
   AND NOT (status = ANY($2::text[]))
@@ -405,6 +643,7 @@ func (q *Queries) GetTopUpsByTypeWhereStatusNotIn(ctx context.Context, arg GetTo
 			&i.NextTopUpAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -416,6 +655,232 @@ func (q *Queries) GetTopUpsByTypeWhereStatusNotIn(ctx context.Context, arg GetTo
 	return items, nil
 }
 
+const getTrialBalance = `-- name: GetTrialBalance :many
+SELECT account, currency, SUM(amount)::numeric AS balance
+FROM ledger_entries
+WHERE ($1::text = '' OR lc_organization_id = $1::text)
+GROUP BY account, currency
+ORDER BY account, currency
+`
+
+type GetTrialBalanceRow struct {
+	Account  string
+	Currency string
+	Balance  pgtype.Numeric
+}
+
+func (q *Queries) GetTrialBalance(ctx context.Context, lcOrganizationID string) ([]GetTrialBalanceRow, error) {
+	rows, err := q.db.Query(ctx, getTrialBalance, lcOrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrialBalanceRow
+	for rows.Next() {
+		var i GetTrialBalanceRow
+		if err := rows.Scan(&i.Account, &i.Currency, &i.Balance); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const initOrganizationBalance = `-- name: InitOrganizationBalance :exec
+INSERT INTO ledger_balances(lc_organization_id, currency, amount, updated_at)
+VALUES ($1, $2, 0, NOW())
+ON CONFLICT (lc_organization_id, currency) DO NOTHING
+`
+
+type InitOrganizationBalanceParams struct {
+	LcOrganizationID string
+	Currency         string
+}
+
+func (q *Queries) InitOrganizationBalance(ctx context.Context, arg InitOrganizationBalanceParams) error {
+	_, err := q.db.Exec(ctx, initOrganizationBalance, arg.LcOrganizationID, arg.Currency)
+	return err
+}
+
+const listEvents = `-- name: ListEvents :many
+SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id
+FROM ledger_events
+WHERE ($1::text = '' OR lc_organization_id = $1::text)
+AND ($2::text = '' OR correlation_id = $2::text)
+AND ($3::text = '' OR action = $3::text)
+AND ($4::text = '' OR type = $4::text)
+AND (NOT $5::bool OR COALESCE(error, '') <> '')
+AND ($6::timestamptz IS NULL OR created_at >= $6::timestamptz)
+AND ($7::timestamptz IS NULL OR created_at < $7::timestamptz)
+AND ($8::timestamptz IS NULL OR (created_at, id, action) < ($8::timestamptz, $9::text, $10::text))
+ORDER BY created_at DESC, id DESC, action DESC
+LIMIT $11::int
+`
+
+type ListEventsParams struct {
+	LcOrganizationID string
+	CorrelationID    string
+	Action           string
+	Type             string
+	ErrorsOnly       bool
+	CreatedFrom      pgtype.Timestamptz
+	CreatedTo        pgtype.Timestamptz
+	CursorCreatedAt  pgtype.Timestamptz
+	CursorID         string
+	CursorAction     string
+	RowLimit         int32
+}
+
+func (q *Queries) ListEvents(ctx context.Context, arg ListEventsParams) ([]LedgerEvent, error) {
+	rows, err := q.db.Query(ctx, listEvents,
+		arg.LcOrganizationID,
+		arg.CorrelationID,
+		arg.Action,
+		arg.Type,
+		arg.ErrorsOnly,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.CursorAction,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LedgerEvent
+	for rows.Next() {
+		var i LedgerEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.LcOrganizationID,
+			&i.Type,
+			&i.Action,
+			&i.Payload,
+			&i.Error,
+			&i.CreatedAt,
+			&i.TraceID,
+			&i.CorrelationID,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredEvents = `-- name: ListExpiredEvents :many
+SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id
+FROM ledger_events
+WHERE created_at < $1::timestamptz
+AND ($2::text = '' OR type = $2::text)
+AND (cardinality($3::text[]) = 0 OR action = ANY($3::text[]))
+AND action <> ALL($4::text[])
+ORDER BY created_at
+LIMIT $5::int
+`
+
+type ListExpiredEventsParams struct {
+	CreatedBefore  pgtype.Timestamptz
+	Type           string
+	Actions        []string
+	ExcludeActions []string
+	RowLimit       int32
+}
+
+func (q *Queries) ListExpiredEvents(ctx context.Context, arg ListExpiredEventsParams) ([]LedgerEvent, error) {
+	rows, err := q.db.Query(ctx, listExpiredEvents,
+		arg.CreatedBefore,
+		arg.Type,
+		arg.Actions,
+		arg.ExcludeActions,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LedgerEvent
+	for rows.Next() {
+		var i LedgerEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.LcOrganizationID,
+			&i.Type,
+			&i.Action,
+			&i.Payload,
+			&i.Error,
+			&i.CreatedAt,
+			&i.TraceID,
+			&i.CorrelationID,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockOrganizationBalance = `-- name: LockOrganizationBalance :one
+SELECT amount FROM ledger_balances
+WHERE lc_organization_id = $1 AND currency = $2
+FOR UPDATE
+`
+
+type LockOrganizationBalanceParams struct {
+	LcOrganizationID string
+	Currency         string
+}
+
+func (q *Queries) LockOrganizationBalance(ctx context.Context, arg LockOrganizationBalanceParams) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, lockOrganizationBalance, arg.LcOrganizationID, arg.Currency)
+	var amount pgtype.Numeric
+	err := row.Scan(&amount)
+	return amount, err
+}
+
+const lockTopUp = `-- name: LockTopUp :exec
+SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))
+`
+
+func (q *Queries) LockTopUp(ctx context.Context, dollar_1 string) error {
+	_, err := q.db.Exec(ctx, lockTopUp, dollar_1)
+	return err
+}
+
+const repairOrganizationBalance = `-- name: RepairOrganizationBalance :one
+INSERT INTO ledger_balances(lc_organization_id, currency, amount, updated_at)
+SELECT $1::varchar, $2::varchar, COALESCE(SUM(amount), 0), NOW()
+FROM ledger_ledger
+WHERE lc_organization_id = $1::varchar AND currency = $2::varchar
+ON CONFLICT (lc_organization_id, currency) DO UPDATE SET amount = EXCLUDED.amount, updated_at = NOW()
+RETURNING amount
+`
+
+type RepairOrganizationBalanceParams struct {
+	LcOrganizationID string
+	Currency         string
+}
+
+func (q *Queries) RepairOrganizationBalance(ctx context.Context, arg RepairOrganizationBalanceParams) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, repairOrganizationBalance, arg.LcOrganizationID, arg.Currency)
+	var amount pgtype.Numeric
+	err := row.Scan(&amount)
+	return amount, err
+}
+
 const updateTopUpRequestStatus = `-- name: UpdateTopUpRequestStatus :exec
 UPDATE ledger_top_ups
 SET status = $1, updated_at = now()
@@ -433,10 +898,10 @@ func (q *Queries) UpdateTopUpRequestStatus(ctx context.Context, arg UpdateTopUpR
 }
 
 const upsertTopUp = `-- name: UpsertTopUp :one
-INSERT INTO ledger_top_ups(id, status, amount, type, lc_organization_id, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(),NOW())
+INSERT INTO ledger_top_ups(id, status, amount, type, lc_organization_id, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, currency, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(),NOW())
 ON CONFLICT ON CONSTRAINT ledger_top_ups_pkey DO UPDATE SET lc_charge = EXCLUDED.lc_charge, status = EXCLUDED.status, current_topped_up_at = EXCLUDED.current_topped_up_at, next_top_up_at = EXCLUDED.next_top_up_at, updated_at = NOW()
-RETURNING id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at
+RETURNING id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency
 `
 
 type UpsertTopUpParams struct {
@@ -449,6 +914,7 @@ type UpsertTopUpParams struct {
 	ConfirmationUrl   string
 	CurrentToppedUpAt pgtype.Timestamptz
 	NextTopUpAt       pgtype.Timestamptz
+	Currency          string
 }
 
 func (q *Queries) UpsertTopUp(ctx context.Context, arg UpsertTopUpParams) (LedgerTopUp, error) {
@@ -462,6 +928,7 @@ func (q *Queries) UpsertTopUp(ctx context.Context, arg UpsertTopUpParams) (Ledge
 		arg.ConfirmationUrl,
 		arg.CurrentToppedUpAt,
 		arg.NextTopUpAt,
+		arg.Currency,
 	)
 	var i LedgerTopUp
 	err := row.Scan(
@@ -476,6 +943,7 @@ func (q *Queries) UpsertTopUp(ctx context.Context, arg UpsertTopUpParams) (Ledge
 		&i.NextTopUpAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
 	)
 	return i, err
 }
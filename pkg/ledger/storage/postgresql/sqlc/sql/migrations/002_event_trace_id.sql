@@ -0,0 +1,2 @@
+ALTER TABLE ledger_events ADD COLUMN trace_id varchar(32) NOT NULL DEFAULT '';
+CREATE INDEX idx_ledger_events_trace_id ON ledger_events(trace_id);
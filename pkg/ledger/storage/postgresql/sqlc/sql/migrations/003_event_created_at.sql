@@ -0,0 +1 @@
+CREATE INDEX idx_ledger_events_created_at ON ledger_events(created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE ledger_events ADD COLUMN correlation_id varchar(36) NOT NULL DEFAULT '', ADD COLUMN parent_id varchar(36) NOT NULL DEFAULT '';
+UPDATE ledger_events SET correlation_id = id WHERE correlation_id = '';
+CREATE INDEX idx_ledger_events_correlation_id ON ledger_events(correlation_id);
@@ -0,0 +1,19 @@
+-- The operations and top ups stored before currencies were added are in the single implicit currency, USD,
+-- except the voucher funds, which are credits.
+ALTER TABLE ledger_ledger ADD COLUMN currency varchar(16) NOT NULL DEFAULT 'USD';
+ALTER TABLE ledger_top_ups ADD COLUMN currency varchar(16) NOT NULL DEFAULT 'USD';
+UPDATE ledger_ledger SET currency = 'credits' WHERE is_voucher = true;
+CREATE INDEX idx_ledger_ledger_organization_currency ON ledger_ledger(lc_organization_id, currency);
+
+CREATE TABLE IF NOT EXISTS ledger_conversions
+(
+    id                 varchar(255) UNIQUE PRIMARY KEY,
+    lc_organization_id varchar(36) NOT NULL,
+    from_currency      varchar(16) NOT NULL,
+    from_amount        numeric(9,3) NOT NULL,
+    to_currency        varchar(16) NOT NULL,
+    to_amount          numeric(9,3) NOT NULL,
+    rate               numeric(18,6) NOT NULL,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT now()
+    );
+CREATE INDEX ON ledger_conversions (lc_organization_id);
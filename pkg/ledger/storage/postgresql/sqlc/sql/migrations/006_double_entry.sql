@@ -0,0 +1,39 @@
+-- Entries are only posted in double-entry mode, operations stored without it have none.
+CREATE TABLE IF NOT EXISTS ledger_entries
+(
+    id                 bigserial PRIMARY KEY,
+    operation_id       varchar(255) NOT NULL REFERENCES ledger_ledger (id),
+    lc_organization_id varchar(36) NOT NULL,
+    account            varchar(32) NOT NULL CHECK (account IN ('customer_wallet', 'revenue', 'promo', 'livechat_clearing', 'currency_exchange')),
+    currency           varchar(16) NOT NULL,
+    amount             numeric(9,3) NOT NULL,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT now()
+    );
+CREATE INDEX ON ledger_entries (operation_id);
+CREATE INDEX ON ledger_entries (lc_organization_id, account, currency);
+
+-- The entries of an operation must sum up to zero in each currency once the transaction inserting them commits.
+CREATE OR REPLACE FUNCTION ledger_entries_check_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM ledger_entries WHERE operation_id = NEW.operation_id GROUP BY currency HAVING SUM(amount) <> 0) THEN
+        RAISE EXCEPTION 'unbalanced ledger entries of operation %', NEW.operation_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_entries_balanced
+    AFTER INSERT ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_check_balanced();
+
+-- Posted entries are never changed, corrections are posted as new operations.
+CREATE OR REPLACE FUNCTION ledger_entries_reject_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger entries are append only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_reject_change();
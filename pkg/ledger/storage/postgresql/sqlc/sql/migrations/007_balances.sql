@@ -0,0 +1,27 @@
+-- Balances are updated in the transaction of every stored operation. The backfill has to run before the operations
+-- are stored with the balance update, operations stored in between are missing from the balance.
+CREATE TABLE IF NOT EXISTS ledger_balances
+(
+    lc_organization_id varchar(36) NOT NULL,
+    currency           varchar(16) NOT NULL,
+    amount             numeric(18,3) NOT NULL,
+    updated_at         TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    PRIMARY KEY (lc_organization_id, currency)
+    );
+
+INSERT INTO ledger_balances(lc_organization_id, currency, amount)
+SELECT lc_organization_id, currency, SUM(amount)
+FROM ledger_ledger
+GROUP BY lc_organization_id, currency
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS ledger_balance_snapshots
+(
+    id                 bigserial PRIMARY KEY,
+    lc_organization_id varchar(36) NOT NULL,
+    currency           varchar(16) NOT NULL,
+    balance            numeric(18,3) NOT NULL,
+    operations_sum     numeric(18,3) NOT NULL,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT now()
+    );
+CREATE INDEX ON ledger_balance_snapshots (created_at);
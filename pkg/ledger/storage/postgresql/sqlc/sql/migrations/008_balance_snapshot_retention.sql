@@ -0,0 +1,2 @@
+-- Snapshots are kept per organization and currency, the retention deletes all but the latest of each.
+CREATE INDEX ON ledger_balance_snapshots (lc_organization_id, currency, created_at);
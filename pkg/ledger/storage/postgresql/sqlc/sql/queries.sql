@@ -1,15 +1,51 @@
 -- name: CreateEvent :exec
-INSERT INTO ledger_events(id, lc_organization_id, type, action, payload, error, created_at)
-VALUES ($1, $2, $3, $4, $5, $6, NOW());
+INSERT INTO ledger_events(id, lc_organization_id, type, action, payload, error, trace_id, correlation_id, parent_id, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW());
+
+-- name: ListEvents :many
+SELECT *
+FROM ledger_events
+WHERE (sqlc.arg(lc_organization_id)::text = '' OR lc_organization_id = sqlc.arg(lc_organization_id)::text)
+AND (sqlc.arg(correlation_id)::text = '' OR correlation_id = sqlc.arg(correlation_id)::text)
+AND (sqlc.arg(action)::text = '' OR action = sqlc.arg(action)::text)
+AND (sqlc.arg(type)::text = '' OR type = sqlc.arg(type)::text)
+AND (NOT sqlc.arg(errors_only)::bool OR COALESCE(error, '') <> '')
+AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from)::timestamptz)
+AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to)::timestamptz)
+AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id, action) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::text, sqlc.arg(cursor_action)::text))
+ORDER BY created_at DESC, id DESC, action DESC
+LIMIT sqlc.arg(row_limit)::int;
+
+-- name: ListExpiredEvents :many
+SELECT *
+FROM ledger_events
+WHERE created_at < sqlc.arg(created_before)::timestamptz
+AND (sqlc.arg(type)::text = '' OR type = sqlc.arg(type)::text)
+AND (cardinality(sqlc.arg(actions)::text[]) = 0 OR action = ANY(sqlc.arg(actions)::text[]))
+AND action <> ALL(sqlc.arg(exclude_actions)::text[])
+ORDER BY created_at
+LIMIT sqlc.arg(row_limit)::int;
+
+-- name: DeleteEvents :execrows
+DELETE FROM ledger_events
+WHERE (id, action) IN (SELECT unnest(sqlc.arg(ids)::text[]), unnest(sqlc.arg(actions)::text[]));
 
 -- name: CreateLedgerOperation :exec
-INSERT INTO ledger_ledger(id, amount, lc_organization_id, payload, is_voucher, created_at)
+INSERT INTO ledger_ledger(id, amount, lc_organization_id, payload, is_voucher, currency, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, NOW());
+
+-- name: CreateLedgerEntry :exec
+INSERT INTO ledger_entries(operation_id, lc_organization_id, account, currency, amount, created_at)
 VALUES ($1, $2, $3, $4, $5, NOW());
 
+-- name: CreateConversion :exec
+INSERT INTO ledger_conversions(id, lc_organization_id, from_currency, from_amount, to_currency, to_amount, rate, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, NOW());
+
 
 -- name: UpsertTopUp :one
-INSERT INTO ledger_top_ups(id, status, amount, type, lc_organization_id, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(),NOW())
+INSERT INTO ledger_top_ups(id, status, amount, type, lc_organization_id, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, currency, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(),NOW())
 ON CONFLICT ON CONSTRAINT ledger_top_ups_pkey DO UPDATE SET lc_charge = EXCLUDED.lc_charge, status = EXCLUDED.status, current_topped_up_at = EXCLUDED.current_topped_up_at, next_top_up_at = EXCLUDED.next_top_up_at, updated_at = NOW()
 RETURNING *;
 
@@ -70,6 +106,11 @@ SET status = $1, updated_at = now()
 WHERE id = $2
 ;
 
+-- name: GetTopUps :many
+SELECT *
+FROM ledger_top_ups
+ORDER BY created_at;
+
 -- name: GetTopUpsByOrganizationID :many
 SELECT *
 FROM ledger_top_ups
@@ -87,6 +128,63 @@ FROM ledger_top_ups
 WHERE lc_organization_id = $1
   AND status = $2;
 
--- name: GetOrganizationBalance :one
-SELECT COALESCE(SUM(amount), 0)::numeric AS amount FROM ledger_ledger WHERE lc_organization_id = $1
-;
\ No newline at end of file
+-- name: GetOrganizationBalances :many
+SELECT currency, amount FROM ledger_balances WHERE lc_organization_id = $1
+ORDER BY currency;
+
+-- name: AddToOrganizationBalance :exec
+INSERT INTO ledger_balances(lc_organization_id, currency, amount, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (lc_organization_id, currency) DO UPDATE SET amount = ledger_balances.amount + EXCLUDED.amount, updated_at = NOW();
+
+-- name: CreateBalanceSnapshots :execrows
+INSERT INTO ledger_balance_snapshots(lc_organization_id, currency, balance, operations_sum, created_at)
+SELECT COALESCE(b.lc_organization_id, o.lc_organization_id), COALESCE(b.currency, o.currency), COALESCE(b.amount, 0), COALESCE(o.amount, 0), NOW()
+FROM ledger_balances b
+FULL JOIN (SELECT lc_organization_id, currency, SUM(amount) AS amount FROM ledger_ledger GROUP BY lc_organization_id, currency) o
+ON o.lc_organization_id = b.lc_organization_id AND o.currency = b.currency;
+
+-- name: DeleteExpiredBalanceSnapshots :execrows
+DELETE FROM ledger_balance_snapshots
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY lc_organization_id, currency ORDER BY created_at DESC, id DESC) AS n
+        FROM ledger_balance_snapshots
+    ) s
+    WHERE s.n > sqlc.arg(keep)::int
+);
+
+-- name: InitOrganizationBalance :exec
+INSERT INTO ledger_balances(lc_organization_id, currency, amount, updated_at)
+VALUES ($1, $2, 0, NOW())
+ON CONFLICT (lc_organization_id, currency) DO NOTHING;
+
+-- name: LockOrganizationBalance :one
+SELECT amount FROM ledger_balances
+WHERE lc_organization_id = $1 AND currency = $2
+FOR UPDATE;
+
+-- name: RepairOrganizationBalance :one
+INSERT INTO ledger_balances(lc_organization_id, currency, amount, updated_at)
+SELECT sqlc.arg(lc_organization_id)::varchar, sqlc.arg(currency)::varchar, COALESCE(SUM(amount), 0), NOW()
+FROM ledger_ledger
+WHERE lc_organization_id = sqlc.arg(lc_organization_id)::varchar AND currency = sqlc.arg(currency)::varchar
+ON CONFLICT (lc_organization_id, currency) DO UPDATE SET amount = EXCLUDED.amount, updated_at = NOW()
+RETURNING amount;
+
+-- name: GetBalanceDrifts :many
+SELECT lc_organization_id, currency, balance, operations_sum, created_at
+FROM ledger_balance_snapshots
+WHERE created_at = (SELECT MAX(created_at) FROM ledger_balance_snapshots)
+  AND balance <> operations_sum
+ORDER BY lc_organization_id, currency;
+
+-- name: GetTrialBalance :many
+SELECT account, currency, SUM(amount)::numeric AS balance
+FROM ledger_entries
+WHERE (sqlc.arg(lc_organization_id)::text = '' OR lc_organization_id = sqlc.arg(lc_organization_id)::text)
+GROUP BY account, currency
+ORDER BY account, currency;
+
+-- name: LockTopUp :exec
+SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0));
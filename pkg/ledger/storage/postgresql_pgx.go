@@ -3,12 +3,14 @@ package storage
 import (
 	"context"
 	"errors"
-	"fmt"
+	"math/big"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"github.com/livechat-integrations/go-billing-sdk/v2/internal/lock"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/ledger"
 	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/ledger/storage/postgresql/sqlc"
@@ -18,33 +20,101 @@ type PGXConn interface {
 	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
 	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
 	QueryRow(context.Context, string, ...interface{}) pgx.Row
+	Begin(context.Context) (pgx.Tx, error)
 }
 
 type PostgresqlPGX struct {
+	conn    PGXConn
 	queries *sqlc.Queries
 }
 
 func NewPostgresqlPGX(conn PGXConn) *PostgresqlPGX {
-	return &PostgresqlPGX{queries: sqlc.New(conn)}
+	return &PostgresqlPGX{conn: conn, queries: sqlc.New(conn)}
 }
 
+// CreateLedgerOperation stores the operation with its entries and adds its amount to the materialized balance in a
+// transaction.
 func (r *PostgresqlPGX) CreateLedgerOperation(ctx context.Context, c ledger.Operation) error {
-	if err := r.queries.CreateLedgerOperation(ctx, sqlc.CreateLedgerOperationParams{
+	if err := c.ValidateEntries(); err != nil {
+		return err
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err = createLedgerOperation(ctx, r.queries.WithTx(tx), c); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CreateConversion stores the conversion and its operations in a transaction.
+func (r *PostgresqlPGX) CreateConversion(ctx context.Context, params ledger.CreateConversionParams) error {
+	for _, o := range params.Operations {
+		if err := o.ValidateEntries(); err != nil {
+			return err
+		}
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	queries := r.queries.WithTx(tx)
+	c := params.Conversion
+	if err = queries.CreateConversion(ctx, sqlc.CreateConversionParams{
 		ID:               c.ID,
-		Amount:           ToPGNumeric(&c.Amount),
 		LcOrganizationID: c.LCOrganizationID,
-		IsVoucher:        c.IsVoucher,
-		Payload:          c.Payload,
+		FromCurrency:     ToPGCurrency(c.FromCurrency),
+		FromAmount:       ToPGNumeric(c.FromAmount),
+		ToCurrency:       ToPGCurrency(c.ToCurrency),
+		ToAmount:         ToPGNumeric(c.ToAmount),
+		Rate:             pgtype.Numeric{Int: big.NewInt(int64(c.Rate)), Exp: -6, Valid: true},
 	}); err != nil {
+		_ = tx.Rollback(ctx)
 		return err
 	}
+	for _, o := range params.Operations {
+		if err = createLedgerOperation(ctx, queries, o); err != nil {
+			_ = tx.Rollback(ctx)
+			return err
+		}
+	}
 
-	return nil
+	return tx.Commit(ctx)
+}
+
+// GetTrialBalance returns the balances of the accounts, the storage rejects unbalanced entries so they sum up to zero
+// in each currency.
+func (r *PostgresqlPGX) GetTrialBalance(ctx context.Context, organizationID string) (ledger.TrialBalance, error) {
+	rows, err := r.q(ctx).GetTrialBalance(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := ledger.TrialBalance{}
+	for _, row := range rows {
+		amount, err := sqlc.ToLedgerMoney(row.Balance)
+		if err != nil {
+			return nil, err
+		}
+		balance = append(balance, ledger.TrialBalanceLine{
+			Account:  ledger.Account(row.Account),
+			Currency: ledger.Currency(row.Currency),
+			Balance:  amount,
+		})
+	}
+
+	return balance, nil
 }
 
 func (r *PostgresqlPGX) GetLedgerOperations(ctx context.Context, organizationID string, isVoucher bool) ([]ledger.Operation, error) {
 	var ops []ledger.Operation
-	rows, err := r.queries.GetLedgerOperationsByOrganizationID(ctx, sqlc.GetLedgerOperationsByOrganizationIDParams{
+	rows, err := r.q(ctx).GetLedgerOperationsByOrganizationID(ctx, sqlc.GetLedgerOperationsByOrganizationIDParams{
 		LcOrganizationID: organizationID,
 		IsVoucher:        isVoucher,
 	})
@@ -65,7 +135,7 @@ func (r *PostgresqlPGX) GetLedgerOperations(ctx context.Context, organizationID
 }
 
 func (r *PostgresqlPGX) GetLedgerOperation(ctx context.Context, params ledger.GetLedgerOperationParams) (*ledger.Operation, error) {
-	dbOp, err := r.queries.GetLedgerOperation(ctx, sqlc.GetLedgerOperationParams{
+	dbOp, err := r.q(ctx).GetLedgerOperation(ctx, sqlc.GetLedgerOperationParams{
 		ID:               params.ID,
 		LcOrganizationID: params.OrganizationID,
 	})
@@ -84,22 +154,137 @@ func (r *PostgresqlPGX) GetLedgerOperation(ctx context.Context, params ledger.Ge
 	return op, nil
 }
 
-func (r *PostgresqlPGX) GetBalance(ctx context.Context, organizationID string) (float32, error) {
-	b, err := r.queries.GetOrganizationBalance(ctx, organizationID)
+func (r *PostgresqlPGX) GetBalance(ctx context.Context, organizationID string) (ledger.Balances, error) {
+	rows, err := r.q(ctx).GetOrganizationBalances(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := ledger.Balances{}
+	for _, row := range rows {
+		amount, err := sqlc.ToLedgerMoney(row.Amount)
+		if err != nil {
+			return nil, err
+		}
+		balances[ledger.Currency(row.Currency)] = amount
+	}
+
+	return balances, nil
+}
+
+// CreateBalanceSnapshots stores the snapshot and deletes the expired ones in a transaction. NOW() is the start of
+// the transaction, so every row of the snapshot has the same created_at.
+func (r *PostgresqlPGX) CreateBalanceSnapshots(ctx context.Context, keep int) (int64, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	queries := r.queries.WithTx(tx)
+	n, err := queries.CreateBalanceSnapshots(ctx)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, err
+	}
+	if _, err = queries.DeleteExpiredBalanceSnapshots(ctx, int32(keep)); err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, err
+	}
+
+	return n, tx.Commit(ctx)
+}
+
+func (r *PostgresqlPGX) GetBalanceDrifts(ctx context.Context) ([]ledger.BalanceDrift, error) {
+	rows, err := r.q(ctx).GetBalanceDrifts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := make([]ledger.BalanceDrift, 0, len(rows))
+	for _, row := range rows {
+		balance, err := sqlc.ToLedgerMoney(row.Balance)
+		if err != nil {
+			return nil, err
+		}
+		sum, err := sqlc.ToLedgerMoney(row.OperationsSum)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, ledger.BalanceDrift{
+			LCOrganizationID: row.LcOrganizationID,
+			Currency:         ledger.Currency(row.Currency),
+			Balance:          balance,
+			OperationsSum:    sum,
+			SnapshotAt:       row.CreatedAt.Time,
+		})
+	}
+
+	return drifts, nil
+}
+
+// RepairBalance locks the balance row and rewrites it from the sum of the operations in a transaction, a missing
+// balance is created as zero and locked before the repair.
+func (r *PostgresqlPGX) RepairBalance(ctx context.Context, organizationID string, currency ledger.Currency) (*ledger.BalanceRepair, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := r.queries.WithTx(tx)
+	repair := &ledger.BalanceRepair{LCOrganizationID: organizationID, Currency: currency}
+	// a missing balance is created first, there would be no row to lock and a concurrent first operation of the
+	// organization could be overwritten by the sum read without it
+	if err = queries.InitOrganizationBalance(ctx, sqlc.InitOrganizationBalanceParams{
+		LcOrganizationID: organizationID,
+		Currency:         ToPGCurrency(currency),
+	}); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+	before, err := queries.LockOrganizationBalance(ctx, sqlc.LockOrganizationBalanceParams{
+		LcOrganizationID: organizationID,
+		Currency:         ToPGCurrency(currency),
+	})
 	if err != nil {
-		return float32(0), err
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+	if repair.Before, err = sqlc.ToLedgerMoney(before); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
 	}
-	v, err := b.Float64Value()
+
+	after, err := queries.RepairOrganizationBalance(ctx, sqlc.RepairOrganizationBalanceParams{
+		LcOrganizationID: organizationID,
+		Currency:         ToPGCurrency(currency),
+	})
 	if err != nil {
-		return float32(0), err
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+	if repair.After, err = sqlc.ToLedgerMoney(after); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
 	}
 
-	return float32(v.Float64), nil
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return repair, nil
+}
+
+func (r *PostgresqlPGX) GetTopUps(ctx context.Context) ([]ledger.TopUp, error) {
+	dbTopUps, err := r.q(ctx).GetTopUps(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ToTopUps(dbTopUps)
 }
 
 func (r *PostgresqlPGX) GetTopUpsByOrganizationID(ctx context.Context, organizationID string) ([]ledger.TopUp, error) {
 	var ts []ledger.TopUp
-	dbTopUps, err := r.queries.GetTopUpsByOrganizationID(ctx, organizationID)
+	dbTopUps, err := r.q(ctx).GetTopUpsByOrganizationID(ctx, organizationID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return ts, nil
@@ -115,7 +300,7 @@ func (r *PostgresqlPGX) UpdateTopUpStatus(ctx context.Context, params ledger.Upd
 		Status: string(params.Status),
 	}
 
-	err := r.queries.UpdateTopUpRequestStatus(ctx, p)
+	err := r.q(ctx).UpdateTopUpRequestStatus(ctx, p)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return ledger.ErrNotFound
@@ -133,7 +318,7 @@ func (r *PostgresqlPGX) GetTopUpByIDAndType(ctx context.Context, params ledger.G
 		Status: string(ledger.TopUpStatusCancelled),
 	}
 
-	t, err := r.queries.GetTopUpByIDAndTypeWhereStatusIsNot(ctx, p)
+	t, err := r.q(ctx).GetTopUpByIDAndTypeWhereStatusIsNot(ctx, p)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -145,7 +330,7 @@ func (r *PostgresqlPGX) GetTopUpByIDAndType(ctx context.Context, params ledger.G
 }
 
 func (r *PostgresqlPGX) CreateEvent(ctx context.Context, e events.Event) error {
-	err := r.queries.CreateEvent(ctx, sqlc.CreateEventParams{
+	err := r.q(ctx).CreateEvent(ctx, sqlc.CreateEventParams{
 		ID:               e.ID,
 		LcOrganizationID: e.LCOrganizationID,
 		Type:             string(e.Type),
@@ -155,6 +340,9 @@ func (r *PostgresqlPGX) CreateEvent(ctx context.Context, e events.Event) error {
 			String: e.Error,
 			Valid:  true,
 		},
+		TraceID:       e.TraceID,
+		CorrelationID: e.CorrelationID,
+		ParentID:      e.ParentID,
 	})
 	if err != nil {
 		return err
@@ -162,8 +350,92 @@ func (r *PostgresqlPGX) CreateEvent(ctx context.Context, e events.Event) error {
 	return nil
 }
 
+// ListEvents returns a page of ledger_events, newest first.
+func (r *PostgresqlPGX) ListEvents(ctx context.Context, filter events.ListEventsFilter) (*events.EventsPage, error) {
+	cursor, err := events.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := filter.PageLimit()
+	params := sqlc.ListEventsParams{
+		LcOrganizationID: filter.OrganizationID,
+		CorrelationID:    filter.CorrelationID,
+		Action:           string(filter.Action),
+		Type:             string(filter.Type),
+		ErrorsOnly:       filter.ErrorsOnly,
+		CreatedFrom:      toPGTimestamptz(filter.From),
+		CreatedTo:        toPGTimestamptz(filter.To),
+		RowLimit:         int32(limit + 1),
+	}
+	if cursor != nil {
+		params.CursorCreatedAt = toPGTimestamptz(cursor.CreatedAt)
+		params.CursorID = cursor.ID
+		params.CursorAction = string(cursor.Action)
+	}
+
+	rows, err := r.q(ctx).ListEvents(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	evs := make([]events.Event, 0, len(rows))
+	for _, row := range rows {
+		evs = append(evs, *row.ToEvent())
+	}
+	return events.NewEventsPage(evs, limit), nil
+}
+
+// ListExpiredEvents returns up to params.Limit ledger_events matching params, oldest first.
+func (r *PostgresqlPGX) ListExpiredEvents(ctx context.Context, params events.ExpiredEventsParams) ([]events.Event, error) {
+	rows, err := r.q(ctx).ListExpiredEvents(ctx, sqlc.ListExpiredEventsParams{
+		CreatedBefore:  toPGTimestamptz(params.CreatedBefore),
+		Type:           string(params.Type),
+		Actions:        toActionStrings(params.Actions),
+		ExcludeActions: toActionStrings(params.ExcludeActions),
+		RowLimit:       int32(params.Limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	evs := make([]events.Event, 0, len(rows))
+	for _, row := range rows {
+		evs = append(evs, *row.ToEvent())
+	}
+	return evs, nil
+}
+
+// DeleteEvents deletes ledger_events by their ID and action.
+func (r *PostgresqlPGX) DeleteEvents(ctx context.Context, evs []events.Event) (int64, error) {
+	params := sqlc.DeleteEventsParams{
+		Ids:     make([]string, 0, len(evs)),
+		Actions: make([]string, 0, len(evs)),
+	}
+	for _, e := range evs {
+		params.Ids = append(params.Ids, e.ID)
+		params.Actions = append(params.Actions, string(e.Action))
+	}
+
+	return r.q(ctx).DeleteEvents(ctx, params)
+}
+
+// toPGTimestamptz maps the zero time to NULL.
+func toPGTimestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: !t.IsZero()}
+}
+
+// toActionStrings never returns nil, a NULL array would not match any row.
+func toActionStrings(actions []events.EventAction) []string {
+	res := make([]string, 0, len(actions))
+	for _, a := range actions {
+		res = append(res, string(a))
+	}
+	return res
+}
+
 func (r *PostgresqlPGX) GetTopUpsByOrganizationIDAndStatus(ctx context.Context, organizationID string, status ledger.TopUpStatus) ([]ledger.TopUp, error) {
-	dbTopUps, err := r.queries.GetTopUpsByOrganizationIDAndStatus(ctx, sqlc.GetTopUpsByOrganizationIDAndStatusParams{
+	dbTopUps, err := r.q(ctx).GetTopUpsByOrganizationIDAndStatus(ctx, sqlc.GetTopUpsByOrganizationIDAndStatusParams{
 		LcOrganizationID: organizationID,
 		Status:           string(status),
 	})
@@ -174,7 +446,7 @@ func (r *PostgresqlPGX) GetTopUpsByOrganizationIDAndStatus(ctx context.Context,
 }
 
 func (r *PostgresqlPGX) GetTopUpByIDAndOrganizationID(ctx context.Context, organizationID string, id string) (*ledger.TopUp, error) {
-	topUp, err := r.queries.GetTopUpByIDAndOrganizationID(ctx, sqlc.GetTopUpByIDAndOrganizationIDParams{
+	topUp, err := r.q(ctx).GetTopUpByIDAndOrganizationID(ctx, sqlc.GetTopUpByIDAndOrganizationIDParams{
 		LcOrganizationID: organizationID,
 		ID:               id,
 	})
@@ -192,7 +464,7 @@ func (r *PostgresqlPGX) GetTopUpsByTypeWhereStatusNotIn(ctx context.Context, par
 	for _, s := range params.Statuses {
 		statuses = append(statuses, string(s))
 	}
-	dbTopUps, err := r.queries.GetTopUpsByTypeWhereStatusNotIn(ctx, sqlc.GetTopUpsByTypeWhereStatusNotInParams{
+	dbTopUps, err := r.q(ctx).GetTopUpsByTypeWhereStatusNotIn(ctx, sqlc.GetTopUpsByTypeWhereStatusNotInParams{
 		Type:    string(params.Type),
 		Column2: statuses,
 	})
@@ -207,7 +479,7 @@ func (r *PostgresqlPGX) GetRecurrentTopUpsWhereStatusNotIn(ctx context.Context,
 	for _, s := range statuses {
 		stringStatuses = append(stringStatuses, string(s))
 	}
-	dbTopUps, err := r.queries.GetRecurrentTopUpsWhereStatusNotIn(ctx, stringStatuses)
+	dbTopUps, err := r.q(ctx).GetRecurrentTopUpsWhereStatusNotIn(ctx, stringStatuses)
 	if err != nil {
 		return HandleTopUpsError(err)
 	}
@@ -215,7 +487,7 @@ func (r *PostgresqlPGX) GetRecurrentTopUpsWhereStatusNotIn(ctx context.Context,
 }
 
 func (r *PostgresqlPGX) GetDirectTopUpsWithoutOperations(ctx context.Context) ([]ledger.TopUp, error) {
-	dbTopUps, err := r.queries.GetDirectTopUpsWithoutOperations(ctx)
+	dbTopUps, err := r.q(ctx).GetDirectTopUpsWithoutOperations(ctx)
 	if err != nil {
 		return HandleTopUpsError(err)
 	}
@@ -226,11 +498,12 @@ func (r *PostgresqlPGX) UpsertTopUp(ctx context.Context, topUp ledger.TopUp) (*l
 	params := sqlc.UpsertTopUpParams{
 		ID:               topUp.ID,
 		Status:           string(topUp.Status),
-		Amount:           ToPGNumeric(&topUp.Amount),
+		Amount:           ToPGNumeric(topUp.Amount),
 		Type:             string(topUp.Type),
 		LcOrganizationID: topUp.LCOrganizationID,
 		LcCharge:         topUp.LCCharge,
 		ConfirmationUrl:  topUp.ConfirmationUrl,
+		Currency:         ToPGCurrency(topUp.Currency),
 	}
 
 	if topUp.CurrentToppedUpAt != nil {
@@ -246,7 +519,7 @@ func (r *PostgresqlPGX) UpsertTopUp(ctx context.Context, topUp ledger.TopUp) (*l
 		}
 	}
 
-	t, err := r.queries.UpsertTopUp(ctx, params)
+	t, err := r.q(ctx).UpsertTopUp(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ledger.ErrNotFound
@@ -257,15 +530,78 @@ func (r *PostgresqlPGX) UpsertTopUp(ctx context.Context, topUp ledger.TopUp) (*l
 	return t.ToLedgerTopUp()
 }
 
-func ToPGNumeric(n *float32) pgtype.Numeric {
-	if n == nil {
-		return pgtype.Numeric{}
+// WithTopUpLock holds a transaction scoped advisory lock while fn runs. The queries fn makes on this storage run in
+// the lock transaction, so a locked top up never needs a second connection from the pool.
+func (r *PostgresqlPGX) WithTopUpLock(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	return lock.WithTx(ctx, r, r.conn, "top_up:"+id, func(ctx context.Context, tx pgx.Tx) error {
+		return r.queries.WithTx(tx).LockTopUp(ctx, "top_up:"+id)
+	}, fn)
+}
+
+// q returns the queries of the lock transaction held in ctx, or of the connection outside of WithTopUpLock.
+func (r *PostgresqlPGX) q(ctx context.Context) *sqlc.Queries {
+	if tx, ok := lock.Conn[pgx.Tx](ctx, r); ok {
+		return r.queries.WithTx(tx)
+	}
+
+	return r.queries
+}
+
+// begin starts a transaction, or a savepoint of the lock transaction held in ctx.
+func (r *PostgresqlPGX) begin(ctx context.Context) (pgx.Tx, error) {
+	if tx, ok := lock.Conn[pgx.Tx](ctx, r); ok {
+		return tx.Begin(ctx)
 	}
 
-	v := pgtype.Numeric{}
-	_ = v.Scan(fmt.Sprintf("%f", *n))
+	return r.conn.Begin(ctx)
+}
+
+func ToPGNumeric(m ledger.Money) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(int64(m)), Exp: -3, Valid: true}
+}
+
+// createLedgerOperation stores the operation and its entries and updates the balance with queries, which must be in a
+// transaction.
+func createLedgerOperation(ctx context.Context, queries *sqlc.Queries, o ledger.Operation) error {
+	if err := queries.CreateLedgerOperation(ctx, sqlc.CreateLedgerOperationParams{
+		ID:               o.ID,
+		Amount:           ToPGNumeric(o.Amount),
+		LcOrganizationID: o.LCOrganizationID,
+		IsVoucher:        o.IsVoucher,
+		Payload:          o.Payload,
+		Currency:         ToPGCurrency(o.Currency),
+	}); err != nil {
+		return err
+	}
+	if err := queries.AddToOrganizationBalance(ctx, sqlc.AddToOrganizationBalanceParams{
+		LcOrganizationID: o.LCOrganizationID,
+		Currency:         ToPGCurrency(o.Currency),
+		Amount:           ToPGNumeric(o.Amount),
+	}); err != nil {
+		return err
+	}
+	for _, e := range o.Entries {
+		if err := queries.CreateLedgerEntry(ctx, sqlc.CreateLedgerEntryParams{
+			OperationID:      e.OperationID,
+			LcOrganizationID: e.LCOrganizationID,
+			Account:          string(e.Account),
+			Currency:         ToPGCurrency(e.Currency),
+			Amount:           ToPGNumeric(e.Amount),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ToPGCurrency stores the amounts without a currency in the default one.
+func ToPGCurrency(c ledger.Currency) string {
+	if c == "" {
+		return string(ledger.DefaultCurrency)
+	}
 
-	return v
+	return string(c)
 }
 
 func ToTopUps(dbTopUps []sqlc.LedgerTopUp) ([]ledger.TopUp, error) {
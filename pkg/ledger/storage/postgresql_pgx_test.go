@@ -3,7 +3,7 @@ package storage
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"math/big"
 	"testing"
 	"time"
 
@@ -29,15 +29,19 @@ func TestPostgresqlSQLC_CreateLedgerOperation(t *testing.T) {
 	t.Run("success positive", func(t *testing.T) {
 		id := "1"
 		lcoid := "lcOrganizationID"
-		amount := float32(3.14)
-		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		amount := ledger.Money(3140)
+		v := pgtype.Numeric{Int: big.NewInt(int64(amount)), Exp: -3, Valid: true}
 		payload := map[string]interface{}{"some": "field"}
 		jp, _ := json.Marshal(payload)
 
+		dbMock.ExpectBegin().Times(1)
 		dbMock.ExpectExec("INSERT INTO ledger_ledger").
-			WithArgs(id, v, lcoid, jp, true).
+			WithArgs(id, v, lcoid, jp, true, "USD").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_balances").
+			WithArgs(lcoid, "USD", v).
 			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectCommit().Times(1)
 
 		err := s.CreateLedgerOperation(context.Background(), ledger.Operation{
 			ID:               id,
@@ -52,15 +56,19 @@ func TestPostgresqlSQLC_CreateLedgerOperation(t *testing.T) {
 	t.Run("success negative", func(t *testing.T) {
 		id := "1"
 		lcoid := "lcOrganizationID"
-		amount := -float32(3.14)
-		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		amount := -ledger.Money(3140)
+		v := pgtype.Numeric{Int: big.NewInt(int64(amount)), Exp: -3, Valid: true}
 		payload := map[string]interface{}{"some": "field"}
 		jp, _ := json.Marshal(payload)
 
+		dbMock.ExpectBegin().Times(1)
 		dbMock.ExpectExec("INSERT INTO ledger_ledger").
-			WithArgs(id, v, lcoid, jp, false).
+			WithArgs(id, v, lcoid, jp, false, "USD").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_balances").
+			WithArgs(lcoid, "USD", v).
 			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectCommit().Times(1)
 
 		err := s.CreateLedgerOperation(context.Background(), ledger.Operation{
 			ID:               id,
@@ -75,14 +83,15 @@ func TestPostgresqlSQLC_CreateLedgerOperation(t *testing.T) {
 	t.Run("error", func(t *testing.T) {
 		id := "1"
 		lcoid := "lcOrganizationID"
-		amount := float32(3.14)
-		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		amount := ledger.Money(3140)
+		v := pgtype.Numeric{Int: big.NewInt(int64(amount)), Exp: -3, Valid: true}
 		payload := map[string]interface{}{"some": "field"}
 		jp, _ := json.Marshal(payload)
 
+		dbMock.ExpectBegin().Times(1)
 		dbMock.ExpectExec("INSERT INTO ledger_ledger").
-			WithArgs(id, v, lcoid, jp, false).Times(1).WillReturnError(assert.AnError)
+			WithArgs(id, v, lcoid, jp, false, "USD").Times(1).WillReturnError(assert.AnError)
+		dbMock.ExpectRollback().Times(1)
 
 		err := s.CreateLedgerOperation(context.Background(), ledger.Operation{
 			ID:               id,
@@ -96,17 +105,304 @@ func TestPostgresqlSQLC_CreateLedgerOperation(t *testing.T) {
 	})
 }
 
+func TestPostgresqlPGX_CreateLedgerOperationWithEntries(t *testing.T) {
+	lcoid := "lcOrganizationID"
+	amount := -ledger.Money(3140)
+	v := pgtype.Numeric{Int: big.NewInt(int64(amount)), Exp: -3, Valid: true}
+	operation := ledger.Operation{
+		ID:               "1",
+		LCOrganizationID: lcoid,
+		Amount:           amount,
+		Currency:         ledger.CurrencyUSD,
+		Entries: []ledger.Entry{
+			{OperationID: "1", LCOrganizationID: lcoid, Account: ledger.AccountCustomerWallet, Currency: ledger.CurrencyUSD, Amount: amount},
+			{OperationID: "1", LCOrganizationID: lcoid, Account: ledger.AccountRevenue, Currency: ledger.CurrencyUSD, Amount: -amount},
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_ledger").
+			WithArgs("1", v, lcoid, []byte(nil), false, "USD").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_balances").
+			WithArgs(lcoid, "USD", v).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_entries").
+			WithArgs("1", lcoid, "customer_wallet", "USD", v).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_entries").
+			WithArgs("1", lcoid, "revenue", "USD", pgtype.Numeric{Int: big.NewInt(int64(-amount)), Exp: -3, Valid: true}).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectCommit().Times(1)
+
+		err := s.CreateLedgerOperation(context.Background(), operation)
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error entry", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_ledger").
+			WithArgs("1", v, lcoid, []byte(nil), false, "USD").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_balances").
+			WithArgs(lcoid, "USD", v).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_entries").
+			WithArgs("1", lcoid, "customer_wallet", "USD", v).
+			Times(1).WillReturnError(assert.AnError)
+		dbMock.ExpectRollback().Times(1)
+
+		err := s.CreateLedgerOperation(context.Background(), operation)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error unbalanced", func(t *testing.T) {
+		unbalanced := operation
+		unbalanced.Entries = operation.Entries[:1]
+
+		err := s.CreateLedgerOperation(context.Background(), unbalanced)
+		assert.ErrorIs(t, err, ledger.ErrUnbalancedEntries)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_GetTrialBalance(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		wallet := pgtype.Numeric{}
+		_ = wallet.Scan(ledger.Money(-3140).String())
+		revenue := pgtype.Numeric{}
+		_ = revenue.Scan(ledger.Money(3140).String())
+		dbMock.ExpectQuery("GetTrialBalance :many SELECT account, currency, SUM").
+			WithArgs("lcOrganizationID").
+			WillReturnRows(
+				pgxmock.NewRows([]string{"account", "currency", "balance"}).
+					AddRow("customer_wallet", "USD", wallet).
+					AddRow("revenue", "USD", revenue)).Times(1)
+
+		balance, err := s.GetTrialBalance(context.Background(), "lcOrganizationID")
+		assert.NoError(t, err)
+		assert.Equal(t, ledger.TrialBalance{
+			{Account: ledger.AccountCustomerWallet, Currency: ledger.CurrencyUSD, Balance: -3140},
+			{Account: ledger.AccountRevenue, Currency: ledger.CurrencyUSD, Balance: 3140},
+		}, balance)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectQuery("GetTrialBalance :many SELECT account, currency, SUM").
+			WithArgs("").Times(1).
+			WillReturnError(assert.AnError)
+
+		balance, err := s.GetTrialBalance(context.Background(), "")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, balance)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_CreateLedgerOperationBalanceError(t *testing.T) {
+	lcoid := "lcOrganizationID"
+	amount := ledger.Money(3140)
+	v := pgtype.Numeric{Int: big.NewInt(int64(amount)), Exp: -3, Valid: true}
+
+	dbMock.ExpectBegin().Times(1)
+	dbMock.ExpectExec("INSERT INTO ledger_ledger").
+		WithArgs("1", v, lcoid, []byte(nil), false, "EUR").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+	dbMock.ExpectExec("INSERT INTO ledger_balances").
+		WithArgs(lcoid, "EUR", v).
+		Times(1).WillReturnError(assert.AnError)
+	dbMock.ExpectRollback().Times(1)
+
+	err := s.CreateLedgerOperation(context.Background(), ledger.Operation{
+		ID:               "1",
+		LCOrganizationID: lcoid,
+		Amount:           amount,
+		Currency:         ledger.CurrencyEUR,
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestPostgresqlPGX_CreateBalanceSnapshots(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("CreateBalanceSnapshots :execrows INSERT INTO ledger_balance_snapshots").
+			WillReturnResult(pgxmock.NewResult("INSERT", 3)).Times(1)
+		dbMock.ExpectExec("DeleteExpiredBalanceSnapshots :execrows DELETE FROM ledger_balance_snapshots").
+			WithArgs(int32(30)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 3)).Times(1)
+		dbMock.ExpectCommit().Times(1)
+
+		n, err := s.CreateBalanceSnapshots(context.Background(), 30)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("CreateBalanceSnapshots :execrows INSERT INTO ledger_balance_snapshots").
+			Times(1).WillReturnError(assert.AnError)
+		dbMock.ExpectRollback().Times(1)
+
+		_, err := s.CreateBalanceSnapshots(context.Background(), 30)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error deleting expired snapshots", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("CreateBalanceSnapshots :execrows INSERT INTO ledger_balance_snapshots").
+			WillReturnResult(pgxmock.NewResult("INSERT", 3)).Times(1)
+		dbMock.ExpectExec("DeleteExpiredBalanceSnapshots :execrows DELETE FROM ledger_balance_snapshots").
+			WithArgs(int32(30)).
+			Times(1).WillReturnError(assert.AnError)
+		dbMock.ExpectRollback().Times(1)
+
+		_, err := s.CreateBalanceSnapshots(context.Background(), 30)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_GetBalanceDrifts(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		balance := pgtype.Numeric{}
+		_ = balance.Scan(ledger.Money(3140).String())
+		sum := pgtype.Numeric{}
+		_ = sum.Scan(ledger.Money(2140).String())
+		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
+		dbMock.ExpectQuery("GetBalanceDrifts :many SELECT lc_organization_id, currency, balance, operations_sum, created_at").
+			WillReturnRows(
+				pgxmock.NewRows([]string{"lc_organization_id", "currency", "balance", "operations_sum", "created_at"}).
+					AddRow("lcOrganizationID", "USD", balance, sum, pgtype.Timestamptz{Time: someDate, Valid: true})).Times(1)
+
+		drifts, err := s.GetBalanceDrifts(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []ledger.BalanceDrift{{
+			LCOrganizationID: "lcOrganizationID",
+			Currency:         ledger.CurrencyUSD,
+			Balance:          3140,
+			OperationsSum:    2140,
+			SnapshotAt:       someDate,
+		}}, drifts)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectQuery("GetBalanceDrifts :many SELECT lc_organization_id, currency, balance, operations_sum, created_at").
+			Times(1).WillReturnError(assert.AnError)
+
+		drifts, err := s.GetBalanceDrifts(context.Background())
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, drifts)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_RepairBalance(t *testing.T) {
+	before := pgtype.Numeric{}
+	_ = before.Scan(ledger.Money(3140).String())
+	after := pgtype.Numeric{}
+	_ = after.Scan(ledger.Money(2140).String())
+
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("InitOrganizationBalance :exec INSERT INTO ledger_balances").
+			WithArgs("lcOrganizationID", "USD").
+			WillReturnResult(pgxmock.NewResult("INSERT", 0)).Times(1)
+		dbMock.ExpectQuery("LockOrganizationBalance :one SELECT amount FROM ledger_balances").
+			WithArgs("lcOrganizationID", "USD").
+			WillReturnRows(pgxmock.NewRows([]string{"amount"}).AddRow(before)).Times(1)
+		dbMock.ExpectQuery("RepairOrganizationBalance :one INSERT INTO ledger_balances").
+			WithArgs("lcOrganizationID", "USD").
+			WillReturnRows(pgxmock.NewRows([]string{"amount"}).AddRow(after)).Times(1)
+		dbMock.ExpectCommit().Times(1)
+
+		repair, err := s.RepairBalance(context.Background(), "lcOrganizationID", ledger.CurrencyUSD)
+		assert.NoError(t, err)
+		assert.Equal(t, &ledger.BalanceRepair{
+			LCOrganizationID: "lcOrganizationID",
+			Currency:         ledger.CurrencyUSD,
+			Before:           3140,
+			After:            2140,
+		}, repair)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("missing balance", func(t *testing.T) {
+		zero := pgtype.Numeric{}
+		_ = zero.Scan(ledger.Money(0).String())
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("InitOrganizationBalance :exec INSERT INTO ledger_balances").
+			WithArgs("lcOrganizationID", "USD").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectQuery("LockOrganizationBalance :one SELECT amount FROM ledger_balances").
+			WithArgs("lcOrganizationID", "USD").
+			WillReturnRows(pgxmock.NewRows([]string{"amount"}).AddRow(zero)).Times(1)
+		dbMock.ExpectQuery("RepairOrganizationBalance :one INSERT INTO ledger_balances").
+			WithArgs("lcOrganizationID", "USD").
+			WillReturnRows(pgxmock.NewRows([]string{"amount"}).AddRow(after)).Times(1)
+		dbMock.ExpectCommit().Times(1)
+
+		repair, err := s.RepairBalance(context.Background(), "lcOrganizationID", ledger.CurrencyUSD)
+		assert.NoError(t, err)
+		assert.Equal(t, &ledger.BalanceRepair{
+			LCOrganizationID: "lcOrganizationID",
+			Currency:         ledger.CurrencyUSD,
+			After:            2140,
+		}, repair)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error creating missing balance", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("InitOrganizationBalance :exec INSERT INTO ledger_balances").
+			WithArgs("lcOrganizationID", "USD").
+			Times(1).WillReturnError(assert.AnError)
+		dbMock.ExpectRollback().Times(1)
+
+		repair, err := s.RepairBalance(context.Background(), "lcOrganizationID", ledger.CurrencyUSD)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, repair)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("InitOrganizationBalance :exec INSERT INTO ledger_balances").
+			WithArgs("lcOrganizationID", "USD").
+			WillReturnResult(pgxmock.NewResult("INSERT", 0)).Times(1)
+		dbMock.ExpectQuery("LockOrganizationBalance :one SELECT amount FROM ledger_balances").
+			WithArgs("lcOrganizationID", "USD").
+			WillReturnRows(pgxmock.NewRows([]string{"amount"}).AddRow(before)).Times(1)
+		dbMock.ExpectQuery("RepairOrganizationBalance :one INSERT INTO ledger_balances").
+			WithArgs("lcOrganizationID", "USD").
+			Times(1).WillReturnError(assert.AnError)
+		dbMock.ExpectRollback().Times(1)
+
+		repair, err := s.RepairBalance(context.Background(), "lcOrganizationID", ledger.CurrencyUSD)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, repair)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
 func TestPostgresqlSQLC_GetLedgerOperations(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		_ = v.Scan(amount.String())
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
-		dbMock.ExpectQuery("GetLedgerOperationsByOrganizationID :many SELECT id, amount, lc_organization_id, payload, is_voucher, created_at").
+		dbMock.ExpectQuery("GetLedgerOperationsByOrganizationID :many SELECT id, amount, lc_organization_id, payload, is_voucher, created_at, currency").
 			WithArgs("lcOrganizationID", false).
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "payload", "is_voucher", "created_at"}).
-					AddRow("1", v, "lcOrganizationID", []byte("{}"), false, pgtype.Timestamptz{Time: someDate, Valid: true})).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "payload", "is_voucher", "created_at", "currency"}).
+					AddRow("1", v, "lcOrganizationID", []byte("{}"), false, pgtype.Timestamptz{Time: someDate, Valid: true}, "USD")).Times(1)
 
 		c, err := s.GetLedgerOperations(context.Background(), "lcOrganizationID", false)
 		assert.NoError(t, err)
@@ -121,15 +417,15 @@ func TestPostgresqlSQLC_GetLedgerOperations(t *testing.T) {
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 	t.Run("success voucher", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		_ = v.Scan(amount.String())
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
-		dbMock.ExpectQuery("GetLedgerOperationsByOrganizationID :many SELECT id, amount, lc_organization_id, payload, is_voucher, created_at").
+		dbMock.ExpectQuery("GetLedgerOperationsByOrganizationID :many SELECT id, amount, lc_organization_id, payload, is_voucher, created_at, currency").
 			WithArgs("lcOrganizationID", true).
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "payload", "is_voucher", "created_at"}).
-					AddRow("1", v, "lcOrganizationID", []byte("{}"), true, pgtype.Timestamptz{Time: someDate, Valid: true})).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "payload", "is_voucher", "created_at", "currency"}).
+					AddRow("1", v, "lcOrganizationID", []byte("{}"), true, pgtype.Timestamptz{Time: someDate, Valid: true}, "USD")).Times(1)
 
 		c, err := s.GetLedgerOperations(context.Background(), "lcOrganizationID", true)
 		assert.NoError(t, err)
@@ -144,13 +440,13 @@ func TestPostgresqlSQLC_GetLedgerOperations(t *testing.T) {
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 	t.Run("no records", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetLedgerOperationsByOrganizationID :many SELECT id, amount, lc_organization_id, payload, is_voucher, created_at").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetLedgerOperationsByOrganizationID :many SELECT id, amount, lc_organization_id, payload, is_voucher, created_at, currency").
 			WithArgs("lcOrganizationID", false).
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "payload", "is_voucher", "created_at"})).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "payload", "is_voucher", "created_at", "currency"})).Times(1)
 
 		c, err := s.GetLedgerOperations(context.Background(), "lcOrganizationID", false)
 		assert.NoError(t, err)
@@ -159,10 +455,10 @@ func TestPostgresqlSQLC_GetLedgerOperations(t *testing.T) {
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 	t.Run("error", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetLedgerOperationsByOrganizationID :many SELECT id, amount, lc_organization_id, payload, is_voucher, created_at").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetLedgerOperationsByOrganizationID :many SELECT id, amount, lc_organization_id, payload, is_voucher, created_at, currency").
 			WithArgs("lcOrganizationID", false).Times(1).WillReturnError(assert.AnError)
 
 		c, err := s.GetLedgerOperations(context.Background(), "lcOrganizationID", false)
@@ -177,15 +473,15 @@ func TestPostgresqlSQLC_GetLedgerOperation(t *testing.T) {
 	id := "1"
 	lcoid := "lcOrganizationID"
 	t.Run("success", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		_ = v.Scan(amount.String())
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
-		dbMock.ExpectQuery("GetLedgerOperation :one SELECT id, amount, lc_organization_id, payload, is_voucher, created_at").
+		dbMock.ExpectQuery("GetLedgerOperation :one SELECT id, amount, lc_organization_id, payload, is_voucher, created_at, currency").
 			WithArgs(lcoid, id).
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "payload", "is_voucher", "created_at"}).
-					AddRow(id, v, lcoid, []byte("{}"), false, pgtype.Timestamptz{Time: someDate, Valid: true})).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "payload", "is_voucher", "created_at", "currency"}).
+					AddRow(id, v, lcoid, []byte("{}"), false, pgtype.Timestamptz{Time: someDate, Valid: true}, "USD")).Times(1)
 
 		c, err := s.GetLedgerOperation(context.Background(), ledger.GetLedgerOperationParams{
 			ID:             id,
@@ -202,13 +498,13 @@ func TestPostgresqlSQLC_GetLedgerOperation(t *testing.T) {
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 	t.Run("no records", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetLedgerOperation :one SELECT id, amount, lc_organization_id, payload, is_voucher, created_at").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetLedgerOperation :one SELECT id, amount, lc_organization_id, payload, is_voucher, created_at, currency").
 			WithArgs(lcoid, id).
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "payload", "is_voucher", "created_at"})).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "payload", "is_voucher", "created_at", "currency"})).Times(1)
 
 		c, err := s.GetLedgerOperation(context.Background(), ledger.GetLedgerOperationParams{
 			ID:             id,
@@ -220,10 +516,10 @@ func TestPostgresqlSQLC_GetLedgerOperation(t *testing.T) {
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 	t.Run("error", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetLedgerOperation :one SELECT id, amount, lc_organization_id, payload, is_voucher, created_at").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetLedgerOperation :one SELECT id, amount, lc_organization_id, payload, is_voucher, created_at, currency").
 			WithArgs(lcoid, id).
 			Times(1).WillReturnError(assert.AnError)
 		c, err := s.GetLedgerOperation(context.Background(), ledger.GetLedgerOperationParams{
@@ -242,20 +538,19 @@ func TestPostgresqlSQLC_UpsertTopUp(t *testing.T) {
 		emptyRawPayload, _ := json.Marshal(json.RawMessage("{}"))
 		id := "1"
 		lcoid := "lcOrganizationID"
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		status := ledger.TopUpStatusPending
 		topUpType := ledger.TopUpTypeRecurrent
-		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		v := pgtype.Numeric{Int: big.NewInt(int64(amount)), Exp: -3, Valid: true}
 		url := "some_url"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		someDate2, _ := time.Parse(time.DateTime, "2025-06-14 12:31:56")
 
 		dbMock.ExpectQuery("UpsertTopUp :one INSERT INTO ledger_top_ups").
-			WithArgs(id, string(status), v, string(topUpType), lcoid, emptyRawPayload, url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}).
+			WithArgs(id, string(status), v, string(topUpType), lcoid, emptyRawPayload, url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, "USD").
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at"}).
-					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil)).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at", "currency"}).
+					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil, "USD")).Times(1)
 
 		ut, err := s.UpsertTopUp(context.Background(), ledger.TopUp{
 			ID:                id,
@@ -281,17 +576,16 @@ func TestPostgresqlSQLC_UpsertTopUp(t *testing.T) {
 		emptyRawPayload, _ := json.Marshal(json.RawMessage("{}"))
 		id := "1"
 		lcoid := "lcOrganizationID"
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		status := ledger.TopUpStatusPending
 		topUpType := ledger.TopUpTypeRecurrent
-		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		v := pgtype.Numeric{Int: big.NewInt(int64(amount)), Exp: -3, Valid: true}
 		url := "some_url"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		someDate2, _ := time.Parse(time.DateTime, "2025-06-14 12:31:56")
 
 		dbMock.ExpectQuery("UpsertTopUp :one INSERT INTO ledger_top_ups").
-			WithArgs(id, string(status), v, string(topUpType), lcoid, emptyRawPayload, url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}).
+			WithArgs(id, string(status), v, string(topUpType), lcoid, emptyRawPayload, url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, "USD").
 			Times(1).WillReturnError(pgx.ErrNoRows)
 
 		_, err := s.UpsertTopUp(context.Background(), ledger.TopUp{
@@ -312,44 +606,113 @@ func TestPostgresqlSQLC_UpsertTopUp(t *testing.T) {
 
 func TestPostgresqlSQLC_GetBalance(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(3.14)
-		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetOrganizationBalance :one SELECT").
+		usd := pgtype.Numeric{}
+		_ = usd.Scan(ledger.Money(3140).String())
+		credits := pgtype.Numeric{}
+		_ = credits.Scan(ledger.Money(-100).String())
+		dbMock.ExpectQuery("GetOrganizationBalances :many SELECT currency, amount FROM ledger_balances").
 			WithArgs("lc_organization_id").
 			WillReturnRows(
-				pgxmock.NewRows([]string{"amount"}).
-					AddRow(v)).Times(1)
+				pgxmock.NewRows([]string{"currency", "amount"}).
+					AddRow("USD", usd).
+					AddRow("credits", credits)).Times(1)
 
-		balance, err := s.GetBalance(context.Background(), "lc_organization_id")
+		balances, err := s.GetBalance(context.Background(), "lc_organization_id")
 		assert.NoError(t, err)
-		assert.Equal(t, amount, balance)
+		assert.Equal(t, ledger.Balances{ledger.CurrencyUSD: 3140, ledger.CurrencyCredits: -100}, balances)
 
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 
 	t.Run("no rows", func(t *testing.T) {
-		dbMock.ExpectQuery("GetOrganizationBalance :one SELECT").
-			WithArgs("lc_organization_id").Times(1).
-			WillReturnError(pgx.ErrNoRows)
+		dbMock.ExpectQuery("GetOrganizationBalances :many SELECT currency, amount FROM ledger_balances").
+			WithArgs("lc_organization_id").
+			WillReturnRows(pgxmock.NewRows([]string{"currency", "amount"})).Times(1)
 
-		balance, err := s.GetBalance(context.Background(), "lc_organization_id")
-		assert.ErrorIs(t, err, pgx.ErrNoRows)
-		assert.Equal(t, float32(0), balance)
+		balances, err := s.GetBalance(context.Background(), "lc_organization_id")
+		assert.NoError(t, err)
+		assert.Empty(t, balances)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 
 	t.Run("error", func(t *testing.T) {
-		amount := float32(3.14)
-		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetOrganizationBalance :one SELECT").
+		dbMock.ExpectQuery("GetOrganizationBalances :many SELECT currency, amount FROM ledger_balances").
 			WithArgs("lc_organization_id").Times(1).
 			WillReturnError(assert.AnError)
 
-		balance, err := s.GetBalance(context.Background(), "lc_organization_id")
+		balances, err := s.GetBalance(context.Background(), "lc_organization_id")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, balances)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_CreateConversion(t *testing.T) {
+	lcoid := "lcOrganizationID"
+	payload := json.RawMessage(`{"id":"1"}`)
+	params := ledger.CreateConversionParams{
+		Conversion: ledger.Conversion{
+			ID:               "1",
+			LCOrganizationID: lcoid,
+			FromCurrency:     ledger.CurrencyUSD,
+			FromAmount:       10000,
+			ToCurrency:       ledger.CurrencyEUR,
+			ToAmount:         9234,
+			Rate:             923400,
+		},
+		Operations: []ledger.Operation{
+			{ID: "1-from", LCOrganizationID: lcoid, Amount: -10000, Currency: ledger.CurrencyUSD, Payload: payload},
+			{ID: "1-to", LCOrganizationID: lcoid, Amount: 9234, Currency: ledger.CurrencyEUR, Payload: payload},
+		},
+	}
+	from := pgtype.Numeric{Int: big.NewInt(10000), Exp: -3, Valid: true}
+	to := pgtype.Numeric{Int: big.NewInt(9234), Exp: -3, Valid: true}
+	rate := pgtype.Numeric{Int: big.NewInt(923400), Exp: -6, Valid: true}
+
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_conversions").
+			WithArgs("1", lcoid, "USD", from, "EUR", to, rate).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_ledger").
+			WithArgs("1-from", pgtype.Numeric{Int: big.NewInt(-10000), Exp: -3, Valid: true}, lcoid, []byte(payload), false, "USD").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_balances").
+			WithArgs(lcoid, "USD", pgtype.Numeric{Int: big.NewInt(-10000), Exp: -3, Valid: true}).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_ledger").
+			WithArgs("1-to", to, lcoid, []byte(payload), false, "EUR").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_balances").
+			WithArgs(lcoid, "EUR", to).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectCommit().Times(1)
+
+		err := s.CreateConversion(context.Background(), params)
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error operation", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_conversions").
+			WithArgs("1", lcoid, "USD", from, "EUR", to, rate).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+		dbMock.ExpectExec("INSERT INTO ledger_ledger").
+			WithArgs("1-from", pgtype.Numeric{Int: big.NewInt(-10000), Exp: -3, Valid: true}, lcoid, []byte(payload), false, "USD").
+			Times(1).WillReturnError(assert.AnError)
+		dbMock.ExpectRollback().Times(1)
+
+		err := s.CreateConversion(context.Background(), params)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error begin", func(t *testing.T) {
+		dbMock.ExpectBegin().Times(1).WillReturnError(assert.AnError)
+
+		err := s.CreateConversion(context.Background(), params)
 		assert.ErrorIs(t, err, assert.AnError)
-		assert.Equal(t, float32(0), balance)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 }
@@ -358,19 +721,19 @@ func TestPostgresqlPGX_GetTopUpsByIDAndOrganizationID(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		id := "id"
 		lcoid := "lcOrganizationID"
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		_ = v.Scan(amount.String())
 		url := "some_url"
 		status := ledger.TopUpStatusActive
 		topUpType := ledger.TopUpTypeDirect
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		someDate2, _ := time.Parse(time.DateTime, "2025-06-14 12:31:56")
-		dbMock.ExpectQuery("GetTopUpByIDAndOrganizationID :one SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at").
+		dbMock.ExpectQuery("GetTopUpByIDAndOrganizationID :one SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency").
 			WithArgs(id, lcoid).
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at"}).
-					AddRow(id, v, lcoid, string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil)).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at", "currency"}).
+					AddRow(id, v, lcoid, string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil, "USD")).Times(1)
 
 		topUp, err := s.GetTopUpByIDAndOrganizationID(context.Background(), lcoid, id)
 		assert.NoError(t, err)
@@ -385,7 +748,7 @@ func TestPostgresqlPGX_GetTopUpsByIDAndOrganizationID(t *testing.T) {
 	t.Run("no top up error", func(t *testing.T) {
 		id := "id"
 		lcoid := "lcOrganizationID"
-		dbMock.ExpectQuery("GetTopUpByIDAndOrganizationID :one SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at").
+		dbMock.ExpectQuery("GetTopUpByIDAndOrganizationID :one SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency").
 			WithArgs(id, lcoid).Times(1).
 			WillReturnError(pgx.ErrNoRows)
 
@@ -398,7 +761,7 @@ func TestPostgresqlPGX_GetTopUpsByIDAndOrganizationID(t *testing.T) {
 	t.Run("error", func(t *testing.T) {
 		id := "id"
 		lcoid := "lcOrganizationID"
-		dbMock.ExpectQuery("GetTopUpByIDAndOrganizationID :one SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at").
+		dbMock.ExpectQuery("GetTopUpByIDAndOrganizationID :one SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency").
 			WithArgs(id, lcoid).Times(1).
 			WillReturnError(assert.AnError)
 
@@ -410,21 +773,48 @@ func TestPostgresqlPGX_GetTopUpsByIDAndOrganizationID(t *testing.T) {
 	})
 }
 
+func TestPostgresqlPGX_GetTopUps(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		amount := ledger.Money(3140)
+		v := pgtype.Numeric{}
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetTopUps :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency FROM ledger_top_ups ORDER BY created_at").
+			WillReturnRows(
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at", "currency"}).
+					AddRow("1", v, "lcOrganizationID", string(ledger.TopUpTypeDirect), string(ledger.TopUpStatusSuccess), []byte("{}"), "", nil, nil, nil, nil, "USD")).Times(1)
+
+		c, err := s.GetTopUps(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, c, 1)
+		assert.Equal(t, "1", c[0].ID)
+		assert.Equal(t, amount, c[0].Amount)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectQuery("GetTopUps :many").Times(1).WillReturnError(assert.AnError)
+
+		_, err := s.GetTopUps(context.Background())
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
 func TestPostgresqlSQLC_GetTopUpsByOrganizationID(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		status := ledger.TopUpStatusActive
 		topUpType := ledger.TopUpTypeDirect
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		_ = v.Scan(amount.String())
 		url := "some_url"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		someDate2, _ := time.Parse(time.DateTime, "2025-06-14 12:31:56")
-		dbMock.ExpectQuery("GetTopUpsByOrganizationID :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at FROM ledger_top_ups").
+		dbMock.ExpectQuery("GetTopUpsByOrganizationID :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency FROM ledger_top_ups").
 			WithArgs("lcOrganizationID").
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at"}).
-					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil)).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at", "currency"}).
+					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil, "USD")).Times(1)
 
 		c, err := s.GetTopUpsByOrganizationID(context.Background(), "lcOrganizationID")
 		assert.NoError(t, err)
@@ -443,10 +833,10 @@ func TestPostgresqlSQLC_GetTopUpsByOrganizationID(t *testing.T) {
 	})
 
 	t.Run("no rows", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at FROM ledger_top_ups WHERE lc_organization_id").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency FROM ledger_top_ups WHERE lc_organization_id").
 			WithArgs("lcOrganizationID").Times(1).
 			WillReturnError(pgx.ErrNoRows)
 
@@ -457,10 +847,10 @@ func TestPostgresqlSQLC_GetTopUpsByOrganizationID(t *testing.T) {
 	})
 
 	t.Run("error", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at FROM ledger_top_ups WHERE lc_organization_id").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency FROM ledger_top_ups WHERE lc_organization_id").
 			WithArgs("lcOrganizationID").Times(1).
 			WillReturnError(assert.AnError)
 
@@ -473,19 +863,19 @@ func TestPostgresqlSQLC_GetTopUpsByOrganizationID(t *testing.T) {
 
 func TestPostgresqlPGX_GetTopUpsByTypeWhereStatusNotIn(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		status := ledger.TopUpStatusActive
 		topUpType := ledger.TopUpTypeDirect
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		_ = v.Scan(amount.String())
 		url := "some_url"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		someDate2, _ := time.Parse(time.DateTime, "2025-06-14 12:31:56")
-		dbMock.ExpectQuery("GetTopUpsByTypeWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at").
+		dbMock.ExpectQuery("GetTopUpsByTypeWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency").
 			WithArgs(string(topUpType), []string{string(ledger.TopUpStatusCancelled), string(ledger.TopUpStatusFailed)}).
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at"}).
-					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil)).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at", "currency"}).
+					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil, "USD")).Times(1)
 
 		topUps, err := s.GetTopUpsByTypeWhereStatusNotIn(context.Background(), ledger.GetTopUpsByTypeWhereStatusNotInParams{
 			Type:     ledger.TopUpTypeDirect,
@@ -507,11 +897,11 @@ func TestPostgresqlPGX_GetTopUpsByTypeWhereStatusNotIn(t *testing.T) {
 	})
 
 	t.Run("no rows", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		topUpType := ledger.TopUpTypeDirect
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetTopUpsByTypeWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetTopUpsByTypeWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency").
 			WithArgs(string(topUpType), []string{string(ledger.TopUpStatusCancelled), string(ledger.TopUpStatusFailed)}).Times(1).
 			WillReturnError(pgx.ErrNoRows)
 		topUps, err := s.GetTopUpsByTypeWhereStatusNotIn(context.Background(), ledger.GetTopUpsByTypeWhereStatusNotInParams{
@@ -524,11 +914,11 @@ func TestPostgresqlPGX_GetTopUpsByTypeWhereStatusNotIn(t *testing.T) {
 	})
 
 	t.Run("error", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		topUpType := ledger.TopUpTypeDirect
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetTopUpsByTypeWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetTopUpsByTypeWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency").
 			WithArgs(string(topUpType), []string{string(ledger.TopUpStatusCancelled), string(ledger.TopUpStatusFailed)}).Times(1).
 			WillReturnError(assert.AnError)
 		topUps, err := s.GetTopUpsByTypeWhereStatusNotIn(context.Background(), ledger.GetTopUpsByTypeWhereStatusNotInParams{
@@ -543,19 +933,19 @@ func TestPostgresqlPGX_GetTopUpsByTypeWhereStatusNotIn(t *testing.T) {
 
 func TestPostgresqlPGX_GetRecurrentTopUpsWhereStatusNotIn(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		status := ledger.TopUpStatusActive
 		topUpType := ledger.TopUpTypeRecurrent
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		_ = v.Scan(amount.String())
 		url := "some_url"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		someDate2, _ := time.Parse(time.DateTime, "2025-06-14 12:31:56")
-		dbMock.ExpectQuery("GetRecurrentTopUpsWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at").
+		dbMock.ExpectQuery("GetRecurrentTopUpsWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency").
 			WithArgs([]string{string(ledger.TopUpStatusCancelled), string(ledger.TopUpStatusFailed)}).
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at"}).
-					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil)).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at", "currency"}).
+					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil, "USD")).Times(1)
 
 		topUps, err := s.GetRecurrentTopUpsWhereStatusNotIn(context.Background(), []ledger.TopUpStatus{ledger.TopUpStatusCancelled, ledger.TopUpStatusFailed})
 		assert.NoError(t, err)
@@ -574,10 +964,10 @@ func TestPostgresqlPGX_GetRecurrentTopUpsWhereStatusNotIn(t *testing.T) {
 	})
 
 	t.Run("no rows", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetRecurrentTopUpsWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetRecurrentTopUpsWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency").
 			WithArgs([]string{string(ledger.TopUpStatusCancelled), string(ledger.TopUpStatusFailed)}).Times(1).
 			WillReturnError(pgx.ErrNoRows)
 		topUps, err := s.GetRecurrentTopUpsWhereStatusNotIn(context.Background(), []ledger.TopUpStatus{ledger.TopUpStatusCancelled, ledger.TopUpStatusFailed})
@@ -587,10 +977,10 @@ func TestPostgresqlPGX_GetRecurrentTopUpsWhereStatusNotIn(t *testing.T) {
 	})
 
 	t.Run("error", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetRecurrentTopUpsWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetRecurrentTopUpsWhereStatusNotIn :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency").
 			WithArgs([]string{string(ledger.TopUpStatusCancelled), string(ledger.TopUpStatusFailed)}).Times(1).
 			WillReturnError(assert.AnError)
 		topUps, err := s.GetRecurrentTopUpsWhereStatusNotIn(context.Background(), []ledger.TopUpStatus{ledger.TopUpStatusCancelled, ledger.TopUpStatusFailed})
@@ -602,19 +992,19 @@ func TestPostgresqlPGX_GetRecurrentTopUpsWhereStatusNotIn(t *testing.T) {
 
 func TestPostgresqlSQLC_GetTopUpsByOrganizationIDAndStatus(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		status := ledger.TopUpStatusActive
 		topUpType := ledger.TopUpTypeDirect
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		_ = v.Scan(amount.String())
 		url := "some_url"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		someDate2, _ := time.Parse(time.DateTime, "2025-06-14 12:31:56")
-		dbMock.ExpectQuery("GetTopUpsByOrganizationIDAndStatus :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at FROM ledger_top_ups WHERE lc_organization_id").
+		dbMock.ExpectQuery("GetTopUpsByOrganizationIDAndStatus :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency FROM ledger_top_ups WHERE lc_organization_id").
 			WithArgs("lcOrganizationID", string(status)).
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at"}).
-					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil)).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at", "currency"}).
+					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil, "USD")).Times(1)
 
 		c, err := s.GetTopUpsByOrganizationIDAndStatus(context.Background(), "lcOrganizationID", status)
 		assert.NoError(t, err)
@@ -633,11 +1023,11 @@ func TestPostgresqlSQLC_GetTopUpsByOrganizationIDAndStatus(t *testing.T) {
 	})
 
 	t.Run("no rows", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		status := ledger.TopUpStatusActive
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetTopUpsByOrganizationIDAndStatus :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at FROM ledger_top_ups WHERE lc_organization_id").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetTopUpsByOrganizationIDAndStatus :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency FROM ledger_top_ups WHERE lc_organization_id").
 			WithArgs("lcOrganizationID", string(status)).Times(1).
 			WillReturnError(pgx.ErrNoRows)
 
@@ -648,11 +1038,11 @@ func TestPostgresqlSQLC_GetTopUpsByOrganizationIDAndStatus(t *testing.T) {
 	})
 
 	t.Run("error", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		status := ledger.TopUpStatusActive
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
-		dbMock.ExpectQuery("GetTopUpsByOrganizationIDAndStatus :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at FROM ledger_top_ups WHERE lc_organization_id").
+		_ = v.Scan(amount.String())
+		dbMock.ExpectQuery("GetTopUpsByOrganizationIDAndStatus :many SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency FROM ledger_top_ups WHERE lc_organization_id").
 			WithArgs("lcOrganizationID", string(status)).Times(1).
 			WillReturnError(assert.AnError)
 
@@ -706,19 +1096,19 @@ func TestPostgresqlSQLC_UpdateTopUpStatus(t *testing.T) {
 
 func TestPostgresqlSQLC_GetTopUpByIDAndType(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		amount := float32(3.14)
+		amount := ledger.Money(3140)
 		status := ledger.TopUpStatusActive
 		topUpType := ledger.TopUpTypeDirect
 		v := pgtype.Numeric{}
-		_ = v.Scan(fmt.Sprintf("%f", amount))
+		_ = v.Scan(amount.String())
 		url := "some_url"
 		someDate, _ := time.Parse(time.DateTime, "2025-03-14 12:31:56")
 		someDate2, _ := time.Parse(time.DateTime, "2025-06-14 12:31:56")
-		dbMock.ExpectQuery("SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at FROM ledger_top_ups WHERE id").
+		dbMock.ExpectQuery("SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency FROM ledger_top_ups WHERE id").
 			WithArgs("1", string(topUpType), string(ledger.TopUpStatusCancelled)).
 			WillReturnRows(
-				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at"}).
-					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil)).Times(1)
+				pgxmock.NewRows([]string{"id", "amount", "lc_organization_id", "type", "status", "lc_charge", "confirmation_url", "current_topped_up_at", "next_top_up_at", "created_at", "updated_at", "currency"}).
+					AddRow("1", v, "lcOrganizationID", string(topUpType), string(status), []byte("{}"), url, pgtype.Timestamptz{Time: someDate, Valid: true}, pgtype.Timestamptz{Time: someDate2, Valid: true}, nil, nil, "USD")).Times(1)
 
 		c, err := s.GetTopUpByIDAndType(context.Background(), ledger.GetTopUpByIDAndTypeParams{
 			ID:   "1",
@@ -740,7 +1130,7 @@ func TestPostgresqlSQLC_GetTopUpByIDAndType(t *testing.T) {
 
 	t.Run("no rows", func(t *testing.T) {
 		topUpType := ledger.TopUpTypeDirect
-		dbMock.ExpectQuery("SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at FROM ledger_top_ups WHERE id").
+		dbMock.ExpectQuery("SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency FROM ledger_top_ups WHERE id").
 			WithArgs("1", string(topUpType), string(ledger.TopUpStatusCancelled)).Times(1).
 			WillReturnError(pgx.ErrNoRows)
 
@@ -755,7 +1145,7 @@ func TestPostgresqlSQLC_GetTopUpByIDAndType(t *testing.T) {
 
 	t.Run("error", func(t *testing.T) {
 		topUpType := ledger.TopUpTypeDirect
-		dbMock.ExpectQuery("SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at FROM ledger_top_ups WHERE id").
+		dbMock.ExpectQuery("SELECT id, amount, lc_organization_id, type, status, lc_charge, confirmation_url, current_topped_up_at, next_top_up_at, created_at, updated_at, currency FROM ledger_top_ups WHERE id").
 			WithArgs("1", string(topUpType), string(ledger.TopUpStatusCancelled)).Times(1).
 			WillReturnError(assert.AnError)
 
@@ -780,7 +1170,7 @@ func TestPostgresqlSQLC_CreateEvent(t *testing.T) {
 			WithArgs(id, lcoid, string(eventType), string(action), emptyRawPayload, pgtype.Text{
 				String: em,
 				Valid:  true,
-			}).
+			}, "4bf92f3577b34da6a3ce929d0e0e4736", "correlation", "parent").
 			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
 
 		err := s.CreateEvent(context.Background(), events.Event{
@@ -790,6 +1180,9 @@ func TestPostgresqlSQLC_CreateEvent(t *testing.T) {
 			Action:           action,
 			Payload:          json.RawMessage("{}"),
 			Error:            em,
+			TraceID:          "4bf92f3577b34da6a3ce929d0e0e4736",
+			CorrelationID:    "correlation",
+			ParentID:         "parent",
 		})
 		assert.NoError(t, err)
 		assert.NoError(t, dbMock.ExpectationsWereMet())
@@ -806,7 +1199,7 @@ func TestPostgresqlSQLC_CreateEvent(t *testing.T) {
 			WithArgs(id, lcoid, string(eventType), string(action), emptyRawPayload, pgtype.Text{
 				String: em,
 				Valid:  true,
-			}).Times(1).
+			}, "", "", "").Times(1).
 			WillReturnError(assert.AnError)
 
 		err := s.CreateEvent(context.Background(), events.Event{
@@ -820,3 +1213,192 @@ func TestPostgresqlSQLC_CreateEvent(t *testing.T) {
 		assert.NoError(t, dbMock.ExpectationsWereMet())
 	})
 }
+
+func TestPostgresqlSQLC_WithTopUpLock(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs("top_up:1").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1)).Times(1)
+		dbMock.ExpectCommit()
+
+		calls := 0
+		err := s.WithTopUpLock(context.Background(), "1", func(ctx context.Context) error {
+			calls++
+			return s.WithTopUpLock(ctx, "1", func(ctx context.Context) error {
+				calls++
+				return nil
+			})
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("fn error", func(t *testing.T) {
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs("top_up:1").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1)).Times(1)
+		// the writes fn made before failing are kept
+		dbMock.ExpectCommit()
+
+		err := s.WithTopUpLock(context.Background(), "1", func(ctx context.Context) error {
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("transactions of fn are savepoints of the lock transaction", func(t *testing.T) {
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs("top_up:1").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1)).Times(1)
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec("CreateBalanceSnapshots :execrows INSERT INTO ledger_balance_snapshots").
+			WillReturnResult(pgxmock.NewResult("INSERT", 3)).Times(1)
+		dbMock.ExpectExec("DeleteExpiredBalanceSnapshots :execrows DELETE FROM ledger_balance_snapshots").
+			WithArgs(int32(30)).
+			Times(1).WillReturnError(assert.AnError)
+		dbMock.ExpectRollback()
+		dbMock.ExpectCommit()
+
+		err := s.WithTopUpLock(context.Background(), "1", func(ctx context.Context) error {
+			_, err := s.CreateBalanceSnapshots(ctx, 30)
+			return err
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_ListEvents(t *testing.T) {
+	createdAt := time.Date(2025, 4, 2, 15, 4, 5, 0, time.UTC)
+	columns := []string{"id", "lc_organization_id", "type", "action", "payload", "error", "created_at", "trace_id", "correlation_id", "parent_id"}
+
+	t.Run("success", func(t *testing.T) {
+		from := createdAt.Add(-time.Hour)
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id FROM ledger_events").
+			WithArgs("lcOrganizationID", "corr", "", string(events.EventTypeError), true, pgtype.Timestamptz{Time: from, Valid: true}, pgtype.Timestamptz{}, pgtype.Timestamptz{}, "", "", int32(2)).
+			WillReturnRows(
+				pgxmock.NewRows(columns).
+					AddRow("2", "lcOrganizationID", "error", "top_up", []byte(`{"a":1}`), pgtype.Text{String: "boom", Valid: true}, pgtype.Timestamptz{Time: createdAt, Valid: true}, "trace", "corr", "1").
+					AddRow("1", "lcOrganizationID", "error", "top_up", []byte(`{}`), pgtype.Text{String: "boom", Valid: true}, pgtype.Timestamptz{Time: createdAt, Valid: true}, "", "corr", "")).Times(1)
+
+		page, err := s.ListEvents(context.Background(), events.ListEventsFilter{
+			OrganizationID: "lcOrganizationID",
+			CorrelationID:  "corr",
+			Type:           events.EventTypeError,
+			ErrorsOnly:     true,
+			From:           from,
+			Limit:          1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []events.Event{{
+			ID:               "2",
+			CorrelationID:    "corr",
+			ParentID:         "1",
+			LCOrganizationID: "lcOrganizationID",
+			Type:             events.EventTypeError,
+			Action:           events.EventActionTopUp,
+			Payload:          json.RawMessage(`{"a":1}`),
+			Error:            "boom",
+			TraceID:          "trace",
+			CreatedAt:        createdAt,
+		}}, page.Events)
+		assert.NotEmpty(t, page.NextCursor)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id FROM ledger_events").
+			WithArgs("", "", "", "", false, pgtype.Timestamptz{}, pgtype.Timestamptz{}, pgtype.Timestamptz{Time: createdAt, Valid: true}, "2", "top_up", int32(2)).
+			WillReturnRows(pgxmock.NewRows(columns)).Times(1)
+
+		page, err = s.ListEvents(context.Background(), events.ListEventsFilter{Cursor: page.NextCursor, Limit: 1})
+		assert.NoError(t, err)
+		assert.Empty(t, page.Events)
+		assert.Empty(t, page.NextCursor)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, err := s.ListEvents(context.Background(), events.ListEventsFilter{Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, events.ErrInvalidCursor)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id FROM ledger_events").
+			WithArgs("", "", "", "", false, pgtype.Timestamptz{}, pgtype.Timestamptz{}, pgtype.Timestamptz{}, "", "", int32(events.DefaultListEventsLimit+1)).
+			Times(1).
+			WillReturnError(assert.AnError)
+
+		_, err := s.ListEvents(context.Background(), events.ListEventsFilter{})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_ListExpiredEvents(t *testing.T) {
+	createdBefore := time.Date(2025, 4, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT id, lc_organization_id, type, action, payload, error, created_at, trace_id, correlation_id, parent_id FROM ledger_events WHERE created_at <").
+			WithArgs(pgtype.Timestamptz{Time: createdBefore, Valid: true}, "info", []string{}, []string{"top_up"}, int32(10)).
+			WillReturnRows(
+				pgxmock.NewRows([]string{"id", "lc_organization_id", "type", "action", "payload", "error", "created_at", "trace_id", "correlation_id", "parent_id"}).
+					AddRow("1", "lcOrganizationID", "info", "create_charge", []byte(`{}`), pgtype.Text{}, pgtype.Timestamptz{Time: createdBefore, Valid: true}, "", "1", "")).Times(1)
+
+		evs, err := s.ListExpiredEvents(context.Background(), events.ExpiredEventsParams{
+			CreatedBefore:  createdBefore,
+			Type:           events.EventTypeInfo,
+			ExcludeActions: []events.EventAction{events.EventActionTopUp},
+			Limit:          10,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, evs, 1)
+		assert.Equal(t, events.EventActionCreateCharge, evs[0].Action)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectQuery("FROM ledger_events WHERE created_at <").
+			WithArgs(pgtype.Timestamptz{Time: createdBefore, Valid: true}, "", []string{"top_up"}, []string{}, int32(10)).
+			Times(1).
+			WillReturnError(assert.AnError)
+
+		_, err := s.ListExpiredEvents(context.Background(), events.ExpiredEventsParams{
+			CreatedBefore: createdBefore,
+			Actions:       []events.EventAction{events.EventActionTopUp},
+			Limit:         10,
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_DeleteEvents(t *testing.T) {
+	evs := []events.Event{{ID: "1", Action: events.EventActionCreateCharge}, {ID: "1", Action: events.EventActionTopUp}}
+
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectExec("DELETE FROM ledger_events").
+			WithArgs([]string{"1", "1"}, []string{"create_charge", "top_up"}).
+			WillReturnResult(pgxmock.NewResult("DELETE", 2)).Times(1)
+
+		deleted, err := s.DeleteEvents(context.Background(), evs)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectExec("DELETE FROM ledger_events").
+			WithArgs([]string{"1", "1"}, []string{"create_charge", "top_up"}).
+			Times(1).
+			WillReturnError(assert.AnError)
+
+		_, err := s.DeleteEvents(context.Background(), evs)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
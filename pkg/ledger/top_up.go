@@ -3,6 +3,8 @@ package ledger
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
 )
 
 type TopUpType string
@@ -31,7 +33,8 @@ type TopUp struct {
 	ID                string          `json:"id"`
 	LCOrganizationID  string          `json:"lc_organization_id"`
 	Status            TopUpStatus     `json:"status"`
-	Amount            float32         `json:"amount"`
+	Amount            Money           `json:"amount"`
+	Currency          Currency        `json:"currency"`
 	Type              TopUpType       `json:"type"`
 	ConfirmationUrl   string          `json:"confirmation_url"`
 	CurrentToppedUpAt *time.Time      `json:"current_topped_up_at"`
@@ -40,3 +43,45 @@ type TopUp struct {
 	CreatedAt         time.Time       `json:"created_at"`
 	UpdatedAt         time.Time       `json:"updated_at"`
 }
+
+// topUpStatus maps a LiveChat charge status to the top up status. It returns false for statuses the top up does not track.
+func topUpStatus(topUpType TopUpType, status livechat.ChargeStatus) (TopUpStatus, bool) {
+	switch topUpType {
+	case TopUpTypeDirect:
+		switch status {
+		case "success":
+			return TopUpStatusSuccess, true
+		case "accepted":
+			return TopUpStatusAccepted, true
+		case "processed":
+			return TopUpStatusProcessing, true
+		case "failed":
+			return TopUpStatusFailed, true
+		case "cancelled":
+			return TopUpStatusCancelled, true
+		case "declined":
+			return TopUpStatusDeclined, true
+		case "frozen":
+			return TopUpStatusFrozen, true
+		}
+	case TopUpTypeRecurrent:
+		switch status {
+		case "active":
+			return TopUpStatusActive, true
+		case "processed":
+			return TopUpStatusProcessing, true
+		case "accepted":
+			return TopUpStatusAccepted, true
+		case "cancelled":
+			return TopUpStatusCancelled, true
+		case "declined":
+			return TopUpStatusDeclined, true
+		case "frozen":
+			return TopUpStatusFrozen, true
+		case "past_due":
+			return TopUpStatusPastDue, true
+		}
+	}
+
+	return "", false
+}
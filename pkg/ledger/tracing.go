@@ -0,0 +1,190 @@
+package ledger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+// WithTracerProvider starts a span for every Service method, storage call and LiveChat Billing API request with a
+// tracer of tp, a nil tp uses the global provider. Without it nothing is traced, the trace context of the caller is
+// still propagated to the API and its trace ID stored in the events.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Service) {
+		s.tracer = tracing.Tracer(tp)
+		s.apiOptions = append(s.apiOptions, livechat.WithTracerProvider(tp))
+	}
+}
+
+// WithHandlerTracerProvider starts a span for every handled webhook with a tracer of tp, a nil tp uses the global provider.
+func WithHandlerTracerProvider(tp trace.TracerProvider) HandlerOption {
+	return func(h *Handler) {
+		h.tracer = tracing.Tracer(tp)
+	}
+}
+
+// Make sure its Storage implementation
+var _ Storage = (*tracingStorage)(nil)
+
+// tracingStorage starts a span for every call of the wrapped Storage.
+type tracingStorage struct {
+	Storage
+	tracer trace.Tracer
+}
+
+func (t *tracingStorage) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, t.tracer, "ledger.storage."+name, attrs...)
+}
+
+func (t *tracingStorage) CreateLedgerOperation(ctx context.Context, c Operation) (err error) {
+	ctx, span := t.start(ctx, "CreateLedgerOperation", tracing.OrganizationIDKey.String(c.LCOrganizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.CreateLedgerOperation(ctx, c)
+}
+
+func (t *tracingStorage) GetLedgerOperations(ctx context.Context, organizationID string, isVoucher bool) (_ []Operation, err error) {
+	ctx, span := t.start(ctx, "GetLedgerOperations", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetLedgerOperations(ctx, organizationID, isVoucher)
+}
+
+func (t *tracingStorage) GetLedgerOperation(ctx context.Context, params GetLedgerOperationParams) (_ *Operation, err error) {
+	ctx, span := t.start(ctx, "GetLedgerOperation", tracing.OrganizationIDKey.String(params.OrganizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetLedgerOperation(ctx, params)
+}
+
+func (t *tracingStorage) GetBalance(ctx context.Context, organizationID string) (_ Balances, err error) {
+	ctx, span := t.start(ctx, "GetBalance", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetBalance(ctx, organizationID)
+}
+
+func (t *tracingStorage) CreateConversion(ctx context.Context, params CreateConversionParams) (err error) {
+	ctx, span := t.start(ctx, "CreateConversion", tracing.OrganizationIDKey.String(params.Conversion.LCOrganizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.CreateConversion(ctx, params)
+}
+
+func (t *tracingStorage) GetTrialBalance(ctx context.Context, organizationID string) (_ TrialBalance, err error) {
+	ctx, span := t.start(ctx, "GetTrialBalance", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetTrialBalance(ctx, organizationID)
+}
+
+func (t *tracingStorage) CreateBalanceSnapshots(ctx context.Context, keep int) (_ int64, err error) {
+	ctx, span := t.start(ctx, "CreateBalanceSnapshots")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.CreateBalanceSnapshots(ctx, keep)
+}
+
+func (t *tracingStorage) GetBalanceDrifts(ctx context.Context) (_ []BalanceDrift, err error) {
+	ctx, span := t.start(ctx, "GetBalanceDrifts")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetBalanceDrifts(ctx)
+}
+
+func (t *tracingStorage) RepairBalance(ctx context.Context, organizationID string, currency Currency) (_ *BalanceRepair, err error) {
+	ctx, span := t.start(ctx, "RepairBalance", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.RepairBalance(ctx, organizationID, currency)
+}
+
+func (t *tracingStorage) GetTopUps(ctx context.Context) (_ []TopUp, err error) {
+	ctx, span := t.start(ctx, "GetTopUps")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetTopUps(ctx)
+}
+
+func (t *tracingStorage) GetTopUpsByOrganizationID(ctx context.Context, organizationID string) (_ []TopUp, err error) {
+	ctx, span := t.start(ctx, "GetTopUpsByOrganizationID", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetTopUpsByOrganizationID(ctx, organizationID)
+}
+
+func (t *tracingStorage) GetTopUpByIDAndOrganizationID(ctx context.Context, organizationID string, id string) (_ *TopUp, err error) {
+	ctx, span := t.start(ctx, "GetTopUpByIDAndOrganizationID", tracing.OrganizationIDKey.String(organizationID), tracing.ChargeIDKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetTopUpByIDAndOrganizationID(ctx, organizationID, id)
+}
+
+func (t *tracingStorage) GetTopUpsByTypeWhereStatusNotIn(ctx context.Context, params GetTopUpsByTypeWhereStatusNotInParams) (_ []TopUp, err error) {
+	ctx, span := t.start(ctx, "GetTopUpsByTypeWhereStatusNotIn")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetTopUpsByTypeWhereStatusNotIn(ctx, params)
+}
+
+func (t *tracingStorage) GetRecurrentTopUpsWhereStatusNotIn(ctx context.Context, statuses []TopUpStatus) (_ []TopUp, err error) {
+	ctx, span := t.start(ctx, "GetRecurrentTopUpsWhereStatusNotIn")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetRecurrentTopUpsWhereStatusNotIn(ctx, statuses)
+}
+
+func (t *tracingStorage) GetDirectTopUpsWithoutOperations(ctx context.Context) (_ []TopUp, err error) {
+	ctx, span := t.start(ctx, "GetDirectTopUpsWithoutOperations")
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetDirectTopUpsWithoutOperations(ctx)
+}
+
+func (t *tracingStorage) UpdateTopUpStatus(ctx context.Context, params UpdateTopUpStatusParams) (err error) {
+	ctx, span := t.start(ctx, "UpdateTopUpStatus", tracing.ChargeIDKey.String(params.ID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.UpdateTopUpStatus(ctx, params)
+}
+
+func (t *tracingStorage) GetTopUpByIDAndType(ctx context.Context, params GetTopUpByIDAndTypeParams) (_ *TopUp, err error) {
+	ctx, span := t.start(ctx, "GetTopUpByIDAndType", tracing.ChargeIDKey.String(params.ID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetTopUpByIDAndType(ctx, params)
+}
+
+func (t *tracingStorage) CreateEvent(ctx context.Context, event events.Event) (err error) {
+	ctx, span := t.start(ctx, "CreateEvent", tracing.OrganizationIDKey.String(event.LCOrganizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.CreateEvent(ctx, event)
+}
+
+func (t *tracingStorage) GetTopUpsByOrganizationIDAndStatus(ctx context.Context, organizationID string, status TopUpStatus) (_ []TopUp, err error) {
+	ctx, span := t.start(ctx, "GetTopUpsByOrganizationIDAndStatus", tracing.OrganizationIDKey.String(organizationID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.GetTopUpsByOrganizationIDAndStatus(ctx, organizationID, status)
+}
+
+func (t *tracingStorage) UpsertTopUp(ctx context.Context, topUp TopUp) (_ *TopUp, err error) {
+	ctx, span := t.start(ctx, "UpsertTopUp", tracing.OrganizationIDKey.String(topUp.LCOrganizationID), tracing.ChargeIDKey.String(topUp.ID))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.UpsertTopUp(ctx, topUp)
+}
+
+// WithTopUpLock spans the time the lock is held, fn included.
+func (t *tracingStorage) WithTopUpLock(ctx context.Context, id string, fn func(ctx context.Context) error) (err error) {
+	ctx, span := t.start(ctx, "WithTopUpLock", tracing.ChargeIDKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	return t.Storage.WithTopUpLock(ctx, id, fn)
+}
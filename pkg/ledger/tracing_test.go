@@ -0,0 +1,48 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+func TestNewService_WithTracerProvider(t *testing.T) {
+	tokenFn := func(ctx context.Context) (string, error) { return "", nil }
+	newService := NewService(nil, nil, nil, "labs", tokenFn, sm, "returnURL", "masterOrgID",
+		WithTracerProvider(sdktrace.NewTracerProvider()))
+
+	assert.NotNil(t, newService.tracer)
+	assert.Equal(t, &tracingStorage{Storage: sm, tracer: newService.tracer}, newService.storage)
+	assert.NotNil(t, newService.billingAPI.(*livechat.Api).Tracer)
+}
+
+func TestService_tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	ms := s
+	ms.tracer = tracing.Tracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	ms.storage = &tracingStorage{Storage: sm, tracer: ms.tracer}
+
+	sm.On("GetBalance", mock.Anything, "lcoid").Return(nil, assert.AnError).Once()
+
+	_, err := ms.GetBalance(ctx, "lcoid")
+	assert.ErrorIs(t, err, assert.AnError)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "ledger.storage.GetBalance", spans[0].Name())
+	assert.Equal(t, "ledger.GetBalance", spans[1].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, spans[1].Attributes(), tracing.OrganizationIDKey.String("lcoid"))
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+
+	assertExpectations(t)
+}
@@ -0,0 +1,594 @@
+package livechat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/common"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+const BillingAPIBaseURL = "https://billing.livechatinc.com"
+
+const (
+	RecurrentChargeStatusPending   ChargeStatus = "pending"
+	RecurrentChargeStatusAccepted  ChargeStatus = "accepted"
+	RecurrentChargeStatusActive    ChargeStatus = "active"
+	RecurrentChargeStatusCancelled ChargeStatus = "cancelled"
+	RecurrentChargeStatusFrozen    ChargeStatus = "frozen"
+	RecurrentChargeStatusPastDue   ChargeStatus = "past_due"
+
+	DirectChargeStatusPending    ChargeStatus = "pending"
+	DirectChargeStatusAccepted   ChargeStatus = "accepted"
+	DirectChargeStatusDeclined   ChargeStatus = "declined"
+	DirectChargeStatusProcessing ChargeStatus = "processed"
+	DirectChargeStatusFailed     ChargeStatus = "failed"
+	DirectChargeStatusSuccess    ChargeStatus = "success"
+)
+
+type ChargeStatus string
+type BaseCharge struct {
+	ID                  string       `json:"id"`
+	BuyerLicenseID      int          `json:"buyer_license_id"`
+	BuyerEntityID       string       `json:"buyer_entity_id"`
+	BuyerOrganizationID string       `json:"buyer_organization_id"`
+	BuyerAccountID      string       `json:"buyer_account_id" redact:"mask"`
+	SellerClientID      string       `json:"seller_client_id"`
+	OrderClientID       string       `json:"order_client_id"`
+	OrderLicenseID      string       `json:"order_license_id"`
+	OrderEntityID       string       `json:"order_entity_id"`
+	OrderOrganizationID string       `json:"order_organization_id"`
+	Name                string       `json:"name"`
+	Price               int          `json:"price"`
+	ReturnURL           string       `json:"return_url"`
+	Test                bool         `json:"test"`
+	PerAccount          bool         `json:"per_account"`
+	Status              ChargeStatus `json:"status"`
+	ConfirmationURL     string       `json:"confirmation_url"`
+	CommissionPercent   int          `json:"commission_percent"`
+	UpdatedAt           *time.Time   `json:"updated_at"`
+	CreatedAt           *time.Time   `json:"created_at"`
+}
+
+type DirectCharge struct {
+	BaseCharge
+	Quantity int `json:"quantity"`
+}
+
+type RecurrentCharge struct {
+	BaseCharge
+	TrialDays       int        `json:"trial_days"`
+	Months          int        `json:"months"`
+	TrialEndsAt     *time.Time `json:"trial_ends_at"`
+	CancelledAt     *time.Time `json:"cancelled_at"`
+	CurrentChargeAt *time.Time `json:"current_charge_at"`
+	NextChargeAt    *time.Time `json:"next_charge_at"`
+}
+
+type ApiInterface interface {
+	CreateDirectCharge(ctx context.Context, params CreateDirectChargeParams) (*DirectCharge, error)
+	GetDirectCharge(ctx context.Context, id string) (*DirectCharge, error)
+	CreateRecurrentCharge(ctx context.Context, params CreateRecurrentChargeParams) (*RecurrentCharge, error)
+	GetRecurrentCharge(ctx context.Context, id string) (*RecurrentCharge, error)
+	CancelRecurrentCharge(ctx context.Context, id string) (*RecurrentCharge, error)
+	ActivateRecurrentCharge(ctx context.Context, id string) (*RecurrentCharge, error)
+	ActivateDirectCharge(ctx context.Context, id string) (*DirectCharge, error)
+}
+
+// HttpCaller sends the requests of Api, *http.Client implements it.
+type HttpCaller interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Api is the LiveChat Billing API v3 client, use NewApi to get one with the default policies.
+type Api struct {
+	HttpClient HttpCaller
+	ApiBaseURL string
+	TokenFn    common.TokenFn
+	Retry      RetryPolicy
+	Breaker    *CircuitBreaker
+	// Timeout limits every call, retries included. Zero means no limit.
+	Timeout time.Duration
+	// Headers are sent with every call, they cannot override Authorization or Content-Type.
+	Headers   http.Header
+	UserAgent string
+	// InvalidateToken drops a token the API answered 401 for, the call is then sent once more with a new token.
+	InvalidateToken func(token string)
+	// Metrics receives a counter and a duration per call, labelled with the method, route and status code. Nil drops them.
+	Metrics metrics.Metrics
+	// Tracer starts a client span per HTTP request, retries included. Nil starts none, the trace context of the call
+	// is propagated either way.
+	Tracer trace.Tracer
+
+	sleepFn func(ctx context.Context, d time.Duration) error
+}
+
+type CreateDirectChargeParams struct {
+	Name              string
+	ReturnURL         string
+	Price             int
+	Test              bool
+	CommissionPercent *int
+	Payload           []byte
+	// IdempotencyKey makes the call safe to retry. Without it the charge is never re-sent.
+	IdempotencyKey string
+}
+
+func (a *Api) CreateDirectCharge(ctx context.Context, params CreateDirectChargeParams) (*DirectCharge, error) {
+	type payload struct {
+		Name              string `json:"name"`
+		Price             int    `json:"price"`
+		ReturnURL         string `json:"return_url"`
+		Test              bool   `json:"test"`
+		Quantity          int    `json:"quantity"`
+		CommissionPercent *int   `json:"commission_percent,omitempty"`
+		Payload           []byte `json:"payload"`
+	}
+
+	resp, err := a.callWithIdempotencyKey(ctx, "POST", "/v3/direct_charge/livechat", params.IdempotencyKey, payload{
+		Name:              params.Name,
+		Price:             params.Price,
+		ReturnURL:         params.ReturnURL,
+		Test:              params.Test,
+		Quantity:          1,
+		CommissionPercent: params.CommissionPercent,
+		Payload:           params.Payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asDirectCharge(resp)
+}
+
+func (a *Api) GetDirectCharge(ctx context.Context, id string) (*DirectCharge, error) {
+	resp, err := a.call(ctx, "GET", "/v3/direct_charge/livechat/"+id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return asDirectCharge(resp)
+}
+
+type CreateRecurrentChargeParams struct {
+	Name              string
+	ReturnURL         string
+	Price             int
+	Test              bool
+	TrialDays         int
+	Months            int
+	CommissionPercent *int
+	// IdempotencyKey makes the call safe to retry. Without it the charge is never re-sent.
+	IdempotencyKey string
+}
+
+func (a *Api) CreateRecurrentCharge(ctx context.Context, params CreateRecurrentChargeParams) (*RecurrentCharge, error) {
+	type payload struct {
+		Name              string `json:"name"`
+		Price             int    `json:"price"`
+		ReturnURL         string `json:"return_url"`
+		Test              bool   `json:"test"`
+		TrialDays         int    `json:"trial_days"`
+		Months            int    `json:"months,omitempty"`
+		CommissionPercent *int   `json:"commission_percent,omitempty"`
+	}
+	resp, err := a.callWithIdempotencyKey(ctx, "POST", "/v3/recurrent_charge/livechat", params.IdempotencyKey, payload{
+		Name:              params.Name,
+		Price:             params.Price,
+		ReturnURL:         params.ReturnURL,
+		Test:              params.Test,
+		TrialDays:         params.TrialDays,
+		Months:            params.Months,
+		CommissionPercent: params.CommissionPercent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asRecurrentCharge(resp)
+}
+
+func (a *Api) GetRecurrentCharge(ctx context.Context, id string) (*RecurrentCharge, error) {
+	resp, err := a.call(ctx, "GET", "/v3/recurrent_charge/livechat/"+id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return asRecurrentCharge(resp)
+}
+
+func (a *Api) CancelRecurrentCharge(ctx context.Context, id string) (*RecurrentCharge, error) {
+	resp, err := a.call(ctx, "PUT", fmt.Sprintf("/v3/recurrent_charge/livechat/%s/cancel", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return asRecurrentCharge(resp)
+}
+
+func (a *Api) ActivateRecurrentCharge(ctx context.Context, id string) (*RecurrentCharge, error) {
+	resp, err := a.call(ctx, "PUT", fmt.Sprintf("/v3/recurrent_charge/livechat/%s/activate", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return asRecurrentCharge(resp)
+}
+
+func (a *Api) ActivateDirectCharge(ctx context.Context, id string) (*DirectCharge, error) {
+	resp, err := a.call(ctx, "PUT", fmt.Sprintf("/v3/direct_charge/livechat/%s/activate", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return asDirectCharge(resp)
+}
+
+// DefaultPerPage is the page size used when ListParams.PerPage is not set.
+const DefaultPerPage = 50
+
+// ListParams selects a page of a list endpoint. Pages start at 1.
+type ListParams struct {
+	Page    int
+	PerPage int
+	// Status filters charges by status, empty lists all of them.
+	Status ChargeStatus
+}
+
+func (p ListParams) query() string {
+	page, perPage := p.Page, p.PerPage
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = DefaultPerPage
+	}
+
+	q := url.Values{}
+	q.Set("page", strconv.Itoa(page))
+	q.Set("per_page", strconv.Itoa(perPage))
+	if p.Status != "" {
+		q.Set("status", string(p.Status))
+	}
+
+	return "?" + q.Encode()
+}
+
+// next returns the params of the page after one with n items, false when it was the last one.
+func (p ListParams) next(n int) (ListParams, bool) {
+	perPage := p.PerPage
+	if perPage < 1 {
+		perPage = DefaultPerPage
+	}
+	if n < perPage {
+		return p, false
+	}
+
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	p.Page++
+
+	return p, true
+}
+
+func (a *Api) ListRecurrentCharges(ctx context.Context, params ListParams) ([]RecurrentCharge, error) {
+	resp, err := a.call(ctx, "GET", "/v3/recurrent_charge/livechat"+params.query(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rcs []RecurrentCharge
+	if err = json.Unmarshal(resp, &rcs); err != nil {
+		return nil, err
+	}
+
+	return rcs, nil
+}
+
+func (a *Api) ListDirectCharges(ctx context.Context, params ListParams) ([]DirectCharge, error) {
+	resp, err := a.call(ctx, "GET", "/v3/direct_charge/livechat"+params.query(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var dcs []DirectCharge
+	if err = json.Unmarshal(resp, &dcs); err != nil {
+		return nil, err
+	}
+
+	return dcs, nil
+}
+
+// ListAllRecurrentCharges walks every page starting at params.Page.
+func (a *Api) ListAllRecurrentCharges(ctx context.Context, params ListParams) ([]RecurrentCharge, error) {
+	var all []RecurrentCharge
+	for {
+		rcs, err := a.ListRecurrentCharges(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, rcs...)
+
+		var more bool
+		if params, more = params.next(len(rcs)); !more {
+			return all, nil
+		}
+	}
+}
+
+// ListAllDirectCharges walks every page starting at params.Page.
+func (a *Api) ListAllDirectCharges(ctx context.Context, params ListParams) ([]DirectCharge, error) {
+	var all []DirectCharge
+	for {
+		dcs, err := a.ListDirectCharges(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, dcs...)
+
+		var more bool
+		if params, more = params.next(len(dcs)); !more {
+			return all, nil
+		}
+	}
+}
+
+func asDirectCharge(body []byte) (*DirectCharge, error) {
+	var dc DirectCharge
+	if err := json.Unmarshal(body, &dc); err != nil {
+		return nil, err
+	}
+
+	return &dc, nil
+}
+
+func asRecurrentCharge(body []byte) (*RecurrentCharge, error) {
+	var rc RecurrentCharge
+	if err := json.Unmarshal(body, &rc); err != nil {
+		return nil, err
+	}
+
+	return &rc, nil
+}
+
+func (a *Api) call(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	return a.callWithIdempotencyKey(ctx, method, path, "", body)
+}
+
+// callWithIdempotencyKey sends the call, retrying it according to a.Retry when
+// it is idempotent: any non-POST call, or a POST with an idempotency key.
+func (a *Api) callWithIdempotencyKey(ctx context.Context, method, path, idempotencyKey string, body interface{}) ([]byte, error) {
+	start := time.Now()
+	status := metrics.StatusError
+	defer func() {
+		a.observe(method, path, status, start)
+	}()
+
+	if err := a.Breaker.allow(); err != nil {
+		status = metrics.StatusUnavailable
+		return nil, err
+	}
+
+	callerCtx := ctx
+	if a.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.Timeout)
+		defer cancel()
+	}
+
+	var b []byte
+	if body != nil {
+		b, _ = json.Marshal(body)
+	}
+
+	resp, failed, err := a.send(ctx, method, path, idempotencyKey, b)
+	switch {
+	case !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded):
+		a.Breaker.record(failed)
+	case callerCtx.Err() != nil:
+		// the caller gave up, which says nothing about the API
+		a.Breaker.abandon()
+	default:
+		// the API did not answer within a.Timeout
+		a.Breaker.record(true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status = strconv.Itoa(resp.StatusCode)
+	return readResponse(resp, method, path)
+}
+
+func (a *Api) observe(method, path, status string, start time.Time) {
+	if a.Metrics == nil {
+		return
+	}
+
+	labels := metrics.Labels{Action: method + " " + route(path), Status: status}
+	a.Metrics.IncCounter(metrics.APIRequestsTotal, labels)
+	metrics.ObserveSince(a.Metrics, metrics.APIRequestDurationSeconds, labels, start)
+
+	var open float64
+	if a.Breaker.isOpen() {
+		open = 1
+	}
+	a.Metrics.SetGauge(metrics.APICircuitOpen, metrics.Labels{}, open)
+}
+
+// route drops the query and the charge ID of path, e.g. /v3/recurrent_charge/livechat/:id/activate.
+func route(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(path, "/")
+	if len(segments) > 4 {
+		segments[4] = ":id"
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// send returns the last response and whether the API failed to answer it.
+func (a *Api) send(ctx context.Context, method, path, idempotencyKey string, body []byte) (*http.Response, bool, error) {
+	retryable := isIdempotent(method, idempotencyKey)
+	reauthorized := false
+	for attempt := 1; ; attempt++ {
+		req, err := a.newRequest(ctx, method, path, idempotencyKey, body)
+		if err != nil {
+			return nil, false, err
+		}
+
+		canRetry := retryable && attempt < a.Retry.maxAttempts()
+
+		resp, err := a.do(req, path)
+		if err != nil {
+			failed := ctx.Err() == nil
+			if canRetry && failed {
+				if err = a.sleep(ctx, a.Retry.backoff(attempt)); err == nil {
+					continue
+				}
+			}
+			return nil, ctx.Err() == nil, fmt.Errorf("billing api: send call: %w", err)
+		}
+
+		// A rejected token is refreshed once, the request was not processed so even POSTs are safe to resend.
+		if resp.StatusCode == http.StatusUnauthorized && a.InvalidateToken != nil && !reauthorized {
+			reauthorized = true
+			a.InvalidateToken(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+			closeBody(resp)
+			attempt--
+			continue
+		}
+
+		failed := isRetryableStatus(resp.StatusCode)
+		if canRetry && failed {
+			if d, ok := a.Retry.delay(attempt, resp); ok {
+				closeBody(resp)
+				if err = a.sleep(ctx, d); err != nil {
+					return nil, false, fmt.Errorf("billing api: send call: %w", err)
+				}
+				continue
+			}
+		}
+
+		return resp, failed, nil
+	}
+}
+
+// do sends req in a client span and propagates its trace context in the request headers.
+func (a *Api) do(req *http.Request, path string) (*http.Response, error) {
+	ctx := req.Context()
+	var span trace.Span
+	if a.Tracer != nil {
+		attrs := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.HTTPRoute(route(path)),
+			semconv.URLFull(req.URL.String()),
+		}
+		if id := chargeID(path); id != "" {
+			attrs = append(attrs, tracing.ChargeIDKey.String(id))
+		}
+		ctx, span = a.Tracer.Start(ctx, "billing api "+req.Method+" "+route(path), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		req = req.WithContext(ctx)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := a.HttpClient.Do(req)
+	if span != nil {
+		if err == nil {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+			if resp.StatusCode >= 300 {
+				span.SetStatus(codes.Error, resp.Status)
+			}
+		}
+		tracing.End(span, err)
+	}
+
+	return resp, err
+}
+
+// chargeID returns the charge ID of path, empty for a path without one.
+func chargeID(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(path, "/")
+	if len(segments) > 4 {
+		return segments[4]
+	}
+
+	return ""
+}
+
+func (a *Api) newRequest(ctx context.Context, method, path, idempotencyKey string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.ApiBaseURL+path, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("billing api: create request: %w", err)
+	}
+
+	token, err := a.TokenFn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing api: get token: %w", err)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("billing api: empty token")
+	}
+
+	for key, values := range a.Headers {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	if a.UserAgent != "" {
+		req.Header.Set("User-Agent", a.UserAgent)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	return req, nil
+}
+
+func (a *Api) sleep(ctx context.Context, d time.Duration) error {
+	if a.sleepFn != nil {
+		return a.sleepFn(ctx, d)
+	}
+	return sleep(ctx, d)
+}
+
+func readResponse(resp *http.Response, method, path string) ([]byte, error) {
+	defer closeBody(resp)
+
+	if resp.StatusCode >= 300 {
+		var e []byte
+		if resp.Body != nil {
+			e, _ = io.ReadAll(resp.Body)
+		}
+
+		return nil, newAPIError(resp, method, path, e)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func closeBody(resp *http.Response) {
+	if resp.Body != nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+}
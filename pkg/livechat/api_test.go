@@ -186,10 +186,35 @@ func TestAPI_call(t *testing.T) {
 		}, nil).Once()
 
 		resp, err := a.call(context.Background(), "GET", "/", nil)
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NotErrorIs(t, err, ErrUnprocessableEntity)
 		assert.Nil(t, resp)
 	})
 
+	t.Run("422", func(t *testing.T) {
+		hm.On("Do", mock.Anything).Return(&http.Response{
+			StatusCode: 422,
+			Header:     http.Header{"X-Request-Id": []string{"req-1"}},
+			Body:       io.NopCloser(strings.NewReader(`{"error":"invalid price"}`)),
+		}, nil).Once()
+
+		resp, err := a.call(context.Background(), "PUT", "/charge", nil)
+		assert.ErrorIs(t, err, ErrUnprocessableEntity)
+		assert.Nil(t, resp)
+
+		var apiErr *APIError
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, &APIError{
+			StatusCode: 422,
+			Method:     "PUT",
+			Endpoint:   "/charge",
+			RequestID:  "req-1",
+			Body:       map[string]interface{}{"error": "invalid price"},
+			RawBody:    `{"error":"invalid price"}`,
+		}, apiErr)
+		assert.EqualError(t, err, `billing api call PUT /charge: bad status code 422, request id: req-1, response: {"error":"invalid price"}`)
+	})
+
 	t.Run("500", func(t *testing.T) {
 		hm.On("Do", mock.Anything).Return(&http.Response{
 			StatusCode: 500,
@@ -225,3 +250,67 @@ func Test_asRecurrentCharge(t *testing.T) {
 		assert.Nil(t, rc)
 	})
 }
+
+func TestAPI_ListRecurrentCharges(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		m.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			q := req.URL.Query()
+			return req.URL.Path == "/v3/recurrent_charge/livechat" && q.Get("page") == "1" && q.Get("per_page") == "50" && q.Get("status") == "active"
+		})).Return(response(200, `[{"id":"1"},{"id":"2"}]`), nil).Once()
+
+		charges, err := api.ListRecurrentCharges(context.Background(), ListParams{Status: RecurrentChargeStatusActive})
+		assert.NoError(t, err)
+		assert.Len(t, charges, 2)
+		assert.Equal(t, "2", charges[1].ID)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		m.On("Do", mock.Anything).Return(response(500, ``), nil).Once()
+
+		charges, err := api.ListRecurrentCharges(context.Background(), ListParams{})
+		assert.Error(t, err)
+		assert.Nil(t, charges)
+	})
+}
+
+func TestAPI_ListAllDirectCharges(t *testing.T) {
+	t.Run("walks pages until a short one", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		page := func(n string) interface{} {
+			return mock.MatchedBy(func(req *http.Request) bool {
+				return req.URL.Path == "/v3/direct_charge/livechat" && req.URL.Query().Get("page") == n && req.URL.Query().Get("per_page") == "2"
+			})
+		}
+		m.On("Do", page("1")).Return(response(200, `[{"id":"1"},{"id":"2"}]`), nil).Once()
+		m.On("Do", page("2")).Return(response(200, `[{"id":"3"}]`), nil).Once()
+
+		charges, err := api.ListAllDirectCharges(context.Background(), ListParams{PerPage: 2})
+		assert.NoError(t, err)
+		assert.Len(t, charges, 3)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		m.On("Do", mock.Anything).Return(response(200, `[{"id":"1"}]`), nil).Once()
+		m.On("Do", mock.Anything).Return(response(500, ``), nil).Once()
+
+		charges, err := api.ListAllDirectCharges(context.Background(), ListParams{PerPage: 1})
+		assert.Error(t, err)
+		assert.Nil(t, charges)
+	})
+}
+
+func TestAPI_ListAllRecurrentCharges(t *testing.T) {
+	api, m, _ := newRetryApi(RetryPolicy{})
+	m.On("Do", mock.Anything).Return(response(200, `[{"id":"1"}]`), nil).Once()
+	m.On("Do", mock.Anything).Return(response(200, `[]`), nil).Once()
+
+	charges, err := api.ListAllRecurrentCharges(context.Background(), ListParams{PerPage: 1})
+	assert.NoError(t, err)
+	assert.Len(t, charges, 1)
+	m.AssertExpectations(t)
+}
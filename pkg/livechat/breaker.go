@@ -0,0 +1,164 @@
+package livechat
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBillingAPIUnavailable is returned without calling the API while the circuit breaker is open.
+var ErrBillingAPIUnavailable = fmt.Errorf("billing api unavailable")
+
+// CircuitBreakerPolicy configures when Api stops calling the Billing API. A call
+// fails when it ends, after retries, with a network error, 429 or 5xx.
+type CircuitBreakerPolicy struct {
+	// FailureRate (0-1) of failed calls in Window that opens the circuit. Zero disables the breaker.
+	FailureRate float64
+	// MinCalls is the number of calls in Window needed before FailureRate is checked.
+	MinCalls int
+	// Window is how long calls are counted before the counters start over.
+	Window time.Duration
+	// OpenTimeout is how long the circuit stays open before a single probe call is let through.
+	OpenTimeout time.Duration
+}
+
+// DefaultCircuitBreakerPolicy opens the circuit when half of at least 10 calls in a minute fail
+// and probes the API again after 30 seconds.
+func DefaultCircuitBreakerPolicy() CircuitBreakerPolicy {
+	return CircuitBreakerPolicy{
+		FailureRate: 0.5,
+		MinCalls:    10,
+		Window:      time.Minute,
+		OpenTimeout: 30 * time.Second,
+	}
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker is shared by all calls of an Api. A nil *CircuitBreaker lets every call through.
+type CircuitBreaker struct {
+	policy CircuitBreakerPolicy
+	now    func() time.Time
+
+	mu          sync.Mutex
+	state       circuitState
+	windowStart time.Time
+	calls       int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+}
+
+// NewCircuitBreaker returns nil when the policy disables the breaker.
+func NewCircuitBreaker(policy CircuitBreakerPolicy) *CircuitBreaker {
+	if policy.FailureRate <= 0 {
+		return nil
+	}
+
+	return &CircuitBreaker{policy: policy, now: time.Now}
+}
+
+// allow reports ErrBillingAPIUnavailable while the circuit is open. Once OpenTimeout passes it lets
+// a single probe through, whose outcome closes or reopens the circuit.
+func (b *CircuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.policy.OpenTimeout {
+			return ErrBillingAPIUnavailable
+		}
+		b.state = circuitHalfOpen
+		b.probing = true
+	case circuitHalfOpen:
+		if b.probing {
+			return ErrBillingAPIUnavailable
+		}
+		b.probing = true
+	}
+
+	return nil
+}
+
+func (b *CircuitBreaker) record(failed bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case circuitOpen:
+		return
+	case circuitHalfOpen:
+		b.probing = false
+		if failed {
+			b.open(now)
+		} else {
+			b.close(now)
+		}
+		return
+	}
+
+	if now.Sub(b.windowStart) >= b.policy.Window {
+		b.windowStart, b.calls, b.failures = now, 0, 0
+	}
+
+	b.calls++
+	if failed {
+		b.failures++
+	}
+
+	if b.calls >= b.policy.MinCalls && float64(b.failures)/float64(b.calls) >= b.policy.FailureRate {
+		b.open(now)
+	}
+}
+
+// abandon ends a call without an outcome, a half open circuit lets the next call probe instead.
+func (b *CircuitBreaker) abandon() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		b.probing = false
+	}
+}
+
+func (b *CircuitBreaker) open(now time.Time) {
+	b.state = circuitOpen
+	b.openedAt = now
+}
+
+func (b *CircuitBreaker) close(now time.Time) {
+	b.state = circuitClosed
+	b.windowStart, b.calls, b.failures = now, 0, 0
+}
+
+// isOpen reports whether calls are rejected, half open included.
+func (b *CircuitBreaker) isOpen() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state != circuitClosed
+}
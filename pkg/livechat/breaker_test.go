@@ -0,0 +1,188 @@
+package livechat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestBreaker(now *time.Time) *CircuitBreaker {
+	b := NewCircuitBreaker(CircuitBreakerPolicy{FailureRate: 0.5, MinCalls: 4, Window: time.Minute, OpenTimeout: 30 * time.Second})
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func TestNewCircuitBreaker(t *testing.T) {
+	assert.Nil(t, NewCircuitBreaker(CircuitBreakerPolicy{}))
+	assert.NotNil(t, NewCircuitBreaker(DefaultCircuitBreakerPolicy()))
+
+	var b *CircuitBreaker
+	assert.NoError(t, b.allow())
+	b.record(true)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("opens at failure rate", func(t *testing.T) {
+		now := time.Now()
+		b := newTestBreaker(&now)
+
+		b.record(true)
+		b.record(true)
+		b.record(false)
+		assert.NoError(t, b.allow(), "below MinCalls")
+
+		b.record(false)
+		assert.ErrorIs(t, b.allow(), ErrBillingAPIUnavailable)
+	})
+
+	t.Run("stays closed below failure rate", func(t *testing.T) {
+		now := time.Now()
+		b := newTestBreaker(&now)
+
+		b.record(true)
+		for i := 0; i < 5; i++ {
+			b.record(false)
+		}
+		assert.NoError(t, b.allow())
+	})
+
+	t.Run("counts calls per window", func(t *testing.T) {
+		now := time.Now()
+		b := newTestBreaker(&now)
+
+		b.record(true)
+		b.record(true)
+		b.record(true)
+		now = now.Add(time.Minute)
+		b.record(true)
+		assert.NoError(t, b.allow())
+	})
+
+	t.Run("probe closes the circuit", func(t *testing.T) {
+		now := time.Now()
+		b := newTestBreaker(&now)
+		for i := 0; i < 4; i++ {
+			b.record(true)
+		}
+
+		now = now.Add(30 * time.Second)
+		assert.NoError(t, b.allow())
+		assert.ErrorIs(t, b.allow(), ErrBillingAPIUnavailable, "one probe at a time")
+
+		b.record(false)
+		assert.NoError(t, b.allow())
+		assert.NoError(t, b.allow())
+	})
+
+	t.Run("failed probe reopens the circuit", func(t *testing.T) {
+		now := time.Now()
+		b := newTestBreaker(&now)
+		for i := 0; i < 4; i++ {
+			b.record(true)
+		}
+
+		now = now.Add(30 * time.Second)
+		assert.NoError(t, b.allow())
+		b.record(true)
+
+		now = now.Add(29 * time.Second)
+		assert.ErrorIs(t, b.allow(), ErrBillingAPIUnavailable)
+	})
+}
+
+func TestAPI_callCircuitBreaker(t *testing.T) {
+	t.Run("fails fast when open", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		api.Breaker = NewCircuitBreaker(CircuitBreakerPolicy{FailureRate: 1, MinCalls: 2, Window: time.Minute, OpenTimeout: time.Minute})
+		m.On("Do", mock.Anything).Return(response(503, ``), nil).Once()
+		m.On("Do", mock.Anything).Return(&http.Response{}, fmt.Errorf("connection refused")).Once()
+
+		_, err := api.GetRecurrentCharge(context.Background(), "1")
+		assert.Error(t, err)
+		_, err = api.GetRecurrentCharge(context.Background(), "1")
+		assert.Error(t, err)
+
+		_, err = api.GetRecurrentCharge(context.Background(), "1")
+		assert.ErrorIs(t, err, ErrBillingAPIUnavailable)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("client errors do not open the circuit", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		api.Breaker = NewCircuitBreaker(CircuitBreakerPolicy{FailureRate: 1, MinCalls: 2, Window: time.Minute, OpenTimeout: time.Minute})
+		m.On("Do", mock.Anything).Return(response(404, ``), nil).Once()
+		m.On("Do", mock.Anything).Return(response(422, ``), nil).Once()
+		m.On("Do", mock.Anything).Return(response(200, `{"id":"1"}`), nil).Once()
+
+		_, _ = api.GetRecurrentCharge(context.Background(), "1")
+		_, _ = api.GetRecurrentCharge(context.Background(), "1")
+		charge, err := api.GetRecurrentCharge(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", charge.ID)
+		m.AssertExpectations(t)
+	})
+	t.Run("client timeouts open the circuit", func(t *testing.T) {
+		hang := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-hang:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(hang)
+		api, _, _ := newRetryApi(RetryPolicy{})
+		api.HttpClient = server.Client()
+		api.ApiBaseURL = server.URL
+		api.Timeout = 10 * time.Millisecond
+		api.Breaker = NewCircuitBreaker(CircuitBreakerPolicy{FailureRate: 1, MinCalls: 2, Window: time.Minute, OpenTimeout: time.Minute})
+
+		_, err := api.GetRecurrentCharge(context.Background(), "1")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		_, err = api.GetRecurrentCharge(context.Background(), "1")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		_, err = api.GetRecurrentCharge(context.Background(), "1")
+		assert.ErrorIs(t, err, ErrBillingAPIUnavailable)
+	})
+
+	t.Run("canceled callers do not open the circuit", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		api.Breaker = NewCircuitBreaker(CircuitBreakerPolicy{FailureRate: 1, MinCalls: 2, Window: time.Minute, OpenTimeout: time.Minute})
+		ctx, cancel := context.WithCancel(context.Background())
+		m.On("Do", mock.Anything).Run(func(args mock.Arguments) {
+			cancel()
+		}).Return(&http.Response{}, context.Canceled).Twice()
+		m.On("Do", mock.Anything).Return(response(200, `{"id":"1"}`), nil).Once()
+
+		_, err := api.GetRecurrentCharge(ctx, "1")
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = api.GetRecurrentCharge(ctx, "1")
+		assert.ErrorIs(t, err, context.Canceled)
+
+		charge, err := api.GetRecurrentCharge(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", charge.ID)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("canceled probe lets the next call probe", func(t *testing.T) {
+		b := NewCircuitBreaker(CircuitBreakerPolicy{FailureRate: 1, MinCalls: 1, Window: time.Minute, OpenTimeout: time.Minute})
+		now := time.Now()
+		b.now = func() time.Time { return now }
+		assert.NoError(t, b.allow())
+		b.record(true)
+		now = now.Add(time.Minute)
+		assert.NoError(t, b.allow())
+
+		b.abandon()
+
+		assert.NoError(t, b.allow())
+	})
+}
@@ -0,0 +1,59 @@
+package livechat
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrNotFound            = fmt.Errorf("not found")
+	ErrUnprocessableEntity = fmt.Errorf("unprocessable entity")
+)
+
+// APIError is returned for every Billing API response with a non-2xx status code.
+// Use errors.Is with ErrNotFound or ErrUnprocessableEntity to check for 404 and 422.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+	// RequestID is the X-Request-Id response header, quote it when reporting issues to LiveChat.
+	RequestID string
+	// Body is the decoded JSON error body, nil when the body is not a JSON object.
+	Body map[string]interface{}
+	// RawBody is the error body as received.
+	RawBody string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("billing api call %s %s: bad status code %d", e.Method, e.Endpoint, e.StatusCode)
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(", request id: %s", e.RequestID)
+	}
+
+	return msg + fmt.Sprintf(", response: %s", e.RawBody)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnprocessableEntity:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	}
+
+	return false
+}
+
+func newAPIError(resp *http.Response, method, endpoint string, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     method,
+		Endpoint:   endpoint,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		RawBody:    string(body),
+	}
+	_ = json.Unmarshal(body, &e.Body)
+
+	return e
+}
@@ -0,0 +1,50 @@
+package livechat
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// LedgerOperation is an entry of the application's LiveChat ledger. Amount is in cents, like charge prices.
+type LedgerOperation struct {
+	ID                  string     `json:"id"`
+	Amount              int        `json:"amount"`
+	Type                string     `json:"type"`
+	ChargeID            string     `json:"charge_id"`
+	BuyerOrganizationID string     `json:"buyer_organization_id"`
+	CreatedAt           *time.Time `json:"created_at"`
+}
+
+// LedgerBalance is the application's LiveChat ledger balance in cents.
+type LedgerBalance struct {
+	Balance int `json:"balance"`
+}
+
+func (a *Api) ListLedgerOperations(ctx context.Context, params ListParams) ([]LedgerOperation, error) {
+	resp, err := a.call(ctx, "GET", "/v3/ledger"+params.query(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []LedgerOperation
+	if err = json.Unmarshal(resp, &ops); err != nil {
+		return nil, err
+	}
+
+	return ops, nil
+}
+
+func (a *Api) GetLedgerBalance(ctx context.Context) (*LedgerBalance, error) {
+	resp, err := a.call(ctx, "GET", "/v3/ledger/balance", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var b LedgerBalance
+	if err = json.Unmarshal(resp, &b); err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
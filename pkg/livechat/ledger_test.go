@@ -0,0 +1,55 @@
+package livechat
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPI_ListLedgerOperations(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		m.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.Path == "/v3/ledger" && req.URL.Query().Get("page") == "2"
+		})).Return(response(200, `[{"id":"1","amount":500,"type":"payout"}]`), nil).Once()
+
+		ops, err := api.ListLedgerOperations(context.Background(), ListParams{Page: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []LedgerOperation{{ID: "1", Amount: 500, Type: "payout"}}, ops)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		m.On("Do", mock.Anything).Return(response(500, ``), nil).Once()
+
+		ops, err := api.ListLedgerOperations(context.Background(), ListParams{})
+		assert.Error(t, err)
+		assert.Nil(t, ops)
+	})
+}
+
+func TestAPI_GetLedgerBalance(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		m.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.Path == "/v3/ledger/balance"
+		})).Return(response(200, `{"balance":1250}`), nil).Once()
+
+		balance, err := api.GetLedgerBalance(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, &LedgerBalance{Balance: 1250}, balance)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		m.On("Do", mock.Anything).Return(response(404, ``), nil).Once()
+
+		balance, err := api.GetLedgerBalance(context.Background())
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, balance)
+	})
+}
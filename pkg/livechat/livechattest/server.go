@@ -0,0 +1,544 @@
+// Package livechattest provides an in-memory fake of the LiveChat Billing API v3 for tests.
+package livechattest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+)
+
+const (
+	DefaultOrganizationID = "test-organization-id"
+	DefaultApplicationID  = "test-application-id"
+	DefaultClientID       = "test-client-id"
+	Token                 = "test-token"
+)
+
+// Server fakes the /v3/recurrent_charge/livechat and /v3/direct_charge/livechat endpoints.
+// Charges move through the LiveChat statuses:
+//
+//	recurrent: pending -> accepted -> active -> past_due -> frozen, any -> cancelled
+//	direct:    pending -> accepted -> success, pending -> declined
+//
+// Buyers act through AcceptCharge and DeclineCharge, time moves with AdvanceTime. Activations,
+// renewals, cancellations and declines queue the DPS webhook LiveChat would send, deliver them with
+// DeliverWebhooks. Accepting, freezing and failed renewals (past_due) change the status without a webhook.
+type Server struct {
+	*httptest.Server
+
+	// OrganizationID, ApplicationID and ClientID are stamped on new charges and webhooks.
+	OrganizationID string
+	ApplicationID  string
+	ClientID       string
+
+	mu        sync.Mutex
+	now       time.Time
+	seq       int
+	recurrent map[string]*livechat.RecurrentCharge
+	direct    map[string]*livechat.DirectCharge
+	failing   map[string]bool
+	webhooks  []Webhook
+	failures  []int
+}
+
+// NewServer starts a fake whose clock starts at now.
+func NewServer(now time.Time) *Server {
+	s := &Server{
+		OrganizationID: DefaultOrganizationID,
+		ApplicationID:  DefaultApplicationID,
+		ClientID:       DefaultClientID,
+		now:            now,
+		recurrent:      make(map[string]*livechat.RecurrentCharge),
+		direct:         make(map[string]*livechat.DirectCharge),
+		failing:        make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v3/recurrent_charge/livechat", s.createRecurrentCharge)
+	mux.HandleFunc("GET /v3/recurrent_charge/livechat", s.listRecurrentCharges)
+	mux.HandleFunc("GET /v3/recurrent_charge/livechat/{id}", s.getRecurrentCharge)
+	mux.HandleFunc("PUT /v3/recurrent_charge/livechat/{id}/activate", s.activateRecurrentCharge)
+	mux.HandleFunc("PUT /v3/recurrent_charge/livechat/{id}/cancel", s.cancelRecurrentCharge)
+	mux.HandleFunc("POST /v3/direct_charge/livechat", s.createDirectCharge)
+	mux.HandleFunc("GET /v3/direct_charge/livechat", s.listDirectCharges)
+	mux.HandleFunc("GET /v3/direct_charge/livechat/{id}", s.getDirectCharge)
+	mux.HandleFunc("PUT /v3/direct_charge/livechat/{id}/activate", s.activateDirectCharge)
+
+	s.Server = httptest.NewServer(s.authorize(mux))
+
+	return s
+}
+
+// Api returns a client of the fake. Retries and the circuit breaker are off unless opts turn them on.
+func (s *Server) Api(opts ...livechat.Option) *livechat.Api {
+	opts = append([]livechat.Option{
+		livechat.WithRetryPolicy(livechat.RetryPolicy{}),
+		livechat.WithCircuitBreaker(livechat.CircuitBreakerPolicy{}),
+	}, opts...)
+
+	return livechat.NewApi(s.Client(), s.URL, func(ctx context.Context) (string, error) {
+		return Token, nil
+	}, opts...)
+}
+
+// Now returns the fake's clock.
+func (s *Server) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.now
+}
+
+// RecurrentCharge returns a copy of the stored charge, nil when it does not exist.
+func (s *Server) RecurrentCharge(id string) *livechat.RecurrentCharge {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rc, ok := s.recurrent[id]; ok {
+		c := *rc
+		return &c
+	}
+	return nil
+}
+
+// DirectCharge returns a copy of the stored charge, nil when it does not exist.
+func (s *Server) DirectCharge(id string) *livechat.DirectCharge {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dc, ok := s.direct[id]; ok {
+		c := *dc
+		return &c
+	}
+	return nil
+}
+
+// AcceptCharge is the buyer accepting a pending recurrent or direct charge.
+func (s *Server) AcceptCharge(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base, err := s.base(id)
+	if err != nil {
+		return err
+	}
+	if base.Status != livechat.RecurrentChargeStatusPending {
+		return fmt.Errorf("charge %s is %s, not pending", id, base.Status)
+	}
+
+	s.setStatus(base, livechat.RecurrentChargeStatusAccepted)
+
+	return nil
+}
+
+// DeclineCharge is the buyer declining a pending direct charge, recurrent charges have no declined status.
+func (s *Server) DeclineCharge(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dc, ok := s.direct[id]
+	if !ok {
+		return fmt.Errorf("direct charge %s not found", id)
+	}
+	if dc.Status != livechat.DirectChargeStatusPending {
+		return fmt.Errorf("charge %s is %s, not pending", id, dc.Status)
+	}
+
+	s.setStatus(&dc.BaseCharge, livechat.DirectChargeStatusDeclined)
+	s.queueWebhook(&dc.BaseCharge, "payment_declined")
+
+	return nil
+}
+
+// FreezeCharge freezes an active or past due recurrent charge, as LiveChat does after unpaid renewals.
+func (s *Server) FreezeCharge(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rc, ok := s.recurrent[id]
+	if !ok {
+		return fmt.Errorf("recurrent charge %s not found", id)
+	}
+	if rc.Status != livechat.RecurrentChargeStatusActive && rc.Status != livechat.RecurrentChargeStatusPastDue {
+		return fmt.Errorf("charge %s is %s, not active or past due", id, rc.Status)
+	}
+
+	s.setStatus(&rc.BaseCharge, livechat.RecurrentChargeStatusFrozen)
+
+	return nil
+}
+
+// FailPayments makes renewals of the recurrent charge fail, moving it to past_due on AdvanceTime.
+func (s *Server) FailPayments(id string, fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failing[id] = fail
+}
+
+// AdvanceTime moves the clock and renews every active or past due recurrent charge that is due.
+func (s *Server) AdvanceTime(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.now = s.now.Add(d)
+
+	for _, id := range s.recurrentIDs() {
+		rc := s.recurrent[id]
+		for rc.NextChargeAt != nil && !rc.NextChargeAt.After(s.now) {
+			if rc.Status != livechat.RecurrentChargeStatusActive && rc.Status != livechat.RecurrentChargeStatusPastDue {
+				break
+			}
+			if s.failing[id] {
+				s.setStatus(&rc.BaseCharge, livechat.RecurrentChargeStatusPastDue)
+				break
+			}
+
+			chargedAt := *rc.NextChargeAt
+			next := chargedAt.AddDate(0, months(rc), 0)
+			rc.CurrentChargeAt, rc.NextChargeAt = &chargedAt, &next
+			s.setStatus(&rc.BaseCharge, livechat.RecurrentChargeStatusActive)
+			s.queueWebhook(&rc.BaseCharge, "payment_collected")
+		}
+	}
+}
+
+// FailNextRequests answers the next len(statusCodes) requests with the given status codes.
+func (s *Server) FailNextRequests(statusCodes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, statusCodes...)
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+Token {
+			writeError(w, http.StatusUnauthorized, "authorization", "invalid token")
+			return
+		}
+
+		s.mu.Lock()
+		var code int
+		if len(s.failures) > 0 {
+			code, s.failures = s.failures[0], s.failures[1:]
+		}
+		s.mu.Unlock()
+		if code != 0 {
+			writeError(w, code, "internal", "injected failure")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) createRecurrentCharge(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name              string `json:"name"`
+		Price             int    `json:"price"`
+		ReturnURL         string `json:"return_url"`
+		Test              bool   `json:"test"`
+		TrialDays         int    `json:"trial_days"`
+		Months            int    `json:"months"`
+		CommissionPercent *int   `json:"commission_percent"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation", err.Error())
+		return
+	}
+	if req.Name == "" || req.Price <= 0 {
+		writeError(w, http.StatusUnprocessableEntity, "validation", "name and a positive price are required")
+		return
+	}
+	if req.Months == 0 {
+		req.Months = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rc := &livechat.RecurrentCharge{
+		BaseCharge: s.newBase(req.Name, req.Price, req.ReturnURL, req.Test, req.CommissionPercent),
+		TrialDays:  req.TrialDays,
+		Months:     req.Months,
+	}
+	s.recurrent[rc.ID] = rc
+
+	writeJSON(w, http.StatusCreated, rc)
+}
+
+func (s *Server) createDirectCharge(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name              string `json:"name"`
+		Price             int    `json:"price"`
+		ReturnURL         string `json:"return_url"`
+		Test              bool   `json:"test"`
+		Quantity          int    `json:"quantity"`
+		CommissionPercent *int   `json:"commission_percent"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "validation", err.Error())
+		return
+	}
+	if req.Name == "" || req.Price <= 0 {
+		writeError(w, http.StatusUnprocessableEntity, "validation", "name and a positive price are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dc := &livechat.DirectCharge{
+		BaseCharge: s.newBase(req.Name, req.Price, req.ReturnURL, req.Test, req.CommissionPercent),
+		Quantity:   req.Quantity,
+	}
+	s.direct[dc.ID] = dc
+
+	writeJSON(w, http.StatusCreated, dc)
+}
+
+func (s *Server) getRecurrentCharge(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rc, ok := s.recurrent[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "charge not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rc)
+}
+
+func (s *Server) getDirectCharge(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dc, ok := s.direct[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "charge not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dc)
+}
+
+func (s *Server) activateRecurrentCharge(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rc, ok := s.recurrent[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "charge not found")
+		return
+	}
+
+	switch rc.Status {
+	case livechat.RecurrentChargeStatusAccepted:
+		now := s.now
+		rc.CurrentChargeAt = &now
+		event := "payment_activated"
+		next := now.AddDate(0, months(rc), 0)
+		if rc.TrialDays > 0 {
+			trialEndsAt := now.AddDate(0, 0, rc.TrialDays)
+			rc.TrialEndsAt, next = &trialEndsAt, trialEndsAt
+			event = "payment_trialstarted"
+		}
+		rc.NextChargeAt = &next
+		s.setStatus(&rc.BaseCharge, livechat.RecurrentChargeStatusActive)
+		s.queueWebhook(&rc.BaseCharge, event)
+	case livechat.RecurrentChargeStatusFrozen:
+		s.setStatus(&rc.BaseCharge, livechat.RecurrentChargeStatusActive)
+		s.queueWebhook(&rc.BaseCharge, "payment_activated")
+	default:
+		writeError(w, http.StatusUnprocessableEntity, "validation", fmt.Sprintf("cannot activate %s charge", rc.Status))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rc)
+}
+
+func (s *Server) cancelRecurrentCharge(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rc, ok := s.recurrent[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "charge not found")
+		return
+	}
+	if rc.Status == livechat.RecurrentChargeStatusCancelled {
+		writeError(w, http.StatusUnprocessableEntity, "validation", "charge is already cancelled")
+		return
+	}
+
+	now := s.now
+	rc.CancelledAt, rc.NextChargeAt = &now, nil
+	s.setStatus(&rc.BaseCharge, livechat.RecurrentChargeStatusCancelled)
+	s.queueWebhook(&rc.BaseCharge, "payment_cancelled")
+
+	writeJSON(w, http.StatusOK, rc)
+}
+
+func (s *Server) activateDirectCharge(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dc, ok := s.direct[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "charge not found")
+		return
+	}
+	if dc.Status != livechat.DirectChargeStatusAccepted {
+		writeError(w, http.StatusUnprocessableEntity, "validation", fmt.Sprintf("cannot activate %s charge", dc.Status))
+		return
+	}
+
+	s.setStatus(&dc.BaseCharge, livechat.DirectChargeStatusSuccess)
+	s.queueWebhook(&dc.BaseCharge, "payment_collected")
+
+	writeJSON(w, http.StatusOK, dc)
+}
+
+func (s *Server) listRecurrentCharges(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var all []livechat.RecurrentCharge
+	for _, id := range s.recurrentIDs() {
+		all = append(all, *s.recurrent[id])
+	}
+
+	writeJSON(w, http.StatusOK, page(r, all, func(rc livechat.RecurrentCharge) livechat.ChargeStatus { return rc.Status }))
+}
+
+func (s *Server) listDirectCharges(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.direct))
+	for id := range s.direct {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var all []livechat.DirectCharge
+	for _, id := range ids {
+		all = append(all, *s.direct[id])
+	}
+
+	writeJSON(w, http.StatusOK, page(r, all, func(dc livechat.DirectCharge) livechat.ChargeStatus { return dc.Status }))
+}
+
+// page applies the page, per_page and status query parameters.
+func page[T any](r *http.Request, all []T, status func(T) livechat.ChargeStatus) []T {
+	q := r.URL.Query()
+	p, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	if p < 1 {
+		p = 1
+	}
+	if perPage < 1 {
+		perPage = livechat.DefaultPerPage
+	}
+
+	filtered := []T{}
+	for _, c := range all {
+		if st := q.Get("status"); st == "" || livechat.ChargeStatus(st) == status(c) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	start := (p - 1) * perPage
+	if start >= len(filtered) {
+		return []T{}
+	}
+	end := start + perPage
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	return filtered[start:end]
+}
+
+func (s *Server) newBase(name string, price int, returnURL string, test bool, commissionPercent *int) livechat.BaseCharge {
+	s.seq++
+	id := fmt.Sprintf("charge-%06d", s.seq)
+	now := s.now
+
+	base := livechat.BaseCharge{
+		ID:                  id,
+		BuyerOrganizationID: s.OrganizationID,
+		SellerClientID:      s.ClientID,
+		OrderClientID:       s.ClientID,
+		Name:                name,
+		Price:               price,
+		ReturnURL:           returnURL,
+		Test:                test,
+		Status:              livechat.RecurrentChargeStatusPending,
+		ConfirmationURL:     s.URL + "/confirm/" + id,
+		CreatedAt:           &now,
+		UpdatedAt:           &now,
+	}
+	if commissionPercent != nil {
+		base.CommissionPercent = *commissionPercent
+	}
+
+	return base
+}
+
+func (s *Server) base(id string) (*livechat.BaseCharge, error) {
+	if rc, ok := s.recurrent[id]; ok {
+		return &rc.BaseCharge, nil
+	}
+	if dc, ok := s.direct[id]; ok {
+		return &dc.BaseCharge, nil
+	}
+
+	return nil, fmt.Errorf("charge %s not found", id)
+}
+
+func (s *Server) setStatus(base *livechat.BaseCharge, status livechat.ChargeStatus) {
+	now := s.now
+	base.Status = status
+	base.UpdatedAt = &now
+}
+
+func (s *Server) recurrentIDs() []string {
+	ids := make([]string, 0, len(s.recurrent))
+	for id := range s.recurrent {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+func months(rc *livechat.RecurrentCharge) int {
+	if rc.Months < 1 {
+		return 1
+	}
+	return rc.Months
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, errType, message string) {
+	w.Header().Set("X-Request-Id", fmt.Sprintf("fake-%d", time.Now().UnixNano()))
+	writeJSON(w, code, map[string]interface{}{
+		"error": map[string]string{"type": errType, "message": message},
+	})
+}
@@ -0,0 +1,231 @@
+package livechattest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/billing"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+)
+
+var start = time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+func events(ws []Webhook) []string {
+	var names []string
+	for _, w := range ws {
+		names = append(names, w.Event)
+	}
+	return names
+}
+
+func TestServer_RecurrentCharge(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer(start)
+	defer srv.Close()
+	api := srv.Api()
+
+	rc, err := api.CreateRecurrentCharge(ctx, livechat.CreateRecurrentChargeParams{Name: "plan", Price: 1000, Months: 1})
+	require.NoError(t, err)
+	assert.Equal(t, livechat.RecurrentChargeStatusPending, rc.Status)
+	assert.Equal(t, DefaultOrganizationID, rc.BuyerOrganizationID)
+
+	_, err = api.ActivateRecurrentCharge(ctx, rc.ID)
+	assert.ErrorIs(t, err, livechat.ErrUnprocessableEntity, "pending charges cannot be activated")
+
+	require.NoError(t, srv.AcceptCharge(rc.ID))
+	rc, err = api.ActivateRecurrentCharge(ctx, rc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, livechat.RecurrentChargeStatusActive, rc.Status)
+	assert.Equal(t, start.AddDate(0, 1, 0), *rc.NextChargeAt)
+
+	srv.AdvanceTime(31 * 24 * time.Hour)
+	rc, err = api.GetRecurrentCharge(ctx, rc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, start.AddDate(0, 1, 0), *rc.CurrentChargeAt)
+	assert.Equal(t, start.AddDate(0, 2, 0), *rc.NextChargeAt)
+
+	srv.FailPayments(rc.ID, true)
+	srv.AdvanceTime(31 * 24 * time.Hour)
+	assert.Equal(t, livechat.RecurrentChargeStatusPastDue, srv.RecurrentCharge(rc.ID).Status)
+
+	require.NoError(t, srv.FreezeCharge(rc.ID))
+	assert.Equal(t, livechat.RecurrentChargeStatusFrozen, srv.RecurrentCharge(rc.ID).Status)
+
+	rc, err = api.ActivateRecurrentCharge(ctx, rc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, livechat.RecurrentChargeStatusActive, rc.Status)
+
+	rc, err = api.CancelRecurrentCharge(ctx, rc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, livechat.RecurrentChargeStatusCancelled, rc.Status)
+	assert.Nil(t, rc.NextChargeAt)
+
+	_, err = api.CancelRecurrentCharge(ctx, rc.ID)
+	assert.ErrorIs(t, err, livechat.ErrUnprocessableEntity)
+
+	assert.Equal(t, []string{"payment_activated", "payment_collected", "payment_activated", "payment_cancelled"}, events(srv.PendingWebhooks()))
+}
+
+func TestServer_Trial(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer(start)
+	defer srv.Close()
+	api := srv.Api()
+
+	rc, err := api.CreateRecurrentCharge(ctx, livechat.CreateRecurrentChargeParams{Name: "plan", Price: 1000, TrialDays: 7})
+	require.NoError(t, err)
+	require.NoError(t, srv.AcceptCharge(rc.ID))
+
+	rc, err = api.ActivateRecurrentCharge(ctx, rc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, start.AddDate(0, 0, 7), *rc.TrialEndsAt)
+	assert.Equal(t, start.AddDate(0, 0, 7), *rc.NextChargeAt)
+
+	srv.AdvanceTime(7 * 24 * time.Hour)
+	assert.Equal(t, start.AddDate(0, 1, 7), *srv.RecurrentCharge(rc.ID).NextChargeAt)
+	assert.Equal(t, []string{"payment_trialstarted", "payment_collected"}, events(srv.PendingWebhooks()))
+}
+
+func TestServer_DirectCharge(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer(start)
+	defer srv.Close()
+	api := srv.Api()
+
+	dc, err := api.CreateDirectCharge(ctx, livechat.CreateDirectChargeParams{Name: "top up", Price: 500})
+	require.NoError(t, err)
+	assert.Equal(t, livechat.DirectChargeStatusPending, dc.Status)
+
+	require.NoError(t, srv.AcceptCharge(dc.ID))
+	dc, err = api.ActivateDirectCharge(ctx, dc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, livechat.DirectChargeStatusSuccess, dc.Status)
+
+	declined, err := api.CreateDirectCharge(ctx, livechat.CreateDirectChargeParams{Name: "top up", Price: 500})
+	require.NoError(t, err)
+	require.NoError(t, srv.DeclineCharge(declined.ID))
+	assert.Error(t, srv.AcceptCharge(declined.ID))
+
+	rc, err := api.CreateRecurrentCharge(ctx, livechat.CreateRecurrentChargeParams{Name: "plan", Price: 500})
+	require.NoError(t, err)
+	assert.ErrorContains(t, srv.DeclineCharge(rc.ID), "direct charge")
+	assert.Equal(t, livechat.RecurrentChargeStatusPending, srv.RecurrentCharge(rc.ID).Status)
+
+	assert.Equal(t, []string{"payment_collected", "payment_declined"}, events(srv.PendingWebhooks()))
+}
+
+func TestServer_Errors(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer(start)
+	defer srv.Close()
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := srv.Api().GetRecurrentCharge(ctx, "unknown")
+		assert.ErrorIs(t, err, livechat.ErrNotFound)
+
+		var apiErr *livechat.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.NotEmpty(t, apiErr.RequestID)
+		assert.Equal(t, map[string]interface{}{"type": "not_found", "message": "charge not found"}, apiErr.Body["error"])
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := srv.Api().CreateRecurrentCharge(ctx, livechat.CreateRecurrentChargeParams{Name: "plan"})
+		assert.ErrorIs(t, err, livechat.ErrUnprocessableEntity)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		api := srv.Api()
+		api.TokenFn = func(ctx context.Context) (string, error) { return "other", nil }
+
+		_, err := api.GetDirectCharge(ctx, "unknown")
+		var apiErr *livechat.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 401, apiErr.StatusCode)
+	})
+
+	t.Run("injected failures are retried", func(t *testing.T) {
+		rc, err := srv.Api().CreateRecurrentCharge(ctx, livechat.CreateRecurrentChargeParams{Name: "plan", Price: 1000})
+		require.NoError(t, err)
+
+		srv.FailNextRequests(503, 502)
+		api := srv.Api(livechat.WithRetryPolicy(livechat.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+		_, err = api.GetRecurrentCharge(ctx, rc.ID)
+		assert.NoError(t, err)
+	})
+}
+
+func TestServer_List(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer(start)
+	defer srv.Close()
+	api := srv.Api()
+
+	for i := 0; i < 5; i++ {
+		_, err := api.CreateRecurrentCharge(ctx, livechat.CreateRecurrentChargeParams{Name: fmt.Sprintf("plan %d", i), Price: 1000})
+		require.NoError(t, err)
+	}
+	require.NoError(t, srv.AcceptCharge("charge-000002"))
+
+	page, err := api.ListRecurrentCharges(ctx, livechat.ListParams{Page: 2, PerPage: 2})
+	require.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.Equal(t, "charge-000003", page[0].ID)
+
+	all, err := api.ListAllRecurrentCharges(ctx, livechat.ListParams{PerPage: 2})
+	require.NoError(t, err)
+	assert.Len(t, all, 5)
+
+	accepted, err := api.ListRecurrentCharges(ctx, livechat.ListParams{Status: livechat.RecurrentChargeStatusAccepted})
+	require.NoError(t, err)
+	assert.Len(t, accepted, 1)
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	ctx := context.Background()
+	srv := NewServer(start)
+	defer srv.Close()
+	api := srv.Api()
+
+	rc, err := api.CreateRecurrentCharge(ctx, livechat.CreateRecurrentChargeParams{Name: "plan", Price: 1000})
+	require.NoError(t, err)
+	require.NoError(t, srv.AcceptCharge(rc.ID))
+	_, err = api.ActivateRecurrentCharge(ctx, rc.ID)
+	require.NoError(t, err)
+
+	var received []billing.DPSWebhookRequest
+	err = DeliverWebhooks(ctx, srv, func(ctx context.Context, req billing.DPSWebhookRequest) error {
+		received = append(received, req)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.Equal(t, "payment_activated", received[0].Event)
+	assert.Equal(t, DefaultOrganizationID, received[0].LCOrganizationID)
+	assert.Equal(t, rc.ID, received[0].Payload["paymentID"])
+	assert.Empty(t, srv.PendingWebhooks())
+
+	t.Run("handler error", func(t *testing.T) {
+		_, err = api.CancelRecurrentCharge(ctx, rc.ID)
+		require.NoError(t, err)
+
+		err = DeliverWebhooks(ctx, srv, func(ctx context.Context, req billing.DPSWebhookRequest) error {
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "payment_cancelled")
+	})
+
+	t.Run("send webhook", func(t *testing.T) {
+		err = SendWebhook(ctx, srv.Webhook(rc.ID, "application_uninstalled"), func(ctx context.Context, req billing.DPSWebhookRequest) error {
+			assert.Equal(t, "application_uninstalled", req.Event)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,104 @@
+package livechattest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/livechat"
+)
+
+// Webhook is a DPS webhook, it has the JSON shape of billing.DPSWebhookRequest and ledger.DPSWebhookRequest.
+type Webhook struct {
+	ApplicationID    string                 `json:"applicationID"`
+	ApplicationName  string                 `json:"applicationName"`
+	ClientID         string                 `json:"clientID"`
+	Date             time.Time              `json:"date"`
+	Event            string                 `json:"event"`
+	License          int32                  `json:"licenseID"`
+	LCOrganizationID string                 `json:"organizationID"`
+	Payload          map[string]interface{} `json:"payload"`
+	UserID           string                 `json:"userID"`
+}
+
+// Webhook builds the webhook LiveChat sends for event on the charge.
+func (s *Server) Webhook(chargeID, event string) Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	organizationID := s.OrganizationID
+	if base, err := s.base(chargeID); err == nil {
+		organizationID = base.BuyerOrganizationID
+	}
+
+	return s.newWebhook(chargeID, organizationID, event)
+}
+
+// PendingWebhooks returns the webhooks queued by status transitions that were not delivered yet.
+func (s *Server) PendingWebhooks() []Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Webhook(nil), s.webhooks...)
+}
+
+func (s *Server) queueWebhook(base *livechat.BaseCharge, event string) {
+	s.webhooks = append(s.webhooks, s.newWebhook(base.ID, base.BuyerOrganizationID, event))
+}
+
+func (s *Server) newWebhook(chargeID, organizationID, event string) Webhook {
+	return Webhook{
+		ApplicationID:    s.ApplicationID,
+		ClientID:         s.ClientID,
+		Date:             s.now,
+		Event:            event,
+		LCOrganizationID: organizationID,
+		Payload:          map[string]interface{}{"paymentID": chargeID},
+	}
+}
+
+func (s *Server) popWebhook() (Webhook, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.webhooks) == 0 {
+		return Webhook{}, false
+	}
+
+	w := s.webhooks[0]
+	s.webhooks = s.webhooks[1:]
+
+	return w, true
+}
+
+// SendWebhook converts w to the handler's request type, e.g. billing.DPSWebhookRequest, and handles it:
+//
+//	err := livechattest.SendWebhook(ctx, srv.Webhook(id, "payment_activated"), handler.HandleDPSWebhook)
+func SendWebhook[T any](ctx context.Context, w Webhook, handle func(ctx context.Context, req T) error) error {
+	raw, err := json.Marshal(w)
+	if err != nil {
+		return fmt.Errorf("marshal webhook: %w", err)
+	}
+
+	var req T
+	if err = json.Unmarshal(raw, &req); err != nil {
+		return fmt.Errorf("unmarshal webhook: %w", err)
+	}
+
+	return handle(ctx, req)
+}
+
+// DeliverWebhooks sends the pending webhooks in order. It stops at the first handler error,
+// the failed webhook is not delivered again.
+func DeliverWebhooks[T any](ctx context.Context, s *Server, handle func(ctx context.Context, req T) error) error {
+	for {
+		w, ok := s.popWebhook()
+		if !ok {
+			return nil
+		}
+		if err := SendWebhook(ctx, w, handle); err != nil {
+			return fmt.Errorf("deliver %s webhook for %v: %w", w.Event, w.Payload["paymentID"], err)
+		}
+	}
+}
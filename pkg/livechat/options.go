@@ -0,0 +1,98 @@
+package livechat
+
+import (
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/common"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+// Option configures optional Api behaviour.
+type Option func(*Api)
+
+// WithRetryPolicy replaces DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(a *Api) {
+		a.Retry = policy
+	}
+}
+
+// WithCircuitBreaker replaces DefaultCircuitBreakerPolicy. A zero FailureRate disables the breaker.
+func WithCircuitBreaker(policy CircuitBreakerPolicy) Option {
+	return func(a *Api) {
+		a.Breaker = NewCircuitBreaker(policy)
+	}
+}
+
+// WithTimeout limits every call, retries included.
+func WithTimeout(timeout time.Duration) Option {
+	return func(a *Api) {
+		a.Timeout = timeout
+	}
+}
+
+// WithHeader adds a header sent with every call.
+func WithHeader(key, value string) Option {
+	return func(a *Api) {
+		if a.Headers == nil {
+			a.Headers = http.Header{}
+		}
+		a.Headers.Add(key, value)
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every call.
+func WithUserAgent(userAgent string) Option {
+	return func(a *Api) {
+		a.UserAgent = userAgent
+	}
+}
+
+// WithTokenProvider takes tokens from provider and refreshes a token the API rejects, e.g. with
+// common.OAuthTokenProvider.
+func WithTokenProvider(provider common.TokenProvider) Option {
+	return func(a *Api) {
+		a.TokenFn = provider.Token
+		a.InvalidateToken = provider.Invalidate
+	}
+}
+
+// WithMetrics reports every call to m.
+func WithMetrics(m metrics.Metrics) Option {
+	return func(a *Api) {
+		a.Metrics = m
+	}
+}
+
+// WithTracerProvider traces every HTTP request with a tracer of tp, a nil tp uses the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(a *Api) {
+		a.Tracer = tracing.Tracer(tp)
+	}
+}
+
+// NewApi returns a client for baseURL, usually BillingAPIBaseURL, that retries with
+// DefaultRetryPolicy and fails fast with DefaultCircuitBreakerPolicy. A nil httpClient
+// uses http.DefaultClient.
+func NewApi(httpClient *http.Client, baseURL string, tokenFn common.TokenFn, opts ...Option) *Api {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	a := &Api{
+		HttpClient: httpClient,
+		ApiBaseURL: baseURL,
+		TokenFn:    tokenFn,
+		Retry:      DefaultRetryPolicy(),
+		Breaker:    NewCircuitBreaker(DefaultCircuitBreakerPolicy()),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
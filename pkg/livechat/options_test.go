@@ -0,0 +1,224 @@
+package livechat
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics/metricstest"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/tracing"
+)
+
+func TestNewApi(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		api := NewApi(nil, BillingAPIBaseURL, nil)
+
+		assert.Equal(t, http.DefaultClient, api.HttpClient)
+		assert.Equal(t, BillingAPIBaseURL, api.ApiBaseURL)
+		assert.Equal(t, DefaultRetryPolicy(), api.Retry)
+		assert.NotNil(t, api.Breaker)
+		assert.Zero(t, api.Timeout)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		api := NewApi(nil, BillingAPIBaseURL, nil,
+			WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+			WithCircuitBreaker(CircuitBreakerPolicy{}),
+			WithTimeout(time.Second),
+			WithHeader("X-Foo", "a"),
+			WithHeader("X-Foo", "b"),
+			WithUserAgent("app/1.0"),
+		)
+
+		assert.Equal(t, RetryPolicy{MaxAttempts: 1}, api.Retry)
+		assert.Nil(t, api.Breaker)
+		assert.Equal(t, time.Second, api.Timeout)
+		assert.Equal(t, []string{"a", "b"}, api.Headers.Values("X-Foo"))
+		assert.Equal(t, "app/1.0", api.UserAgent)
+	})
+}
+
+func TestAPI_callRequestOptions(t *testing.T) {
+	t.Run("headers and user agent", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		WithHeader("X-Foo", "bar")(api)
+		WithHeader("Authorization", "ignored")(api)
+		WithUserAgent("app/1.0")(api)
+		m.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Header.Get("X-Foo") == "bar" &&
+				req.Header.Get("User-Agent") == "app/1.0" &&
+				req.Header.Get("Authorization") == "Bearer token"
+		})).Return(response(200, `{"id":"1"}`), nil).Once()
+
+		_, err := api.GetDirectCharge(context.Background(), "1")
+		assert.NoError(t, err)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		WithTimeout(time.Minute)(api)
+		m.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			deadline, ok := req.Context().Deadline()
+			return ok && time.Until(deadline) <= time.Minute
+		})).Return(response(200, `{"id":"1"}`), nil).Once()
+
+		_, err := api.GetDirectCharge(context.Background(), "1")
+		assert.NoError(t, err)
+		m.AssertExpectations(t)
+	})
+}
+
+type tokenProviderMock struct {
+	tokens      []string
+	invalidated []string
+}
+
+func (m *tokenProviderMock) Token(ctx context.Context) (string, error) {
+	return m.tokens[len(m.invalidated)], nil
+}
+
+func (m *tokenProviderMock) Invalidate(token string) {
+	m.invalidated = append(m.invalidated, token)
+}
+
+func TestAPI_callUnauthorized(t *testing.T) {
+	bearer := func(token string) interface{} {
+		return mock.MatchedBy(func(req *http.Request) bool {
+			return req.Header.Get("Authorization") == "Bearer "+token
+		})
+	}
+
+	t.Run("retries once with a new token", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		provider := &tokenProviderMock{tokens: []string{"old", "new"}}
+		WithTokenProvider(provider)(api)
+		m.On("Do", bearer("old")).Return(response(401, ``), nil).Once()
+		m.On("Do", bearer("new")).Return(response(201, `{"id":"1"}`), nil).Once()
+
+		charge, err := api.CreateRecurrentCharge(context.Background(), CreateRecurrentChargeParams{})
+		assert.NoError(t, err)
+		assert.Equal(t, "1", charge.ID)
+		assert.Equal(t, []string{"old"}, provider.invalidated)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("gives up on a second 401", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		provider := &tokenProviderMock{tokens: []string{"old", "new", "newer"}}
+		WithTokenProvider(provider)(api)
+		m.On("Do", bearer("old")).Return(response(401, ``), nil).Once()
+		m.On("Do", bearer("new")).Return(response(401, ``), nil).Once()
+
+		_, err := api.GetRecurrentCharge(context.Background(), "1")
+		var apiErr *APIError
+		assert.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 401, apiErr.StatusCode)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("without a provider", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		m.On("Do", mock.Anything).Return(response(401, ``), nil).Once()
+
+		_, err := api.GetRecurrentCharge(context.Background(), "1")
+		assert.Error(t, err)
+		m.AssertExpectations(t)
+	})
+}
+
+func TestAPI_callMetrics(t *testing.T) {
+	t.Run("status and route", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		recorder := metricstest.NewRecorder()
+		WithMetrics(recorder)(api)
+		m.On("Do", mock.Anything).Return(response(200, `{"id":"1"}`), nil).Once()
+		m.On("Do", mock.Anything).Return(response(404, ``), nil).Once()
+
+		_, err := api.ActivateRecurrentCharge(context.Background(), "1")
+		assert.NoError(t, err)
+		_, err = api.GetRecurrentCharge(context.Background(), "2")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		assert.Equal(t, 1, recorder.Counter(metrics.APIRequestsTotal, "PUT /v3/recurrent_charge/livechat/:id/activate", "200"))
+		assert.Equal(t, 1, recorder.Counter(metrics.APIRequestsTotal, "GET /v3/recurrent_charge/livechat/:id", "404"))
+		assert.Len(t, recorder.Observations(metrics.APIRequestDurationSeconds, "GET /v3/recurrent_charge/livechat/:id", "404"), 1)
+		open, _ := recorder.Gauge(metrics.APICircuitOpen, "", "")
+		assert.Zero(t, open)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("circuit open", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		recorder := metricstest.NewRecorder()
+		WithMetrics(recorder)(api)
+		WithCircuitBreaker(CircuitBreakerPolicy{FailureRate: 0.5, MinCalls: 1, Window: time.Minute, OpenTimeout: time.Minute})(api)
+		m.On("Do", mock.Anything).Return((*http.Response)(nil), assert.AnError).Once()
+
+		_, err := api.ListLedgerOperations(context.Background(), ListParams{})
+		assert.Error(t, err)
+		_, err = api.ListLedgerOperations(context.Background(), ListParams{})
+		assert.ErrorIs(t, err, ErrBillingAPIUnavailable)
+
+		assert.Equal(t, 1, recorder.Counter(metrics.APIRequestsTotal, "GET /v3/ledger", metrics.StatusError))
+		assert.Equal(t, 1, recorder.Counter(metrics.APIRequestsTotal, "GET /v3/ledger", metrics.StatusUnavailable))
+		open, _ := recorder.Gauge(metrics.APICircuitOpen, "", "")
+		assert.Equal(t, float64(1), open)
+		m.AssertExpectations(t)
+	})
+}
+
+func TestAPI_callTracing(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator()) })
+
+	t.Run("client span per request", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		recorder := tracetest.NewSpanRecorder()
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))(api)
+		m.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return strings.HasPrefix(req.Header.Get("Traceparent"), "00-")
+		})).Return(response(404, ``), nil).Once()
+
+		_, err := api.GetRecurrentCharge(context.Background(), "1")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "billing api GET /v3/recurrent_charge/livechat/:id", spans[0].Name())
+		assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Contains(t, spans[0].Attributes(), tracing.ChargeIDKey.String("1"))
+		assert.Contains(t, spans[0].Attributes(), semconv.HTTPResponseStatusCode(404))
+		m.AssertExpectations(t)
+	})
+
+	t.Run("propagates the caller trace without a tracer", func(t *testing.T) {
+		api, m, _ := newRetryApi(RetryPolicy{})
+		tp := sdktrace.NewTracerProvider()
+		ctx, span := tp.Tracer("test").Start(context.Background(), "caller")
+		defer span.End()
+		traceID := span.SpanContext().TraceID().String()
+		m.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return strings.Contains(req.Header.Get("Traceparent"), traceID)
+		})).Return(response(200, `{"id":"1"}`), nil).Once()
+
+		_, err := api.GetDirectCharge(ctx, "1")
+		assert.NoError(t, err)
+		m.AssertExpectations(t)
+	})
+}
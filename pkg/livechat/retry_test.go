@@ -0,0 +1,194 @@
+package livechat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newRetryApi(policy RetryPolicy) (*Api, *httpMock, *[]time.Duration) {
+	m := new(httpMock)
+	var waits []time.Duration
+	return &Api{
+		HttpClient: m,
+		ApiBaseURL: "https://api.livechatinc.com",
+		TokenFn: func(ctx context.Context) (string, error) {
+			return "token", nil
+		},
+		Retry: policy,
+		sleepFn: func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		},
+	}, m, &waits
+}
+
+func response(code int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 300*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 900*time.Millisecond, p.backoff(3))
+	assert.Equal(t, time.Second, p.backoff(4))
+
+	t.Run("jitter", func(t *testing.T) {
+		p.Jitter = 0.5
+		for i := 0; i < 100; i++ {
+			d := p.backoff(2)
+			assert.GreaterOrEqual(t, d, 150*time.Millisecond)
+			assert.LessOrEqual(t, d, 300*time.Millisecond)
+		}
+	})
+}
+
+func Test_retryAfter(t *testing.T) {
+	d, ok := retryAfter("3")
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	d, ok = retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour, d, float64(2*time.Second))
+
+	_, ok = retryAfter("")
+	assert.False(t, ok)
+
+	_, ok = retryAfter("soon")
+	assert.False(t, ok)
+}
+
+func TestAPI_callRetries(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 10 * time.Second, Multiplier: 2}
+
+	t.Run("retries GET on 5xx", func(t *testing.T) {
+		api, m, waits := newRetryApi(policy)
+		m.On("Do", mock.Anything).Return(response(503, ``), nil).Once()
+		m.On("Do", mock.Anything).Return(response(502, ``), nil).Once()
+		m.On("Do", mock.Anything).Return(response(200, `{"id":"1"}`), nil).Once()
+
+		charge, err := api.GetRecurrentCharge(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", charge.ID)
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, *waits)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("retries PUT on network error", func(t *testing.T) {
+		api, m, waits := newRetryApi(policy)
+		m.On("Do", mock.Anything).Return(&http.Response{}, fmt.Errorf("connection reset")).Once()
+		m.On("Do", mock.Anything).Return(response(200, `{"id":"1"}`), nil).Once()
+
+		charge, err := api.CancelRecurrentCharge(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", charge.ID)
+		assert.Len(t, *waits, 1)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		api, m, _ := newRetryApi(policy)
+		for i := 0; i < 3; i++ {
+			m.On("Do", mock.Anything).Return(response(500, `oops`), nil).Once()
+		}
+
+		charge, err := api.GetDirectCharge(context.Background(), "1")
+		assert.ErrorContains(t, err, "bad status code 500, response: oops")
+		assert.Nil(t, charge)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("honors Retry-After on 429", func(t *testing.T) {
+		api, m, waits := newRetryApi(policy)
+		limited := response(429, ``)
+		limited.Header.Set("Retry-After", "7")
+		m.On("Do", mock.Anything).Return(limited, nil).Once()
+		m.On("Do", mock.Anything).Return(response(200, `{"id":"1"}`), nil).Once()
+
+		_, err := api.ActivateRecurrentCharge(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Duration{7 * time.Second}, *waits)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("does not wait for Retry-After above max backoff", func(t *testing.T) {
+		api, m, waits := newRetryApi(policy)
+		limited := response(429, ``)
+		limited.Header.Set("Retry-After", "60")
+		m.On("Do", mock.Anything).Return(limited, nil).Once()
+
+		_, err := api.ActivateDirectCharge(context.Background(), "1")
+		assert.ErrorContains(t, err, "bad status code 429")
+		assert.Empty(t, *waits)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("does not retry 4xx", func(t *testing.T) {
+		api, m, _ := newRetryApi(policy)
+		m.On("Do", mock.Anything).Return(response(422, ``), nil).Once()
+
+		_, err := api.GetRecurrentCharge(context.Background(), "1")
+		assert.ErrorIs(t, err, ErrUnprocessableEntity)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("does not retry POST without idempotency key", func(t *testing.T) {
+		api, m, _ := newRetryApi(policy)
+		m.On("Do", mock.Anything).Return(response(503, ``), nil).Once()
+
+		charge, err := api.CreateRecurrentCharge(context.Background(), CreateRecurrentChargeParams{})
+		assert.Error(t, err)
+		assert.Nil(t, charge)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("retries POST with idempotency key", func(t *testing.T) {
+		api, m, _ := newRetryApi(policy)
+		withKey := mock.MatchedBy(func(req *http.Request) bool {
+			body, _ := req.GetBody()
+			b, _ := io.ReadAll(body)
+			return req.Header.Get("Idempotency-Key") == "key" && strings.Contains(string(b), `"name":"name"`)
+		})
+		m.On("Do", withKey).Return(response(503, ``), nil).Once()
+		m.On("Do", withKey).Return(response(201, `{"id":"1"}`), nil).Once()
+
+		charge, err := api.CreateDirectCharge(context.Background(), CreateDirectChargeParams{Name: "name", IdempotencyKey: "key"})
+		assert.NoError(t, err)
+		assert.Equal(t, "1", charge.ID)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("stops when context is done", func(t *testing.T) {
+		api, m, _ := newRetryApi(policy)
+		api.sleepFn = func(ctx context.Context, d time.Duration) error {
+			return context.Canceled
+		}
+		m.On("Do", mock.Anything).Return(response(503, ``), nil).Once()
+
+		_, err := api.GetRecurrentCharge(context.Background(), "1")
+		assert.ErrorIs(t, err, context.Canceled)
+		m.AssertExpectations(t)
+	})
+}
+
+func Test_sleep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, sleep(ctx, time.Hour), context.Canceled)
+	assert.NoError(t, sleep(context.Background(), time.Millisecond))
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import "time"
+
+// Metric names, every metric is labelled with Labels.
+const (
+	// ChargesTotal counts the charges and top ups a run or activation handled, e.g. {action="sync_charges", status="error"}.
+	ChargesTotal = "charges_total"
+	// RunCharges is the number of charges or top ups the last run picked up.
+	RunCharges = "run_charges"
+	// RunDurationSeconds is how long a SyncCharges, CleanupFailedCharges or SyncOrCancelTopUpRequests run took.
+	RunDurationSeconds = "run_duration_seconds"
+	// WebhooksTotal counts handled webhooks, the action is the webhook event.
+	WebhooksTotal = "webhooks_total"
+	// WebhookDurationSeconds is how long handling a webhook took.
+	WebhookDurationSeconds = "webhook_duration_seconds"
+	// APIRequestsTotal counts LiveChat Billing API calls, the action is the method and route, the status the HTTP status code.
+	APIRequestsTotal = "api_requests_total"
+	// APIRequestDurationSeconds is how long a LiveChat Billing API call took, retries included.
+	APIRequestDurationSeconds = "api_request_duration_seconds"
+	// APICircuitOpen is 1 while the LiveChat Billing API circuit breaker rejects calls.
+	APICircuitOpen = "api_circuit_open"
+)
+
+// Actions of the runs, webhooks and API calls use their event and route.
+const (
+	ActionSyncCharges               = "sync_charges"
+	ActionCleanupFailedCharges      = "cleanup_failed_charges"
+	ActionSyncOrCancelTopUpRequests = "sync_or_cancel_top_up_requests"
+	ActionActivateCharge            = "activate_charge"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+	// StatusUnavailable is an API call the circuit breaker rejected.
+	StatusUnavailable = "unavailable"
+)
+
+// Labels every metric is labelled with. Values must come from a small set, never IDs.
+type Labels struct {
+	Action string
+	Status string
+}
+
+// Metrics receives the SDK counters, histograms and gauges, see Noop and the prometheus subpackage.
+type Metrics interface {
+	// IncCounter adds one to the counter.
+	IncCounter(name string, labels Labels)
+	// ObserveHistogram records value, durations are in seconds.
+	ObserveHistogram(name string, labels Labels, value float64)
+	// SetGauge sets the gauge to value.
+	SetGauge(name string, labels Labels, value float64)
+}
+
+// Noop drops everything, it is used when no Metrics is configured.
+type Noop struct{}
+
+func (Noop) IncCounter(string, Labels)                {}
+func (Noop) ObserveHistogram(string, Labels, float64) {}
+func (Noop) SetGauge(string, Labels, float64)         {}
+
+// Status is StatusError for a non-nil err, StatusSuccess otherwise.
+func Status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusSuccess
+}
+
+// ObserveSince records the seconds passed since start.
+func ObserveSince(m Metrics, name string, labels Labels, start time.Time) {
+	m.ObserveHistogram(name, labels, time.Since(start).Seconds())
+}
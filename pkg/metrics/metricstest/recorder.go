@@ -0,0 +1,80 @@
+// Package metricstest provides an in-memory metrics.Metrics for tests.
+package metricstest
+
+import (
+	"sync"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+)
+
+// Make sure its Metrics implementation
+var _ metrics.Metrics = (*Recorder)(nil)
+
+// Key identifies a metric and its labels.
+type Key struct {
+	Name string
+	metrics.Labels
+}
+
+// Recorder keeps the counters and gauges and every observed histogram value.
+type Recorder struct {
+	mu         sync.Mutex
+	counters   map[Key]int
+	histograms map[Key][]float64
+	gauges     map[Key]float64
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		counters:   map[Key]int{},
+		histograms: map[Key][]float64{},
+		gauges:     map[Key]float64{},
+	}
+}
+
+func (r *Recorder) IncCounter(name string, labels metrics.Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counters[Key{name, labels}]++
+}
+
+func (r *Recorder) ObserveHistogram(name string, labels metrics.Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := Key{name, labels}
+	r.histograms[k] = append(r.histograms[k], value)
+}
+
+func (r *Recorder) SetGauge(name string, labels metrics.Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.gauges[Key{name, labels}] = value
+}
+
+// Counter returns the counter, 0 when it was never incremented.
+func (r *Recorder) Counter(name, action, status string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.counters[Key{name, metrics.Labels{Action: action, Status: status}}]
+}
+
+// Observations returns the values observed in the histogram.
+func (r *Recorder) Observations(name, action, status string) []float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.histograms[Key{name, metrics.Labels{Action: action, Status: status}}]
+}
+
+// Gauge returns the gauge and whether it was ever set.
+func (r *Recorder) Gauge(name, action, status string) (float64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.gauges[Key{name, metrics.Labels{Action: action, Status: status}}]
+	return v, ok
+}
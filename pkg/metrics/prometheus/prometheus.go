@@ -0,0 +1,133 @@
+package prometheus
+
+import (
+	"errors"
+	"sync"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+)
+
+// Make sure its Metrics implementation
+var _ metrics.Metrics = (*Metrics)(nil)
+
+var labelNames = []string{"action", "status"}
+
+var help = map[string]string{
+	metrics.ChargesTotal:              "Charges and top ups handled by a run or activation.",
+	metrics.RunCharges:                "Charges or top ups picked up by the last run.",
+	metrics.RunDurationSeconds:        "Duration of a billing run.",
+	metrics.WebhooksTotal:             "Handled webhooks.",
+	metrics.WebhookDurationSeconds:    "Duration of handling a webhook.",
+	metrics.APIRequestsTotal:          "LiveChat Billing API calls.",
+	metrics.APIRequestDurationSeconds: "Duration of a LiveChat Billing API call, retries included.",
+	metrics.APICircuitOpen:            "1 while the LiveChat Billing API circuit breaker rejects calls.",
+}
+
+// Option configures optional Metrics behaviour.
+type Option func(*Metrics)
+
+// WithNamespace prefixes every metric name, e.g. "billing" gives billing_charges_total.
+func WithNamespace(namespace string) Option {
+	return func(m *Metrics) {
+		m.namespace = namespace
+	}
+}
+
+// WithBuckets replaces prometheus.DefBuckets of the histograms.
+func WithBuckets(buckets []float64) Option {
+	return func(m *Metrics) {
+		m.buckets = buckets
+	}
+}
+
+// Metrics registers a vector labelled with action and status the first time a metric is used.
+type Metrics struct {
+	registerer prom.Registerer
+	namespace  string
+	buckets    []float64
+
+	mu         sync.Mutex
+	counters   map[string]*prom.CounterVec
+	histograms map[string]*prom.HistogramVec
+	gauges     map[string]*prom.GaugeVec
+}
+
+// New registers the metrics with registerer, a nil registerer uses prometheus.DefaultRegisterer.
+func New(registerer prom.Registerer, opts ...Option) *Metrics {
+	if registerer == nil {
+		registerer = prom.DefaultRegisterer
+	}
+
+	m := &Metrics{
+		registerer: registerer,
+		buckets:    prom.DefBuckets,
+		counters:   map[string]*prom.CounterVec{},
+		histograms: map[string]*prom.HistogramVec{},
+		gauges:     map[string]*prom.GaugeVec{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func (m *Metrics) IncCounter(name string, labels metrics.Labels) {
+	m.mu.Lock()
+	vec, ok := m.counters[name]
+	if !ok {
+		vec = register(m.registerer, prom.NewCounterVec(prom.CounterOpts{Namespace: m.namespace, Name: name, Help: helpFor(name)}, labelNames))
+		m.counters[name] = vec
+	}
+	m.mu.Unlock()
+
+	vec.WithLabelValues(labels.Action, labels.Status).Inc()
+}
+
+func (m *Metrics) ObserveHistogram(name string, labels metrics.Labels, value float64) {
+	m.mu.Lock()
+	vec, ok := m.histograms[name]
+	if !ok {
+		vec = register(m.registerer, prom.NewHistogramVec(prom.HistogramOpts{Namespace: m.namespace, Name: name, Help: helpFor(name), Buckets: m.buckets}, labelNames))
+		m.histograms[name] = vec
+	}
+	m.mu.Unlock()
+
+	vec.WithLabelValues(labels.Action, labels.Status).Observe(value)
+}
+
+func (m *Metrics) SetGauge(name string, labels metrics.Labels, value float64) {
+	m.mu.Lock()
+	vec, ok := m.gauges[name]
+	if !ok {
+		vec = register(m.registerer, prom.NewGaugeVec(prom.GaugeOpts{Namespace: m.namespace, Name: name, Help: helpFor(name)}, labelNames))
+		m.gauges[name] = vec
+	}
+	m.mu.Unlock()
+
+	vec.WithLabelValues(labels.Action, labels.Status).Set(value)
+}
+
+// register returns the collector registered before under the same name, e.g. by a second Metrics sharing the
+// registerer. A collector that can't be registered is still returned, its values are just not exported.
+func register[T prom.Collector](registerer prom.Registerer, c T) T {
+	if err := registerer.Register(c); err != nil {
+		var are prom.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+	}
+
+	return c
+}
+
+func helpFor(name string) string {
+	if h, ok := help[name]; ok {
+		return h
+	}
+	return name
+}
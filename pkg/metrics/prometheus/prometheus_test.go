@@ -0,0 +1,61 @@
+package prometheus
+
+import (
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("registers vectors labelled with action and status", func(t *testing.T) {
+		reg := prom.NewRegistry()
+		m := New(reg, WithNamespace("billing"), WithBuckets([]float64{1}))
+		labels := metrics.Labels{Action: metrics.ActionSyncCharges, Status: metrics.StatusSuccess}
+
+		m.IncCounter(metrics.ChargesTotal, labels)
+		m.IncCounter(metrics.ChargesTotal, labels)
+		m.ObserveHistogram(metrics.RunDurationSeconds, labels, 0.5)
+		m.SetGauge(metrics.RunCharges, metrics.Labels{Action: metrics.ActionSyncCharges}, 3)
+
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		require.Len(t, families, 3)
+
+		byName := map[string]int{}
+		for i, f := range families {
+			byName[f.GetName()] = i
+		}
+
+		counter := families[byName["billing_charges_total"]]
+		assert.Equal(t, "Charges and top ups handled by a run or activation.", counter.GetHelp())
+		require.Len(t, counter.GetMetric(), 1)
+		assert.Equal(t, float64(2), counter.GetMetric()[0].GetCounter().GetValue())
+		assert.Equal(t, "action", counter.GetMetric()[0].GetLabel()[0].GetName())
+		assert.Equal(t, metrics.ActionSyncCharges, counter.GetMetric()[0].GetLabel()[0].GetValue())
+		assert.Equal(t, metrics.StatusSuccess, counter.GetMetric()[0].GetLabel()[1].GetValue())
+
+		histogram := families[byName["billing_run_duration_seconds"]].GetMetric()[0].GetHistogram()
+		assert.Equal(t, uint64(1), histogram.GetSampleCount())
+		assert.Len(t, histogram.GetBucket(), 1)
+
+		gauge := families[byName["billing_run_charges"]].GetMetric()[0].GetGauge()
+		assert.Equal(t, float64(3), gauge.GetValue())
+	})
+
+	t.Run("shares collectors registered before", func(t *testing.T) {
+		reg := prom.NewRegistry()
+		labels := metrics.Labels{Action: "GET /v3/ledger", Status: "200"}
+
+		New(reg).IncCounter(metrics.APIRequestsTotal, labels)
+		New(reg).IncCounter(metrics.APIRequestsTotal, labels)
+
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		require.Len(t, families, 1)
+		assert.Equal(t, float64(2), families[0].GetMetric()[0].GetCounter().GetValue())
+	})
+}
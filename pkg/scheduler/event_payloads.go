@@ -0,0 +1,23 @@
+package scheduler
+
+import (
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+// RegisterEventPayloads registers the payloads of the job events, to the registry of the events table the scheduler
+// writes to.
+func RegisterEventPayloads(r *events.PayloadRegistry) {
+	r.Register(events.EventActionJobStarted, JobEventPayload{})
+	r.Register(events.EventActionJobFinished, JobEventPayload{})
+}
+
+// JobEventPayload is the payload of a job run, finished runs have their duration.
+type JobEventPayload struct {
+	Version  int    `json:"version"`
+	Job      string `json:"job"`
+	RunID    string `json:"run_id"`
+	Holder   string `json:"holder"`
+	Duration string `json:"duration,omitempty"`
+}
+
+func (JobEventPayload) PayloadVersion() int { return 1 }
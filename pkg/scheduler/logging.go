@@ -0,0 +1,22 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+// WithLogger replaces slog.Default. Failed lease calls and event writes are logged with the job name.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Scheduler) {
+		s.logger = logger
+	}
+}
+
+// createEvent stores a job event, a failed write is logged instead of failing the run.
+func (s *Scheduler) createEvent(ctx context.Context, event events.Event) {
+	if err := s.eventService.CreateEvent(ctx, event); err != nil {
+		s.logger.ErrorContext(ctx, "failed to store job event", "event", event, "error", err)
+	}
+}
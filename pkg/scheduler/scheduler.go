@@ -0,0 +1,293 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/billing"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/ledger"
+)
+
+const (
+	JobNameSyncCharges          = "billing_sync_charges"
+	JobNameCleanupFailedCharges = "billing_cleanup_failed_charges"
+	JobNameSyncTopUpRequests    = "ledger_sync_top_up_requests"
+	JobNameReconcileCharges     = "billing_reconcile_charges"
+	JobNameReconcileTopUps      = "ledger_reconcile_top_ups"
+	JobNamePurgeBillingEvents   = "billing_purge_events"
+	JobNamePurgeLedgerEvents    = "ledger_purge_events"
+	JobNameSnapshotBalances     = "ledger_snapshot_balances"
+	JobNameVerifyBalances       = "ledger_verify_balances"
+)
+
+// ErrLeaseLost cancels a running job when its lease could not be renewed, another replica may already run it.
+var ErrLeaseLost = errors.New("job lease lost")
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// LeaseTTL is how long a replica keeps the job after taking it. Defaults to twice the interval,
+	// so the leader renews the lease on every tick and another replica takes over once it stops doing so.
+	// The lease is also renewed every half TTL while the job runs, so runs longer than the TTL keep it.
+	LeaseTTL time.Duration
+	Run      func(ctx context.Context) error
+}
+
+func (j Job) leaseTTL() time.Duration {
+	if j.LeaseTTL > 0 {
+		return j.LeaseTTL
+	}
+
+	return 2 * j.Interval
+}
+
+func SyncChargesJob(billingService billing.ServiceInterface, interval time.Duration) Job {
+	return Job{
+		Name:     JobNameSyncCharges,
+		Interval: interval,
+		Run:      billingService.SyncCharges,
+	}
+}
+
+func CleanupFailedChargesJob(billingService billing.ServiceInterface, interval time.Duration) Job {
+	return Job{
+		Name:     JobNameCleanupFailedCharges,
+		Interval: interval,
+		Run:      billingService.CleanupFailedCharges,
+	}
+}
+
+func SyncTopUpRequestsJob(ledgerService ledger.LedgerInterface, interval time.Duration) Job {
+	return Job{
+		Name:     JobNameSyncTopUpRequests,
+		Interval: interval,
+		Run:      ledgerService.SyncOrCancelTopUpRequests,
+	}
+}
+
+// ReconcileChargesJob stores the report as a reconcile event, the job only fails when charges could not be checked.
+func ReconcileChargesJob(billingService billing.ServiceInterface, interval time.Duration, opts billing.ReconcileOptions) Job {
+	return Job{
+		Name:     JobNameReconcileCharges,
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := billingService.Reconcile(ctx, opts)
+			return err
+		},
+	}
+}
+
+func ReconcileTopUpsJob(ledgerService ledger.LedgerInterface, interval time.Duration, opts ledger.ReconcileOptions) Job {
+	return Job{
+		Name:     JobNameReconcileTopUps,
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := ledgerService.Reconcile(ctx, opts)
+			return err
+		},
+	}
+}
+
+func SnapshotBalancesJob(ledgerService ledger.LedgerInterface, interval time.Duration) Job {
+	return Job{
+		Name:     JobNameSnapshotBalances,
+		Interval: interval,
+		Run:      ledgerService.SnapshotBalances,
+	}
+}
+
+// VerifyBalancesJob stores the report as a verify_balances event, the job fails when the latest snapshot drifted.
+func VerifyBalancesJob(ledgerService ledger.LedgerInterface, interval time.Duration) Job {
+	return Job{
+		Name:     JobNameVerifyBalances,
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := ledgerService.VerifyBalances(ctx)
+			return err
+		},
+	}
+}
+
+// PurgeEventsJob applies the retention policy of one events table, use JobNamePurgeBillingEvents or
+// JobNamePurgeLedgerEvents as its name.
+func PurgeEventsJob(name string, retention *events.Retention, interval time.Duration) Job {
+	return Job{
+		Name:     name,
+		Interval: interval,
+		Run:      retention.Run,
+	}
+}
+
+type SchedulerInterface interface {
+	Start(ctx context.Context) error
+	RunJob(ctx context.Context, job Job) (bool, error)
+}
+
+type Scheduler struct {
+	idProvider   events.IdProviderInterface
+	eventService events.EventService
+	storage      Storage
+	holderID     string
+	jobs         []Job
+	logger       *slog.Logger
+}
+
+// Option configures optional Scheduler behaviour.
+type Option func(*Scheduler)
+
+func NewScheduler(eventService events.EventService, idProvider events.IdProviderInterface, storage Storage, jobs []Job, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		idProvider:   idProvider,
+		eventService: eventService,
+		storage:      storage,
+		holderID:     idProvider.GenerateId(),
+		jobs:         jobs,
+		logger:       slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Start runs every job on its interval until ctx is done and releases the leases held by this replica on the way out.
+func (s *Scheduler) Start(ctx context.Context) error {
+	for _, job := range s.jobs {
+		if job.Name == "" || job.Interval <= 0 || job.Run == nil {
+			return fmt.Errorf("invalid job %q: name, interval and run function are required", job.Name)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	defer func() {
+		_ = s.storage.ReleaseLease(context.WithoutCancel(ctx), ReleaseLeaseParams{
+			Name:   job.Name,
+			Holder: s.holderID,
+		})
+	}()
+
+	for {
+		// job failures are stored as job events and lease errors are logged, the next tick retries
+		ran, err := s.RunJob(ctx, job)
+		if err != nil && !ran && ctx.Err() == nil {
+			s.logger.ErrorContext(ctx, "failed to acquire job lease", "job", job.Name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunJob runs the job once if this replica holds its lease. It returns false when another replica owns the job.
+func (s *Scheduler) RunJob(ctx context.Context, job Job) (bool, error) {
+	acquired, err := s.storage.AcquireLease(ctx, AcquireLeaseParams{
+		Name:   job.Name,
+		Holder: s.holderID,
+		TTL:    job.leaseTTL(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease for job %s: %w", job.Name, err)
+	}
+	if !acquired {
+		return false, nil
+	}
+
+	runID := s.idProvider.GenerateId()
+	payload := JobEventPayload{Job: job.Name, RunID: runID, Holder: s.holderID}
+	started := s.eventService.ToEvent(ctx, "", events.EventActionJobStarted, events.EventTypeInfo, payload)
+	s.createEvent(ctx, started)
+	ctx = events.WithParent(ctx, started)
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stopRenewal := s.renewLease(runCtx, cancel, job)
+
+	startedAt := time.Now()
+	runErr := job.Run(runCtx)
+	stopRenewal()
+	if runErr != nil && errors.Is(context.Cause(runCtx), ErrLeaseLost) {
+		runErr = fmt.Errorf("%w: %w", context.Cause(runCtx), runErr)
+	}
+
+	payload.Duration = time.Since(startedAt).String()
+	event := s.eventService.ToEvent(ctx, "", events.EventActionJobFinished, events.EventTypeInfo, payload)
+	if runErr != nil {
+		event.Type = events.EventTypeError
+		return true, s.eventService.ToError(ctx, events.ToErrorParams{
+			Event: event,
+			Err:   fmt.Errorf("job %s failed: %w", job.Name, runErr),
+		})
+	}
+
+	s.createEvent(ctx, event)
+
+	return true, nil
+}
+
+// renewLease extends the lease every half TTL until the returned function is called. When the lease
+// can't be renewed the run is canceled with ErrLeaseLost, so two replicas don't run the job at once.
+func (s *Scheduler) renewLease(ctx context.Context, cancel context.CancelCauseFunc, job Job) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(job.leaseTTL() / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			acquired, err := s.storage.AcquireLease(ctx, AcquireLeaseParams{
+				Name:   job.Name,
+				Holder: s.holderID,
+				TTL:    job.leaseTTL(),
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				s.logger.ErrorContext(ctx, "failed to renew job lease", "job", job.Name, "error", err)
+				cancel(fmt.Errorf("%w: %w", ErrLeaseLost, err))
+				return
+			}
+			if !acquired {
+				cancel(ErrLeaseLost)
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
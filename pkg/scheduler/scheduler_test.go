@@ -0,0 +1,298 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/events"
+)
+
+var sm = new(storageMock)
+var em = new(eventMock)
+var xm = new(xIdMock)
+var ctx = context.Background()
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+var s = Scheduler{
+	idProvider:   xm,
+	eventService: em,
+	storage:      sm,
+	holderID:     "holder",
+	logger:       discardLogger,
+}
+
+var assertExpectations = func(t *testing.T) {
+	mock.AssertExpectationsForObjects(t, sm, em, xm)
+	sm.Calls = nil
+	em.Calls = nil
+	xm.Calls = nil
+
+	sm.ExpectedCalls = nil
+	em.ExpectedCalls = nil
+	xm.ExpectedCalls = nil
+}
+
+type xIdMock struct {
+	mock.Mock
+}
+
+func (x *xIdMock) GenerateId() string {
+	args := x.Called()
+	return args.Get(0).(string)
+}
+
+type eventMock struct {
+	mock.Mock
+}
+
+func (m *eventMock) CreateEvent(ctx context.Context, e events.Event) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+func (m *eventMock) ToError(ctx context.Context, params events.ToErrorParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *eventMock) ToEvent(ctx context.Context, organizationID string, action events.EventAction, eventType events.EventType, payload any) events.Event {
+	args := m.Called(ctx, organizationID, action, eventType, payload)
+	return args.Get(0).(events.Event)
+}
+
+type storageMock struct {
+	mock.Mock
+}
+
+func (m *storageMock) AcquireLease(ctx context.Context, params AcquireLeaseParams) (bool, error) {
+	args := m.Called(ctx, params)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *storageMock) ReleaseLease(ctx context.Context, params ReleaseLeaseParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestNewScheduler(t *testing.T) {
+	t.Run("NewScheduler", func(t *testing.T) {
+		xm.On("GenerateId").Return("holder").Once()
+
+		newScheduler := NewScheduler(em, xm, sm, []Job{{Name: "job", Interval: time.Minute}}, WithLogger(discardLogger))
+
+		assert.NotNil(t, newScheduler)
+		assert.Equal(t, "holder", newScheduler.holderID)
+		assert.Equal(t, discardLogger, newScheduler.logger)
+		assertExpectations(t)
+	})
+}
+
+func TestScheduler_RunJob(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		runs := 0
+		job := Job{Name: "job", Interval: time.Minute, Run: func(ctx context.Context) error {
+			runs++
+			return nil
+		}}
+		sm.On("AcquireLease", ctx, AcquireLeaseParams{Name: "job", Holder: "holder", TTL: 2 * time.Minute}).Return(true, nil).Once()
+		xm.On("GenerateId").Return("run").Once()
+		started := events.Event{ID: "1", Action: events.EventActionJobStarted}
+		finished := events.Event{ID: "2", Action: events.EventActionJobFinished}
+		em.On("ToEvent", ctx, "", events.EventActionJobStarted, events.EventTypeInfo, JobEventPayload{Job: "job", RunID: "run", Holder: "holder"}).Return(started).Once()
+		em.On("CreateEvent", ctx, started).Return(nil).Once()
+		em.On("ToEvent", events.WithParent(ctx, started), "", events.EventActionJobFinished, events.EventTypeInfo, mock.MatchedBy(func(p JobEventPayload) bool {
+			return p.Job == "job" && p.RunID == "run" && p.Holder == "holder" && p.Duration != ""
+		})).Return(finished).Once()
+		em.On("CreateEvent", events.WithParent(ctx, started), finished).Return(nil).Once()
+
+		ran, err := s.RunJob(ctx, job)
+
+		assert.NoError(t, err)
+		assert.True(t, ran)
+		assert.Equal(t, 1, runs)
+		assertExpectations(t)
+	})
+
+	t.Run("failed event write does not fail the run", func(t *testing.T) {
+		job := Job{Name: "job", Interval: time.Minute, Run: func(ctx context.Context) error {
+			return nil
+		}}
+		sm.On("AcquireLease", ctx, mock.Anything).Return(true, nil).Once()
+		xm.On("GenerateId").Return("run").Once()
+		started := events.Event{ID: "1", Action: events.EventActionJobStarted}
+		finished := events.Event{ID: "2", Action: events.EventActionJobFinished}
+		em.On("ToEvent", ctx, "", events.EventActionJobStarted, events.EventTypeInfo, mock.Anything).Return(started).Once()
+		em.On("CreateEvent", ctx, started).Return(assert.AnError).Once()
+		em.On("ToEvent", events.WithParent(ctx, started), "", events.EventActionJobFinished, events.EventTypeInfo, mock.Anything).Return(finished).Once()
+		em.On("CreateEvent", events.WithParent(ctx, started), finished).Return(assert.AnError).Once()
+
+		ran, err := s.RunJob(ctx, job)
+
+		assert.NoError(t, err)
+		assert.True(t, ran)
+		assertExpectations(t)
+	})
+
+	t.Run("lease lost while running", func(t *testing.T) {
+		job := Job{Name: "job", Interval: time.Minute, LeaseTTL: 20 * time.Millisecond, Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+		sm.On("AcquireLease", ctx, mock.Anything).Return(true, nil).Once()
+		sm.On("AcquireLease", mock.Anything, AcquireLeaseParams{Name: "job", Holder: "holder", TTL: 20 * time.Millisecond}).Return(true, nil).Once()
+		sm.On("AcquireLease", mock.Anything, mock.Anything).Return(false, nil).Once()
+		xm.On("GenerateId").Return("run").Once()
+		started := events.Event{ID: "1", Action: events.EventActionJobStarted}
+		finished := events.Event{ID: "2", Action: events.EventActionJobFinished}
+		em.On("ToEvent", ctx, "", events.EventActionJobStarted, events.EventTypeInfo, mock.Anything).Return(started).Once()
+		em.On("CreateEvent", ctx, started).Return(nil).Once()
+		em.On("ToEvent", events.WithParent(ctx, started), "", events.EventActionJobFinished, events.EventTypeInfo, mock.Anything).Return(finished).Once()
+		em.On("ToError", events.WithParent(ctx, started), mock.MatchedBy(func(p events.ToErrorParams) bool {
+			return p.Event.Type == events.EventTypeError && errors.Is(p.Err, ErrLeaseLost) && errors.Is(p.Err, context.Canceled)
+		})).Return(assert.AnError).Once()
+
+		ran, err := s.RunJob(ctx, job)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.True(t, ran)
+		assertExpectations(t)
+	})
+
+	t.Run("error renewing lease", func(t *testing.T) {
+		job := Job{Name: "job", Interval: time.Minute, LeaseTTL: 20 * time.Millisecond, Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+		sm.On("AcquireLease", ctx, mock.Anything).Return(true, nil).Once()
+		sm.On("AcquireLease", mock.Anything, mock.Anything).Return(false, assert.AnError).Once()
+		xm.On("GenerateId").Return("run").Once()
+		started := events.Event{ID: "1", Action: events.EventActionJobStarted}
+		finished := events.Event{ID: "2", Action: events.EventActionJobFinished}
+		em.On("ToEvent", ctx, "", events.EventActionJobStarted, events.EventTypeInfo, mock.Anything).Return(started).Once()
+		em.On("CreateEvent", ctx, started).Return(nil).Once()
+		em.On("ToEvent", events.WithParent(ctx, started), "", events.EventActionJobFinished, events.EventTypeInfo, mock.Anything).Return(finished).Once()
+		em.On("ToError", events.WithParent(ctx, started), mock.MatchedBy(func(p events.ToErrorParams) bool {
+			return errors.Is(p.Err, ErrLeaseLost) && errors.Is(p.Err, assert.AnError)
+		})).Return(assert.AnError).Once()
+
+		ran, err := s.RunJob(ctx, job)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.True(t, ran)
+		assertExpectations(t)
+	})
+
+	t.Run("lease held by another replica", func(t *testing.T) {
+		job := Job{Name: "job", Interval: time.Minute, LeaseTTL: time.Hour, Run: func(ctx context.Context) error {
+			t.Fatal("job should not run")
+			return nil
+		}}
+		sm.On("AcquireLease", ctx, AcquireLeaseParams{Name: "job", Holder: "holder", TTL: time.Hour}).Return(false, nil).Once()
+
+		ran, err := s.RunJob(ctx, job)
+
+		assert.NoError(t, err)
+		assert.False(t, ran)
+		assertExpectations(t)
+	})
+
+	t.Run("error acquiring lease", func(t *testing.T) {
+		job := Job{Name: "job", Interval: time.Minute}
+		sm.On("AcquireLease", ctx, mock.Anything).Return(false, assert.AnError).Once()
+
+		ran, err := s.RunJob(ctx, job)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.False(t, ran)
+		assertExpectations(t)
+	})
+
+	t.Run("job error", func(t *testing.T) {
+		job := Job{Name: "job", Interval: time.Minute, Run: func(ctx context.Context) error {
+			return assert.AnError
+		}}
+		sm.On("AcquireLease", ctx, mock.Anything).Return(true, nil).Once()
+		xm.On("GenerateId").Return("run").Once()
+		started := events.Event{ID: "1", Action: events.EventActionJobStarted}
+		finished := events.Event{ID: "2", Action: events.EventActionJobFinished}
+		em.On("ToEvent", ctx, "", events.EventActionJobStarted, events.EventTypeInfo, mock.Anything).Return(started).Once()
+		em.On("CreateEvent", ctx, started).Return(nil).Once()
+		em.On("ToEvent", events.WithParent(ctx, started), "", events.EventActionJobFinished, events.EventTypeInfo, mock.Anything).Return(finished).Once()
+		finished.Type = events.EventTypeError
+		em.On("ToError", events.WithParent(ctx, started), events.ToErrorParams{
+			Event: finished,
+			Err:   fmt.Errorf("job job failed: %w", assert.AnError),
+		}).Return(assert.AnError).Once()
+
+		ran, err := s.RunJob(ctx, job)
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.True(t, ran)
+		assertExpectations(t)
+	})
+}
+
+func TestScheduler_Start(t *testing.T) {
+	t.Run("invalid job", func(t *testing.T) {
+		sc := Scheduler{storage: sm, jobs: []Job{{Name: "job"}}}
+
+		err := sc.Start(ctx)
+
+		assert.ErrorContains(t, err, "invalid job")
+		assertExpectations(t)
+	})
+
+	t.Run("logs lease errors", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(ctx)
+		var buf bytes.Buffer
+		// the loop waits for the next tick after logging, stopping it from the log write keeps the test short
+		w := writerFunc(func(p []byte) (int, error) {
+			defer cancel()
+			return buf.Write(p)
+		})
+		sc := Scheduler{storage: sm, holderID: "holder", logger: slog.New(slog.NewTextHandler(w, nil)), jobs: []Job{{Name: "job", Interval: time.Hour, Run: func(ctx context.Context) error {
+			return nil
+		}}}}
+		sm.On("AcquireLease", cctx, mock.Anything).Return(false, assert.AnError).Once()
+		sm.On("ReleaseLease", mock.Anything, ReleaseLeaseParams{Name: "job", Holder: "holder"}).Return(nil).Once()
+
+		err := sc.Start(cctx)
+
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "failed to acquire job lease")
+		assert.Contains(t, buf.String(), "job=job")
+		assertExpectations(t)
+	})
+
+	t.Run("releases leases when stopped", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(ctx)
+		sc := Scheduler{storage: sm, holderID: "holder", jobs: []Job{{Name: "job", Interval: time.Hour, Run: func(ctx context.Context) error {
+			return nil
+		}}}}
+		sm.On("AcquireLease", cctx, mock.Anything).Run(func(args mock.Arguments) {
+			cancel()
+		}).Return(false, nil).Once()
+		sm.On("ReleaseLease", mock.Anything, ReleaseLeaseParams{Name: "job", Holder: "holder"}).Return(nil).Once()
+
+		err := sc.Start(cctx)
+
+		assert.NoError(t, err)
+		assertExpectations(t)
+	})
+}
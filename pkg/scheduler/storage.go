@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+type AcquireLeaseParams struct {
+	Name   string
+	Holder string
+	TTL    time.Duration
+}
+
+type ReleaseLeaseParams struct {
+	Name   string
+	Holder string
+}
+
+// Storage keeps job leases shared by all replicas. A lease is a row keyed by job name
+// that belongs to a single holder until it expires, so it works with pooled connections
+// where session scoped locks (advisory locks, GET_LOCK) could be released on a different connection.
+type Storage interface {
+	// AcquireLease takes the lease or extends it when the holder already owns it.
+	// It returns false when another holder owns a lease that has not expired yet.
+	AcquireLease(ctx context.Context, params AcquireLeaseParams) (bool, error)
+	ReleaseLease(ctx context.Context, params ReleaseLeaseParams) error
+}
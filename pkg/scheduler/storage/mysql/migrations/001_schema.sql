@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS scheduler_leases
+(
+    name        VARCHAR(255) NOT NULL,
+    holder      VARCHAR(36)  NOT NULL,
+    acquired_at DATETIME     NOT NULL,
+    expires_at  DATETIME     NOT NULL,
+    PRIMARY KEY (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_0900_ai_ci;
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	stdsql "database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/scheduler"
+)
+
+// Make sure its Storage implementation
+var _ scheduler.Storage = (*SQLClient)(nil)
+
+type SQLClient struct {
+	db *sqlx.DB
+}
+
+// NewSQLClient accepts a standard *sql.DB configured with github.com/go-sql-driver/mysql
+func NewSQLClient(client *stdsql.DB) *SQLClient {
+	return &SQLClient{
+		db: sqlx.NewDb(client, "mysql"),
+	}
+}
+
+// AcquireLease inserts the lease row when it does not exist yet, otherwise it takes the row over
+// when the caller already holds it or the previous holder let it expire. Expiry is compared with
+// the database clock, so replicas with skewed clocks agree on who holds the lease.
+func (c *SQLClient) AcquireLease(ctx context.Context, params scheduler.AcquireLeaseParams) (bool, error) {
+	ttl := params.TTL.Microseconds()
+
+	res, err := c.db.ExecContext(ctx, "INSERT IGNORE INTO scheduler_leases(name, holder, acquired_at, expires_at) VALUES (?, ?, NOW(), NOW() + INTERVAL ? MICROSECOND)", params.Name, params.Holder, ttl)
+	if err != nil {
+		return false, fmt.Errorf("couldn't insert lease: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected > 0 {
+		return true, nil
+	}
+
+	// acquired_at goes first, MySQL evaluates assignments left to right
+	res, err = c.db.ExecContext(ctx, `
+		UPDATE scheduler_leases
+		SET acquired_at = IF(holder = ?, acquired_at, NOW()),
+		    holder = ?,
+		    expires_at = NOW() + INTERVAL ? MICROSECOND
+		WHERE name = ?
+		AND (holder = ? OR expires_at < NOW())`,
+		params.Holder, params.Holder, ttl, params.Name, params.Holder)
+	if err != nil {
+		return false, fmt.Errorf("couldn't update lease: %w", err)
+	}
+	affected, _ := res.RowsAffected()
+
+	return affected > 0, nil
+}
+
+func (c *SQLClient) ReleaseLease(ctx context.Context, params scheduler.ReleaseLeaseParams) error {
+	if _, err := c.db.ExecContext(ctx, "DELETE FROM scheduler_leases WHERE name = ? AND holder = ?", params.Name, params.Holder); err != nil {
+		return fmt.Errorf("couldn't release lease: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/scheduler"
+)
+
+func TestSQLClient_AcquireLease(t *testing.T) {
+	params := scheduler.AcquireLeaseParams{Name: "job", Holder: "holder", TTL: time.Minute}
+
+	t.Run("inserted", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		client := NewSQLClient(db)
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO scheduler_leases(name, holder, acquired_at, expires_at) VALUES (?, ?, NOW(), NOW() + INTERVAL ? MICROSECOND)")).
+			WithArgs("job", "holder", time.Minute.Microseconds()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		acquired, err := client.AcquireLease(context.Background(), params)
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("taken over", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		client := NewSQLClient(db)
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO scheduler_leases")).
+			WithArgs("job", "holder", time.Minute.Microseconds()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE scheduler_leases")).
+			WithArgs("holder", "holder", time.Minute.Microseconds(), "job", "holder").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		acquired, err := client.AcquireLease(context.Background(), params)
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("held by another holder", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		client := NewSQLClient(db)
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO scheduler_leases")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE scheduler_leases")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		acquired, err := client.AcquireLease(context.Background(), params)
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		client := NewSQLClient(db)
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO scheduler_leases")).
+			WillReturnError(assert.AnError)
+
+		acquired, err := client.AcquireLease(context.Background(), params)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.False(t, acquired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLClient_ReleaseLease(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		client := NewSQLClient(db)
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM scheduler_leases WHERE name = ? AND holder = ?")).
+			WithArgs("job", "holder").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = client.ReleaseLease(context.Background(), scheduler.ReleaseLeaseParams{Name: "job", Holder: "holder"})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
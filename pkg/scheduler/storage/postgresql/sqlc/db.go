@@ -0,0 +1,3 @@
+//go:generate go run -mod=readonly github.com/sqlc-dev/sqlc/cmd/sqlc@v1.29.0 generate
+
+package sqlc
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS scheduler_leases
+(
+    name        varchar(255) PRIMARY KEY,
+    holder      varchar(36)  NOT NULL,
+    acquired_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    expires_at  TIMESTAMPTZ  NOT NULL
+);
@@ -0,0 +1,14 @@
+-- name: AcquireLease :execrows
+INSERT INTO scheduler_leases(name, holder, acquired_at, expires_at)
+VALUES ($1, $2, NOW(), NOW() + make_interval(secs => $3::float8))
+ON CONFLICT (name) DO UPDATE
+SET acquired_at = CASE WHEN scheduler_leases.holder = EXCLUDED.holder THEN scheduler_leases.acquired_at ELSE EXCLUDED.acquired_at END,
+    holder      = EXCLUDED.holder,
+    expires_at  = EXCLUDED.expires_at
+WHERE scheduler_leases.holder = EXCLUDED.holder
+   OR scheduler_leases.expires_at < NOW();
+
+-- name: ReleaseLease :exec
+DELETE FROM scheduler_leases
+WHERE name = $1
+AND holder = $2;
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/scheduler"
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/scheduler/storage/postgresql/sqlc"
+)
+
+type PGXConn interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...interface{}) pgx.Row
+}
+
+// Make sure its Storage implementation
+var _ scheduler.Storage = (*PostgresqlPGX)(nil)
+
+type PostgresqlPGX struct {
+	queries *sqlc.Queries
+}
+
+func NewPostgresqlPGX(conn PGXConn) *PostgresqlPGX {
+	return &PostgresqlPGX{queries: sqlc.New(conn)}
+}
+
+func (r *PostgresqlPGX) AcquireLease(ctx context.Context, params scheduler.AcquireLeaseParams) (bool, error) {
+	affected, err := r.queries.AcquireLease(ctx, sqlc.AcquireLeaseParams{
+		Name:    params.Name,
+		Holder:  params.Holder,
+		Column3: params.TTL.Seconds(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *PostgresqlPGX) ReleaseLease(ctx context.Context, params scheduler.ReleaseLeaseParams) error {
+	return r.queries.ReleaseLease(ctx, sqlc.ReleaseLeaseParams{
+		Name:   params.Name,
+		Holder: params.Holder,
+	})
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/livechat-integrations/go-billing-sdk/v2/pkg/scheduler"
+)
+
+var dbMock, _ = pgxmock.NewConn()
+var s = NewPostgresqlPGX(dbMock)
+
+func TestNewPostgresqlPGX(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		assert.NotNil(t, NewPostgresqlPGX(dbMock))
+	})
+}
+
+func TestPostgresqlPGX_AcquireLease(t *testing.T) {
+	t.Run("acquired", func(t *testing.T) {
+		dbMock.ExpectExec("INSERT INTO scheduler_leases").
+			WithArgs("job", "holder", float64(120)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1)).Times(1)
+
+		acquired, err := s.AcquireLease(context.Background(), scheduler.AcquireLeaseParams{Name: "job", Holder: "holder", TTL: 2 * time.Minute})
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("held by another holder", func(t *testing.T) {
+		dbMock.ExpectExec("INSERT INTO scheduler_leases").
+			WithArgs("job", "holder", float64(120)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0)).Times(1)
+
+		acquired, err := s.AcquireLease(context.Background(), scheduler.AcquireLeaseParams{Name: "job", Holder: "holder", TTL: 2 * time.Minute})
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		dbMock.ExpectExec("INSERT INTO scheduler_leases").
+			WithArgs("job", "holder", float64(120)).Times(1).
+			WillReturnError(assert.AnError)
+
+		acquired, err := s.AcquireLease(context.Background(), scheduler.AcquireLeaseParams{Name: "job", Holder: "holder", TTL: 2 * time.Minute})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.False(t, acquired)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresqlPGX_ReleaseLease(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dbMock.ExpectExec("DELETE FROM scheduler_leases").
+			WithArgs("job", "holder").
+			WillReturnResult(pgxmock.NewResult("DELETE", 1)).Times(1)
+
+		err := s.ReleaseLease(context.Background(), scheduler.ReleaseLeaseParams{Name: "job", Holder: "holder"})
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// InstrumentationName names the tracer of every SDK span.
+const InstrumentationName = "github.com/livechat-integrations/go-billing-sdk/v2"
+
+// Attributes of the SDK spans, top ups use the ID of their LiveChat charge.
+const (
+	OrganizationIDKey = attribute.Key("livechat.organization_id")
+	ChargeIDKey       = attribute.Key("livechat.charge_id")
+	WebhookEventKey   = attribute.Key("livechat.webhook_event")
+	EventIDKey        = attribute.Key("livechat.event_id")
+)
+
+// Tracer returns the SDK tracer of tp, a nil tp uses the global otel.GetTracerProvider.
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(InstrumentationName)
+}
+
+// Start starts a span named name. A nil tracer returns ctx unchanged with a span that records nothing.
+func Start(ctx context.Context, tracer trace.Tracer, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if tracer == nil {
+		return ctx, noop.Span{}
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records a non-nil err on span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// LogAttrs converts span attributes to log attributes with the same keys, so logs and spans can be matched.
+func LogAttrs(attrs []attribute.KeyValue) []slog.Attr {
+	res := make([]slog.Attr, 0, len(attrs))
+	for _, kv := range attrs {
+		res = append(res, slog.Any(string(kv.Key), kv.Value.AsInterface()))
+	}
+	return res
+}
+
+// TraceID returns the trace ID of the span in ctx, empty without one.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}